@@ -10,7 +10,9 @@ import (
 
 	"storj.io/private/process"
 	"storj.io/private/version"
+	"storj.io/storj/pkg/revocation"
 	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/satellitedb"
 )
 
@@ -36,7 +38,23 @@ func cmdAdminRun(cmd *cobra.Command, args []string) (err error) {
 		err = errs.Combine(err, db.Close())
 	}()
 
-	peer, err := satellite.NewAdmin(log, identity, db, version.Build, &runCfg.Config, process.AtomicLevel(cmd))
+	pointerDB, err := metainfo.NewStore(log.Named("pointerdb"), runCfg.Metainfo.DatabaseURL)
+	if err != nil {
+		return errs.New("Error creating pointerDB connection on satellite admin: %+v", err)
+	}
+	defer func() {
+		err = errs.Combine(err, pointerDB.Close())
+	}()
+
+	revocationDB, err := revocation.NewDBFromCfg(runCfg.Server.Config)
+	if err != nil {
+		return errs.New("Error creating revocation database on satellite admin: %+v", err)
+	}
+	defer func() {
+		err = errs.Combine(err, revocationDB.Close())
+	}()
+
+	peer, err := satellite.NewAdmin(log, identity, db, pointerDB, revocationDB, version.Build, &runCfg.Config, process.AtomicLevel(cmd))
 	if err != nil {
 		return err
 	}
@@ -50,6 +68,11 @@ func cmdAdminRun(cmd *cobra.Command, args []string) (err error) {
 		log.Warn("Failed to initialize telemetry batcher on satellite admin", zap.Error(err))
 	}
 
+	err = pointerDB.MigrateToLatest(ctx)
+	if err != nil {
+		return errs.New("Error creating pointerDB tables on satellite admin: %+v", err)
+	}
+
 	err = db.CheckVersion(ctx)
 	if err != nil {
 		log.Error("Failed satellite database version check.", zap.Error(err))
@@ -76,6 +76,17 @@ func cmdAPIRun(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	if runCfg.Config.Metainfo.ListReplicaDatabaseURL != "" {
+		listReplicaDB, replicaErr := metainfo.NewStore(log.Named("pointerdb:list-replica"), runCfg.Config.Metainfo.ListReplicaDatabaseURL)
+		if replicaErr != nil {
+			return errs.New("Error creating metainfodb replica connection on satellite api: %+v", replicaErr)
+		}
+		defer func() {
+			err = errs.Combine(err, listReplicaDB.Close())
+		}()
+		peer.Metainfo.Service.SetListReplica(listReplicaDB)
+	}
+
 	_, err = peer.Version.Service.CheckVersion(ctx)
 	if err != nil {
 		return err
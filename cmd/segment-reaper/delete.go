@@ -20,6 +20,7 @@ import (
 	"storj.io/common/storj"
 	"storj.io/private/process"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/satellitedb"
 	"storj.io/storj/storage"
 )
 
@@ -34,8 +35,11 @@ var (
 	}
 
 	deleteCfg struct {
-		DatabaseURL string `help:"the database connection string to use" default:"postgres://"`
-		DryRun      bool   `help:"with this option no deletion will be done, only printing results" default:"false"`
+		DatabaseURL          string `help:"the database connection string to use" default:"postgres://"`
+		SatelliteDatabaseURL string `help:"the satellite database connection string, for releasing deduplicated segments" default:""`
+		DryRun               bool   `help:"with this option no deletion will be done, only printing results" default:"false"`
+		BatchSize            int    `help:"number of segments whose pointers are read at once" default:"100"`
+		Skip                 int    `help:"number of records of the input file to skip" default:"0"`
 	}
 )
 
@@ -57,6 +61,24 @@ func cmdDelete(cmd *cobra.Command, args []string) (err error) {
 		err = errs.Combine(err, db.Close())
 	}()
 
+	if deleteCfg.BatchSize <= 0 || deleteCfg.BatchSize > db.LookupLimit() {
+		return errs.New("batch size %d must be between 1 and %d", deleteCfg.BatchSize, db.LookupLimit())
+	}
+
+	var references referencesDB
+	if deleteCfg.SatelliteDatabaseURL != "" {
+		satelliteDB, err := satellitedb.New(log.Named("db"), deleteCfg.SatelliteDatabaseURL, satellitedb.Options{})
+		if err != nil {
+			return errs.New("error connecting satellite database: %+v", err)
+		}
+		defer func() {
+			err = errs.Combine(err, satelliteDB.Close())
+		}()
+		references = satelliteDB
+	} else {
+		log.Warn("the satellite database isn't set, the references of the deleted segments to deduplicated inline contents and shared segments won't be released")
+	}
+
 	inputFile, err := os.Open(args[0])
 	if err != nil {
 		return errs.New("error opening input file: %+v", err)
@@ -65,14 +87,69 @@ func cmdDelete(cmd *cobra.Command, args []string) (err error) {
 		err = errs.Combine(err, inputFile.Close())
 	}()
 
-	csvReader := csv.NewReader(inputFile)
+	summary, err := deleteSegments(ctx, log, db, references, inputFile, deleteCfg.BatchSize, deleteCfg.Skip, deleteCfg.DryRun)
+	log.Info("summary",
+		zap.Int("deleted", summary.Deleted),
+		zap.Int("skipped", summary.Skipped),
+		zap.Int("errored", summary.Errored),
+		zap.Int("batches", summary.Batches),
+		zap.Int("cursor", summary.Cursor),
+	)
+	return err
+}
+
+// zombieSegment is a segment of the input file.
+type zombieSegment struct {
+	path         string
+	rawPath      string
+	creationDate time.Time
+}
+
+// deleteSummary is the outcome of deleteSegments.
+type deleteSummary struct {
+	Deleted int
+	Skipped int
+	Errored int
+	// Batches is the number of batches which have been processed.
+	Batches int
+	// Cursor is the number of records of the input which have been
+	// processed, including the skipped ones, up to the last processed
+	// batch. It's the value of --skip for resuming: the records of a batch
+	// which was interrupted before being processed aren't counted.
+	Cursor int
+}
+
+// deleteSegments deletes the segments of the CSV input, batchSize of them at
+// a time, after skipping the first skip records. The pointers of a batch are
+// read at once, then every one of them is deleted on its own. The cursor is
+// logged after every batch, so that an interrupted run can be resumed.
+func deleteSegments(ctx context.Context, log *zap.Logger, db metainfo.PointerDB, references referencesDB, input io.Reader, batchSize, skip int, dryRun bool) (summary deleteSummary, err error) {
+	csvReader := csv.NewReader(input)
 	csvReader.FieldsPerRecord = 6
 	csvReader.ReuseRecord = true
 
-	segmentsDeleted := 0
-	segmentsErrored := 0
-	segmentsSkipped := 0
+	// read is the number of records which have been read, the ones of the
+	// pending batch included.
+	read := 0
+	batch := make([]zombieSegment, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			summary.Cursor = read
+			return
+		}
+		deleteBatch(ctx, log, db, references, batch, dryRun, &summary)
+		summary.Batches++
+		summary.Cursor = read
+		batch = batch[:0]
+
+		log.Info("batch processed", zap.Int("batch", summary.Batches), zap.Int("cursor", summary.Cursor))
+	}
+
 	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
 		record, err := csvReader.Read()
 		if errors.Is(err, io.EOF) {
 			break
@@ -81,6 +158,11 @@ func cmdDelete(cmd *cobra.Command, args []string) (err error) {
 			log.Error("error while reading record", zap.Error(err))
 			continue
 		}
+		read++
+		if read <= skip {
+			summary.Cursor = read
+			continue
+		}
 
 		projectID := record[0]
 		segmentIndex := record[1]
@@ -98,30 +180,71 @@ func cmdDelete(cmd *cobra.Command, args []string) (err error) {
 			continue
 		}
 
-		path := storj.JoinPaths(projectID, segmentIndex, bucketName, string(encryptedPath))
-		rawPath := storj.JoinPaths(projectID, segmentIndex, bucketName, encodedPath)
+		batch = append(batch, zombieSegment{
+			path:         storj.JoinPaths(projectID, segmentIndex, bucketName, string(encryptedPath)),
+			rawPath:      storj.JoinPaths(projectID, segmentIndex, bucketName, encodedPath),
+			creationDate: creationDateFromReport,
+		})
+		if len(batch) >= batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return summary, nil
+}
+
+// deleteBatch deletes the segments of the batch and adds the outcome to the
+// summary.
+func deleteBatch(ctx context.Context, log *zap.Logger, db metainfo.PointerDB, references referencesDB, batch []zombieSegment, dryRun bool, summary *deleteSummary) {
+	keys := make(storage.Keys, len(batch))
+	for i, segment := range batch {
+		keys[i] = storage.Key(segment.path)
+	}
+
+	values, err := db.GetAll(ctx, keys)
+	if err != nil {
+		log.Error("error while reading the batch", zap.Int("segments", len(batch)), zap.Error(err))
+		summary.Errored += len(batch)
+		return
+	}
 
-		err = deleteSegment(ctx, db, path, creationDateFromReport, deleteCfg.DryRun)
+	for i, segment := range batch {
+		var err error
+		if values[i] == nil {
+			err = errKnown.New("segment already deleted by user")
+		} else {
+			err = deleteFetchedSegment(ctx, log, db, references, segment.path, values[i], segment.creationDate, dryRun)
+		}
 		if err != nil {
 			if errKnown.Has(err) {
-				segmentsSkipped++
+				summary.Skipped++
 			} else {
-				segmentsErrored++
+				summary.Errored++
 			}
-			log.Error("error while deleting segment", zap.String("path", rawPath), zap.Error(err))
+			log.Error("error while deleting segment", zap.String("path", segment.rawPath), zap.Error(err))
 			continue
 		}
 
-		log.Debug("segment deleted", zap.String("path", rawPath))
-		segmentsDeleted++
+		log.Debug("segment deleted", zap.String("path", segment.rawPath))
+		summary.Deleted++
 	}
+}
 
-	log.Info("summary", zap.Int("deleted", segmentsDeleted), zap.Int("skipped", segmentsSkipped), zap.Int("errored", segmentsErrored))
-
-	return nil
+// referencesDB are the databases of the references of the segments, which are
+// released when the segments are deleted.
+type referencesDB interface {
+	// InlineContents returns the database of the deduplicated contents of inline segments.
+	InlineContents() metainfo.InlineContentsDB
+	// SharedSegments returns the database of the references to the segments shared by copied objects.
+	SharedSegments() metainfo.SharedSegmentsDB
 }
 
-func deleteSegment(ctx context.Context, db metainfo.PointerDB, path string, creationDate time.Time, dryRun bool) error {
+// deleteSegment deletes the pointer at path when it hasn't been replaced since
+// creationDate. The references of the segment to a deduplicated inline content
+// or to a shared segment are released when references isn't nil. The pieces
+// are left to garbage collection.
+func deleteSegment(ctx context.Context, log *zap.Logger, db metainfo.PointerDB, references referencesDB, path string, creationDate time.Time, dryRun bool) error {
 	pointerBytes, err := db.Get(ctx, []byte(path))
 	if err != nil {
 		if storage.ErrKeyNotFound.Has(err) {
@@ -130,8 +253,14 @@ func deleteSegment(ctx context.Context, db metainfo.PointerDB, path string, crea
 		return err
 	}
 
+	return deleteFetchedSegment(ctx, log, db, references, path, pointerBytes, creationDate, dryRun)
+}
+
+// deleteFetchedSegment is like deleteSegment, for a pointer which has already
+// been read.
+func deleteFetchedSegment(ctx context.Context, log *zap.Logger, db metainfo.PointerDB, references referencesDB, path string, pointerBytes []byte, creationDate time.Time, dryRun bool) error {
 	pointer := &pb.Pointer{}
-	err = pb.Unmarshal(pointerBytes, pointer)
+	err := pb.Unmarshal(pointerBytes, pointer)
 	if err != nil {
 		return err
 	}
@@ -152,6 +281,12 @@ func deleteSegment(ctx context.Context, db metainfo.PointerDB, path string, crea
 			}
 			return err
 		}
+		if references != nil {
+			metainfo.ReleaseInlineContents(ctx, log, references.InlineContents(), []*pb.Pointer{pointer})
+			if _, err := metainfo.ReleaseSharedSegments(ctx, references.SharedSegments(), []*pb.Pointer{pointer}); err != nil {
+				log.Error("failed to release shared segment", zap.String("path", path), zap.Error(err))
+			}
+		}
 	}
 
 	return nil
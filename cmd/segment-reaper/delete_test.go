@@ -4,13 +4,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
 
 	"storj.io/common/pb"
+	"storj.io/common/storj"
 	"storj.io/common/testcontext"
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/storage"
@@ -29,7 +37,7 @@ func TestDeleteSegment(t *testing.T) {
 		require.NoError(t, err)
 
 		dryRun := false
-		deleteError := deleteSegment(ctx, db, "path1", time.Unix(10, 0), dryRun)
+		deleteError := deleteSegment(ctx, zaptest.NewLogger(t), db, nil, "path1", time.Unix(10, 0), dryRun)
 		require.NoError(t, deleteError)
 		_, err = db.Get(ctx, storage.Key("path1"))
 		require.Error(t, err)
@@ -40,7 +48,7 @@ func TestDeleteSegment(t *testing.T) {
 		require.NoError(t, err)
 
 		dryRun := true
-		deleteError := deleteSegment(ctx, db, "path2", time.Unix(10, 0), dryRun)
+		deleteError := deleteSegment(ctx, zaptest.NewLogger(t), db, nil, "path2", time.Unix(10, 0), dryRun)
 		require.NoError(t, deleteError)
 		pointer, err := db.Get(ctx, storage.Key("path2"))
 		require.NoError(t, err)
@@ -53,7 +61,7 @@ func TestDeleteSegment(t *testing.T) {
 		require.NoError(t, err)
 
 		dryRun := false
-		deleteError := deleteSegment(ctx, db, "path3", time.Unix(99, 0), dryRun)
+		deleteError := deleteSegment(ctx, zaptest.NewLogger(t), db, nil, "path3", time.Unix(99, 0), dryRun)
 		require.Error(t, deleteError)
 		require.True(t, errKnown.Has(deleteError))
 		pointer, err := db.Get(ctx, storage.Key("path3"))
@@ -64,12 +72,168 @@ func TestDeleteSegment(t *testing.T) {
 	})
 	t.Run("segment is not deleted because not exists", func(t *testing.T) {
 		dryRun := false
-		deleteError := deleteSegment(ctx, db, "not-existing-path", time.Unix(10, 0), dryRun)
+		deleteError := deleteSegment(ctx, zaptest.NewLogger(t), db, nil, "not-existing-path", time.Unix(10, 0), dryRun)
 		require.Error(t, deleteError)
 		require.True(t, errKnown.Has(deleteError))
 	})
 }
 
+func TestDeleteSegments(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	db := teststore.New()
+	defer ctx.Check(db.Close)
+
+	const segmentCount = 25
+	const batchSize = 10
+
+	var input bytes.Buffer
+	writer := csv.NewWriter(&input)
+	require.NoError(t, writer.Write([]string{"ProjectID", "SegmentIndex", "Bucket", "EncodedEncryptedPath", "CreationDate", "Size"}))
+
+	creationDate := time.Unix(10, 0).UTC()
+	var paths []string
+	for i := 0; i < segmentCount; i++ {
+		encryptedPath := fmt.Sprintf("object-%d", i)
+		path := storj.JoinPaths("project", "s0", "bucket", encryptedPath)
+		_, err := makeSegment(ctx, db, path, creationDate)
+		require.NoError(t, err)
+		paths = append(paths, path)
+
+		require.NoError(t, writer.Write([]string{
+			"project", "s0", "bucket",
+			base64.StdEncoding.EncodeToString([]byte(encryptedPath)),
+			creationDate.Format(time.RFC3339Nano),
+			"0",
+		}))
+	}
+	writer.Flush()
+	require.NoError(t, writer.Error())
+	data := input.Bytes()
+
+	// the first run is interrupted after the header and 12 records.
+	db.CallCount.GetAll = 0
+	summary, err := deleteSegments(ctx, zaptest.NewLogger(t), db, nil, bytes.NewReader(data[:recordsEnd(data, 13)]), batchSize, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, 12, summary.Deleted)
+	require.Equal(t, 2, summary.Batches)
+	require.Equal(t, 13, summary.Cursor)
+	require.Equal(t, 2, db.CallCount.GetAll)
+
+	// the next run resumes from the cursor.
+	summary, err = deleteSegments(ctx, zaptest.NewLogger(t), db, nil, bytes.NewReader(data), batchSize, summary.Cursor, false)
+	require.NoError(t, err)
+	require.Equal(t, segmentCount-12, summary.Deleted)
+	require.Zero(t, summary.Skipped)
+	require.Zero(t, summary.Errored)
+	require.Equal(t, 2, summary.Batches)
+	require.Equal(t, segmentCount+1, summary.Cursor)
+
+	for _, path := range paths {
+		_, err := db.Get(ctx, storage.Key(path))
+		require.True(t, storage.ErrKeyNotFound.Has(err))
+	}
+
+	// the segments are skipped once they are deleted.
+	summary, err = deleteSegments(ctx, zaptest.NewLogger(t), db, nil, bytes.NewReader(data), batchSize, 1, false)
+	require.NoError(t, err)
+	require.Zero(t, summary.Deleted)
+	require.Equal(t, segmentCount, summary.Skipped)
+}
+
+func TestDeleteSegments_Canceled(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	db := teststore.New()
+	defer ctx.Check(db.Close)
+
+	const segmentCount = 25
+	const batchSize = 10
+
+	var input bytes.Buffer
+	writer := csv.NewWriter(&input)
+	require.NoError(t, writer.Write([]string{"ProjectID", "SegmentIndex", "Bucket", "EncodedEncryptedPath", "CreationDate", "Size"}))
+
+	creationDate := time.Unix(10, 0).UTC()
+	for i := 0; i < segmentCount; i++ {
+		encryptedPath := fmt.Sprintf("object-%d", i)
+		_, err := makeSegment(ctx, db, storj.JoinPaths("project", "s0", "bucket", encryptedPath), creationDate)
+		require.NoError(t, err)
+
+		require.NoError(t, writer.Write([]string{
+			"project", "s0", "bucket",
+			base64.StdEncoding.EncodeToString([]byte(encryptedPath)),
+			creationDate.Format(time.RFC3339Nano),
+			"0",
+		}))
+	}
+	writer.Flush()
+	require.NoError(t, writer.Error())
+	data := input.Bytes()
+
+	// the run is canceled in the middle of the second batch, after the
+	// header and 13 records have been read.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reader := &cancelingReader{
+		first:  data[:recordsEnd(data, 13)],
+		rest:   data[recordsEnd(data, 13):],
+		cancel: cancel,
+	}
+	summary, err := deleteSegments(cancelCtx, zaptest.NewLogger(t), db, nil, reader, batchSize, 0, false)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, batchSize, summary.Deleted)
+	require.Equal(t, 1, summary.Batches)
+	// the cursor is the end of the first batch, the records of the second
+	// one haven't been deleted.
+	require.Equal(t, batchSize+1, summary.Cursor)
+
+	// the next run resumes from the cursor and deletes all the others.
+	summary, err = deleteSegments(ctx, zaptest.NewLogger(t), db, nil, bytes.NewReader(data), batchSize, summary.Cursor, false)
+	require.NoError(t, err)
+	require.Equal(t, segmentCount-batchSize, summary.Deleted)
+	require.Zero(t, summary.Skipped)
+	require.Zero(t, summary.Errored)
+	require.Equal(t, segmentCount+1, summary.Cursor)
+
+	keys, err := db.List(ctx, nil, 0)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+// cancelingReader returns first, then cancels the context before returning
+// rest.
+type cancelingReader struct {
+	first, rest []byte
+	cancel      func()
+}
+
+func (reader *cancelingReader) Read(p []byte) (int, error) {
+	if len(reader.first) > 0 {
+		n := copy(p, reader.first)
+		reader.first = reader.first[n:]
+		return n, nil
+	}
+	reader.cancel()
+	if len(reader.rest) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, reader.rest)
+	reader.rest = reader.rest[n:]
+	return n, nil
+}
+
+// recordsEnd returns the offset of the end of the first count lines of data.
+func recordsEnd(data []byte, count int) int {
+	end := 0
+	for i := 0; i < count; i++ {
+		end += bytes.IndexByte(data[end:], '\n') + 1
+	}
+	return end
+}
+
 func makeSegment(ctx context.Context, db metainfo.PointerDB, path string, creationDate time.Time) (pointerBytes []byte, err error) {
 	pointer := &pb.Pointer{
 		CreationDate: creationDate,
@@ -86,3 +86,10 @@ var MaxMetadataSize = func(maxMetadataSize memory.Size) func(log *zap.Logger, in
 		config.Metainfo.MaxMetadataSize = maxMetadataSize
 	}
 }
+
+// MaxPointerSize returns function to change satellite max pointer size value.
+var MaxPointerSize = func(maxPointerSize memory.Size) func(log *zap.Logger, index int, config *satellite.Config) {
+	return func(log *zap.Logger, index int, config *satellite.Config) {
+		config.Metainfo.MaxPointerSize = maxPointerSize
+	}
+}
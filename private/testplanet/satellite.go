@@ -50,6 +50,8 @@ import (
 	"storj.io/storj/satellite/mailservice"
 	"storj.io/storj/satellite/marketingweb"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/deletionverifier"
 	"storj.io/storj/satellite/metainfo/expireddeletion"
 	"storj.io/storj/satellite/metainfo/objectdeletion"
 	"storj.io/storj/satellite/metainfo/piecedeletion"
@@ -393,6 +395,12 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 			},
 			Admin: admin.Config{
 				Address: "127.0.0.1:0",
+				NodeObjects: metainfo.NodeObjectsConfig{
+					MaxConcurrentSearches: 1,
+					MaxFinishedSearches:   10,
+					MaxTrackedObjects:     1000,
+				},
+				PieceLayoutPageSize: 100,
 			},
 			Contact: contact.Config{
 				Timeout: 1 * time.Minute,
@@ -426,13 +434,17 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 				},
 			},
 			Metainfo: metainfo.Config{
-				DatabaseURL:          "", // not used
-				MinRemoteSegmentSize: 0,  // TODO: fix tests to work with 1024
-				MaxInlineSegmentSize: 4 * memory.KiB,
-				MaxSegmentSize:       64 * memory.MiB,
-				MaxMetadataSize:      2 * memory.KiB,
-				MaxCommitInterval:    1 * time.Hour,
-				Overlay:              true,
+				DatabaseURL:            "", // not used
+				MinRemoteSegmentSize:   0,  // TODO: fix tests to work with 1024
+				MaxInlineSegmentSize:   4 * memory.KiB,
+				MaxSegmentSize:         64 * memory.MiB,
+				MaxMetadataSize:        2 * memory.KiB,
+				MaxPointerSize:         64 * memory.KiB,
+				MaxCommitInterval:      1 * time.Hour,
+				ListCompressionMinSize: 1 * memory.KiB,
+				TransactionRetries:     3,
+				TransactionRetryJitter: time.Millisecond,
+				Overlay:                true,
 				RS: metainfo.RSConfig{
 					MaxBufferMem:     memory.Size(256),
 					ErasureShareSize: memory.Size(256),
@@ -470,12 +482,21 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 					DialTimeout:    2 * time.Second,
 					RequestTimeout: 2 * time.Second,
 					FailThreshold:  2 * time.Second,
+
+					TransientRetries:      2,
+					TransientRetryBackoff: 10 * time.Millisecond,
+
+					MaxRetryPieces:  10000,
+					RetryInterval:   defaultInterval,
+					RetryMaxBackoff: defaultInterval,
 				},
 				ObjectDeletion: objectdeletion.Config{
 					MaxObjectsPerRequest:     100,
 					ZombieSegmentsPerRequest: 3,
 					MaxConcurrentRequests:    100,
 				},
+				MaxConcurrentBucketDeletions: 10,
+				DetailedTracing:              true,
 			},
 			Orders: orders.Config{
 				Expiration:                 7 * 24 * time.Hour,
@@ -531,11 +552,26 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 				FalsePositiveRate: 0.1,
 				ConcurrentSends:   1,
 				RunInCore:         false,
+
+				DeletedPiecesMinAge: 24 * time.Hour,
 			},
 			ExpiredDeletion: expireddeletion.Config{
 				Interval: defaultInterval,
 				Enabled:  true,
 			},
+			DeletionVerifier: deletionverifier.Config{
+				Enabled:  true,
+				Interval: defaultInterval,
+				Delay:    defaultInterval,
+			},
+			DeleteWebhook: deletewebhook.Config{
+				Enabled:     false,
+				QueueSize:   1000,
+				Concurrency: 1,
+				Timeout:     10 * time.Second,
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+			},
 			DBCleanup: dbcleanup.Config{
 				SerialsInterval: defaultInterval,
 			},
@@ -651,7 +687,7 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 			return xs, err
 		}
 
-		adminPeer, err := planet.newAdmin(i, identity, db, config, versionInfo)
+		adminPeer, err := planet.newAdmin(i, identity, db, pointerDB, config, versionInfo)
 		if err != nil {
 			return xs, err
 		}
@@ -665,7 +701,6 @@ func (planet *Planet) newSatellites(count int, satelliteDatabases satellitedbtes
 		if err != nil {
 			return xs, err
 		}
-
 		log.Debug("id=" + peer.ID().String() + " addr=" + api.Addr())
 
 		system := createNewSystem(log, config, peer, api, repairerPeer, adminPeer, gcPeer)
@@ -776,11 +811,17 @@ func (planet *Planet) newAPI(count int, identity *identity.FullIdentity, db sate
 	return satellite.NewAPI(log, identity, db, pointerDB, revocationDB, liveAccounting, rollupsWriteCache, &config, versionInfo, nil)
 }
 
-func (planet *Planet) newAdmin(count int, identity *identity.FullIdentity, db satellite.DB, config satellite.Config, versionInfo version.Info) (*satellite.Admin, error) {
+func (planet *Planet) newAdmin(count int, identity *identity.FullIdentity, db satellite.DB, pointerDB metainfo.PointerDB, config satellite.Config, versionInfo version.Info) (*satellite.Admin, error) {
 	prefix := "satellite-admin" + strconv.Itoa(count)
 	log := planet.log.Named(prefix)
 
-	return satellite.NewAdmin(log, identity, db, versionInfo, &config, nil)
+	revocationDB, err := revocation.NewDBFromCfg(config.Server.Config)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	planet.databases = append(planet.databases, revocationDB)
+
+	return satellite.NewAdmin(log, identity, db, pointerDB, revocationDB, versionInfo, &config, nil)
 }
 
 func (planet *Planet) newRepairer(count int, identity *identity.FullIdentity, db satellite.DB, pointerDB metainfo.PointerDB, config satellite.Config, versionInfo version.Info) (*satellite.Repairer, error) {
@@ -91,6 +91,12 @@ type BucketUsage struct {
 	Egress      float64
 	ObjectCount int64
 
+	// InlineStorage and RemoteStorage split Storage into the bytes of the
+	// inline segments, which are stored in the metainfo database, and the
+	// bytes of the remote segments, which are stored on the nodes.
+	InlineStorage float64
+	RemoteStorage float64
+
 	Since  time.Time
 	Before time.Time
 }
@@ -199,6 +205,8 @@ type ProjectAccounting interface {
 	GetBucketUsageRollups(ctx context.Context, projectID uuid.UUID, since, before time.Time) ([]BucketUsageRollup, error)
 	// GetBucketTotals returns per bucket usage summary for specified period of time.
 	GetBucketTotals(ctx context.Context, projectID uuid.UUID, cursor BucketUsageCursor, since, before time.Time) (*BucketUsagePage, error)
+	// GetLargestBuckets returns the latest storage tallies of the buckets of a projectID, at most limit of them, by descending storage usage.
+	GetLargestBuckets(ctx context.Context, projectID uuid.UUID, limit int) ([]BucketStorageTally, error)
 }
 
 // Cache stores live information about project storage which has not yet been synced to ProjectAccounting.
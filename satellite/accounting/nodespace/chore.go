@@ -0,0 +1,152 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package nodespace reconciles the space the storage nodes report to use for
+// the pieces of the satellite with the space expected from metainfo.
+package nodespace
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+	"storj.io/common/sync2"
+	"storj.io/storj/satellite/metainfo"
+)
+
+var (
+	// Error is the error class for this package.
+	Error = errs.Class("node space error")
+
+	mon = monkit.Package()
+)
+
+// Config contains configurable values for the node space reconciliation.
+type Config struct {
+	// Interval is how frequently the reported space is reconciled.
+	Interval time.Duration
+	// Threshold is the relative drift above which a node is reported, e.g.
+	// 0.1 for a node which reports 10% more or less than expected.
+	Threshold float64
+}
+
+// UsageSource returns the space a storage node reports to use for the pieces
+// of the satellite, without the piece headers.
+//
+// The storage nodes don't send their piece usage to the satellite, hence the
+// source is provided by the caller.
+type UsageSource interface {
+	SpaceUsedForPieces(ctx context.Context, nodeID storj.NodeID) (piecesContentSize int64, err error)
+}
+
+// Drift is the difference between the space a node reports to use for the
+// pieces and the space expected from metainfo.
+type Drift struct {
+	Expected int64
+	Reported int64
+}
+
+// Bytes returns how many bytes the node reports more than expected. It's
+// negative when the node reports less than expected.
+func (drift Drift) Bytes() int64 {
+	return drift.Reported - drift.Expected
+}
+
+// Ratio returns the drift relative to the expected space.
+func (drift Drift) Ratio() float64 {
+	if drift.Expected == 0 {
+		if drift.Reported == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return float64(drift.Bytes()) / float64(drift.Expected)
+}
+
+// Chore periodically compares the space the storage nodes report to use for
+// the pieces with the space expected from metainfo.
+//
+// architecture: Chore
+type Chore struct {
+	log  *zap.Logger
+	Loop *sync2.Cycle
+
+	config       Config
+	metainfoLoop *metainfo.Loop
+	source       UsageSource
+	nowFn        func() time.Time
+}
+
+// NewChore creates a new node space reconciliation chore.
+func NewChore(log *zap.Logger, config Config, metainfoLoop *metainfo.Loop, source UsageSource) *Chore {
+	return &Chore{
+		log:  log,
+		Loop: sync2.NewCycle(config.Interval),
+
+		config:       config,
+		metainfoLoop: metainfoLoop,
+		source:       source,
+		nowFn:        time.Now,
+	}
+}
+
+// Run runs the reconciliation loop.
+func (chore *Chore) Run(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	return chore.Loop.Run(ctx, func(ctx context.Context) error {
+		_, err := chore.Reconcile(ctx)
+		if err != nil {
+			chore.log.Error("node space reconciliation failed", zap.Error(err))
+		}
+		return nil
+	})
+}
+
+// Close stops the chore.
+func (chore *Chore) Close() error {
+	chore.Loop.Close()
+	return nil
+}
+
+// Reconcile compares the reported space of every node which stores pieces
+// according to metainfo once and returns the drift of each of them. The nodes
+// whose usage can't be retrieved are skipped.
+func (chore *Chore) Reconcile(ctx context.Context) (drifts map[storj.NodeID]Drift, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	observer := NewObserver(chore.log.Named("observer"), chore.nowFn())
+	err = chore.metainfoLoop.Join(ctx, observer)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	drifts = make(map[storj.NodeID]Drift, len(observer.Node))
+	for nodeID, expected := range observer.Node {
+		reported, err := chore.source.SpaceUsedForPieces(ctx, nodeID)
+		if err != nil {
+			chore.log.Warn("unable to get reported node space", zap.Stringer("Node ID", nodeID), zap.Error(err))
+			continue
+		}
+
+		drift := Drift{Expected: expected, Reported: reported}
+		drifts[nodeID] = drift
+
+		mon.IntVal("node_space_drift_bytes").Observe(drift.Bytes())
+		if math.Abs(drift.Ratio()) > chore.config.Threshold {
+			mon.Meter("node_space_drift_exceeded").Mark(1)
+			chore.log.Warn("node space drift",
+				zap.Stringer("Node ID", nodeID),
+				zap.Int64("expected", drift.Expected),
+				zap.Int64("reported", drift.Reported),
+			)
+		}
+	}
+
+	return drifts, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package nodespace_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/memory"
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite/accounting/nodespace"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func TestObserver(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	nodes := []storj.NodeID{testrand.NodeID(), testrand.NodeID(), testrand.NodeID()}
+	redundancy := &pb.RedundancyScheme{
+		Type:             pb.RedundancyScheme_RS,
+		MinReq:           2,
+		RepairThreshold:  2,
+		SuccessThreshold: 3,
+		Total:            3,
+		ErasureShareSize: 256,
+	}
+
+	remote := func(segmentSize int64, expiration time.Time, nodes ...storj.NodeID) *pb.Pointer {
+		pointer := &pb.Pointer{
+			Type:           pb.Pointer_REMOTE,
+			SegmentSize:    segmentSize,
+			ExpirationDate: expiration,
+			Remote: &pb.RemoteSegment{
+				RootPieceId: testrand.PieceID(),
+				Redundancy:  redundancy,
+			},
+		}
+		for num, node := range nodes {
+			pointer.Remote.RemotePieces = append(pointer.Remote.RemotePieces, &pb.RemotePiece{
+				PieceNum: int32(num),
+				NodeId:   node,
+			})
+		}
+		return pointer
+	}
+
+	now := time.Now()
+	observer := nodespace.NewObserver(zaptest.NewLogger(t), now)
+
+	// a segment of 2 stripes of 2 shares, i.e. 2 shares of 256 bytes per piece.
+	require.NoError(t, observer.RemoteSegment(ctx, metabase.SegmentLocation{}, remote(1020, time.Time{}, nodes...)))
+	require.NoError(t, observer.RemoteSegment(ctx, metabase.SegmentLocation{}, remote(1020, now.Add(time.Hour), nodes[0])))
+	// expired segments are deleted by the nodes.
+	require.NoError(t, observer.RemoteSegment(ctx, metabase.SegmentLocation{}, remote(1020, now.Add(-time.Hour), nodes...)))
+	require.NoError(t, observer.InlineSegment(ctx, metabase.SegmentLocation{}, &pb.Pointer{
+		Type:          pb.Pointer_INLINE,
+		InlineSegment: testrand.Bytes(memory.KiB),
+	}))
+
+	require.Equal(t, map[storj.NodeID]int64{
+		nodes[0]: 1024,
+		nodes[1]: 512,
+		nodes[2]: 512,
+	}, observer.Node)
+}
+
+func TestChore_Reconcile(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			// no long tail, so every node stores only the pieces in metainfo.
+			Satellite: testplanet.ReconfigureRS(2, 3, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		uplnk := planet.Uplinks[0]
+
+		for i, size := range []memory.Size{5 * memory.KiB, 100 * memory.KiB, 3 * memory.MiB} {
+			err := uplnk.Upload(ctx, satelliteSys, "bucket", "object"+strconv.Itoa(i), testrand.Bytes(size))
+			require.NoError(t, err)
+		}
+
+		chore := nodespace.NewChore(zaptest.NewLogger(t), nodespace.Config{
+			Interval:  time.Hour,
+			Threshold: 0.01,
+		}, satelliteSys.Metainfo.Loop, &nodesUsage{planet: planet})
+		defer ctx.Check(chore.Close)
+
+		drifts, err := chore.Reconcile(ctx)
+		require.NoError(t, err)
+		require.Len(t, drifts, len(planet.StorageNodes))
+
+		for nodeID, drift := range drifts {
+			require.NotZero(t, drift.Expected, nodeID)
+			require.InDelta(t, 0, drift.Ratio(), 0.01, nodeID)
+		}
+	})
+}
+
+// nodesUsage returns the space reported by the storage nodes of the planet
+// for the pieces of the first satellite.
+type nodesUsage struct {
+	planet *testplanet.Planet
+}
+
+func (usage *nodesUsage) SpaceUsedForPieces(ctx context.Context, nodeID storj.NodeID) (int64, error) {
+	for _, node := range usage.planet.StorageNodes {
+		if node.ID() == nodeID {
+			_, contentSize, err := node.Storage2.Store.SpaceUsedBySatellite(ctx, usage.planet.Satellites[0].ID())
+			return contentSize, err
+		}
+	}
+	return 0, nodespace.Error.New("unknown node %s", nodeID)
+}
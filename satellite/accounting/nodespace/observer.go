@@ -0,0 +1,67 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package nodespace
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/uplink/private/eestream"
+)
+
+var _ metainfo.Observer = (*Observer)(nil)
+
+// Observer observes metainfo and adds up the expected piece bytes of the
+// nodes.
+type Observer struct {
+	Now  time.Time
+	Log  *zap.Logger
+	Node map[storj.NodeID]int64
+}
+
+// NewObserver returns a metainfo loop observer which adds up the expected
+// piece bytes of the nodes. The now argument controls when the observer
+// considers pointers to be expired, their pieces are deleted by the nodes.
+func NewObserver(log *zap.Logger, now time.Time) *Observer {
+	return &Observer{
+		Now:  now,
+		Log:  log,
+		Node: make(map[storj.NodeID]int64),
+	}
+}
+
+// Object is called for each object once.
+func (observer *Observer) Object(ctx context.Context, location metabase.SegmentLocation, pointer *pb.Pointer) (err error) {
+	return nil
+}
+
+// InlineSegment is called for each inline segment.
+func (observer *Observer) InlineSegment(ctx context.Context, location metabase.SegmentLocation, pointer *pb.Pointer) (err error) {
+	return nil
+}
+
+// RemoteSegment is called for each remote segment.
+func (observer *Observer) RemoteSegment(ctx context.Context, location metabase.SegmentLocation, pointer *pb.Pointer) (err error) {
+	if !pointer.ExpirationDate.IsZero() && pointer.ExpirationDate.Before(observer.Now) {
+		return nil
+	}
+
+	redundancy, err := eestream.NewRedundancyStrategyFromProto(pointer.GetRemote().GetRedundancy())
+	if err != nil {
+		observer.Log.Error("failed sanity check", zap.ByteString("key", location.Encode()), zap.Error(err))
+		return nil
+	}
+
+	pieceSize := eestream.CalcPieceSize(pointer.GetSegmentSize(), redundancy)
+	for _, piece := range pointer.GetRemote().GetRemotePieces() {
+		observer.Node[piece.NodeId] += pieceSize
+	}
+	return nil
+}
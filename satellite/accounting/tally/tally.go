@@ -246,7 +246,7 @@ func (observer *Observer) InlineSegment(ctx context.Context, location metabase.S
 
 	bucket := observer.ensureBucket(ctx, location)
 	bucket.InlineSegments++
-	bucket.InlineBytes += int64(len(pointer.InlineSegment))
+	bucket.InlineBytes += metainfo.InlineSegmentSize(pointer)
 	bucket.MetadataSize += int64(len(pointer.Metadata))
 
 	return nil
@@ -423,3 +423,64 @@ func satelliteRS(satellite *testplanet.Satellite) storj.RedundancyScheme {
 		ShareSize:      satellite.Config.Metainfo.RS.ErasureShareSize.Int32(),
 	}
 }
+
+func TestTallyInlineAndRemoteBytes(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellitePeer := planet.Satellites[0]
+		satellitePeer.Accounting.Tally.Loop.Pause()
+		uplink := planet.Uplinks[0]
+		projectID := uplink.Projects[0].ID
+
+		// the inline data is only extended by the encryption authentication
+		// overhead, see TestOnlyInline.
+		const encryptionAuthOverhead = 16 // bytes
+		inlineData := testrand.Bytes(1 * memory.KiB)
+		inlineBytes := int64(len(inlineData) + encryptionAuthOverhead)
+
+		// TODO uplink currently hardcode block size so we need to use the same value in test
+		remoteData := testrand.Bytes(50 * memory.KiB)
+		remoteBytes, err := encryption.CalcEncryptedSize(int64(len(remoteData)), storj.EncryptionParameters{
+			CipherSuite: storj.EncAESGCM,
+			BlockSize:   29 * 256 * memory.B.Int32(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, uplink.Upload(ctx, satellitePeer, "inline", "a", inlineData))
+		require.NoError(t, uplink.Upload(ctx, satellitePeer, "inline", "b", inlineData))
+		require.NoError(t, uplink.Upload(ctx, satellitePeer, "remote", "a", remoteData))
+		require.NoError(t, uplink.Upload(ctx, satellitePeer, "mixed", "a", inlineData))
+		require.NoError(t, uplink.Upload(ctx, satellitePeer, "mixed", "b", remoteData))
+
+		expected := map[string][2]int64{
+			"inline": {2 * inlineBytes, 0},
+			"remote": {0, remoteBytes},
+			"mixed":  {inlineBytes, remoteBytes},
+		}
+
+		obs := tally.NewObserver(satellitePeer.Log.Named("observer"), time.Now())
+		err = satellitePeer.Metainfo.Loop.Join(ctx, obs)
+		require.NoError(t, err)
+
+		require.Len(t, obs.Bucket, len(expected))
+		for location, bucketTally := range obs.Bucket {
+			require.Equal(t, expected[location.BucketName], [2]int64{bucketTally.InlineBytes, bucketTally.RemoteBytes}, location.BucketName)
+		}
+
+		since := time.Now().Add(-time.Hour)
+		err = satellitePeer.DB.ProjectAccounting().SaveTallies(ctx, time.Now(), obs.Bucket)
+		require.NoError(t, err)
+
+		page, err := satellitePeer.DB.ProjectAccounting().GetBucketTotals(ctx, projectID, accounting.BucketUsageCursor{Limit: 10, Page: 1}, since, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		require.Len(t, page.BucketUsages, len(expected))
+		for _, usage := range page.BucketUsages {
+			bytes := expected[usage.BucketName]
+			require.Equal(t, memory.Size(bytes[0]).GB(), usage.InlineStorage, usage.BucketName)
+			require.Equal(t, memory.Size(bytes[1]).GB(), usage.RemoteStorage, usage.BucketName)
+			require.Equal(t, memory.Size(bytes[0]+bytes[1]).GB(), usage.Storage, usage.BucketName)
+		}
+	})
+}
@@ -14,14 +14,24 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"storj.io/common/identity"
+	"storj.io/common/pb"
+	"storj.io/common/peertls/extensions"
+	"storj.io/common/peertls/tlsopts"
+	"storj.io/common/rpc"
+	"storj.io/common/signing"
 	"storj.io/common/storj"
 	"storj.io/private/debug"
 	"storj.io/private/version"
 	"storj.io/storj/private/lifecycle"
 	"storj.io/storj/private/version/checker"
 	"storj.io/storj/satellite/admin"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+	"storj.io/storj/satellite/orders"
+	"storj.io/storj/satellite/overlay"
 	"storj.io/storj/satellite/payments"
 	"storj.io/storj/satellite/payments/stripecoinpayments"
+	"storj.io/storj/satellite/repair/repairer"
 )
 
 // Admin is the satellite core process that runs chores
@@ -36,6 +46,8 @@ type Admin struct {
 	Servers  *lifecycle.Group
 	Services *lifecycle.Group
 
+	Dialer rpc.Dialer
+
 	Debug struct {
 		Listener net.Listener
 		Server   *debug.Server
@@ -46,6 +58,32 @@ type Admin struct {
 		Service *checker.Service
 	}
 
+	Overlay struct {
+		DB      overlay.DB
+		Service *overlay.Service
+	}
+
+	Orders struct {
+		DB      *orders.RollupsWriteCache
+		Service *orders.Service
+		Chore   *orders.Chore
+	}
+
+	Metainfo struct {
+		Database            metainfo.PointerDB
+		Service             *metainfo.Service
+		PieceDeletion       *piecedeletion.Service
+		SegmentDeleter      *metainfo.SegmentDeleter
+		ObjectCopier        *metainfo.ObjectCopier
+		DeleteCostEstimator *metainfo.DeleteCostEstimator
+		NodeObjects         *metainfo.NodeObjectsSearches
+	}
+
+	Repair struct {
+		SegmentRepairer *repairer.SegmentRepairer
+		Reencoder       *repairer.Reencoder
+	}
+
 	Payments struct {
 		Accounts payments.Accounts
 		Service  *stripecoinpayments.Service
@@ -60,6 +98,7 @@ type Admin struct {
 
 // NewAdmin creates a new satellite admin peer.
 func NewAdmin(log *zap.Logger, full *identity.FullIdentity, db DB,
+	pointerDB metainfo.PointerDB, revocationDB extensions.RevocationDB,
 	versionInfo version.Info, config *Config, atomicLogLevel *zap.AtomicLevel) (*Admin, error) {
 	peer := &Admin{
 		Log:      log,
@@ -108,6 +147,140 @@ func NewAdmin(log *zap.Logger, full *identity.FullIdentity, db DB,
 		})
 	}
 
+	{ // setup dialer
+		sc := config.Server
+
+		tlsOptions, err := tlsopts.NewOptions(peer.Identity, sc.Config, revocationDB)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+
+		peer.Dialer = rpc.NewDefaultDialer(tlsOptions)
+	}
+
+	{ // setup overlay
+		peer.Overlay.DB = peer.DB.OverlayCache()
+		peer.Overlay.Service = overlay.NewService(peer.Log.Named("overlay"), peer.Overlay.DB, config.Overlay)
+		peer.Services.Add(lifecycle.Item{
+			Name:  "overlay",
+			Close: peer.Overlay.Service.Close,
+		})
+	}
+
+	{ // setup metainfo
+		peer.Metainfo.Database = pointerDB
+		peer.Metainfo.Service = metainfo.NewService(peer.Log.Named("metainfo:service"),
+			peer.Metainfo.Database,
+			peer.DB.Buckets(),
+		)
+
+		var err error
+		peer.Metainfo.PieceDeletion, err = piecedeletion.NewService(
+			peer.Log.Named("metainfo:piecedeletion"),
+			peer.Dialer,
+			peer.Overlay.Service,
+			peer.DB.DeletionRetries(),
+			peer.DB.DeletionDeadLetters(),
+			config.Metainfo.PieceDeletion,
+		)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Services.Add(lifecycle.Item{
+			Name:  "metainfo:piecedeletion",
+			Run:   peer.Metainfo.PieceDeletion.Run,
+			Close: peer.Metainfo.PieceDeletion.Close,
+		})
+
+		peer.Metainfo.SegmentDeleter = metainfo.NewSegmentDeleter(
+			peer.Log.Named("metainfo:segment-deleter"),
+			peer.Metainfo.Service,
+			peer.Metainfo.PieceDeletion,
+			peer.DB.InlineContents(),
+			peer.DB.SharedSegments(),
+		)
+
+		peer.Metainfo.ObjectCopier = metainfo.NewObjectCopier(
+			peer.Log.Named("metainfo:object-copier"),
+			peer.Metainfo.Service,
+			peer.Metainfo.PieceDeletion,
+			peer.DB.InlineContents(),
+			peer.DB.SharedSegments(),
+			config.Metainfo,
+		)
+
+		peer.Metainfo.DeleteCostEstimator = metainfo.NewDeleteCostEstimator(
+			peer.Metainfo.Service,
+			config.Metainfo.PieceDeletion.MaxPiecesPerRequest,
+		)
+
+		peer.Metainfo.NodeObjects = metainfo.NewNodeObjectsSearches(
+			peer.Log.Named("metainfo:node-objects"),
+			peer.Metainfo.Service,
+			config.Admin.NodeObjects,
+		)
+		peer.Services.Add(lifecycle.Item{
+			Name:  "metainfo:node-objects",
+			Close: peer.Metainfo.NodeObjects.Close,
+		})
+	}
+
+	{ // setup orders
+		peer.Orders.DB = orders.NewRollupsWriteCache(peer.Log.Named("orders-write-cache"), peer.DB.Orders(), config.Orders.FlushBatchSize)
+		peer.Orders.Chore = orders.NewChore(peer.Log.Named("orders:chore"), peer.Orders.DB, config.Orders)
+		peer.Services.Add(lifecycle.Item{
+			Name: "orders:chore",
+			Run:  peer.Orders.Chore.Run,
+			Close: func() error {
+				return errs.Combine(
+					peer.Orders.Chore.Close(),
+					peer.Orders.DB.CloseAndFlush(context.Background()),
+				)
+			},
+		})
+		peer.Debug.Server.Panel.Add(
+			debug.Cycle("Orders Chore", peer.Orders.Chore.Loop))
+
+		var err error
+		peer.Orders.Service, err = orders.NewService(
+			peer.Log.Named("orders"),
+			signing.SignerFromFullIdentity(peer.Identity),
+			peer.Overlay.Service,
+			peer.Orders.DB,
+			peer.DB.Buckets(),
+			config.Orders,
+			&pb.NodeAddress{
+				Transport: pb.NodeTransport_TCP_TLS_GRPC,
+				Address:   config.Contact.ExternalAddress,
+			},
+		)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+	}
+
+	{ // setup re-encoding
+		peer.Repair.SegmentRepairer = repairer.NewSegmentRepairer(
+			peer.Log.Named("segment-repair"),
+			peer.Metainfo.Service,
+			peer.Orders.Service,
+			peer.Overlay.Service,
+			peer.Dialer,
+			config.Repairer.Timeout,
+			config.Repairer.MaxExcessRateOptimalThreshold,
+			config.Checker.RepairOverride,
+			config.Repairer.DownloadTimeout,
+			config.Repairer.InMemoryRepair,
+			signing.SigneeFromPeerIdentity(peer.Identity.PeerIdentity()),
+		)
+		peer.Repair.Reencoder = repairer.NewReencoder(
+			peer.Log.Named("reencoder"),
+			peer.Repair.SegmentRepairer,
+			peer.DB.SharedSegments(),
+			peer.Metainfo.PieceDeletion,
+		)
+	}
+
 	{ // setup payments
 		pc := config.Payments
 
@@ -159,6 +332,16 @@ func NewAdmin(log *zap.Logger, full *identity.FullIdentity, db DB,
 		adminConfig.AuthorizationToken = config.Console.AuthToken
 
 		peer.Admin.Server = admin.NewServer(log.Named("admin"), peer.Admin.Listener, peer.DB, peer.Payments.Accounts, adminConfig)
+		peer.Admin.Server.SegmentDeleter = peer.Metainfo.SegmentDeleter
+		peer.Admin.Server.NodeObjectsFinder = peer.Metainfo.NodeObjects
+		peer.Admin.Server.AtRiskObjectsFinder = peer.Metainfo.Service
+		peer.Admin.Server.BucketDeletionPreflighter = peer.Metainfo.Service
+		peer.Admin.Server.SegmentKeysLister = peer.Metainfo.Service
+		peer.Admin.Server.ObjectPieceLayoutGetter = peer.Metainfo.Service
+		peer.Admin.Server.ObjectReencoder = peer.Repair.Reencoder
+		peer.Admin.Server.ObjectCopier = peer.Metainfo.ObjectCopier
+		peer.Admin.Server.DeleteCostEstimator = peer.Metainfo.DeleteCostEstimator
+		peer.Admin.Server.NodeDeletionFlusher = peer.Metainfo.PieceDeletion
 		peer.Servers.Add(lifecycle.Item{
 			Name:  "admin",
 			Run:   peer.Admin.Server.Run,
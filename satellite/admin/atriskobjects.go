@@ -0,0 +1,97 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/schema"
+
+	"storj.io/storj/satellite/metainfo"
+)
+
+// defaultAtRiskObjectsLimit is the number of at risk objects returned when
+// the request doesn't specify a limit.
+const defaultAtRiskObjectsLimit = 100
+
+func (server *Server) atRiskObjects(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.AtRiskObjectsFinder == nil {
+		httpJSONError(w, "at risk objects not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	var arguments struct {
+		Limit *int `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err := decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := metainfo.AtRiskObjectsOptions{Limit: defaultAtRiskObjectsLimit}
+	if arguments.Limit != nil {
+		if *arguments.Limit < 0 {
+			httpJSONError(w, "negative limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		opts.Limit = *arguments.Limit
+	}
+
+	objects, err := server.AtRiskObjectsFinder.AtRiskObjects(ctx, opts)
+	if err != nil {
+		httpJSONError(w, "unable to find the at risk objects",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type object struct {
+		ProjectID          string `json:"projectId"`
+		BucketName         string `json:"bucketName"`
+		EncryptedObjectKey string `json:"encryptedObjectKey"`
+		Segments           int    `json:"segments"`
+		Margin             int    `json:"margin"`
+	}
+	var output struct {
+		Count   int64    `json:"count"`
+		Objects []object `json:"objects"`
+	}
+	output.Count = objects.Count
+	output.Objects = make([]object, 0, len(objects.Objects))
+	for _, atRisk := range objects.Objects {
+		output.Objects = append(output.Objects, object{
+			ProjectID:          atRisk.Location.ProjectID.String(),
+			BucketName:         atRisk.Location.BucketName,
+			EncryptedObjectKey: base64.RawURLEncoding.EncodeToString([]byte(atRisk.Location.ObjectKey)),
+			Segments:           atRisk.Segments,
+			Margin:             atRisk.Margin,
+		})
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
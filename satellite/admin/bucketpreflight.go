@@ -0,0 +1,66 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"storj.io/common/storj"
+)
+
+func (server *Server) deleteBucketPreflight(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.BucketDeletionPreflighter == nil {
+		httpJSONError(w, "bucket deletion preflight not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	bucketName, ok := mux.Vars(r)["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	result, err := server.BucketDeletionPreflighter.DeleteBucketPreflight(ctx, projectUUID, []byte(bucketName))
+	if err != nil {
+		if storj.ErrBucketNotFound.Has(err) {
+			httpJSONError(w, "bucket not found",
+				err.Error(), http.StatusNotFound)
+			return
+		}
+		httpJSONError(w, "unable to count the bucket segments",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var output struct {
+		Objects  int   `json:"objects"`
+		Segments int   `json:"segments"`
+		Bytes    int64 `json:"bytes"`
+	}
+	output.Objects = result.Objects
+	output.Segments = result.Segments
+	output.Bytes = result.Bytes
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestDeleteBucketPreflight(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				func(log *zap.Logger, index int, config *satellite.Config) {
+					config.Admin.Address = "127.0.0.1:0"
+				},
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(13*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "preflight-bucket"
+
+		err := planet.Uplinks[0].Upload(ctx, sat, bucketName, "inline-object", testrand.Bytes(1*memory.KiB))
+		require.NoError(t, err)
+		err = planet.Uplinks[0].Upload(ctx, sat, bucketName, "single-segment-object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+		err = planet.Uplinks[0].Upload(ctx, sat, bucketName, "multi-segment-object", testrand.Bytes(50*memory.KiB))
+		require.NoError(t, err)
+
+		// the objects of other buckets aren't counted.
+		err = planet.Uplinks[0].Upload(ctx, sat, "other-bucket", "object", testrand.Bytes(50*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		var expectedSegments int
+		var expectedBytes int64
+		for _, key := range keys {
+			location, err := metabase.ParseSegmentKey(metabase.SegmentKey(key))
+			require.NoError(t, err)
+			if location.BucketName != bucketName {
+				continue
+			}
+			pointer, err := sat.Metainfo.Service.Get(ctx, location.Encode())
+			require.NoError(t, err)
+			expectedSegments++
+			expectedBytes += pointer.SegmentSize
+		}
+		require.Greater(t, expectedSegments, 3)
+
+		preflight := func(bucket string) (int, string) {
+			link := "http://" + address.String() + "/api/project/" + projectID.String() + "/bucket/" + bucket + "/delete-preflight"
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			return response.StatusCode, string(data)
+		}
+
+		status, body := preflight(bucketName)
+		require.Equal(t, http.StatusOK, status, body)
+
+		var output struct {
+			Objects  int   `json:"objects"`
+			Segments int   `json:"segments"`
+			Bytes    int64 `json:"bytes"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &output))
+		require.Equal(t, 3, output.Objects)
+		require.Equal(t, expectedSegments, output.Segments)
+		require.Equal(t, expectedBytes, output.Bytes)
+
+		// the preflight doesn't delete anything.
+		after, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, after, len(keys))
+
+		status, body = preflight("missing-bucket")
+		require.Equal(t, http.StatusNotFound, status, body)
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"storj.io/common/errs2"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo"
+)
+
+func (server *Server) copyObject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.ObjectCopier == nil {
+		httpJSONError(w, "object copies not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucketName, ok := vars["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	objectKey, err := base64.RawURLEncoding.DecodeString(vars["objectkey"])
+	if err != nil || len(objectKey) == 0 {
+		httpJSONError(w, "invalid encrypted object key",
+			"the key must be base64url encoded without padding", http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpJSONError(w, "failed to read body",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var input struct {
+		BucketName         string `json:"bucketName"`
+		EncryptedObjectKey string `json:"encryptedObjectKey"`
+		EncryptedMetadata  []byte `json:"encryptedMetadata"`
+		Segments           []struct {
+			Index             int64  `json:"index"`
+			EncryptedKey      []byte `json:"encryptedKey"`
+			EncryptedKeyNonce []byte `json:"encryptedKeyNonce"`
+		} `json:"segments"`
+	}
+
+	err = json.Unmarshal(body, &input)
+	if err != nil {
+		httpJSONError(w, "failed to unmarshal request",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.BucketName == "" {
+		httpJSONError(w, "bucketName is not set",
+			"", http.StatusBadRequest)
+		return
+	}
+	copyKey, err := base64.RawURLEncoding.DecodeString(input.EncryptedObjectKey)
+	if err != nil || len(copyKey) == 0 {
+		httpJSONError(w, "invalid encryptedObjectKey",
+			"the key must be base64url encoded without padding", http.StatusBadRequest)
+		return
+	}
+
+	keys := metainfo.CopyObjectKeys{
+		EncryptedMetadata: input.EncryptedMetadata,
+	}
+	for _, segment := range input.Segments {
+		nonce, err := storj.NonceFromBytes(segment.EncryptedKeyNonce)
+		if err != nil {
+			httpJSONError(w, "invalid encryptedKeyNonce",
+				err.Error(), http.StatusBadRequest)
+			return
+		}
+		keys.Segments = append(keys.Segments, metainfo.CopySegmentKey{
+			Index:             segment.Index,
+			EncryptedKey:      segment.EncryptedKey,
+			EncryptedKeyNonce: nonce,
+		})
+	}
+
+	err = server.ObjectCopier.CopyObject(ctx, projectUUID, []byte(bucketName), objectKey, []byte(input.BucketName), copyKey, keys)
+	if err != nil {
+		switch {
+		case errs2.IsRPC(err, rpcstatus.InvalidArgument):
+			httpJSONError(w, "invalid arguments",
+				err.Error(), http.StatusBadRequest)
+		case errs2.IsRPC(err, rpcstatus.NotFound):
+			httpJSONError(w, "object or bucket not found",
+				err.Error(), http.StatusNotFound)
+		case errs2.IsRPC(err, rpcstatus.AlreadyExists),
+			errs2.IsRPC(err, rpcstatus.FailedPrecondition),
+			errs2.IsRPC(err, rpcstatus.Aborted):
+			httpJSONError(w, "unable to copy the object",
+				err.Error(), http.StatusConflict)
+		default:
+			httpJSONError(w, "unable to copy the object",
+				err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
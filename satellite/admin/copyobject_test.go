@@ -0,0 +1,93 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestCopyObject(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		location, err := metabase.ParseSegmentKey(metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		pointer, err := sat.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+
+		copyObject := func(encryptedMetadata []byte) (int, string) {
+			body, err := json.Marshal(map[string]interface{}{
+				"bucketName":         "testbucket",
+				"encryptedObjectKey": base64.RawURLEncoding.EncodeToString([]byte("copy")),
+				"encryptedMetadata":  encryptedMetadata,
+			})
+			require.NoError(t, err)
+
+			link := "http://" + address.String() + "/api/project/" + location.ProjectID.String() +
+				"/bucket/testbucket/object/" + base64.RawURLEncoding.EncodeToString([]byte(location.ObjectKey)) + "/copy"
+			req, err := http.NewRequest(http.MethodPost, link, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			return response.StatusCode, string(data)
+		}
+
+		status, body := copyObject([]byte("invalid"))
+		require.Equal(t, http.StatusBadRequest, status, body)
+
+		// the object has a single segment, whose key is in the metadata.
+		status, body = copyObject(pointer.Metadata)
+		require.Equal(t, http.StatusNoContent, status, body)
+
+		copied, err := sat.Metainfo.Service.Get(ctx, metabase.SegmentLocation{
+			ProjectID:  location.ProjectID,
+			BucketName: location.BucketName,
+			ObjectKey:  "copy",
+			Index:      metabase.LastSegmentIndex,
+		}.Encode())
+		require.NoError(t, err)
+		require.Equal(t, pointer.Remote, copied.Remote)
+
+		status, body = copyObject(pointer.Metadata)
+		require.Equal(t, http.StatusConflict, status, body)
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func (server *Server) estimateDeleteCost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.DeleteCostEstimator == nil {
+		httpJSONError(w, "delete cost estimate not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucketName, ok := vars["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	objectKey, err := base64.RawURLEncoding.DecodeString(vars["objectkey"])
+	if err != nil || len(objectKey) == 0 {
+		httpJSONError(w, "invalid encrypted object key",
+			"the key must be base64url encoded without padding", http.StatusBadRequest)
+		return
+	}
+
+	cost, err := server.DeleteCostEstimator.EstimateDeleteCost(ctx, metabase.ObjectLocation{
+		ProjectID:  projectUUID,
+		BucketName: bucketName,
+		ObjectKey:  metabase.ObjectKey(objectKey),
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if storj.ErrObjectNotFound.Has(err) {
+			status = http.StatusNotFound
+		}
+		httpJSONError(w, "unable to estimate the delete cost",
+			err.Error(), status)
+		return
+	}
+
+	var output struct {
+		Segments int   `json:"segments"`
+		Nodes    int   `json:"nodes"`
+		RPCs     int   `json:"rpcs"`
+		Pieces   int   `json:"pieces"`
+		Bytes    int64 `json:"bytes"`
+	}
+	output.Segments = cost.Segments
+	output.Nodes = cost.Nodes
+	output.RPCs = cost.RPCs
+	output.Pieces = cost.Pieces
+	output.Bytes = cost.Bytes
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
+	"storj.io/storj/storage"
+)
+
+func TestEstimateDeleteCost(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				func(log *zap.Logger, index int, config *satellite.Config) {
+					config.Admin.Address = "127.0.0.1:0"
+				},
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(10*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(25*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+
+		location, err := metabase.ParseSegmentKey(metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		object := location.Object()
+
+		type cost struct {
+			Segments int   `json:"segments"`
+			Nodes    int   `json:"nodes"`
+			RPCs     int   `json:"rpcs"`
+			Pieces   int   `json:"pieces"`
+			Bytes    int64 `json:"bytes"`
+		}
+
+		estimate := func() (int, cost) {
+			link := "http://" + address.String() + "/api/project/" + object.ProjectID.String() + "/bucket/" + object.BucketName +
+				"/object/" + base64.RawURLEncoding.EncodeToString([]byte(object.ObjectKey)) + "/delete-cost"
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+
+			var output cost
+			if response.StatusCode == http.StatusOK {
+				require.NoError(t, json.Unmarshal(data, &output))
+			}
+			return response.StatusCode, output
+		}
+
+		status, estimated := estimate()
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 3, estimated.Segments)
+		require.Equal(t, 4, estimated.Nodes)
+		require.Equal(t, estimated.Nodes, estimated.RPCs)
+		require.Equal(t, 12, estimated.Pieces)
+
+		// estimating doesn't delete anything.
+		after, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, after, 3)
+
+		// the estimate matches what the deletion deletes.
+		result, err := sat.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(ctx, object.ProjectID,
+			[]byte(object.BucketName), []byte(object.ObjectKey), metainfo.DeleteObjectPiecesOptions{})
+		require.NoError(t, err)
+
+		deleted := result.DeletedPointers()
+		require.Len(t, deleted, estimated.Segments)
+		require.Equal(t, estimated.Nodes, result.Nodes)
+
+		var pieces int
+		var bytes int64
+		for _, pointer := range deleted {
+			pieces += len(pointer.GetRemote().GetRemotePieces())
+		}
+		for _, size := range objectdeletion.GroupPieceSizesByNodeID(deleted) {
+			bytes += size
+		}
+		require.Equal(t, estimated.Pieces, pieces)
+		require.Equal(t, estimated.Bytes, bytes)
+		require.NotZero(t, estimated.Bytes)
+
+		status, _ = estimate()
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// defaultDeletedStubsLimit is the number of stubs returned when the request
+// doesn't specify a limit.
+const defaultDeletedStubsLimit = 100
+
+func (server *Server) deletedStubs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	projectUUIDString, ok := vars["project"]
+	if !ok {
+		httpJSONError(w, "project-uuid missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	projectUUID, err := uuid.FromString(projectUUIDString)
+	if err != nil {
+		httpJSONError(w, "invalid project-uuid",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bucketName, ok := vars["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	var arguments struct {
+		Cursor string `schema:"cursor"`
+		Limit  *int   `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err = decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursor, err := base64.RawURLEncoding.DecodeString(arguments.Cursor)
+	if err != nil {
+		httpJSONError(w, "invalid cursor",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultDeletedStubsLimit
+	if arguments.Limit != nil {
+		if *arguments.Limit <= 0 {
+			httpJSONError(w, "non-positive limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		limit = *arguments.Limit
+	}
+
+	stubs, err := server.db.DeletedObjectStubs().List(ctx, metabase.BucketLocation{
+		ProjectID:  projectUUID,
+		BucketName: bucketName,
+	}, metabase.ObjectKey(cursor), limit)
+	if err != nil {
+		httpJSONError(w, "unable to list the deleted object stubs",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type stub struct {
+		EncryptedObjectKey string    `json:"encryptedObjectKey"`
+		Size               int64     `json:"size"`
+		Segments           int       `json:"segments"`
+		CreatedAt          time.Time `json:"createdAt"`
+		DeletedAt          time.Time `json:"deletedAt"`
+	}
+	output := make([]stub, 0, len(stubs))
+	for _, s := range stubs {
+		output = append(output, stub{
+			EncryptedObjectKey: base64.RawURLEncoding.EncodeToString([]byte(s.ObjectKey)),
+			Size:               s.Size,
+			Segments:           s.Segments,
+			CreatedAt:          s.ObjectCreated,
+			DeletedAt:          s.Deleted,
+		})
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestDeletedStubs(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		location, err := metabase.ParseSegmentKey(metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		pointer, err := sat.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+
+		result, err := sat.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(ctx, location.ProjectID,
+			[]byte(location.BucketName), []byte(location.ObjectKey), metainfo.DeleteObjectPiecesOptions{
+				KeepStub: true,
+			})
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+
+		// the stub isn't listed with the objects.
+		listed, err := sat.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, location.ProjectID, []byte(location.BucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, listed.Items)
+
+		type stub struct {
+			EncryptedObjectKey string `json:"encryptedObjectKey"`
+			Size               int64  `json:"size"`
+			Segments           int    `json:"segments"`
+		}
+
+		get := func(query string) (stubs []stub) {
+			link := "http://" + address.String() + "/api/project/" + location.ProjectID.String() + "/bucket/" + location.BucketName + "/stubs" + query
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, http.StatusOK, response.StatusCode, string(data))
+
+			require.NoError(t, json.Unmarshal(data, &stubs))
+			return stubs
+		}
+
+		encodedKey := base64.RawURLEncoding.EncodeToString([]byte(location.ObjectKey))
+		require.Equal(t, []stub{{
+			EncryptedObjectKey: encodedKey,
+			Size:               pointer.SegmentSize,
+			Segments:           1,
+		}}, get(""))
+
+		// the page after the stub is empty.
+		require.Empty(t, get("?cursor="+encodedKey))
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+)
+
+// deleteWebhookSecretSize is the size of the generated secrets of the delete
+// webhooks.
+const deleteWebhookSecretSize = 32
+
+// projectFromVars returns the project of the request, it writes the error
+// response when it's missing or invalid.
+func projectFromVars(w http.ResponseWriter, r *http.Request) (_ uuid.UUID, ok bool) {
+	vars := mux.Vars(r)
+	projectUUIDString, ok := vars["project"]
+	if !ok {
+		httpJSONError(w, "project-uuid missing",
+			"", http.StatusBadRequest)
+		return uuid.UUID{}, false
+	}
+
+	projectUUID, err := uuid.FromString(projectUUIDString)
+	if err != nil {
+		httpJSONError(w, "invalid project-uuid",
+			err.Error(), http.StatusBadRequest)
+		return uuid.UUID{}, false
+	}
+	return projectUUID, true
+}
+
+func (server *Server) getDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := server.db.DeleteWebhooks().Get(ctx, projectUUID)
+	if err != nil {
+		httpJSONError(w, "unable to get the delete webhook",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if webhook == nil {
+		httpJSONError(w, "the project has no delete webhook",
+			"", http.StatusNotFound)
+		return
+	}
+
+	// the secret is only returned when the webhook is registered.
+	var output struct {
+		URL       string    `json:"url"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	output.URL = webhook.URL
+	output.CreatedAt = webhook.CreatedAt
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
+
+func (server *Server) putDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	var arguments struct {
+		URL string `schema:"url"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err := decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhookURL, err := url.Parse(arguments.URL)
+	if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		httpJSONError(w, "invalid url",
+			"the url must be an absolute http or https url", http.StatusBadRequest)
+		return
+	}
+
+	_, err = server.db.Console().Projects().Get(ctx, projectUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpJSONError(w, "project with specified uuid does not exist",
+			"", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		httpJSONError(w, "failed to get project",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	secret := make([]byte, deleteWebhookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		httpJSONError(w, "unable to generate the secret",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = server.db.DeleteWebhooks().Set(ctx, deletewebhook.Webhook{
+		ProjectID: projectUUID,
+		URL:       webhookURL.String(),
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		httpJSONError(w, "unable to set the delete webhook",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var output struct {
+		URL    string `json:"url"`
+		Secret string `json:"secret"`
+	}
+	output.URL = webhookURL.String()
+	output.Secret = hex.EncodeToString(secret)
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
+
+func (server *Server) deleteDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	err := server.db.DeleteWebhooks().Delete(ctx, projectUUID)
+	if err != nil {
+		httpJSONError(w, "unable to delete the delete webhook",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"storj.io/common/errs2"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func (server *Server) deletionBacklog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	retryPieces, err := server.db.DeletionRetries().Count(ctx)
+	if err != nil {
+		httpJSONError(w, "unable to count the queued piece deletions",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(fmt.Sprintf(`{"retryPieces":%d}`, retryPieces))) // nothing to do with the error response, probably the client requesting disappeared
+}
+
+func (server *Server) forceDeleteSegment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.SegmentDeleter == nil {
+		httpJSONError(w, "segment deletion not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	vars := mux.Vars(r)
+	encodedKey, ok := vars["segmentkey"]
+	if !ok {
+		httpJSONError(w, "segment-key missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil {
+		httpJSONError(w, "invalid segment-key",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deletedPieces, err := server.SegmentDeleter.ForceDeleteSegment(ctx, metabase.SegmentKey(key))
+	if err != nil {
+		switch {
+		case errs2.IsRPC(err, rpcstatus.InvalidArgument):
+			httpJSONError(w, "invalid segment-key",
+				err.Error(), http.StatusBadRequest)
+		case errs2.IsRPC(err, rpcstatus.NotFound):
+			httpJSONError(w, "segment not found",
+				err.Error(), http.StatusNotFound)
+		default:
+			httpJSONError(w, "unable to delete segment",
+				err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(fmt.Sprintf(`{"deletedPieces":%d}`, deletedPieces))) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestDeletionBacklog(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 2,
+		UplinkCount:      0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+		link := "http://" + address.String() + "/api/deletion/backlog"
+
+		// the retries are only sent by flushing them.
+		sat.API.Metainfo.PieceDeletionRetry.Loop.Pause()
+
+		backlog := func() int64 {
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, http.StatusOK, response.StatusCode, string(data))
+
+			var output struct {
+				RetryPieces int64 `json:"retryPieces"`
+			}
+			require.NoError(t, json.Unmarshal(data, &output))
+			return output.RetryPieces
+		}
+
+		require.Zero(t, backlog())
+
+		retries := sat.DB.DeletionRetries()
+		require.NoError(t, retries.Add(ctx, planet.StorageNodes[0].ID(), []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, 0))
+		require.NoError(t, retries.Add(ctx, planet.StorageNodes[1].ID(), []storj.PieceID{testrand.PieceID()}, 0))
+
+		require.EqualValues(t, 3, backlog())
+	})
+}
+
+func TestForceDeleteSegment(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		deleteSegment := func(key []byte) (int, string) {
+			link := "http://" + address.String() + "/api/segment/" + base64.RawURLEncoding.EncodeToString(key)
+			req, err := http.NewRequest(http.MethodDelete, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			return response.StatusCode, string(data)
+		}
+
+		// the admin peer deletes the segment with its own piece deletion
+		// service.
+		status, body := deleteSegment(keys[0])
+		require.Equal(t, http.StatusOK, status, body)
+		require.Contains(t, body, `"deletedPieces":`)
+
+		_, err = sat.Metainfo.Service.Get(ctx, metabase.SegmentKey(keys[0]))
+		require.True(t, storj.ErrObjectNotFound.Has(err), err)
+
+		status, body = deleteSegment(keys[0])
+		require.Equal(t, http.StatusNotFound, status, body)
+
+		status, body = deleteSegment([]byte("invalid"))
+		require.Equal(t, http.StatusBadRequest, status, body)
+	})
+}
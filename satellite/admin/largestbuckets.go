@@ -0,0 +1,100 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/uuid"
+)
+
+// defaultLargestBucketsLimit is the number of buckets returned when the
+// request doesn't specify a limit.
+const defaultLargestBucketsLimit = 10
+
+func (server *Server) largestBuckets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	projectUUIDString, ok := vars["project"]
+	if !ok {
+		httpJSONError(w, "project-uuid missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	projectUUID, err := uuid.FromString(projectUUIDString)
+	if err != nil {
+		httpJSONError(w, "invalid project-uuid",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var arguments struct {
+		Limit *int `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err = decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultLargestBucketsLimit
+	if arguments.Limit != nil {
+		if *arguments.Limit < 0 {
+			httpJSONError(w, "negative limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		limit = *arguments.Limit
+	}
+
+	tallies, err := server.db.ProjectAccounting().GetLargestBuckets(ctx, projectUUID, limit)
+	if err != nil {
+		httpJSONError(w, "unable to get the largest buckets",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type bucket struct {
+		BucketName  string    `json:"bucketName"`
+		Bytes       int64     `json:"bytes"`
+		ObjectCount int64     `json:"objectCount"`
+		TalliedAt   time.Time `json:"talliedAt"`
+	}
+	output := make([]bucket, 0, len(tallies))
+	for _, tally := range tallies {
+		output = append(output, bucket{
+			BucketName:  tally.BucketName,
+			Bytes:       tally.InlineBytes + tally.RemoteBytes,
+			ObjectCount: tally.ObjectCount,
+			TalliedAt:   tally.IntervalStart,
+		})
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
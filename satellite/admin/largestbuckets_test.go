@@ -0,0 +1,92 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/testcontext"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/accounting"
+)
+
+func TestLargestBuckets(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 0,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		latest := time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC)
+		tally := func(intervalStart time.Time, bucketName string, inline, remote int64) {
+			err := sat.DB.ProjectAccounting().CreateStorageTally(ctx, accounting.BucketStorageTally{
+				BucketName:    bucketName,
+				ProjectID:     projectID,
+				IntervalStart: intervalStart,
+				ObjectCount:   1,
+				InlineBytes:   inline,
+				RemoteBytes:   remote,
+			})
+			require.NoError(t, err)
+		}
+
+		// the bucket was emptied since the previous tally.
+		tally(latest.Add(-time.Hour), "emptied", 0, 1000000)
+		tally(latest.Add(-time.Hour), "medium", 0, 100)
+
+		tally(latest, "small", 10, 0)
+		tally(latest, "large", 10, 5000)
+		tally(latest, "medium", 0, 2000)
+
+		type bucket struct {
+			BucketName string `json:"bucketName"`
+			Bytes      int64  `json:"bytes"`
+		}
+
+		get := func(query string) (buckets []bucket) {
+			link := "http://" + address.String() + "/api/project/" + projectID.String() + "/buckets/largest" + query
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, http.StatusOK, response.StatusCode, string(data))
+
+			require.NoError(t, json.Unmarshal(data, &buckets))
+			return buckets
+		}
+
+		require.Equal(t, []bucket{
+			{BucketName: "large", Bytes: 5010},
+			{BucketName: "medium", Bytes: 2000},
+			{BucketName: "small", Bytes: 10},
+		}, get(""))
+
+		require.Equal(t, []bucket{
+			{BucketName: "large", Bytes: 5010},
+			{BucketName: "medium", Bytes: 2000},
+		}, get("?limit=2"))
+	})
+}
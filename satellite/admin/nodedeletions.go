@@ -0,0 +1,75 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (server *Server) nodeDeletions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	nodeID, ok := nodeIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	node, err := server.db.DeletionRetries().Get(ctx, nodeID)
+	if err != nil {
+		httpJSONError(w, "unable to get the queued deletions of the node",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var output struct {
+		Pieces         int64      `json:"pieces"`
+		Bytes          int64      `json:"bytes"`
+		FailedAttempts int        `json:"failedAttempts"`
+		RetryAfter     *time.Time `json:"retryAfter,omitempty"`
+	}
+	output.Pieces = node.PieceCount
+	output.Bytes = node.Bytes
+	output.FailedAttempts = node.FailedAttempts
+	if !node.RetryAfter.IsZero() {
+		output.RetryAfter = &node.RetryAfter
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
+
+func (server *Server) flushNodeDeletions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.NodeDeletionFlusher == nil {
+		httpJSONError(w, "node deletions not available",
+			"the admin server has no access to the piece deletion service", http.StatusNotImplemented)
+		return
+	}
+
+	nodeID, ok := nodeIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	flushed, err := server.NodeDeletionFlusher.FlushNode(ctx, nodeID)
+	if err != nil {
+		httpJSONError(w, "unable to flush the deletions of the node",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(fmt.Sprintf(`{"flushedPieces":%d}`, flushed))) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+)
+
+func TestFlushNodeDeletions(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 1,
+		UplinkCount:      0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		// the retries are only sent by flushing them.
+		sat.API.Metainfo.PieceDeletionRetry.Loop.Pause()
+
+		node := planet.StorageNodes[0].ID()
+		retries := sat.DB.DeletionRetries()
+		require.NoError(t, retries.Add(ctx, node, []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, 0))
+
+		flush := func(nodeID string) (int, string) {
+			link := "http://" + address.String() + "/api/node/" + nodeID + "/deletions/flush"
+			req, err := http.NewRequest(http.MethodPost, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			return response.StatusCode, string(data)
+		}
+
+		status, body := flush("invalid")
+		require.Equal(t, http.StatusBadRequest, status, body)
+
+		var output struct {
+			FlushedPieces int `json:"flushedPieces"`
+		}
+
+		status, body = flush(node.String())
+		require.Equal(t, http.StatusOK, status, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &output))
+		require.Equal(t, 2, output.FlushedPieces)
+
+		count, err := retries.Count(ctx)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		// flushing an empty queue is a no-op.
+		status, body = flush(node.String())
+		require.Equal(t, http.StatusOK, status, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &output))
+		require.Zero(t, output.FlushedPieces)
+	})
+}
+
+func TestNodeDeletions(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 1,
+		UplinkCount:      0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		// the retries are only sent by flushing them.
+		sat.API.Metainfo.PieceDeletionRetry.Loop.Pause()
+
+		node := planet.StorageNodes[0].ID()
+		retries := sat.DB.DeletionRetries()
+		require.NoError(t, retries.Add(ctx, node, []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, 1000))
+
+		get := func(nodeID string) (int, string) {
+			link := "http://" + address.String() + "/api/node/" + nodeID + "/deletions"
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			return response.StatusCode, string(data)
+		}
+
+		status, body := get("invalid")
+		require.Equal(t, http.StatusBadRequest, status, body)
+
+		var output struct {
+			Pieces     int64      `json:"pieces"`
+			Bytes      int64      `json:"bytes"`
+			RetryAfter *time.Time `json:"retryAfter"`
+		}
+
+		status, body = get(node.String())
+		require.Equal(t, http.StatusOK, status, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &output))
+		require.EqualValues(t, 2, output.Pieces)
+		require.EqualValues(t, 2000, output.Bytes)
+		require.NotNil(t, output.RetryAfter)
+
+		// a node without queued deletions has nothing to reclaim.
+		output.RetryAfter = nil
+		status, body = get(testrand.NodeID().String())
+		require.Equal(t, http.StatusOK, status, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &output))
+		require.Zero(t, output.Pieces)
+		require.Zero(t, output.Bytes)
+		require.Nil(t, output.RetryAfter)
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo"
+)
+
+// defaultNodeObjectsLimit is the number of objects returned for a node when
+// the request doesn't specify a limit.
+const defaultNodeObjectsLimit = 100
+
+func (server *Server) searchNodeObjects(w http.ResponseWriter, r *http.Request) {
+	if server.NodeObjectsFinder == nil {
+		httpJSONError(w, "node objects not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	nodeID, ok := nodeIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := server.NodeObjectsFinder.Start(nodeID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if metainfo.ErrTooManyNodeObjectsSearches.Has(err) {
+			status = http.StatusTooManyRequests
+		}
+		httpJSONError(w, "unable to search the objects of the node",
+			err.Error(), status)
+		return
+	}
+
+	objects, _ := server.NodeObjectsFinder.Get(nodeID, 0)
+	writeNodeObjects(w, http.StatusAccepted, objects)
+}
+
+func (server *Server) nodeObjects(w http.ResponseWriter, r *http.Request) {
+	if server.NodeObjectsFinder == nil {
+		httpJSONError(w, "node objects not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	nodeID, ok := nodeIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var arguments struct {
+		Limit *int `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err := decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultNodeObjectsLimit
+	if arguments.Limit != nil {
+		if *arguments.Limit < 0 {
+			httpJSONError(w, "negative limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		limit = *arguments.Limit
+	}
+
+	objects, ok := server.NodeObjectsFinder.Get(nodeID, limit)
+	if !ok {
+		httpJSONError(w, "the objects of the node haven't been searched",
+			"", http.StatusNotFound)
+		return
+	}
+
+	writeNodeObjects(w, http.StatusOK, objects)
+}
+
+// nodeIDFromRequest returns the node ID of the request path. It writes the
+// error response and returns false when it's invalid.
+func nodeIDFromRequest(w http.ResponseWriter, r *http.Request) (storj.NodeID, bool) {
+	vars := mux.Vars(r)
+	nodeIDString, ok := vars["nodeid"]
+	if !ok {
+		httpJSONError(w, "node-id missing",
+			"", http.StatusBadRequest)
+		return storj.NodeID{}, false
+	}
+
+	nodeID, err := storj.NodeIDFromString(nodeIDString)
+	if err != nil {
+		httpJSONError(w, "invalid node-id",
+			err.Error(), http.StatusBadRequest)
+		return storj.NodeID{}, false
+	}
+	return nodeID, true
+}
+
+// writeNodeObjects writes the progress of the search of the objects of a node.
+func writeNodeObjects(w http.ResponseWriter, status int, objects metainfo.NodeObjects) {
+	type object struct {
+		ProjectID          string `json:"projectId"`
+		BucketName         string `json:"bucketName"`
+		EncryptedObjectKey string `json:"encryptedObjectKey"`
+	}
+	var output struct {
+		StartedAt  time.Time  `json:"startedAt"`
+		FinishedAt *time.Time `json:"finishedAt,omitempty"`
+		Done       bool       `json:"done"`
+		Error      string     `json:"error,omitempty"`
+		Pointers   int64      `json:"pointers"`
+		Segments   int64      `json:"segments"`
+		Count      int64      `json:"count"`
+		Truncated  bool       `json:"truncated"`
+		Objects    []object   `json:"objects"`
+	}
+	output.StartedAt = objects.StartedAt
+	if objects.Done {
+		output.FinishedAt = &objects.FinishedAt
+	}
+	output.Done = objects.Done
+	if objects.Err != nil {
+		output.Error = objects.Err.Error()
+	}
+	output.Pointers = objects.Pointers
+	output.Segments = objects.Segments
+	output.Count = objects.Count
+	output.Truncated = objects.Truncated
+	output.Objects = make([]object, 0, len(objects.Objects))
+	for _, location := range objects.Objects {
+		output.Objects = append(output.Objects, object{
+			ProjectID:          location.ProjectID.String(),
+			BucketName:         location.BucketName,
+			EncryptedObjectKey: base64.RawURLEncoding.EncodeToString([]byte(location.ObjectKey)),
+		})
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
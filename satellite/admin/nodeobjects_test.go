@@ -0,0 +1,126 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestNodeObjects(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		location, err := metabase.ParseSegmentKey(metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		pointer, err := sat.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+		require.NotEmpty(t, pointer.GetRemote().GetRemotePieces())
+
+		type object struct {
+			ProjectID          string `json:"projectId"`
+			BucketName         string `json:"bucketName"`
+			EncryptedObjectKey string `json:"encryptedObjectKey"`
+		}
+		type nodeObjects struct {
+			Done    bool     `json:"done"`
+			Error   string   `json:"error"`
+			Count   int64    `json:"count"`
+			Objects []object `json:"objects"`
+		}
+
+		do := func(method string, nodeID storj.NodeID, query string, expectedStatus int) (result nodeObjects) {
+			link := "http://" + address.String() + "/api/node/" + nodeID.String() + "/objects" + query
+			req, err := http.NewRequest(method, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, expectedStatus, response.StatusCode, string(data))
+
+			require.NoError(t, json.Unmarshal(data, &result))
+			return result
+		}
+
+		// search starts the search of the node and waits for its end.
+		search := func(nodeID storj.NodeID) {
+			do(http.MethodPost, nodeID, "", http.StatusAccepted)
+			require.Eventually(t, func() bool {
+				return do(http.MethodGet, nodeID, "?limit=0", http.StatusOK).Done
+			}, 30*time.Second, 10*time.Millisecond)
+		}
+
+		get := func(nodeID storj.NodeID, query string) nodeObjects {
+			result := do(http.MethodGet, nodeID, query, http.StatusOK)
+			require.True(t, result.Done)
+			require.Empty(t, result.Error)
+			return result
+		}
+
+		expected := object{
+			ProjectID:          location.ProjectID.String(),
+			BucketName:         location.BucketName,
+			EncryptedObjectKey: base64.RawURLEncoding.EncodeToString([]byte(location.ObjectKey)),
+		}
+		for _, piece := range pointer.GetRemote().GetRemotePieces() {
+			search(piece.NodeId)
+
+			result := get(piece.NodeId, "")
+			require.EqualValues(t, 1, result.Count)
+			require.Equal(t, []object{expected}, result.Objects)
+
+			// the objects are still counted without being returned.
+			result = get(piece.NodeId, "?limit=0")
+			require.EqualValues(t, 1, result.Count)
+			require.Empty(t, result.Objects)
+		}
+
+		empty := testrand.NodeID()
+		// the node hasn't been searched yet.
+		do(http.MethodGet, empty, "", http.StatusNotFound)
+
+		search(empty)
+		result := get(empty, "")
+		require.Zero(t, result.Count)
+		require.Empty(t, result.Objects)
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func (server *Server) objectPieceLayout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.ObjectPieceLayoutGetter == nil {
+		httpJSONError(w, "piece layout not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucketName, ok := vars["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	objectKey, err := base64.RawURLEncoding.DecodeString(vars["objectkey"])
+	if err != nil || len(objectKey) == 0 {
+		httpJSONError(w, "invalid encrypted object key",
+			"the key must be base64url encoded without padding", http.StatusBadRequest)
+		return
+	}
+
+	var arguments struct {
+		Cursor int64 `schema:"cursor"`
+		Limit  *int  `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err = decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if arguments.Cursor < 0 {
+		httpJSONError(w, "negative cursor",
+			fmt.Sprintf("%v", arguments.Cursor), http.StatusBadRequest)
+		return
+	}
+
+	limit := server.pieceLayoutPageSize
+	if arguments.Limit != nil {
+		if *arguments.Limit <= 0 {
+			httpJSONError(w, "non-positive limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		if *arguments.Limit < limit {
+			limit = *arguments.Limit
+		}
+	}
+
+	page, err := server.ObjectPieceLayoutGetter.GetObjectPieceLayout(ctx, metabase.ObjectLocation{
+		ProjectID:  projectUUID,
+		BucketName: bucketName,
+		ObjectKey:  metabase.ObjectKey(objectKey),
+	}, metainfo.GetObjectPieceLayoutOptions{
+		Cursor: arguments.Cursor,
+		Limit:  limit,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if storj.ErrObjectNotFound.Has(err) {
+			status = http.StatusNotFound
+		}
+		httpJSONError(w, "unable to get the piece layout",
+			err.Error(), status)
+		return
+	}
+
+	type piece struct {
+		PieceNum int32  `json:"pieceNum"`
+		NodeID   string `json:"nodeId"`
+	}
+	type segment struct {
+		Index       int64   `json:"index"`
+		Inline      bool    `json:"inline"`
+		Size        int64   `json:"size"`
+		RootPieceID string  `json:"rootPieceId,omitempty"`
+		Pieces      []piece `json:"pieces,omitempty"`
+	}
+	var output struct {
+		Segments []segment `json:"segments"`
+		More     bool      `json:"more"`
+		Cursor   int64     `json:"cursor,omitempty"`
+	}
+	output.Segments = make([]segment, 0, len(page.Segments))
+	for _, s := range page.Segments {
+		out := segment{
+			Index:  s.Index,
+			Inline: s.Inline,
+			Size:   s.Size,
+		}
+		if !s.Inline {
+			out.RootPieceID = s.RootPieceID.String()
+			for _, p := range s.Pieces {
+				out.Pieces = append(out.Pieces, piece{
+					PieceNum: p.PieceNum,
+					NodeID:   p.NodeId.String(),
+				})
+			}
+		}
+		output.Segments = append(output.Segments, out)
+	}
+	output.More = page.More
+	if page.More {
+		output.Cursor = page.Cursor
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+func TestObjectPieceLayout(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Admin.Address = "127.0.0.1:0"
+				config.Admin.PieceLayoutPageSize = 16
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+
+		const segmentCount = 250
+
+		err := planet.Uplinks[0].Upload(ctx, sat, "testbucket", "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		keys, err := sat.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		lastLocation, err := metabase.ParseSegmentKey(metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		object := lastLocation.Object()
+		lastSegment, err := sat.Metainfo.Service.Get(ctx, lastLocation.Encode())
+		require.NoError(t, err)
+		require.NotNil(t, lastSegment.Remote)
+
+		// turn the object into one with many segments, each with its own
+		// root piece ID.
+		rootPieceIDs := make([]storj.PieceID, segmentCount-1)
+		for i := range rootPieceIDs {
+			location, err := object.Segment(int64(i))
+			require.NoError(t, err)
+
+			segment, err := sat.Metainfo.Service.Get(ctx, lastLocation.Encode())
+			require.NoError(t, err)
+			segment.Metadata = nil
+			rootPieceIDs[i] = testrand.PieceID()
+			segment.Remote.RootPieceId = rootPieceIDs[i]
+			err = sat.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), segment)
+			require.NoError(t, err)
+		}
+
+		setSegmentCount := func(count int64) {
+			streamMeta := &pb.StreamMeta{}
+			require.NoError(t, pb.Unmarshal(lastSegment.Metadata, streamMeta))
+			streamMeta.NumberOfSegments = count
+			lastSegment.Metadata, err = pb.Marshal(streamMeta)
+			require.NoError(t, err)
+			err = sat.Metainfo.Service.UnsynchronizedPut(ctx, lastLocation.Encode(), lastSegment)
+			require.NoError(t, err)
+		}
+
+		type segment struct {
+			Index       int64  `json:"index"`
+			Inline      bool   `json:"inline"`
+			RootPieceID string `json:"rootPieceId"`
+			Pieces      []struct {
+				PieceNum int32  `json:"pieceNum"`
+				NodeID   string `json:"nodeId"`
+			} `json:"pieces"`
+		}
+		type page struct {
+			Segments []segment `json:"segments"`
+			More     bool      `json:"more"`
+			Cursor   int64     `json:"cursor"`
+		}
+
+		get := func(objectKey metabase.ObjectKey, query url.Values) (int, page) {
+			link := "http://" + address.String() + "/api/project/" + object.ProjectID.String() + "/bucket/" + object.BucketName +
+				"/object/" + base64.RawURLEncoding.EncodeToString([]byte(objectKey)) + "/pieces?" + query.Encode()
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+
+			var output page
+			if response.StatusCode == http.StatusOK {
+				require.NoError(t, json.Unmarshal(data, &output))
+			}
+			return response.StatusCode, output
+		}
+
+		// the number of segments is known, or encrypted by the client.
+		for _, count := range []int64{segmentCount, 0} {
+			setSegmentCount(count)
+
+			var layout []segment
+			pages := 0
+			var cursor int64
+			for {
+				status, output := get(object.ObjectKey, url.Values{"cursor": {strconv.FormatInt(cursor, 10)}})
+				require.Equal(t, http.StatusOK, status)
+				require.LessOrEqual(t, len(output.Segments), 16)
+				layout = append(layout, output.Segments...)
+				pages++
+				if !output.More {
+					break
+				}
+				cursor = output.Cursor
+			}
+			require.Equal(t, (segmentCount+15)/16, pages)
+
+			require.Len(t, layout, segmentCount)
+			for i, seg := range layout[:segmentCount-1] {
+				require.Equal(t, int64(i), seg.Index)
+				require.False(t, seg.Inline)
+				require.Equal(t, rootPieceIDs[i].String(), seg.RootPieceID)
+				require.Len(t, seg.Pieces, len(lastSegment.Remote.RemotePieces))
+			}
+			last := layout[segmentCount-1]
+			require.Equal(t, metabase.LastSegmentIndex, last.Index)
+			require.Equal(t, lastSegment.Remote.RootPieceId.String(), last.RootPieceID)
+		}
+
+		// smaller pages are allowed, larger ones are capped.
+		status, output := get(object.ObjectKey, url.Values{"cursor": {"10"}, "limit": {"3"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, output.Segments, 3)
+		require.True(t, output.More)
+		require.Equal(t, int64(13), output.Cursor)
+		require.Equal(t, int64(10), output.Segments[0].Index)
+
+		status, output = get(object.ObjectKey, url.Values{"limit": {"1000"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, output.Segments, 16)
+
+		status, _ = get("missing-object", url.Values{})
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
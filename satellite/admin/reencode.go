@@ -0,0 +1,110 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func (server *Server) reencodeObject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.ObjectReencoder == nil {
+		httpJSONError(w, "re-encoding not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucketName, ok := vars["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	objectKey, err := base64.RawURLEncoding.DecodeString(vars["objectkey"])
+	if err != nil || len(objectKey) == 0 {
+		httpJSONError(w, "invalid encrypted object key",
+			"the key must be base64url encoded without padding", http.StatusBadRequest)
+		return
+	}
+
+	var arguments struct {
+		MinReq    int32 `schema:"minReq,required"`
+		Repair    int32 `schema:"repair,required"`
+		Success   int32 `schema:"success,required"`
+		Total     int32 `schema:"total,required"`
+		ShareSize int32 `schema:"shareSize,required"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err = decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := server.ObjectReencoder.ReencodeObject(ctx, metabase.ObjectLocation{
+		ProjectID:  projectUUID,
+		BucketName: bucketName,
+		ObjectKey:  metabase.ObjectKey(objectKey),
+	}, &pb.RedundancyScheme{
+		Type:             pb.RedundancyScheme_RS,
+		MinReq:           arguments.MinReq,
+		RepairThreshold:  arguments.Repair,
+		SuccessThreshold: arguments.Success,
+		Total:            arguments.Total,
+		ErasureShareSize: arguments.ShareSize,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if storj.ErrObjectNotFound.Has(err) {
+			status = http.StatusNotFound
+		}
+		httpJSONError(w, "unable to re-encode the object",
+			err.Error(), status)
+		return
+	}
+
+	var output struct {
+		Segments        int `json:"segments"`
+		SkippedSegments int `json:"skippedSegments"`
+		ReclaimedPieces int `json:"reclaimedPieces"`
+	}
+	output.Segments = result.Segments
+	output.SkippedSegments = result.SkippedSegments
+	output.ReclaimedPieces = result.ReclaimedPieces
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
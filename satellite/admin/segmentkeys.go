@@ -0,0 +1,119 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// defaultSegmentKeysLimit is the number of segment keys returned when the
+// request doesn't specify a limit.
+const defaultSegmentKeysLimit = 100
+
+func (server *Server) segmentKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if server.SegmentKeysLister == nil {
+		httpJSONError(w, "segment keys listing not available",
+			"the admin server has no access to pointerDB", http.StatusNotImplemented)
+		return
+	}
+
+	projectUUID, ok := projectFromVars(w, r)
+	if !ok {
+		return
+	}
+
+	bucketName, ok := mux.Vars(r)["bucket"]
+	if !ok {
+		httpJSONError(w, "bucket name missing",
+			"", http.StatusBadRequest)
+		return
+	}
+
+	var arguments struct {
+		Prefix string `schema:"prefix"`
+		Cursor string `schema:"cursor"`
+		Limit  *int   `schema:"limit"`
+	}
+
+	if err := r.ParseForm(); err != nil {
+		httpJSONError(w, "invalid form",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder := schema.NewDecoder()
+	err := decoder.Decode(&arguments, r.Form)
+	if err != nil {
+		httpJSONError(w, "invalid arguments",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prefix, err := base64.RawURLEncoding.DecodeString(arguments.Prefix)
+	if err != nil {
+		httpJSONError(w, "invalid prefix",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursor, err := base64.RawURLEncoding.DecodeString(arguments.Cursor)
+	if err != nil {
+		httpJSONError(w, "invalid cursor",
+			err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSegmentKeysLimit
+	if arguments.Limit != nil {
+		if *arguments.Limit <= 0 {
+			httpJSONError(w, "non-positive limit",
+				fmt.Sprintf("%v", *arguments.Limit), http.StatusBadRequest)
+			return
+		}
+		limit = *arguments.Limit
+	}
+
+	keys, more, err := server.SegmentKeysLister.ListSegmentKeys(ctx, projectUUID, []byte(bucketName), prefix, metabase.SegmentKey(cursor), limit)
+	if err != nil {
+		if metainfo.ErrInvalidSegmentKeyCursor.Has(err) {
+			httpJSONError(w, "invalid cursor",
+				err.Error(), http.StatusBadRequest)
+			return
+		}
+		httpJSONError(w, "unable to list the segment keys",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var output struct {
+		SegmentKeys []string `json:"segmentKeys"`
+		More        bool     `json:"more"`
+	}
+	output.SegmentKeys = make([]string, 0, len(keys))
+	for _, key := range keys {
+		output.SegmentKeys = append(output.SegmentKeys, base64.RawURLEncoding.EncodeToString(key))
+	}
+	output.More = more
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		httpJSONError(w, "json encoding failed",
+			err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data) // nothing to do with the error response, probably the client requesting disappeared
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package admin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/common/memory"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func TestSegmentKeys(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 4,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				func(log *zap.Logger, index int, config *satellite.Config) {
+					config.Admin.Address = "127.0.0.1:0"
+				},
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(10*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+		address := sat.Admin.Admin.Listener.Addr()
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "a-bucket"
+
+		err := planet.Uplinks[0].Upload(ctx, sat, bucketName, "multi-segment", testrand.Bytes(21*memory.KiB))
+		require.NoError(t, err)
+
+		objects, err := sat.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, objects.Items, 1)
+		encryptedPath := objects.Items[0].EncryptedPath
+
+		err = planet.Uplinks[0].Upload(ctx, sat, bucketName, "inline", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		var expected []string
+		for _, index := range []int64{metabase.LastSegmentIndex, 0, 1} {
+			location, err := metainfo.CreatePath(ctx, projectID, index, []byte(bucketName), encryptedPath)
+			require.NoError(t, err)
+			expected = append(expected, base64.RawURLEncoding.EncodeToString(location.Encode()))
+		}
+
+		type page struct {
+			SegmentKeys []string `json:"segmentKeys"`
+			More        bool     `json:"more"`
+		}
+
+		list := func(query url.Values) (int, page) {
+			link := "http://" + address.String() + "/api/project/" + projectID.String() + "/bucket/" + bucketName + "/segments?" + query.Encode()
+			req, err := http.NewRequest(http.MethodGet, link, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", sat.Config.Console.AuthToken)
+
+			response, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+
+			var output page
+			if response.StatusCode == http.StatusOK {
+				require.NoError(t, json.Unmarshal(data, &output))
+			}
+			return response.StatusCode, output
+		}
+
+		prefix := base64.RawURLEncoding.EncodeToString(encryptedPath)
+
+		status, output := list(url.Values{"prefix": {prefix}})
+		require.Equal(t, http.StatusOK, status)
+		require.False(t, output.More)
+		require.Equal(t, expected, output.SegmentKeys)
+
+		// the keys of the other object are listed without a prefix.
+		status, output = list(url.Values{})
+		require.Equal(t, http.StatusOK, status)
+		require.False(t, output.More)
+		require.Len(t, output.SegmentKeys, len(expected)+1)
+
+		// paging continues after the cursor.
+		var paged []string
+		cursor := ""
+		for {
+			status, output := list(url.Values{"prefix": {prefix}, "cursor": {cursor}, "limit": {"1"}})
+			require.Equal(t, http.StatusOK, status)
+			require.Len(t, output.SegmentKeys, 1)
+			paged = append(paged, output.SegmentKeys...)
+			if !output.More {
+				break
+			}
+			cursor = output.SegmentKeys[0]
+		}
+		require.Equal(t, expected, paged)
+
+		// the cursor has to be a key of the listed bucket.
+		otherKey, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte("other-bucket"), encryptedPath)
+		require.NoError(t, err)
+		status, _ = list(url.Values{"cursor": {base64.RawURLEncoding.EncodeToString(otherKey.Encode())}})
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
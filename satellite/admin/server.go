@@ -17,11 +17,18 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"storj.io/common/errs2"
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/common/uuid"
 	"storj.io/storj/satellite/accounting"
 	"storj.io/storj/satellite/console"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
 	"storj.io/storj/satellite/payments"
 	"storj.io/storj/satellite/payments/stripecoinpayments"
+	"storj.io/storj/satellite/repair/repairer"
 )
 
 // Config defines configuration for debug server.
@@ -29,6 +36,10 @@ type Config struct {
 	Address string `help:"admin peer http listening address" releaseDefault:"" devDefault:""`
 
 	AuthorizationToken string `internal:"true"`
+
+	NodeObjects metainfo.NodeObjectsConfig
+
+	PieceLayoutPageSize int `help:"maximum number of segments in a page of an object's piece layout" default:"100"`
 }
 
 // DB is databases needed for the admin server.
@@ -41,6 +52,67 @@ type DB interface {
 	StripeCoinPayments() stripecoinpayments.DB
 	// Buckets returns database for satellite buckets
 	Buckets() metainfo.BucketsDB
+	// DeletedObjectStubs returns database for the stubs of the deleted objects
+	DeletedObjectStubs() metainfo.DeletedObjectStubsDB
+	// DeleteWebhooks returns database for the webhooks notified of the deleted objects
+	DeleteWebhooks() deletewebhook.DB
+	// DeletionRetries returns database for the piece deletions waiting for a retry
+	DeletionRetries() piecedeletion.Retries
+}
+
+// SegmentDeleter deletes segments regardless of the state of their objects.
+type SegmentDeleter interface {
+	ForceDeleteSegment(ctx context.Context, key metabase.SegmentKey) (deletedPieces int, err error)
+}
+
+// NodeObjectsFinder searches the objects which have pieces on a node in the
+// background.
+type NodeObjectsFinder interface {
+	Start(nodeID storj.NodeID) error
+	Get(nodeID storj.NodeID, limit int) (metainfo.NodeObjects, bool)
+}
+
+// AtRiskObjectsFinder finds the objects which have segments below their
+// repair threshold.
+type AtRiskObjectsFinder interface {
+	AtRiskObjects(ctx context.Context, opts metainfo.AtRiskObjectsOptions) (metainfo.AtRiskObjects, error)
+}
+
+// BucketDeletionPreflighter counts what deleting a bucket with its objects
+// would delete.
+type BucketDeletionPreflighter interface {
+	DeleteBucketPreflight(ctx context.Context, projectID uuid.UUID, bucketName []byte) (metainfo.DeleteBucketPreflightResult, error)
+}
+
+// SegmentKeysLister lists the raw keys of the segments of a bucket.
+type SegmentKeysLister interface {
+	ListSegmentKeys(ctx context.Context, projectID uuid.UUID, bucket, prefix []byte, cursor metabase.SegmentKey, limit int) ([]metabase.SegmentKey, bool, error)
+}
+
+// ObjectPieceLayoutGetter returns the piece layout of objects.
+type ObjectPieceLayoutGetter interface {
+	GetObjectPieceLayout(ctx context.Context, location metabase.ObjectLocation, opts metainfo.GetObjectPieceLayoutOptions) (metainfo.ObjectPieceLayoutPage, error)
+}
+
+// ObjectReencoder re-encodes the segments of objects with another redundancy
+// scheme.
+type ObjectReencoder interface {
+	ReencodeObject(ctx context.Context, location metabase.ObjectLocation, redundancy *pb.RedundancyScheme) (repairer.ReencodeResult, error)
+}
+
+// ObjectCopier copies objects without copying their data.
+type ObjectCopier interface {
+	CopyObject(ctx context.Context, projectID uuid.UUID, srcBucket, srcEncPath, dstBucket, dstEncPath []byte, keys metainfo.CopyObjectKeys) error
+}
+
+// DeleteCostEstimator estimates the cost of deleting objects.
+type DeleteCostEstimator interface {
+	EstimateDeleteCost(ctx context.Context, location metabase.ObjectLocation) (metainfo.DeleteCost, error)
+}
+
+// NodeDeletionFlusher sends the queued piece deletions of a node.
+type NodeDeletionFlusher interface {
+	FlushNode(ctx context.Context, nodeID storj.NodeID) (flushed int, err error)
 }
 
 // Server provides endpoints for administrative tasks.
@@ -55,6 +127,37 @@ type Server struct {
 	payments payments.Accounts
 
 	nowFn func() time.Time
+
+	pieceLayoutPageSize int
+
+	// SegmentDeleter is used for force deleting segments.
+	SegmentDeleter SegmentDeleter
+	// NodeObjectsFinder is used for finding the objects with pieces on a
+	// node.
+	NodeObjectsFinder NodeObjectsFinder
+	// AtRiskObjectsFinder is used for finding the objects with segments
+	// below their repair threshold.
+	AtRiskObjectsFinder AtRiskObjectsFinder
+	// BucketDeletionPreflighter is used for counting what deleting a bucket
+	// would delete.
+	BucketDeletionPreflighter BucketDeletionPreflighter
+	// SegmentKeysLister is used for listing the raw keys of the segments of
+	// a bucket.
+	SegmentKeysLister SegmentKeysLister
+	// ObjectPieceLayoutGetter is used for getting the piece layout of
+	// objects.
+	ObjectPieceLayoutGetter ObjectPieceLayoutGetter
+	// ObjectReencoder is used for re-encoding the segments of objects with
+	// another redundancy scheme.
+	ObjectReencoder ObjectReencoder
+	// ObjectCopier is used for copying objects without copying their data.
+	ObjectCopier ObjectCopier
+	// DeleteCostEstimator is used for estimating the cost of deleting
+	// objects.
+	DeleteCostEstimator DeleteCostEstimator
+	// NodeDeletionFlusher is used for sending the queued piece deletions of
+	// a node.
+	NodeDeletionFlusher NodeDeletionFlusher
 }
 
 // NewServer returns a new administration Server.
@@ -69,6 +172,8 @@ func NewServer(log *zap.Logger, listener net.Listener, db DB, accounts payments.
 		payments: accounts,
 
 		nowFn: time.Now,
+
+		pieceLayoutPageSize: config.PieceLayoutPageSize,
 	}
 
 	server.server.Handler = &protectedServer{
@@ -90,7 +195,25 @@ func NewServer(log *zap.Logger, listener net.Listener, db DB, accounts payments.
 	server.mux.HandleFunc("/api/project/{project}", server.getProject).Methods("GET")
 	server.mux.HandleFunc("/api/project/{project}", server.renameProject).Methods("PUT")
 	server.mux.HandleFunc("/api/project/{project}", server.deleteProject).Methods("DELETE")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/stubs", server.deletedStubs).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/delete-preflight", server.deleteBucketPreflight).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/segments", server.segmentKeys).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/object/{objectkey}/pieces", server.objectPieceLayout).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/object/{objectkey}/reencode", server.reencodeObject).Methods("POST")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/object/{objectkey}/copy", server.copyObject).Methods("POST")
+	server.mux.HandleFunc("/api/project/{project}/bucket/{bucket}/object/{objectkey}/delete-cost", server.estimateDeleteCost).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/buckets/largest", server.largestBuckets).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/webhooks/delete", server.getDeleteWebhook).Methods("GET")
+	server.mux.HandleFunc("/api/project/{project}/webhooks/delete", server.putDeleteWebhook).Methods("PUT", "POST")
+	server.mux.HandleFunc("/api/project/{project}/webhooks/delete", server.deleteDeleteWebhook).Methods("DELETE")
 	server.mux.HandleFunc("/api/project", server.addProject).Methods("POST")
+	server.mux.HandleFunc("/api/deletion/backlog", server.deletionBacklog).Methods("GET")
+	server.mux.HandleFunc("/api/segment/{segmentkey}", server.forceDeleteSegment).Methods("DELETE")
+	server.mux.HandleFunc("/api/node/{nodeid}/objects", server.nodeObjects).Methods("GET")
+	server.mux.HandleFunc("/api/node/{nodeid}/objects", server.searchNodeObjects).Methods("POST")
+	server.mux.HandleFunc("/api/node/{nodeid}/deletions", server.nodeDeletions).Methods("GET")
+	server.mux.HandleFunc("/api/node/{nodeid}/deletions/flush", server.flushNodeDeletions).Methods("POST")
+	server.mux.HandleFunc("/api/objects/at-risk", server.atRiskObjects).Methods("GET")
 
 	return server
 }
@@ -41,6 +41,8 @@ import (
 	"storj.io/storj/satellite/mailservice/simulate"
 	"storj.io/storj/satellite/marketingweb"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/deletionverifier"
 	"storj.io/storj/satellite/metainfo/piecedeletion"
 	"storj.io/storj/satellite/nodestats"
 	"storj.io/storj/satellite/orders"
@@ -96,10 +98,13 @@ type API struct {
 	}
 
 	Metainfo struct {
-		Database      metainfo.PointerDB
-		Service       *metainfo.Service
-		PieceDeletion *piecedeletion.Service
-		Endpoint2     *metainfo.Endpoint
+		Database           metainfo.PointerDB
+		Service            *metainfo.Service
+		PieceDeletion      *piecedeletion.Service
+		PieceDeletionRetry *piecedeletion.RetryChore
+		Endpoint2          *metainfo.Endpoint
+		DeletionVerifier   *deletionverifier.Chore
+		DeleteWebhooks     *deletewebhook.Service
 	}
 
 	Inspector struct {
@@ -396,11 +401,14 @@ func NewAPI(log *zap.Logger, full *identity.FullIdentity, db DB,
 			peer.Metainfo.Database,
 			peer.DB.Buckets(),
 		)
+		peer.Metainfo.Service.SetStrictSegmentKeys(config.Metainfo.StrictSegmentKeys)
 
 		peer.Metainfo.PieceDeletion, err = piecedeletion.NewService(
 			peer.Log.Named("metainfo:piecedeletion"),
 			peer.Dialer,
 			peer.Overlay.Service,
+			peer.DB.DeletionRetries(),
+			peer.DB.DeletionDeadLetters(),
 			config.Metainfo.PieceDeletion,
 		)
 		if err != nil {
@@ -412,6 +420,21 @@ func NewAPI(log *zap.Logger, full *identity.FullIdentity, db DB,
 			Close: peer.Metainfo.PieceDeletion.Close,
 		})
 
+		// the retries are only sent by this process, the other ones only
+		// add to them.
+		peer.Metainfo.PieceDeletionRetry = piecedeletion.NewRetryChore(
+			peer.Log.Named("metainfo:piecedeletion:retry"),
+			peer.Metainfo.PieceDeletion,
+			config.Metainfo.PieceDeletion,
+		)
+		peer.Services.Add(lifecycle.Item{
+			Name:  "metainfo:piecedeletion:retry",
+			Run:   peer.Metainfo.PieceDeletionRetry.Run,
+			Close: peer.Metainfo.PieceDeletionRetry.Close,
+		})
+		peer.Debug.Server.Panel.Add(
+			debug.Cycle("Metainfo Piece Deletion Retry", peer.Metainfo.PieceDeletionRetry.Loop))
+
 		peer.Metainfo.Endpoint2, err = metainfo.NewEndpoint(
 			peer.Log.Named("metainfo:endpoint"),
 			peer.Metainfo.Service,
@@ -426,6 +449,9 @@ func NewAPI(log *zap.Logger, full *identity.FullIdentity, db DB,
 			peer.DB.Console().Projects(),
 			signing.SignerFromFullIdentity(peer.Identity),
 			peer.DB.Revocation(),
+			peer.DB.InlineContents(),
+			peer.DB.DeletedObjectStubs(),
+			peer.DB.SharedSegments(),
 			config.Metainfo,
 		)
 		if err != nil {
@@ -440,6 +466,38 @@ func NewAPI(log *zap.Logger, full *identity.FullIdentity, db DB,
 			Name:  "metainfo:endpoint",
 			Close: peer.Metainfo.Endpoint2.Close,
 		})
+
+		if config.DeleteWebhook.Enabled {
+			peer.Metainfo.DeleteWebhooks = deletewebhook.NewService(
+				peer.Log.Named("metainfo:deletewebhook"),
+				peer.DB.DeleteWebhooks(),
+				config.DeleteWebhook,
+			)
+			peer.Services.Add(lifecycle.Item{
+				Name:  "metainfo:deletewebhook",
+				Run:   peer.Metainfo.DeleteWebhooks.Run,
+				Close: peer.Metainfo.DeleteWebhooks.Close,
+			})
+			peer.Metainfo.Endpoint2.SetDeleteWebhooks(peer.Metainfo.DeleteWebhooks)
+		}
+
+		// the sampled deleted objects are only known by this process. There's
+		// no way of checking the pieces on the nodes without downloading them,
+		// hence only pointerDB is verified.
+		peer.Metainfo.DeletionVerifier = deletionverifier.NewChore(
+			peer.Log.Named("metainfo:deletionverifier"),
+			config.DeletionVerifier,
+			peer.Metainfo.Service,
+			peer.Metainfo.Endpoint2.DeletionSampler(),
+			nil,
+		)
+		peer.Services.Add(lifecycle.Item{
+			Name:  "metainfo:deletionverifier",
+			Run:   peer.Metainfo.DeletionVerifier.Run,
+			Close: peer.Metainfo.DeletionVerifier.Close,
+		})
+		peer.Debug.Server.Panel.Add(
+			debug.Cycle("Metainfo Deletion Verifier", peer.Metainfo.DeletionVerifier.Loop))
 	}
 
 	{ // setup datarepair
@@ -52,6 +52,10 @@ const (
 	FieldBucketUsages = "bucketUsages"
 	// FieldStorage is a field name for storage total.
 	FieldStorage = "storage"
+	// FieldInlineStorage is a field name for inline storage total.
+	FieldInlineStorage = "inlineStorage"
+	// FieldRemoteStorage is a field name for remote storage total.
+	FieldRemoteStorage = "remoteStorage"
 	// FieldEgress is a field name for egress total.
 	FieldEgress = "egress"
 	// FieldObjectCount is a field name for objects count.
@@ -284,6 +288,12 @@ func graphqlBucketUsage() *graphql.Object {
 			FieldStorage: &graphql.Field{
 				Type: graphql.Float,
 			},
+			FieldInlineStorage: &graphql.Field{
+				Type: graphql.Float,
+			},
+			FieldRemoteStorage: &graphql.Field{
+				Type: graphql.Float,
+			},
 			FieldEgress: &graphql.Field{
 				Type: graphql.Float,
 			},
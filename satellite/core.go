@@ -37,6 +37,7 @@ import (
 	"storj.io/storj/satellite/gracefulexit"
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/metainfo/expireddeletion"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
 	"storj.io/storj/satellite/metrics"
 	"storj.io/storj/satellite/orders"
 	"storj.io/storj/satellite/overlay"
@@ -80,9 +81,10 @@ type Core struct {
 	}
 
 	Metainfo struct {
-		Database metainfo.PointerDB // TODO: move into pointerDB
-		Service  *metainfo.Service
-		Loop     *metainfo.Loop
+		Database      metainfo.PointerDB // TODO: move into pointerDB
+		Service       *metainfo.Service
+		Loop          *metainfo.Loop
+		PieceDeletion *piecedeletion.Service
 	}
 
 	Orders struct {
@@ -368,6 +370,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB,
 				peer.Dialer,
 				peer.Overlay.DB,
 				peer.Metainfo.Loop,
+				db.DeletionRetries(),
 			)
 			peer.Services.Add(lifecycle.Item{
 				Name: "core-garbage-collection",
@@ -379,11 +382,35 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB,
 	}
 
 	{ // setup expired segment cleanup
+		var deleter expireddeletion.PieceDeleter
+		if config.ExpiredDeletion.DeletePieces {
+			peer.Metainfo.PieceDeletion, err = piecedeletion.NewService(
+				peer.Log.Named("metainfo:piecedeletion"),
+				peer.Dialer,
+				peer.Overlay.Service,
+				peer.DB.DeletionRetries(),
+				peer.DB.DeletionDeadLetters(),
+				config.Metainfo.PieceDeletion,
+			)
+			if err != nil {
+				return nil, errs.Combine(err, peer.Close())
+			}
+			peer.Services.Add(lifecycle.Item{
+				Name:  "metainfo:piecedeletion",
+				Run:   peer.Metainfo.PieceDeletion.Run,
+				Close: peer.Metainfo.PieceDeletion.Close,
+			})
+			deleter = peer.Metainfo.PieceDeletion
+		}
+
 		peer.ExpiredDeletion.Chore = expireddeletion.NewChore(
 			peer.Log.Named("core-expired-deletion"),
 			config.ExpiredDeletion,
 			peer.Metainfo.Service,
+			peer.DB.InlineContents(),
+			peer.DB.SharedSegments(),
 			peer.Metainfo.Loop,
+			deleter,
 		)
 		peer.Services.Add(lifecycle.Item{
 			Name: "expireddeletion:chore",
@@ -155,6 +155,7 @@ func NewGarbageCollection(log *zap.Logger, full *identity.FullIdentity, db DB,
 			peer.Dialer,
 			peer.Overlay.DB,
 			peer.Metainfo.Loop,
+			db.DeletionRetries(),
 		)
 		peer.Services.Add(lifecycle.Item{
 			Name: "garbage-collection",
@@ -124,6 +124,82 @@ func TestGarbageCollection(t *testing.T) {
 	})
 }
 
+// TestGarbageCollection_RecentDeletions checks that the pieces of a direct
+// deletion, which is waiting to be retried, are reclaimed by garbage
+// collection only after the minimum age has passed.
+func TestGarbageCollection_RecentDeletions(t *testing.T) {
+	const minAge = 3 * time.Second
+
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.GarbageCollection.FalsePositiveRate = 0.000000001
+				config.GarbageCollection.Interval = 500 * time.Millisecond
+				config.GarbageCollection.DeletedPiecesMinAge = minAge
+			},
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Retain.MaxTimeSkew = 0
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellite := planet.Satellites[0]
+		upl := planet.Uplinks[0]
+		targetNode := planet.StorageNodes[0]
+		gcService := satellite.GarbageCollection.Service
+		gcService.Loop.Pause()
+
+		err := upl.Upload(ctx, satellite, "testbucket", "test/path/1", testrand.Bytes(8*memory.KiB))
+		require.NoError(t, err)
+		deletedEncPath, pointerToDelete := getPointer(ctx, t, satellite, upl, "testbucket", "test/path/1")
+		var deletedPieceID storj.PieceID
+		for _, p := range pointerToDelete.GetRemote().GetRemotePieces() {
+			if p.NodeId == targetNode.ID() {
+				deletedPieceID = pointerToDelete.GetRemote().RootPieceId.Derive(p.NodeId, p.PieceNum)
+				break
+			}
+		}
+		require.NotZero(t, deletedPieceID)
+
+		// keep an object, so the node receives a bloom filter
+		err = upl.Upload(ctx, satellite, "testbucket", "test/path/2", testrand.Bytes(8*memory.KiB))
+		require.NoError(t, err)
+
+		// delete the object, but fail to reach the node, so the deletion is
+		// queued for retrying. The retries aren't sent during the test.
+		satellite.API.Metainfo.PieceDeletionRetry.Loop.Pause()
+		err = satellite.Metainfo.Service.UnsynchronizedDelete(ctx, deletedEncPath)
+		require.NoError(t, err)
+		err = satellite.DB.DeletionRetries().Add(ctx, targetNode.ID(), []storj.PieceID{deletedPieceID}, 0)
+		require.NoError(t, err)
+
+		pieceExists := func() bool {
+			_, err := targetNode.DB.Pieces().Stat(ctx, storage.BlobRef{
+				Namespace: satellite.ID().Bytes(),
+				Key:       deletedPieceID.Bytes(),
+			})
+			return err == nil
+		}
+		runGC := func() {
+			// see TestGarbageCollection about the precision of the timestamps.
+			time.Sleep(1 * time.Second)
+
+			gcService.Loop.Restart()
+			gcService.Loop.TriggerWait()
+			targetNode.Storage2.RetainService.TestWaitUntilEmpty()
+		}
+
+		// the deletion is recent, so the piece is retained
+		runGC()
+		require.True(t, pieceExists())
+
+		// after the minimum age the piece is reclaimed
+		time.Sleep(minAge)
+		runGC()
+		require.False(t, pieceExists())
+	})
+}
+
 func getPointer(ctx *testcontext.Context, t *testing.T, satellite *testplanet.Satellite, upl *testplanet.Uplink, bucket, path string) (_ metabase.SegmentKey, pointer *pb.Pointer) {
 	access := upl.Access[satellite.ID()]
 
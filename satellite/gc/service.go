@@ -38,6 +38,15 @@ type Config struct {
 	FalsePositiveRate float64       `help:"the false positive rate used for creating a garbage collection bloom filter" releaseDefault:"0.1" devDefault:"0.1"`
 	ConcurrentSends   int           `help:"the number of nodes to concurrently send garbage collection bloom filters to" releaseDefault:"1" devDefault:"1"`
 	RetainSendTimeout time.Duration `help:"the amount of time to allow a node to handle a retain request" default:"1m"`
+
+	DeletedPiecesMinAge time.Duration `help:"the minimum age of a failed direct deletion before garbage collection reclaims its pieces" default:"24h"`
+}
+
+// RecentDeletions provides the pieces which were deleted directly, but which
+// are still waiting for the deletion to be retried.
+type RecentDeletions interface {
+	// QueuedAfter returns the pieces, grouped by node, which were queued after since.
+	QueuedAfter(ctx context.Context, since time.Time) (map[storj.NodeID][]storj.PieceID, error)
 }
 
 // Service implements the garbage collection service
@@ -51,6 +60,11 @@ type Service struct {
 	dialer       rpc.Dialer
 	overlay      overlay.DB
 	metainfoLoop *metainfo.Loop
+
+	// recentDeletions is used for retaining the pieces of the recent direct
+	// deletions, so they are left to the deletion retries until they are
+	// older than DeletedPiecesMinAge.
+	recentDeletions RecentDeletions
 }
 
 // RetainInfo contains info needed for a storage node to retain important data and delete garbage data.
@@ -61,14 +75,15 @@ type RetainInfo struct {
 }
 
 // NewService creates a new instance of the gc service.
-func NewService(log *zap.Logger, config Config, dialer rpc.Dialer, overlay overlay.DB, loop *metainfo.Loop) *Service {
+func NewService(log *zap.Logger, config Config, dialer rpc.Dialer, overlay overlay.DB, loop *metainfo.Loop, recentDeletions RecentDeletions) *Service {
 	return &Service{
-		log:          log,
-		config:       config,
-		Loop:         sync2.NewCycle(config.Interval),
-		dialer:       dialer,
-		overlay:      overlay,
-		metainfoLoop: loop,
+		log:             log,
+		config:          config,
+		Loop:            sync2.NewCycle(config.Interval),
+		dialer:          dialer,
+		overlay:         overlay,
+		metainfoLoop:    loop,
+		recentDeletions: recentDeletions,
 	}
 }
 
@@ -109,6 +124,21 @@ func (service *Service) Run(ctx context.Context) (err error) {
 			return nil
 		}
 
+		// retain the pieces which are waiting for the deletion to be retried
+		since := time.Now().Add(-service.config.DeletedPiecesMinAge)
+		recent, err := service.recentDeletions.QueuedAfter(ctx, since)
+		if err != nil {
+			// without them the pieces of the recent deletions could be
+			// reclaimed, so no bloom filter is sent.
+			service.log.Error("error getting recent deletions", zap.Error(err))
+			return nil
+		}
+		for nodeID, pieceIDs := range recent {
+			for _, pieceID := range pieceIDs {
+				pieceTracker.add(nodeID, pieceID)
+			}
+		}
+
 		// save piece counts in memory for next iteration
 		for id := range lastPieceCounts {
 			delete(lastPieceCounts, id)
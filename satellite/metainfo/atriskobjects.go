@@ -0,0 +1,134 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/time/rate"
+
+	"storj.io/common/pb"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+// AtRiskObjectsOptions defines the options for finding the objects which
+// have segments below their repair threshold.
+type AtRiskObjectsOptions struct {
+	// Limit is the maximum number of objects returned. The objects beyond it
+	// are still counted, hence zero only counts them.
+	Limit int
+	// RateLimit is the maximum number of pointers read per second. Zero means
+	// no limit.
+	RateLimit float64
+}
+
+// AtRiskObject is an object which has at least one segment below its repair
+// threshold.
+type AtRiskObject struct {
+	Location metabase.ObjectLocation
+	// Segments is the number of segments of the object below their repair
+	// threshold.
+	Segments int
+	// Margin is the lowest number of pieces of a segment of the object above
+	// the minimum required for downloading it. It's negative when a segment
+	// is already lost.
+	Margin int
+}
+
+// AtRiskObjects are the objects which have segments below their repair
+// threshold.
+type AtRiskObjects struct {
+	// Count is the number of objects.
+	Count int64
+	// Objects are at most AtRiskObjectsOptions.Limit of the objects, the ones
+	// closest to being lost first.
+	Objects []AtRiskObject
+}
+
+// AtRiskObjects returns the objects which have at least one segment with at
+// most as many pieces as its repair threshold, so that operators can
+// prioritize their repair.
+//
+// Like the repair checker, a segment whose repair and success thresholds are
+// the same isn't at risk. Unlike it, the pieces are counted from the pointers
+// without checking whether their nodes are online, hence the result is only
+// as accurate as the last repair or audit of the segments. All the pointers
+// are read, since pointerDB has no index by piece count.
+func (s *Service) AtRiskObjects(ctx context.Context, opts AtRiskObjectsOptions) (_ AtRiskObjects, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	objects := map[metabase.ObjectLocation]*AtRiskObject{}
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if opts.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RateLimit), 1)
+	}
+
+	err = s.db.IterateWithoutLookupLimit(ctx, storage.IterateOptions{
+		Recurse: true,
+		Limit:   nodeObjectsIterateLimit,
+	}, func(ctx context.Context, it storage.Iterator) error {
+		var item storage.ListItem
+		for it.Next(ctx, &item) {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+
+			location, err := metabase.ParseSegmentKey(metabase.SegmentKey(item.Key))
+			if err != nil {
+				// not a segment key.
+				continue
+			}
+
+			pointer := &pb.Pointer{}
+			if err := pb.Unmarshal(item.Value, pointer); err != nil {
+				return err
+			}
+
+			redundancy := pointer.GetRemote().GetRedundancy()
+			if redundancy == nil {
+				continue
+			}
+			pieces := int32(len(pointer.Remote.RemotePieces))
+			if pieces > redundancy.RepairThreshold || pieces >= redundancy.SuccessThreshold {
+				continue
+			}
+
+			margin := int(pieces - redundancy.MinReq)
+			object, ok := objects[location.Object()]
+			if !ok {
+				object = &AtRiskObject{Location: location.Object(), Margin: margin}
+				objects[location.Object()] = object
+			}
+			object.Segments++
+			if margin < object.Margin {
+				object.Margin = margin
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return AtRiskObjects{}, Error.Wrap(err)
+	}
+
+	result := AtRiskObjects{Count: int64(len(objects))}
+	for _, object := range objects {
+		result.Objects = append(result.Objects, *object)
+	}
+	sort.Slice(result.Objects, func(i, k int) bool {
+		if result.Objects[i].Margin != result.Objects[k].Margin {
+			return result.Objects[i].Margin < result.Objects[k].Margin
+		}
+		return result.Objects[i].Segments > result.Objects[k].Segments
+	})
+	if opts.Limit < 0 {
+		opts.Limit = 0
+	}
+	if len(result.Objects) > opts.Limit {
+		result.Objects = result.Objects[:opts.Limit]
+	}
+	return result, nil
+}
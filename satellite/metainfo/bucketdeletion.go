@@ -0,0 +1,261 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+
+	"storj.io/common/uuid"
+)
+
+// maxFinishedBucketDeletions is the number of finished bucket deletions whose
+// state is kept, see BucketDeletionLimiter.Jobs.
+const maxFinishedBucketDeletions = 100
+
+// BucketDeletionLimiter limits the number of buckets whose objects are deleted
+// at the same time. The further deletions wait in a queue until one of the
+// running deletions finishes.
+type BucketDeletionLimiter struct {
+	// queued and running are the first fields to keep them 64-bit aligned
+	// for atomic access.
+	queued  int64
+	running int64
+
+	sem *semaphore.Weighted
+
+	// stallTimeout is how long a deletion can go without progress before
+	// the watchdog fails it, 0 means never.
+	stallTimeout time.Duration
+
+	mu       sync.Mutex
+	active   map[*BucketDeletionJob]struct{}
+	finished []BucketDeletionStatus
+}
+
+// NewBucketDeletionLimiter returns a limiter which runs at most max bucket
+// deletions at the same time. max <= 0 means no limit.
+//
+// The deletions started with Start which make no progress for stallTimeout
+// are failed by a watchdog, which frees their slot. stallTimeout <= 0 means
+// no watchdog.
+func NewBucketDeletionLimiter(max int, stallTimeout time.Duration) *BucketDeletionLimiter {
+	limiter := &BucketDeletionLimiter{
+		stallTimeout: stallTimeout,
+		active:       map[*BucketDeletionJob]struct{}{},
+	}
+	if max > 0 {
+		limiter.sem = semaphore.NewWeighted(int64(max))
+	}
+	return limiter
+}
+
+// Acquire waits until a bucket deletion can start. The returned release func
+// must be called when the deletion finishes. It returns an error when ctx is
+// canceled while waiting.
+func (limiter *BucketDeletionLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	if limiter.sem != nil {
+		mon.IntVal("bucket_deletion_queue_depth").Observe(atomic.AddInt64(&limiter.queued, 1))
+		err = limiter.sem.Acquire(ctx, 1)
+		mon.IntVal("bucket_deletion_queue_depth").Observe(atomic.AddInt64(&limiter.queued, -1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mon.IntVal("bucket_deletion_running").Observe(atomic.AddInt64(&limiter.running, 1))
+	return func() {
+		mon.IntVal("bucket_deletion_running").Observe(atomic.AddInt64(&limiter.running, -1))
+		if limiter.sem != nil {
+			limiter.sem.Release(1)
+		}
+	}, nil
+}
+
+// Queued returns the number of bucket deletions waiting for their turn.
+func (limiter *BucketDeletionLimiter) Queued() int64 {
+	return atomic.LoadInt64(&limiter.queued)
+}
+
+// Running returns the number of bucket deletions in progress.
+func (limiter *BucketDeletionLimiter) Running() int64 {
+	return atomic.LoadInt64(&limiter.running)
+}
+
+// Start waits until the deletion of the bucket can start, like Acquire, and
+// returns the job tracking it. The deletion must run with the returned
+// context, which is canceled when the watchdog fails the job, report its
+// progress with Progress and call Finish at the end.
+func (limiter *BucketDeletionLimiter) Start(ctx context.Context, projectID uuid.UUID, bucket []byte) (_ context.Context, _ *BucketDeletionJob, err error) {
+	release, err := limiter.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	now := time.Now()
+	job := &BucketDeletionJob{
+		limiter: limiter,
+		cancel:  cancel,
+		release: release,
+		status: BucketDeletionStatus{
+			ProjectID:  projectID,
+			Bucket:     string(bucket),
+			State:      BucketDeletionRunning,
+			Started:    now,
+			Progressed: now,
+		},
+	}
+
+	limiter.mu.Lock()
+	limiter.active[job] = struct{}{}
+	limiter.mu.Unlock()
+
+	if limiter.stallTimeout > 0 {
+		job.watchdog = time.AfterFunc(limiter.stallTimeout, job.stalled)
+	}
+	return ctx, job, nil
+}
+
+// Jobs returns the state of the running bucket deletions started with Start
+// and of the last finished ones.
+func (limiter *BucketDeletionLimiter) Jobs() []BucketDeletionStatus {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	jobs := append([]BucketDeletionStatus{}, limiter.finished...)
+	for job := range limiter.active {
+		jobs = append(jobs, job.status)
+	}
+	return jobs
+}
+
+// BucketDeletionState is the state of a bucket deletion.
+type BucketDeletionState int
+
+const (
+	// BucketDeletionRunning means that the deletion is in progress.
+	BucketDeletionRunning BucketDeletionState = iota
+	// BucketDeletionSucceeded means that the deletion has finished without
+	// errors.
+	BucketDeletionSucceeded
+	// BucketDeletionFailed means that the deletion has failed or that the
+	// watchdog has failed it because it made no progress.
+	BucketDeletionFailed
+)
+
+// String returns a string representation of the state.
+func (state BucketDeletionState) String() string {
+	switch state {
+	case BucketDeletionRunning:
+		return "running"
+	case BucketDeletionSucceeded:
+		return "succeeded"
+	case BucketDeletionFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// BucketDeletionStatus describes a bucket deletion.
+type BucketDeletionStatus struct {
+	ProjectID uuid.UUID
+	Bucket    string
+	State     BucketDeletionState
+	// Err is the error of a failed deletion. It's ErrBucketDeletionStalled
+	// when the watchdog has failed it.
+	Err error
+
+	Started    time.Time
+	Progressed time.Time
+	Finished   time.Time
+}
+
+// BucketDeletionJob tracks a bucket deletion started with
+// BucketDeletionLimiter.Start.
+type BucketDeletionJob struct {
+	limiter  *BucketDeletionLimiter
+	cancel   func()
+	release  func()
+	watchdog *time.Timer
+
+	// status is protected by limiter.mu.
+	status BucketDeletionStatus
+}
+
+// Progress records that the deletion has made progress, which postpones the
+// watchdog.
+func (job *BucketDeletionJob) Progress() {
+	job.limiter.mu.Lock()
+	defer job.limiter.mu.Unlock()
+
+	if job.status.State != BucketDeletionRunning {
+		return
+	}
+	job.status.Progressed = time.Now()
+	if job.watchdog != nil {
+		job.watchdog.Reset(job.limiter.stallTimeout)
+	}
+}
+
+// Finish records the outcome of the deletion and frees its slot. It returns
+// ErrBucketDeletionStalled when the watchdog has already failed the job.
+func (job *BucketDeletionJob) Finish(err error) error {
+	if job.watchdog != nil {
+		job.watchdog.Stop()
+	}
+
+	state := BucketDeletionSucceeded
+	if err != nil {
+		state = BucketDeletionFailed
+	}
+	if !job.finish(state, err) {
+		job.limiter.mu.Lock()
+		defer job.limiter.mu.Unlock()
+		return job.status.Err
+	}
+	return err
+}
+
+// stalled fails the job when the watchdog fires.
+func (job *BucketDeletionJob) stalled() {
+	job.limiter.mu.Lock()
+	progressed := job.status.Progressed
+	job.limiter.mu.Unlock()
+
+	if job.finish(BucketDeletionFailed, ErrBucketDeletionStalled.New("no progress since %s", progressed.Format(time.RFC3339))) {
+		mon.Meter("bucket_deletion_stalled").Mark(1)
+	}
+}
+
+// finish moves the job to the finished ones, cancels it and frees its slot.
+// It returns false when the job had already finished.
+func (job *BucketDeletionJob) finish(state BucketDeletionState, err error) bool {
+	limiter := job.limiter
+
+	limiter.mu.Lock()
+	if job.status.State != BucketDeletionRunning {
+		limiter.mu.Unlock()
+		return false
+	}
+	job.status.State = state
+	job.status.Err = err
+	job.status.Finished = time.Now()
+
+	delete(limiter.active, job)
+	limiter.finished = append(limiter.finished, job.status)
+	if len(limiter.finished) > maxFinishedBucketDeletions {
+		limiter.finished = limiter.finished[len(limiter.finished)-maxFinishedBucketDeletions:]
+	}
+	limiter.mu.Unlock()
+
+	job.cancel()
+	job.release()
+	return true
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/satellite/metainfo"
+)
+
+func TestBucketDeletionLimiter(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const (
+		max  = 2
+		jobs = 5
+	)
+
+	limiter := metainfo.NewBucketDeletionLimiter(max, 0)
+
+	finish := make(chan struct{})
+	started := make(chan struct{}, jobs)
+
+	waitFor := func(running, queued int64) {
+		for limiter.Running() != running || limiter.Queued() != queued || len(started) < int(running) {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("running %d and queued %d, expected %d and %d",
+					limiter.Running(), limiter.Queued(), running, queued)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	for i := 0; i < jobs; i++ {
+		ctx.Go(func() error {
+			release, err := limiter.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+	}
+
+	// the jobs above the limit wait in the queue.
+	waitFor(max, jobs-max)
+	require.Len(t, started, max)
+
+	// a queued job gives up when its context is canceled.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := limiter.Acquire(canceled)
+	require.Error(t, err)
+	require.EqualValues(t, jobs-max, limiter.Queued())
+
+	// the queued jobs run when the running ones finish.
+	close(finish)
+	ctx.Wait()
+	require.Len(t, started, jobs)
+	waitFor(0, 0)
+
+	{ // no limit
+		limiter := metainfo.NewBucketDeletionLimiter(0, 0)
+		release, err := limiter.Acquire(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, limiter.Running())
+		release()
+		require.Zero(t, limiter.Running())
+	}
+}
+
+func TestBucketDeletionLimiter_Watchdog(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const stallTimeout = 100 * time.Millisecond
+
+	limiter := metainfo.NewBucketDeletionLimiter(1, stallTimeout)
+	projectID := testrand.UUID()
+
+	// a job making progress isn't failed.
+	jobCtx, job, err := limiter.Start(ctx, projectID, []byte("progressing"))
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		time.Sleep(stallTimeout / 2)
+		job.Progress()
+	}
+	require.NoError(t, jobCtx.Err())
+	require.NoError(t, job.Finish(nil))
+
+	// a stalled job is failed, canceled and frees its slot.
+	stalledCtx, stalled, err := limiter.Start(ctx, projectID, []byte("stalled"))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, limiter.Running())
+
+	select {
+	case <-stalledCtx.Done():
+	case <-time.After(10 * stallTimeout):
+		t.Fatal("the stalled job hasn't been failed")
+	}
+	require.Zero(t, limiter.Running())
+
+	_, next, err := limiter.Start(ctx, projectID, []byte("next"))
+	require.NoError(t, err)
+
+	// the outcome of the stalled job is the watchdog's one.
+	err = stalled.Finish(nil)
+	require.True(t, metainfo.ErrBucketDeletionStalled.Has(err), err)
+	require.NoError(t, next.Finish(nil))
+
+	states := map[string]metainfo.BucketDeletionState{}
+	for _, status := range limiter.Jobs() {
+		states[status.Bucket] = status.State
+		if status.Bucket == "stalled" {
+			require.True(t, metainfo.ErrBucketDeletionStalled.Has(status.Err), status.Err)
+		}
+	}
+	require.Equal(t, map[string]metainfo.BucketDeletionState{
+		"progressing": metainfo.BucketDeletionSucceeded,
+		"stalled":     metainfo.BucketDeletionFailed,
+		"next":        metainfo.BucketDeletionSucceeded,
+	}, states)
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"strings"
+
+	"storj.io/common/storj"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/uplink/private/storage/meta"
+)
+
+// DeleteBucketPreflightResult is what deleting a bucket with its objects would
+// delete.
+type DeleteBucketPreflightResult struct {
+	// Objects is the number of objects which have a last segment, which is
+	// reported as DeletedObjects by the deletion.
+	Objects int
+	// Segments is the number of segments, including the ones of the objects
+	// without a last segment.
+	Segments int
+	// Bytes is the total encrypted size of the segments.
+	Bytes int64
+}
+
+// DeleteBucketPreflight counts what deleting the bucket with its objects
+// would delete, without modifying anything. Only the segments are scanned,
+// not their pieces. storj.ErrBucketNotFound is returned when the bucket
+// doesn't exist.
+func (s *Service) DeleteBucketPreflight(ctx context.Context, projectID uuid.UUID, bucketName []byte) (result DeleteBucketPreflightResult, err error) {
+	defer mon.Task()(&ctx, projectID.String())(&err)
+
+	_, err = s.GetBucket(ctx, bucketName, projectID)
+	if err != nil {
+		return result, err
+	}
+
+	return s.countBucketSegments(ctx, projectID, bucketName)
+}
+
+// countBucketSegments counts the segments of the bucket, including the ones of
+// the objects without a last segment. pointerDB is keyed by segment index
+// first, so the segments of the bucket are listed once for every segment
+// index used in the project.
+func (s *Service) countBucketSegments(ctx context.Context, projectID uuid.UUID, bucketName []byte) (result DeleteBucketPreflightResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	projectPrefix := metabase.SegmentKey(projectID.String())
+	indexCursor := ""
+	for {
+		indexes, moreIndexes, err := s.List(ctx, projectPrefix, indexCursor, false, 0, meta.None)
+		if err != nil {
+			return result, err
+		}
+
+		for _, index := range indexes {
+			if !index.IsPrefix {
+				continue
+			}
+			segmentIndex := strings.TrimSuffix(index.Path, "/")
+			prefix := metabase.SegmentKey(storj.JoinPaths(projectID.String(), segmentIndex, string(bucketName)))
+
+			cursor := ""
+			for {
+				segments, more, err := s.List(ctx, prefix, cursor, true, 0, meta.Size)
+				if err != nil {
+					return result, err
+				}
+
+				for _, segment := range segments {
+					result.Segments++
+					if segmentIndex == metabase.LastSegmentName {
+						result.Objects++
+					}
+					if segment.Pointer != nil {
+						result.Bytes += segment.Pointer.SegmentSize
+					}
+				}
+
+				if !more {
+					break
+				}
+				cursor = segments[len(segments)-1].Path
+			}
+		}
+
+		if !moreIndexes {
+			return result, nil
+		}
+		indexCursor = indexes[len(indexes)-1].Path
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"storj.io/common/pb"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
+)
+
+// deleteUnchangedObjects deletes the pointers of the objects like
+// objectdeletion.Service.Delete, but only the segments which haven't changed
+// since they were read, see DeleteObjectPiecesOptions.KeepConcurrentUploads.
+//
+// The objects which haven't been deleted, because they're missing, not
+// committed or replaced meanwhile, are reported as deleted without segments,
+// like the missing objects.
+func (endpoint *Endpoint) deleteUnchangedObjects(ctx context.Context, reqs ...*metabase.ObjectLocation) (reports []objectdeletion.Report, err error) {
+	defer mon.Task()(&ctx, len(reqs))(&err)
+
+	for _, req := range reqs {
+		keys, pointers, err := endpoint.metainfo.DeleteObjectUnchanged(ctx, *req)
+		if len(keys) == 0 && err == nil {
+			keys = []metabase.SegmentKey{req.LastSegment().Encode()}
+			pointers = []*pb.Pointer{nil}
+		}
+		if len(keys) > 0 {
+			report := objectdeletion.GenerateReport(ctx, endpoint.log, []*metabase.ObjectLocation{req}, keys, pointers)
+			reports = append(reports, report)
+		}
+		if err != nil {
+			return reports, objectdeletion.Error.Wrap(err)
+		}
+	}
+	return reports, nil
+}
@@ -56,19 +56,39 @@ type ProjectLimitConfig struct {
 
 // Config is a configuration struct that is everything you need to start a metainfo.
 type Config struct {
-	DatabaseURL          string                `help:"the database connection string to use" default:"postgres://"`
-	MinRemoteSegmentSize memory.Size           `default:"1240" help:"minimum remote segment size"`
-	MaxInlineSegmentSize memory.Size           `default:"4KiB" help:"maximum inline segment size"`
-	MaxSegmentSize       memory.Size           `default:"64MiB" help:"maximum segment size"`
-	MaxMetadataSize      memory.Size           `default:"2KiB" help:"maximum segment metadata size"`
-	MaxCommitInterval    time.Duration         `default:"48h" help:"maximum time allowed to pass between creating and committing a segment"`
-	Overlay              bool                  `default:"true" help:"toggle flag if overlay is enabled"`
-	RS                   RSConfig              `help:"redundancy scheme configuration"`
-	Loop                 LoopConfig            `help:"loop configuration"`
-	RateLimiter          RateLimiterConfig     `help:"rate limiter configuration"`
-	ProjectLimits        ProjectLimitConfig    `help:"project limit configuration"`
-	PieceDeletion        piecedeletion.Config  `help:"piece deletion configuration"`
-	ObjectDeletion       objectdeletion.Config `help:"object deletion configuration"`
+	DatabaseURL                  string                 `help:"the database connection string to use" default:"postgres://"`
+	ListReplicaDatabaseURL       string                 `help:"read replica serving the listings that allow stale results (empty means the primary)" default:""`
+	MinRemoteSegmentSize         memory.Size            `default:"1240" help:"minimum remote segment size"`
+	MaxInlineSegmentSize         memory.Size            `default:"4KiB" help:"maximum inline segment size"`
+	DeduplicateInlineSegments    bool                   `default:"false" help:"store identical inline segments once"`
+	MaxSegmentSize               memory.Size            `default:"64MiB" help:"maximum segment size"`
+	MaxMetadataSize              memory.Size            `default:"2KiB" help:"maximum segment metadata size"`
+	MaxPointerSize               memory.Size            `default:"64KiB" help:"maximum size of a serialized segment pointer (0 means no limit)"`
+	MaxCommitInterval            time.Duration          `default:"48h" help:"maximum time allowed to pass between creating and committing a segment"`
+	Overlay                      bool                   `default:"true" help:"toggle flag if overlay is enabled"`
+	RS                           RSConfig               `help:"redundancy scheme configuration"`
+	Loop                         LoopConfig             `help:"loop configuration"`
+	RateLimiter                  RateLimiterConfig      `help:"rate limiter configuration"`
+	ProjectLimits                ProjectLimitConfig     `help:"project limit configuration"`
+	PieceDeletion                piecedeletion.Config   `help:"piece deletion configuration"`
+	ObjectDeletion               objectdeletion.Config  `help:"object deletion configuration"`
+	MaxConcurrentBucketDeletions int                    `default:"10" help:"maximum number of concurrent bucket deletions (0 means no limit)"`
+	BucketDeletionStallTimeout   time.Duration          `default:"30m" help:"how long a stalled bucket deletion keeps its slot (0 means forever)"`
+	MaxConcurrentListsPerProject int                    `default:"0" help:"maximum number of concurrent listings per project (0 means no limit)"`
+	ListQueryTimeout             time.Duration          `default:"1m" help:"timeout of each pointerDB query of an object listing (0 means no timeout)"`
+	CaseInsensitiveListing       bool                   `default:"false" help:"allow case-insensitive listings, which scan the whole bucket"`
+	ListMaxScannedKeys           int                    `default:"100000" help:"maximum number of keys scanned by a page of an unindexed listing (0 disables them)"`
+	ListCompressionMinSize       memory.Size            `default:"1KiB" help:"minimum size of a compressed listing"`
+	DeleteQueryTimeout           time.Duration          `default:"5m" help:"timeout of each pointerDB query deleting objects (0 means no timeout)"`
+	DeleteLatencySLO             time.Duration          `default:"0" help:"delete latency counted as an SLO violation (0 means no SLO)"`
+	StrictSegmentKeys            bool                   `default:"false" help:"fail a delete on a malformed segment key instead of skipping it"`
+	DeleteDeadlineMetabaseShare  float64                `default:"0" help:"share of a delete's deadline given to deleting the pointers (0 means no split)"`
+	TransactionRetries           int                    `default:"3" help:"number of retries of a pointerDB transaction after a serialization failure (0 means no retry)"`
+	TransactionRetryJitter       time.Duration          `default:"50ms" help:"maximum random delay before retrying a pointerDB transaction"`
+	MaxDeletionBacklog           int                    `default:"0" help:"maximum number of pieces waiting for deletion before deletes are refused (0 means no limit)"`
+	DeletionRetryAfter           time.Duration          `default:"10s" help:"retry hint given with a delete refused for the backlog"`
+	DeletionSampling             DeletionSamplingConfig `help:"sampling of the deleted objects for verifying their removal"`
+	DetailedTracing              bool                   `default:"true" help:"annotate the delete and list spans with counts"`
 }
 
 // PointerDB stores pointers.
@@ -0,0 +1,384 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"bytes"
+	"context"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/context2"
+	"storj.io/common/memory"
+	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/storj"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+	"storj.io/storj/storage"
+)
+
+// CopyObjectKeys are the encryption keys of a copy. The keys of the segments
+// of an object are encrypted with a key derived from its path, hence the ones
+// of the source can't decrypt the copy: the caller decrypts them, e.g. from
+// the responses of the download requests, and encrypts them again for the
+// path of the copy, like the uplinks encrypt them for uploads.
+type CopyObjectKeys struct {
+	// EncryptedMetadata is the encrypted pb.StreamMeta of the copy, like the
+	// one committed by pb.ObjectCommitRequest. It holds the key of the last
+	// segment.
+	EncryptedMetadata []byte
+	// Segments are the keys of the other segments of the copy.
+	Segments []CopySegmentKey
+}
+
+// CopySegmentKey is the encrypted key of a segment of a copy, like the one
+// committed by pb.SegmentCommitRequest.
+type CopySegmentKey struct {
+	Index             int64
+	EncryptedKey      []byte
+	EncryptedKeyNonce storj.Nonce
+}
+
+// ObjectCopier copies objects without copying their data. It only needs
+// pointerDB, the databases of the references and the piece deletion service,
+// hence it can run in a different process than the metainfo endpoint, e.g.
+// the admin peer.
+//
+// architecture: Service
+type ObjectCopier struct {
+	log            *zap.Logger
+	metainfo       *Service
+	deletePieces   *piecedeletion.Service
+	inlineContents InlineContentsDB
+	sharedSegments SharedSegmentsDB
+	config         Config
+}
+
+// NewObjectCopier creates a new object copier.
+func NewObjectCopier(log *zap.Logger, metainfo *Service, deletePieces *piecedeletion.Service, inlineContents InlineContentsDB, sharedSegments SharedSegmentsDB, config Config) *ObjectCopier {
+	return &ObjectCopier{
+		log:            log,
+		metainfo:       metainfo,
+		deletePieces:   deletePieces,
+		inlineContents: inlineContents,
+		sharedSegments: sharedSegments,
+		config:         config,
+	}
+}
+
+// CopyObject copies the object at srcEncPath of srcBucket to dstEncPath of
+// dstBucket without copying its data: the pointers of the copy reference the
+// same pieces on the storage nodes. Each remote segment gets a reference in
+// SharedSegmentsDB, so that its pieces are only deleted with the last object
+// referencing them, see ReleaseSharedSegments. The contents of the inline
+// segments are copied, or referenced when they are deduplicated.
+//
+// It fails with rpcstatus.AlreadyExists when the destination exists and with
+// rpcstatus.FailedPrecondition when the source is missing some of its
+// segments. The segments left behind by another upload of the source aren't
+// copied. The pointers are copied with the keys of the copy, it fails with
+// rpcstatus.InvalidArgument when they don't match the segments of the source.
+//
+// Repair, re-encoding and graceful exit update the pointers one at a time,
+// hence the copies of a segment may reference different pieces afterwards.
+// Re-encoding releases the reference of the re-encoded pointer and keeps the
+// old pieces for the other copies.
+//
+// It doesn't perform any authorization check, hence it's only meant for
+// administrative tools.
+func (copier *ObjectCopier) CopyObject(ctx context.Context, projectID uuid.UUID, srcBucket, srcEncPath, dstBucket, dstEncPath []byte, keys CopyObjectKeys) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	src, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, srcBucket, srcEncPath)
+	if err != nil {
+		return rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+	dst, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, dstBucket, dstEncPath)
+	if err != nil {
+		return rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+	if src == dst {
+		return rpcstatus.Error(rpcstatus.InvalidArgument, "the object can't be copied to itself")
+	}
+
+	_, err = copier.metainfo.GetBucket(ctx, dstBucket, projectID)
+	if err != nil {
+		if storj.ErrBucketNotFound.Has(err) {
+			return rpcstatus.Error(rpcstatus.NotFound, err.Error())
+		}
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	if err := checkObjectMissing(ctx, copier.log, copier.metainfo, projectID, dstBucket, dstEncPath); err != nil {
+		return err
+	}
+
+	// the last segment is read first, for checking that the object hasn't
+	// changed while its segments are referenced.
+	lastSegmentBytes, _, err := copier.metainfo.GetWithBytes(ctx, src.Encode())
+	if err != nil {
+		if storj.ErrObjectNotFound.Has(err) {
+			return rpcstatus.Error(rpcstatus.NotFound, err.Error())
+		}
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	listed, err := copier.metainfo.ListObjectSegments(ctx, src.Object())
+	if err != nil {
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	segments, err := objectSegmentsToCopy(listed)
+	if err != nil {
+		return err
+	}
+	metadata, err := copyMetadata(segments, keys, copier.config.MaxMetadataSize)
+	if err != nil {
+		return err
+	}
+
+	// We should ignore client cancelling for not leaving references behind.
+	ctx = context2.WithoutCancellation(ctx)
+
+	copied := &copiedObject{}
+	defer func() {
+		if err != nil {
+			copier.rollbackCopy(ctx, copied)
+		}
+	}()
+
+	pointers := make([]*pb.Pointer, len(segments))
+	for i, segment := range segments {
+		pointers[i] = segment.Pointer
+	}
+	copied.referenced, err = copier.referenceSharedSegments(ctx, pointers)
+	if err != nil {
+		copier.log.Error("unable to reference the segments", zap.Error(err))
+		return rpcstatus.Error(rpcstatus.Internal, "unable to copy object")
+	}
+
+	err = copier.checkSourceUnchanged(ctx, segments, lastSegmentBytes)
+	if err != nil {
+		return err
+	}
+
+	// the last segment is put last, so that the copy is only visible once
+	// all its segments are.
+	for i, segment := range segments {
+		pointer := *segment.Pointer
+		pointer.Metadata = metadata[i]
+		if pointer.Type == pb.Pointer_INLINE {
+			if err := resolveInline(ctx, copier.inlineContents, &pointer); err != nil {
+				copier.log.Error("unable to resolve inline segment", zap.Error(err))
+				return rpcstatus.Error(rpcstatus.Internal, "unable to copy object")
+			}
+		}
+		stored, err := deduplicateInline(ctx, copier.inlineContents, copier.config.DeduplicateInlineSegments, &pointer)
+		if err != nil {
+			copier.log.Error("unable to deduplicate inline segment", zap.Error(err))
+			return rpcstatus.Error(rpcstatus.Internal, "unable to copy object")
+		}
+		if stored != &pointer {
+			copied.inline = append(copied.inline, stored)
+		}
+
+		location := dst
+		location.Index = segment.Index
+		err = copier.metainfo.Put(ctx, location.Encode(), stored)
+		if err != nil {
+			if storage.ErrValueChanged.Has(err) {
+				return rpcstatus.Errorf(rpcstatus.AlreadyExists, "object %q/%q already exists", dstBucket, dstEncPath)
+			}
+			copier.log.Error("unable to put pointer", zap.Error(err))
+			return rpcstatus.Error(rpcstatus.Internal, "unable to copy object")
+		}
+		copied.keys = append(copied.keys, location.Encode())
+	}
+
+	mon.Meter("copy_object").Mark(1)
+	mon.IntVal("copy_object_segments").Observe(int64(len(segments)))
+	mon.IntVal("copy_object_shared_segments").Observe(int64(len(copied.referenced)))
+	return nil
+}
+
+// checkSourceUnchanged checks that the segments of the source of a copy
+// haven't changed since they were read, once their references are added: a
+// segment deleted before its reference was added may have had its pieces
+// deleted with it, while the ones deleted afterwards release the reference
+// of the copy instead. The last segment has to be the one of lastSegmentBytes.
+func (copier *ObjectCopier) checkSourceUnchanged(ctx context.Context, segments []ObjectSegment, lastSegmentBytes []byte) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	changed := func() error {
+		mon.Meter("copy_object_source_changed").Mark(1)
+		return rpcstatus.Error(rpcstatus.Aborted, "the object has changed while being copied")
+	}
+
+	others, last := segments[:len(segments)-1], segments[len(segments)-1]
+	keys := make([]metabase.SegmentKey, len(others))
+	for i, segment := range others {
+		keys[i] = segment.Key
+	}
+	if len(keys) > 0 {
+		pointers, err := copier.metainfo.GetItems(ctx, keys)
+		if err != nil {
+			return rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		for i, pointer := range pointers {
+			listed := others[i].Pointer
+			if pointer == nil || pointer.Type != listed.Type {
+				return changed()
+			}
+			if pointer.Type == pb.Pointer_REMOTE && (pointer.Remote == nil || listed.Remote == nil || pointer.Remote.RootPieceId != listed.Remote.RootPieceId) {
+				return changed()
+			}
+		}
+	}
+
+	// the listed last segment may be more recent than lastSegmentBytes.
+	currentBytes, _, err := copier.metainfo.GetWithBytes(ctx, last.Key)
+	if err != nil && !storj.ErrObjectNotFound.Has(err) {
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	if !bytes.Equal(currentBytes, lastSegmentBytes) {
+		return changed()
+	}
+	return nil
+}
+
+// copyMetadata returns the metadata of the pointers of the copy of the
+// segments, with the keys of the copy, in the same order.
+func copyMetadata(segments []ObjectSegment, keys CopyObjectKeys, maxMetadataSize memory.Size) ([][]byte, error) {
+	if memory.Size(len(keys.EncryptedMetadata)) > maxMetadataSize {
+		return nil, rpcstatus.Errorf(rpcstatus.InvalidArgument, "Metadata is too large, got %v, maximum allowed is %v", memory.Size(len(keys.EncryptedMetadata)), maxMetadataSize)
+	}
+	streamMeta := &pb.StreamMeta{}
+	if err := pb.Unmarshal(keys.EncryptedMetadata, streamMeta); err != nil {
+		return nil, rpcstatus.Error(rpcstatus.InvalidArgument, "invalid metadata structure")
+	}
+	if streamMeta.NumberOfSegments != 0 && streamMeta.NumberOfSegments != int64(len(segments)) {
+		return nil, rpcstatus.Errorf(rpcstatus.InvalidArgument, "the metadata has %d segments, the object has %d", streamMeta.NumberOfSegments, len(segments))
+	}
+
+	others := segments[:len(segments)-1]
+	if len(keys.Segments) != len(others) {
+		return nil, rpcstatus.Errorf(rpcstatus.InvalidArgument, "got the keys of %d segments, the object has %d", len(keys.Segments), len(others))
+	}
+	segmentKeys := make(map[int64]CopySegmentKey, len(keys.Segments))
+	for _, key := range keys.Segments {
+		segmentKeys[key.Index] = key
+	}
+
+	metadata := make([][]byte, 0, len(segments))
+	for _, segment := range others {
+		key, ok := segmentKeys[segment.Index]
+		if !ok {
+			return nil, rpcstatus.Errorf(rpcstatus.InvalidArgument, "missing the key of segment %d", segment.Index)
+		}
+		segmentMeta, err := pb.Marshal(&pb.SegmentMeta{
+			EncryptedKey: key.EncryptedKey,
+			KeyNonce:     key.EncryptedKeyNonce.Bytes(),
+		})
+		if err != nil {
+			return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		metadata = append(metadata, segmentMeta)
+	}
+	return append(metadata, keys.EncryptedMetadata), nil
+}
+
+// copiedObject is what has been done so far for copying an object.
+type copiedObject struct {
+	// referenced are the pointers of the source whose segments are referenced.
+	referenced []*pb.Pointer
+	// inline are the stored pointers which reference deduplicated contents.
+	inline []*pb.Pointer
+	// keys are the keys of the stored pointers.
+	keys []metabase.SegmentKey
+}
+
+// rollbackCopy deletes the pointers of a failed copy and releases its
+// references. The pieces are deleted when the source has been deleted in the
+// meantime.
+func (copier *ObjectCopier) rollbackCopy(ctx context.Context, copied *copiedObject) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	if len(copied.keys) > 0 {
+		_, _, err = copier.metainfo.UnsynchronizedGetDel(ctx, copied.keys)
+		if err != nil {
+			// the references are kept for the pointers left behind.
+			copier.log.Error("unable to delete the pointers of the failed copy", zap.Error(err))
+			return
+		}
+	}
+	ReleaseInlineContents(ctx, copier.log, copier.inlineContents, copied.inline)
+
+	unshared, err := releaseSegmentReferences(ctx, copier.sharedSegments, copied.referenced)
+	if err != nil {
+		copier.log.Error("failed to release the segments of the failed copy, their pieces are left to garbage collection", zap.Error(err))
+		return
+	}
+	if requests := newPieceDeletionRequests(unshared); len(requests) > 0 {
+		if err = copier.deletePieces.Delete(ctx, requests, deleteObjectPiecesSuccessThreshold); err != nil {
+			copier.log.Error("failed to delete pieces", zap.Error(err))
+		}
+	}
+}
+
+// objectSegmentsToCopy returns the segments of the object which are copied,
+// from the segments listed by ListObjectSegments, with the last segment at
+// the end. The segments past the number of segments of the object are left
+// behind by another upload, e.g. a pending one, and aren't copied.
+func objectSegmentsToCopy(segments []ObjectSegment) ([]ObjectSegment, error) {
+	if len(segments) == 0 || segments[len(segments)-1].Index != metabase.LastSegmentIndex {
+		return nil, rpcstatus.Error(rpcstatus.NotFound, "object not found")
+	}
+	last := segments[len(segments)-1]
+	others := segments[:len(segments)-1]
+
+	streamMeta := &pb.StreamMeta{}
+	if err := pb.Unmarshal(last.Pointer.Metadata, streamMeta); err != nil {
+		return nil, rpcstatus.Wrap(rpcstatus.FailedPrecondition, ErrCorruptObject.Wrap(err))
+	}
+	// old objects don't tell their number of segments.
+	count := int64(len(others))
+	if streamMeta.NumberOfSegments > 0 {
+		count = streamMeta.NumberOfSegments - 1
+	}
+
+	// the copy of a sparse object would be missing the same segments.
+	if int64(len(others)) < count {
+		return nil, rpcstatus.Wrap(rpcstatus.FailedPrecondition, ErrCorruptObject.New("object with missing segments"))
+	}
+	for index := int64(0); index < count; index++ {
+		if others[index].Index != index {
+			return nil, rpcstatus.Wrap(rpcstatus.FailedPrecondition, ErrCorruptObject.New("object with missing segments"))
+		}
+	}
+
+	return append(others[:count:count], last), nil
+}
+
+// checkObjectMissing returns rpcstatus.AlreadyExists when the object exists
+// in metainfo.
+func checkObjectMissing(ctx context.Context, log *zap.Logger, metainfo *Service, projectID uuid.UUID, bucket, encryptedPath []byte) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	location, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, bucket, encryptedPath)
+	if err != nil {
+		log.Error("unable to create path", zap.Error(err))
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+
+	_, err = metainfo.Get(ctx, location.Encode())
+	switch {
+	case err == nil:
+		return rpcstatus.Errorf(rpcstatus.AlreadyExists, "object %q/%q already exists", bucket, encryptedPath)
+	case storj.ErrObjectNotFound.Has(err):
+		return nil
+	default:
+		log.Error("unable to check object", zap.Error(err))
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/common/errs2"
+	"storj.io/common/memory"
+	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+func TestObjectCopier_CopyObject(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(13*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+			copier       = satelliteSys.Admin.Metainfo.ObjectCopier
+		)
+
+		const (
+			bucketName = "a-bucket"
+			otherName  = "other-bucket"
+		)
+
+		// two remote segments and an inline last segment.
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(28*memory.KiB))
+		require.NoError(t, err)
+		err = uplnk.CreateBucket(ctx, satelliteSys, otherName)
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		usedSpace := func() (total int64) {
+			for _, sn := range planet.StorageNodes {
+				piecesTotal, _, err := sn.Storage2.Store.SpaceUsedForPieces(ctx)
+				require.NoError(t, err)
+				total += piecesTotal
+			}
+			return total
+		}
+		uploaded := usedSpace()
+		require.NotZero(t, uploaded)
+
+		segments := func(bucket string) []metainfo.ObjectSegment {
+			segments, err := satelliteSys.Metainfo.Service.ListObjectSegments(ctx, metabase.ObjectLocation{
+				ProjectID:  projectID,
+				BucketName: bucket,
+				ObjectKey:  metabase.ObjectKey(encryptedPath),
+			})
+			require.NoError(t, err)
+			return segments
+		}
+
+		keys := copyObjectKeys(t, segments(bucketName))
+
+		// the keys of every segment are required.
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte(otherName), encryptedPath, metainfo.CopyObjectKeys{
+			EncryptedMetadata: keys.EncryptedMetadata,
+			Segments:          keys.Segments[1:],
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+		require.Empty(t, segments(otherName))
+
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte(otherName), encryptedPath, keys)
+		require.NoError(t, err)
+
+		source, copied := segments(bucketName), segments(otherName)
+		require.Len(t, source, 3)
+		require.Len(t, copied, 3)
+		for i := range source {
+			require.Equal(t, source[i].Index, copied[i].Index)
+			require.Equal(t, source[i].Pointer.Type, copied[i].Pointer.Type)
+			if i < len(keys.Segments) {
+				segmentMeta := &pb.SegmentMeta{}
+				require.NoError(t, pb.Unmarshal(copied[i].Pointer.Metadata, segmentMeta))
+				require.Equal(t, keys.Segments[i].EncryptedKey, segmentMeta.EncryptedKey)
+				require.Equal(t, keys.Segments[i].EncryptedKeyNonce.Bytes(), segmentMeta.KeyNonce)
+			} else {
+				require.Equal(t, keys.EncryptedMetadata, copied[i].Pointer.Metadata)
+			}
+			if source[i].Pointer.Type == pb.Pointer_REMOTE {
+				// the source is left as is, the copy shares its pieces.
+				require.Equal(t, source[i].Pointer.Remote, copied[i].Pointer.Remote)
+			}
+		}
+
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte(otherName), encryptedPath, keys)
+		require.True(t, errs2.IsRPC(err, rpcstatus.AlreadyExists), err)
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), []byte("missing"), []byte(otherName), []byte("missing"), keys)
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte("missing-bucket"), encryptedPath, keys)
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte(bucketName), encryptedPath, keys)
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+
+		// the pieces are kept for the copy.
+		_, err = endpoint.DeleteObjectPieces(ctx, projectID, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		planet.WaitForStorageNodeDeleters(ctx)
+
+		require.Empty(t, segments(bucketName))
+		require.Len(t, segments(otherName), 3)
+		require.Equal(t, uploaded, usedSpace())
+
+		// the pieces are deleted with the last reference.
+		_, err = endpoint.DeleteObjectPieces(ctx, projectID, []byte(otherName), encryptedPath)
+		require.NoError(t, err)
+		planet.WaitForStorageNodeDeleters(ctx)
+
+		require.Empty(t, segments(otherName))
+		require.Zero(t, usedSpace())
+	})
+}
+
+// copyObjectKeys returns new keys for the copy of the object of the segments,
+// as if they were encrypted for the path of the copy.
+func copyObjectKeys(t *testing.T, segments []metainfo.ObjectSegment) metainfo.CopyObjectKeys {
+	require.NotEmpty(t, segments)
+
+	var keys metainfo.CopyObjectKeys
+	for _, segment := range segments[:len(segments)-1] {
+		keys.Segments = append(keys.Segments, metainfo.CopySegmentKey{
+			Index:             segment.Index,
+			EncryptedKey:      testrand.BytesInt(48),
+			EncryptedKeyNonce: testrand.Nonce(),
+		})
+	}
+
+	streamMeta := &pb.StreamMeta{}
+	require.NoError(t, pb.Unmarshal(segments[len(segments)-1].Pointer.Metadata, streamMeta))
+	streamMeta.LastSegmentMeta = &pb.SegmentMeta{
+		EncryptedKey: testrand.BytesInt(48),
+		KeyNonce:     testrand.Nonce().Bytes(),
+	}
+	var err error
+	keys.EncryptedMetadata, err = pb.Marshal(streamMeta)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestObjectCopier_CopyObjectSparse(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(13*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			copier       = satelliteSys.Admin.Metainfo.ObjectCopier
+		)
+
+		const bucketName = "a-bucket"
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(28*memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		// the first segment is missing.
+		first, err := metainfo.CreatePath(ctx, projectID, 0, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		err = satelliteSys.Metainfo.Service.UnsynchronizedDelete(ctx, first.Encode())
+		require.NoError(t, err)
+
+		err = copier.CopyObject(ctx, projectID, []byte(bucketName), encryptedPath, []byte(bucketName), []byte("copy"), metainfo.CopyObjectKeys{})
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+		require.True(t, metainfo.ErrCorruptObject.Has(err), err)
+
+		// nothing is copied.
+		segments, err := satelliteSys.Metainfo.Service.ListObjectSegments(ctx, metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: bucketName,
+			ObjectKey:  "copy",
+		})
+		require.NoError(t, err)
+		require.Empty(t, segments)
+	})
+}
@@ -5,6 +5,7 @@ package metainfo
 
 import (
 	"context"
+	"time"
 
 	"storj.io/common/macaroon"
 	"storj.io/common/storj"
@@ -28,6 +29,82 @@ type BucketsDB interface {
 	DeleteBucket(ctx context.Context, bucketName []byte, projectID uuid.UUID) (err error)
 	// List returns all buckets for a project
 	ListBuckets(ctx context.Context, projectID uuid.UUID, listOpts storj.BucketListOptions, allowedBuckets macaroon.AllowedBuckets) (bucketList storj.BucketList, err error)
+	// ListBucketsWithOptions returns the buckets of a project which match opts
+	ListBucketsWithOptions(ctx context.Context, projectID uuid.UUID, opts ListBucketsOptions, allowedBuckets macaroon.AllowedBuckets) (bucketList storj.BucketList, err error)
 	// CountBuckets returns the number of buckets a project currently has
 	CountBuckets(ctx context.Context, projectID uuid.UUID) (int, error)
 }
+
+// ListBucketsOptions defines the options for listing the buckets of a project.
+type ListBucketsOptions struct {
+	storj.BucketListOptions
+
+	// CreatedAfter, when set, skips the buckets created at or before it.
+	CreatedAfter time.Time
+	// CreatedBefore, when set, skips the buckets created at or after it.
+	CreatedBefore time.Time
+}
+
+// Matches returns whether the bucket passes the creation time filters.
+func (opts ListBucketsOptions) Matches(bucket storj.Bucket) bool {
+	if !opts.CreatedAfter.IsZero() && !bucket.Created.After(opts.CreatedAfter) {
+		return false
+	}
+	if !opts.CreatedBefore.IsZero() && !bucket.Created.Before(opts.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// DeletedObjectStubsDB is the interface for the database to interact with the
+// stubs of the deleted objects, see DeleteObjectPiecesOptions.KeepStub.
+//
+// architecture: Database
+type DeletedObjectStubsDB interface {
+	// Add records the stub of a deleted object, replacing the stub of a previous object with the same key
+	Add(ctx context.Context, stub DeletedObjectStub) error
+	// List returns at most limit stubs of the bucket whose key is after cursor, ordered by key
+	List(ctx context.Context, bucket metabase.BucketLocation, cursor metabase.ObjectKey, limit int) ([]DeletedObjectStub, error)
+}
+
+// DeletedObjectStub records that an object existed after its data has been
+// deleted.
+type DeletedObjectStub struct {
+	metabase.ObjectLocation
+
+	// Size is the total encrypted size of the object.
+	Size int64
+	// Segments is the number of segments of the object.
+	Segments int
+	// ObjectCreated is the creation time of the last segment of the object.
+	ObjectCreated time.Time
+	// Deleted is when the object was deleted.
+	Deleted time.Time
+}
+
+// InlineContentsDB is the interface for the database to interact with the
+// deduplicated contents of inline segments, which are stored once per content
+// hash and referenced from the pointers.
+//
+// architecture: Database
+type InlineContentsDB interface {
+	// Reference stores the data of the hash, when it isn't stored yet, and adds a reference to it
+	Reference(ctx context.Context, hash []byte, data []byte) error
+	// Get returns the data of the hash, it fails with ErrInlineContentNotFound when it isn't stored
+	Get(ctx context.Context, hash []byte) ([]byte, error)
+	// Release removes a reference to the data of the hash and deletes the data when it was the last one
+	Release(ctx context.Context, hash []byte) (deleted bool, err error)
+}
+
+// SharedSegmentsDB is the interface for the database to interact with the
+// references to the remote segments which are shared by copied objects. The
+// segments are identified by their root piece ID. A segment without a stored
+// reference count has a single reference.
+//
+// architecture: Database
+type SharedSegmentsDB interface {
+	// Reference adds a reference to the segment
+	Reference(ctx context.Context, rootPieceID storj.PieceID) error
+	// Release removes a reference to each of the segments, once per occurrence, and returns the ones which are still referenced
+	Release(ctx context.Context, rootPieceIDs []storj.PieceID) (referenced []storj.PieceID, err error)
+}
@@ -5,6 +5,7 @@ package metainfo_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -15,6 +16,7 @@ import (
 	"storj.io/common/uuid"
 	"storj.io/storj/satellite"
 	"storj.io/storj/satellite/console"
+	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/satellitedb/satellitedbtest"
 )
 
@@ -208,3 +210,71 @@ func TestListBucketsNotAllowed(t *testing.T) {
 		}
 	})
 }
+
+func TestListBucketsCreatedRange(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		consoleDB := db.Console()
+		project, err := consoleDB.Projects().Insert(ctx, &console.Project{Name: "testproject1"})
+		require.NoError(t, err)
+
+		bucketsDB := db.Buckets()
+
+		// the buckets are created in a different order than their names,
+		// so that the creation time range doesn't match a range of names
+		names := []string{"ddd", "aaa", "eee", "bbb", "fff", "ccc"}
+		created := map[string]time.Time{}
+		for _, name := range names {
+			_, err := bucketsDB.CreateBucket(ctx, newTestBucket(name, project.ID))
+			require.NoError(t, err)
+
+			bucket, err := bucketsDB.GetBucket(ctx, []byte(name), project.ID)
+			require.NoError(t, err)
+			created[name] = bucket.Created
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		allowedBuckets := macaroon.AllowedBuckets{All: true}
+
+		list := func(opts metainfo.ListBucketsOptions) (names []string) {
+			opts.Direction = storj.After
+			for {
+				bucketList, err := bucketsDB.ListBucketsWithOptions(ctx, project.ID, opts, allowedBuckets)
+				require.NoError(t, err)
+				for _, bucket := range bucketList.Items {
+					names = append(names, bucket.Name)
+				}
+				if !bucketList.More {
+					return names
+				}
+				require.NotEmpty(t, bucketList.Items)
+				opts.Cursor = bucketList.Items[len(bucketList.Items)-1].Name
+			}
+		}
+
+		for _, limit := range []int{1, 2, 10} {
+			require.Equal(t, []string{"aaa", "bbb", "ccc", "ddd", "eee", "fff"}, list(metainfo.ListBucketsOptions{
+				BucketListOptions: storj.BucketListOptions{Limit: limit},
+			}))
+
+			// created after ddd and before ccc
+			require.Equal(t, []string{"aaa", "bbb", "eee", "fff"}, list(metainfo.ListBucketsOptions{
+				BucketListOptions: storj.BucketListOptions{Limit: limit},
+				CreatedAfter:      created["ddd"],
+				CreatedBefore:     created["ccc"],
+			}))
+
+			// created after eee
+			require.Equal(t, []string{"bbb", "ccc", "fff"}, list(metainfo.ListBucketsOptions{
+				BucketListOptions: storj.BucketListOptions{Limit: limit},
+				CreatedAfter:      created["eee"],
+			}))
+
+			// created before eee
+			require.Equal(t, []string{"aaa", "ddd"}, list(metainfo.ListBucketsOptions{
+				BucketListOptions: storj.BucketListOptions{Limit: limit},
+				CreatedBefore:     created["eee"],
+			}))
+		}
+	})
+}
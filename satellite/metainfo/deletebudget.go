@@ -0,0 +1,60 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"time"
+)
+
+// deleteBudget splits the time left before the deadline of a delete between
+// deleting the pointers of the objects and deleting their pieces on the
+// nodes, so that a slow pointerDB doesn't leave no time for the nodes, or
+// the other way around.
+type deleteBudget struct {
+	// deadline is the deadline of the delete, it's zero when there is none.
+	deadline time.Time
+	// metabaseShare is the fraction of the time left which is given to
+	// deleting the pointers.
+	metabaseShare float64
+}
+
+// newDeleteBudget returns the budget of a delete with the deadline of ctx.
+// The pointers get metabaseShare of the time left, the nodes get the rest
+// and whatever the pointers didn't use. A share of 1 or more lets both
+// phases use up to the deadline. A share of 0 or less ignores the deadline:
+// neither phase is limited by it.
+func newDeleteBudget(ctx context.Context, metabaseShare float64) deleteBudget {
+	if metabaseShare <= 0 {
+		return deleteBudget{}
+	}
+	if metabaseShare > 1 {
+		metabaseShare = 1
+	}
+
+	deadline, _ := ctx.Deadline()
+	return deleteBudget{
+		deadline:      deadline,
+		metabaseShare: metabaseShare,
+	}
+}
+
+// metabase returns a context for deleting the pointers, which times out once
+// their share of the time left is used.
+func (budget deleteBudget) metabase(ctx context.Context) (context.Context, context.CancelFunc) {
+	if budget.deadline.IsZero() {
+		return ctx, func() {}
+	}
+	left := time.Until(budget.deadline)
+	return context.WithTimeout(ctx, time.Duration(float64(left)*budget.metabaseShare))
+}
+
+// nodes returns a context for deleting the pieces on the nodes, which times
+// out at the deadline of the delete.
+func (budget deleteBudget) nodes(ctx context.Context) (context.Context, context.CancelFunc) {
+	if budget.deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, budget.deadline)
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// DeleteCost is the estimated cost of deleting an object, see
+// DeleteCostEstimator.EstimateDeleteCost.
+type DeleteCost struct {
+	// Segments is the number of segments of the object.
+	Segments int
+	// Nodes is the number of nodes storing pieces of the object.
+	Nodes int
+	// RPCs is the number of delete requests sent to the nodes: a node gets
+	// one request per piecedeletion.Config.MaxPiecesPerRequest pieces.
+	RPCs int
+	// Pieces is the number of pieces of the object.
+	Pieces int
+	// Bytes is the expected total size of the pieces on the nodes.
+	Bytes int64
+}
+
+// DeleteCostEstimator estimates the cost of deleting objects.
+type DeleteCostEstimator struct {
+	metainfo         *Service
+	piecesPerRequest int
+}
+
+// NewDeleteCostEstimator creates a new delete cost estimator. piecesPerRequest
+// is the maximum number of pieces sent to a node in one delete request, as
+// configured for the piece deletion service.
+func NewDeleteCostEstimator(metainfo *Service, piecesPerRequest int) *DeleteCostEstimator {
+	return &DeleteCostEstimator{
+		metainfo:         metainfo,
+		piecesPerRequest: piecesPerRequest,
+	}
+}
+
+// EstimateDeleteCost returns how many node requests, pieces and bytes deleting
+// the object would involve. It only reads pointerDB and doesn't delete
+// anything.
+//
+// The estimate assumes that every request is sent once: retries of failed
+// requests aren't included, and neither are the pieces of decommissioning
+// nodes, which aren't sent. The pieces of segments shared with copies of
+// the object are counted, although they are only deleted with the last
+// copy.
+func (estimator *DeleteCostEstimator) EstimateDeleteCost(ctx context.Context, location metabase.ObjectLocation) (cost DeleteCost, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	segments, err := estimator.metainfo.ListObjectSegments(ctx, location)
+	if err != nil {
+		return DeleteCost{}, err
+	}
+
+	// the segments of an object without its last segment aren't deleted
+	// with it.
+	if len(segments) == 0 || segments[len(segments)-1].Index != metabase.LastSegmentIndex {
+		return DeleteCost{}, storj.ErrObjectNotFound.New("%q", location.ObjectKey)
+	}
+
+	pointers := make([]*pb.Pointer, len(segments))
+	for i, segment := range segments {
+		pointers[i] = segment.Pointer
+	}
+
+	cost.Segments = len(segments)
+	for _, request := range newPieceDeletionRequests(pointers) {
+		cost.Nodes++
+		cost.Pieces += len(request.Pieces)
+		cost.Bytes += request.Bytes
+		if estimator.piecesPerRequest > 0 {
+			cost.RPCs += (len(request.Pieces) + estimator.piecesPerRequest - 1) / estimator.piecesPerRequest
+		} else {
+			cost.RPCs++
+		}
+	}
+	return cost, nil
+}
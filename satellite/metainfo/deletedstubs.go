@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"time"
+
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
+)
+
+// addDeletedStubs records the stubs of the deleted objects, see
+// DeleteObjectPiecesOptions.KeepStub.
+func (endpoint *Endpoint) addDeletedStubs(ctx context.Context, deleted []*objectdeletion.ObjectState) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	now := time.Now()
+	for _, state := range deleted {
+		// the segments left by an interrupted upload aren't an object.
+		if state.LastSegment == nil {
+			continue
+		}
+
+		stub := DeletedObjectStub{
+			ObjectLocation: state.ObjectLocation,
+			Size:           deletedObjectSize(state),
+			Segments:       1 + len(state.OtherSegments),
+			ObjectCreated:  state.LastSegment.CreationDate,
+			Deleted:        now,
+		}
+
+		if err := endpoint.deletedStubs.Add(ctx, stub); err != nil {
+			return Error.Wrap(err)
+		}
+		mon.Meter("delete_stub_added").Mark(1)
+	}
+	return nil
+}
+
+// notifyDeleteWebhooks queues the notifications of the deleted objects to
+// the webhooks of their projects, see SetDeleteWebhooks.
+func (endpoint *Endpoint) notifyDeleteWebhooks(deleted []*objectdeletion.ObjectState) {
+	if endpoint.deleteWebhooks == nil {
+		return
+	}
+
+	now := time.Now()
+	events := make([]deletewebhook.Event, 0, len(deleted))
+	for _, state := range deleted {
+		// the segments left by an interrupted upload aren't an object.
+		if state.LastSegment == nil {
+			continue
+		}
+		events = append(events, deletewebhook.Event{
+			Location: state.ObjectLocation,
+			Size:     deletedObjectSize(state),
+			Deleted:  now,
+		})
+	}
+	endpoint.deleteWebhooks.Notify(events...)
+}
+
+// deletedObjectSize returns the total encrypted size of the segments of a
+// deleted object.
+func deletedObjectSize(state *objectdeletion.ObjectState) int64 {
+	size := state.LastSegment.SegmentSize
+	for _, segment := range state.OtherSegments {
+		size += segment.SegmentSize
+	}
+	return size
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"go.uber.org/zap"
+)
+
+var allDeleteLatencies = newDeleteLatencies()
+
+// deleteLatencies tracks the latencies of the object deletions, from the
+// start of the deletion of the pointers to the end of the fan-out to the
+// nodes, per segment count bucket, so that the deletions of small and large
+// objects can be told apart.
+type deleteLatencies struct {
+	mu    sync.Mutex
+	dists map[string]*monkit.DurationDist
+}
+
+func newDeleteLatencies() *deleteLatencies {
+	latencies := &deleteLatencies{
+		dists: make(map[string]*monkit.DurationDist),
+	}
+	mon.Chain(latencies)
+	return latencies
+}
+
+// Observe records the latency of the deletion of an object with segments
+// segments.
+func (latencies *deleteLatencies) Observe(segments int, latency time.Duration) {
+	bucket := segmentCountBucket(segments)
+
+	latencies.mu.Lock()
+	defer latencies.mu.Unlock()
+
+	dist, ok := latencies.dists[bucket]
+	if !ok {
+		dist = monkit.NewDurationDist(monkit.NewSeriesKey("delete_object_latency").WithTag("segments", bucket))
+		latencies.dists[bucket] = dist
+	}
+	dist.Insert(latency)
+}
+
+// Stats implements monkit.StatSource.
+func (latencies *deleteLatencies) Stats(cb func(key monkit.SeriesKey, field string, val float64)) {
+	latencies.mu.Lock()
+	defer latencies.mu.Unlock()
+
+	for _, dist := range latencies.dists {
+		dist.Stats(cb)
+	}
+}
+
+// checkDeleteLatencySLO counts the deletion of an object which took latency
+// as a violation of the configured SLO when it exceeds it, so operators can be
+// alerted of slow deletions.
+func (endpoint *Endpoint) checkDeleteLatencySLO(latency time.Duration) {
+	slo := endpoint.config.DeleteLatencySLO
+	if slo <= 0 || latency <= slo {
+		return
+	}
+	mon.Counter("delete_object_slo_violations").Inc(1)
+	endpoint.log.Debug("object deletion exceeded the latency SLO",
+		zap.Duration("latency", latency), zap.Duration("slo", slo))
+}
+
+// segmentCountBucket returns the bucket of the segment count of an object,
+// by order of magnitude.
+func segmentCountBucket(segments int) string {
+	switch {
+	case segments <= 0:
+		return "0"
+	case segments == 1:
+		return "1"
+	case segments < 10:
+		return "2-9"
+	case segments < 100:
+		return "10-99"
+	default:
+		return "100+"
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"sort"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// DeletePlan is what deleting an object would delete, see
+// DeleteObjectPiecesOptions.DryRun.
+type DeletePlan struct {
+	// Segments is the number of segments which would be deleted.
+	Segments int
+	// Pieces is the number of pieces which would be deleted.
+	Pieces int
+	// Nodes are the nodes which would receive delete requests, sorted. The
+	// decommissioning nodes are included, although their pieces aren't
+	// sent to them.
+	Nodes []storj.NodeID
+}
+
+// planObjectDeletion returns what deleting the object at location with opts
+// would delete, without deleting anything. It looks up the segments like the
+// deletion does: the zombie segments are included, and so are the leftover
+// ones with Strict.
+func (endpoint *Endpoint) planObjectDeletion(ctx context.Context, location *metabase.ObjectLocation, opts DeleteObjectPiecesOptions) (plan DeletePlan, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var pointers []*pb.Pointer
+	switch {
+	case opts.SegmentTypes != 0 && opts.SegmentTypes != SegmentTypesAll:
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, *location)
+		if err != nil {
+			return DeletePlan{}, err
+		}
+		for _, segment := range segments {
+			if opts.SegmentTypes.Has(segment.Pointer) {
+				pointers = append(pointers, segment.Pointer)
+			}
+		}
+
+	case opts.KeepConcurrentUploads:
+		// only the segments of committed objects are deleted.
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, *location)
+		if err != nil {
+			return DeletePlan{}, err
+		}
+		if len(segments) > 0 && segments[len(segments)-1].Index == metabase.LastSegmentIndex {
+			for _, segment := range segments {
+				pointers = append(pointers, segment.Pointer)
+			}
+		}
+
+	default:
+		var keys []metabase.SegmentKey
+		pointers, keys, err = endpoint.deleteObjects.FindPointers(ctx, []*metabase.ObjectLocation{location})
+		if err != nil {
+			return DeletePlan{}, err
+		}
+		if !opts.Strict {
+			break
+		}
+
+		found := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			found[string(key)] = true
+		}
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, *location)
+		if err != nil {
+			return DeletePlan{}, err
+		}
+		for _, segment := range segments {
+			if !found[string(segment.Key)] {
+				pointers = append(pointers, segment.Pointer)
+			}
+		}
+	}
+
+	plan.Segments = len(pointers)
+	for _, request := range newPieceDeletionRequests(pointers) {
+		plan.Pieces += len(request.Pieces)
+		plan.Nodes = append(plan.Nodes, request.Node.ID)
+	}
+	sort.Slice(plan.Nodes, func(i, k int) bool {
+		return plan.Nodes[i].Less(plan.Nodes[k])
+	})
+	return plan, nil
+}
@@ -0,0 +1,245 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package deletewebhook notifies the webhooks registered by the projects of
+// their permanently deleted objects.
+package deletewebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/common/sync2"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+var (
+	// Error is the error class for this package.
+	Error = errs.Class("delete webhook error")
+
+	mon = monkit.Package()
+)
+
+// SignatureHeader is the header of the webhook requests which contains the
+// signature of their body, see Sign.
+const SignatureHeader = "Storj-Signature"
+
+// Config contains configurable values for the delete webhooks.
+type Config struct {
+	Enabled     bool          `help:"notify the project webhooks of the deleted objects" default:"false"`
+	QueueSize   int           `help:"maximum number of queued notifications, further ones are dropped" default:"10000"`
+	Concurrency int           `help:"number of webhook notifications sent concurrently" default:"4"`
+	Timeout     time.Duration `help:"timeout of a webhook request" default:"10s"`
+	MaxAttempts int           `help:"maximum number of attempts to notify a webhook of a deleted object" default:"5"`
+	Backoff     time.Duration `help:"delay before retrying a failed webhook request, doubled after every attempt" default:"1s"`
+}
+
+// Webhook is the URL which a project registered to be notified of its
+// deleted objects.
+type Webhook struct {
+	ProjectID uuid.UUID
+	URL       string
+	// Secret is the key of the signatures of the requests, see Sign.
+	Secret    []byte
+	CreatedAt time.Time
+}
+
+// DB is the interface for the database to interact with the webhooks of the
+// projects.
+//
+// architecture: Database
+type DB interface {
+	// Set registers the webhook of a project, replacing its previous one
+	Set(ctx context.Context, webhook Webhook) error
+	// Get returns the webhook of a project, or nil when it has none
+	Get(ctx context.Context, projectID uuid.UUID) (*Webhook, error)
+	// Delete removes the webhook of a project
+	Delete(ctx context.Context, projectID uuid.UUID) error
+}
+
+// Event is a permanently deleted object.
+type Event struct {
+	Location metabase.ObjectLocation
+	// Size is the total encrypted size of the object.
+	Size    int64
+	Deleted time.Time
+}
+
+// Payload is the body of the webhook requests, encoded as JSON.
+type Payload struct {
+	ProjectID          uuid.UUID `json:"projectId"`
+	BucketName         string    `json:"bucketName"`
+	EncryptedObjectKey []byte    `json:"encryptedObjectKey"`
+	Size               int64     `json:"size"`
+	DeletedAt          time.Time `json:"deletedAt"`
+}
+
+// Sign returns the signature of a request body: the hex encoded HMAC-SHA256
+// of the body with the secret of the webhook.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Service notifies the webhooks of the deleted objects.
+//
+// The notifications are sent in the background, so the deletions don't wait
+// for the webhooks. They are kept in memory, hence the ones which haven't
+// been sent are lost when the satellite stops.
+//
+// architecture: Service
+type Service struct {
+	log    *zap.Logger
+	config Config
+	db     DB
+	client *http.Client
+
+	events chan Event
+}
+
+// NewService creates a new delete webhooks service.
+func NewService(log *zap.Logger, db DB, config Config) *Service {
+	return &Service{
+		log:    log,
+		config: config,
+		db:     db,
+		client: &http.Client{Timeout: config.Timeout},
+		events: make(chan Event, config.QueueSize),
+	}
+}
+
+// Notify queues the notifications of the deleted objects. The ones which
+// don't fit into the queue are dropped.
+func (service *Service) Notify(events ...Event) {
+	for _, event := range events {
+		select {
+		case service.events <- event:
+		default:
+			mon.Meter("delete_webhook_dropped").Mark(1)
+		}
+	}
+}
+
+// Run sends the queued notifications until the context is canceled.
+func (service *Service) Run(ctx context.Context) error {
+	concurrency := service.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var group errgroup.Group
+	for i := 0; i < concurrency; i++ {
+		group.Go(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case event := <-service.events:
+					service.notify(ctx, event)
+				}
+			}
+		})
+	}
+	return group.Wait()
+}
+
+// Close closes the service.
+func (service *Service) Close() error { return nil }
+
+// notify sends the notification of the deleted object to the webhook of its
+// project, if it has one, retrying with exponential backoff when it fails.
+func (service *Service) notify(ctx context.Context, event Event) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	webhook, err := service.db.Get(ctx, event.Location.ProjectID)
+	if err != nil {
+		service.log.Error("unable to get the delete webhook",
+			zap.Stringer("Project ID", event.Location.ProjectID),
+			zap.Error(err),
+		)
+		return
+	}
+	if webhook == nil {
+		return
+	}
+
+	body, err := json.Marshal(Payload{
+		ProjectID:          event.Location.ProjectID,
+		BucketName:         event.Location.BucketName,
+		EncryptedObjectKey: []byte(event.Location.ObjectKey),
+		Size:               event.Size,
+		DeletedAt:          event.Deleted.UTC(),
+	})
+	if err != nil {
+		service.log.Error("unable to encode the delete webhook payload", zap.Error(err))
+		return
+	}
+	signature := Sign(webhook.Secret, body)
+
+	backoff := service.config.Backoff
+	for attempt := 1; ; attempt++ {
+		err = service.send(ctx, webhook.URL, body, signature)
+		if err == nil {
+			mon.Meter("delete_webhook_delivered").Mark(1)
+			return
+		}
+		mon.Meter("delete_webhook_attempt_failed").Mark(1)
+
+		if attempt >= service.config.MaxAttempts {
+			break
+		}
+		if !sync2.Sleep(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	mon.Meter("delete_webhook_failed").Mark(1)
+	service.log.Warn("unable to notify the delete webhook",
+		zap.Stringer("Project ID", event.Location.ProjectID),
+		zap.String("URL", webhook.URL),
+		zap.Error(err),
+	)
+}
+
+// send posts the body to the URL, the response must have a 2xx status.
+func (service *Service) send(ctx context.Context, url string, body []byte, signature string) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+
+	resp, err := service.client.Do(req)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, resp.Body.Close()) }()
+	// read the response, so the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Error.New("unexpected status %q", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package deletewebhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// memoryDB keeps the webhooks in memory.
+type memoryDB struct {
+	mu       sync.Mutex
+	webhooks map[uuid.UUID]deletewebhook.Webhook
+}
+
+func (db *memoryDB) Set(ctx context.Context, webhook deletewebhook.Webhook) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.webhooks[webhook.ProjectID] = webhook
+	return nil
+}
+
+func (db *memoryDB) Get(ctx context.Context, projectID uuid.UUID) (*deletewebhook.Webhook, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	webhook, ok := db.webhooks[projectID]
+	if !ok {
+		return nil, nil
+	}
+	return &webhook, nil
+}
+
+func (db *memoryDB) Delete(ctx context.Context, projectID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.webhooks, projectID)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	secret := testrand.BytesInt(32)
+
+	var mu sync.Mutex
+	var delivered []deletewebhook.Payload
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodPost || r.Header.Get(deletewebhook.SignatureHeader) != deletewebhook.Sign(secret, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload deletewebhook.Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		key := string(payload.EncryptedObjectKey)
+		attempts[key]++
+		// the first notification of the object fails, so it's retried.
+		if key == "retried" && attempts[key] == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		delivered = append(delivered, payload)
+	}))
+	defer server.Close()
+
+	projectID := testrand.UUID()
+	db := &memoryDB{webhooks: map[uuid.UUID]deletewebhook.Webhook{}}
+	require.NoError(t, db.Set(ctx, deletewebhook.Webhook{
+		ProjectID: projectID,
+		URL:       server.URL,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}))
+
+	service := deletewebhook.NewService(zaptest.NewLogger(t), db, deletewebhook.Config{
+		Enabled:     true,
+		QueueSize:   10,
+		Concurrency: 1,
+		Timeout:     10 * time.Second,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	})
+
+	deleted := time.Date(2020, time.November, 1, 12, 0, 0, 0, time.UTC)
+	event := func(projectID uuid.UUID, key string, size int64) deletewebhook.Event {
+		return deletewebhook.Event{
+			Location: metabase.ObjectLocation{
+				ProjectID:  projectID,
+				BucketName: "bucket",
+				ObjectKey:  metabase.ObjectKey(key),
+			},
+			Size:    size,
+			Deleted: deleted,
+		}
+	}
+
+	service.Notify(
+		event(projectID, "retried", 100),
+		// the project has no webhook.
+		event(testrand.UUID(), "ignored", 200),
+		event(projectID, "object", 300),
+	)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx.Go(func() error { return service.Run(runCtx) })
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) == 2
+	}, 10*time.Second, time.Millisecond)
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []deletewebhook.Payload{
+		{ProjectID: projectID, BucketName: "bucket", EncryptedObjectKey: []byte("retried"), Size: 100, DeletedAt: deleted},
+		{ProjectID: projectID, BucketName: "bucket", EncryptedObjectKey: []byte("object"), Size: 300, DeletedAt: deleted},
+	}, delivered)
+	require.Equal(t, map[string]int{"retried": 2, "object": 1}, attempts)
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// DeletionSamplingConfig defines how the deleted objects are sampled for
+// verifying that they are truly gone.
+type DeletionSamplingConfig struct {
+	Rate            float64 `help:"fraction of the deleted objects sampled for verification (0 means no sampling)" default:"0"`
+	MaxObjects      int     `help:"maximum number of sampled objects waiting for verification" default:"1000"`
+	PiecesPerObject int     `help:"number of pieces verified per sampled object" default:"3"`
+}
+
+// DeletedObjectSample is a deleted object whose removal is verified later.
+type DeletedObjectSample struct {
+	Location metabase.ObjectLocation
+	Deleted  time.Time
+	// Pieces are some of the pieces of the object, which should have been
+	// deleted from their nodes.
+	Pieces []DeletedPiece
+}
+
+// DeletedPiece is a piece which should have been deleted from its node.
+type DeletedPiece struct {
+	Node    storj.NodeID
+	PieceID storj.PieceID
+}
+
+// DeletionSampler keeps a random sample of the recently deleted objects.
+//
+// The samples are kept in memory, the ones which are lost on restart aren't
+// verified.
+type DeletionSampler struct {
+	config DeletionSamplingConfig
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	nodes   map[storj.NodeID]struct{}
+	samples []DeletedObjectSample
+}
+
+// NewDeletionSampler returns a new sampler of the deleted objects.
+func NewDeletionSampler(config DeletionSamplingConfig) *DeletionSampler {
+	return &DeletionSampler{
+		config: config,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetNodes restricts the sampled pieces to the ones stored by the nodes, so
+// that the deletions on suspect nodes can be investigated rather than the
+// ones on whichever nodes store the first pieces. The objects are still
+// sampled at Rate. No nodes samples the pieces of any node again.
+func (sampler *DeletionSampler) SetNodes(nodes []storj.NodeID) {
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	if len(nodes) == 0 {
+		sampler.nodes = nil
+		return
+	}
+	sampler.nodes = make(map[storj.NodeID]struct{}, len(nodes))
+	for _, node := range nodes {
+		sampler.nodes[node] = struct{}{}
+	}
+}
+
+// Add samples the deleted object whose segments were pointers.
+func (sampler *DeletionSampler) Add(location metabase.ObjectLocation, pointers []*pb.Pointer) {
+	if sampler == nil || sampler.config.Rate <= 0 {
+		return
+	}
+
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	if sampler.rand.Float64() >= sampler.config.Rate {
+		return
+	}
+	if len(sampler.samples) >= sampler.config.MaxObjects {
+		mon.Meter("deletion_sample_dropped").Mark(1)
+		return
+	}
+
+	sample := DeletedObjectSample{
+		Location: location,
+		Deleted:  time.Now(),
+	}
+	for _, pointer := range pointers {
+		if pointer == nil || pointer.Type != pb.Pointer_REMOTE || pointer.Remote == nil {
+			continue
+		}
+		for _, piece := range pointer.Remote.RemotePieces {
+			if len(sample.Pieces) >= sampler.config.PiecesPerObject {
+				break
+			}
+			if sampler.nodes != nil {
+				if _, ok := sampler.nodes[piece.NodeId]; !ok {
+					continue
+				}
+			}
+			sample.Pieces = append(sample.Pieces, DeletedPiece{
+				Node:    piece.NodeId,
+				PieceID: pointer.Remote.RootPieceId.Derive(piece.NodeId, piece.PieceNum),
+			})
+		}
+	}
+
+	sampler.samples = append(sampler.samples, sample)
+}
+
+// TakeBefore removes and returns the samples of the objects deleted at or
+// before the time.
+func (sampler *DeletionSampler) TakeBefore(before time.Time) []DeletedObjectSample {
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	var taken, kept []DeletedObjectSample
+	for _, sample := range sampler.samples {
+		if !sample.Deleted.After(before) {
+			taken = append(taken, sample)
+		} else {
+			kept = append(kept, sample)
+		}
+	}
+	sampler.samples = kept
+	return taken
+}
+
+// Count returns the number of samples waiting for being verified.
+func (sampler *DeletionSampler) Count() int {
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+
+	return len(sampler.samples)
+}
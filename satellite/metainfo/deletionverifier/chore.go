@@ -0,0 +1,211 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package deletionverifier verifies that a sample of the deleted objects are
+// truly gone, i.e. none of their segments are left in pointerDB and their
+// pieces have been deleted from the nodes.
+package deletionverifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+	"storj.io/common/sync2"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+var (
+	// Error is the error class for this package.
+	Error = errs.Class("deletion verifier error")
+
+	mon = monkit.Package()
+)
+
+// Config contains configurable values for the deletion verifier.
+type Config struct {
+	Enabled  bool          `help:"verify that the sampled deleted objects are gone" default:"false"`
+	Interval time.Duration `help:"how frequently the sampled deleted objects are verified" releaseDefault:"1h" devDefault:"1m"`
+	Delay    time.Duration `help:"delay between deleting an object and verifying it" releaseDefault:"1h" devDefault:"10s"`
+}
+
+// PieceChecker returns whether a node still stores a piece.
+//
+// The satellite can't ask a node whether it stores a piece without
+// downloading it, hence the checker is provided by the caller and the pieces
+// aren't verified without it. The satellite peers don't provide one, so the
+// chore is disabled by default and only verifies pointerDB when enabled.
+type PieceChecker interface {
+	PieceExists(ctx context.Context, node storj.NodeID, pieceID storj.PieceID) (bool, error)
+}
+
+// Report is the outcome of a verification.
+type Report struct {
+	// Objects is the number of verified objects.
+	Objects int
+	// FailedObjects is the number of sampled objects which couldn't be
+	// verified, e.g. because pointerDB couldn't be read. They are dropped.
+	FailedObjects int
+	// LingeringObjects are the objects which still have segments in
+	// pointerDB which were created before their deletion.
+	LingeringObjects []metabase.ObjectLocation
+	// LingeringPieces are the pieces which are still stored by their nodes.
+	LingeringPieces []metainfo.DeletedPiece
+}
+
+// VerifyOptions restricts a verification.
+type VerifyOptions struct {
+	// Nodes are the only nodes which are asked whether they still store the
+	// sampled pieces, e.g. a suspect subset of them. The pieces of the other
+	// nodes are skipped. No nodes asks every node.
+	Nodes []storj.NodeID
+}
+
+// Chore verifies the sampled deleted objects.
+//
+// architecture: Chore
+type Chore struct {
+	log    *zap.Logger
+	config Config
+	Loop   *sync2.Cycle
+
+	metainfo *metainfo.Service
+	sampler  *metainfo.DeletionSampler
+	pieces   PieceChecker
+}
+
+// NewChore creates a new deletion verifier. pieces may be nil, in which case
+// only pointerDB is verified.
+func NewChore(log *zap.Logger, config Config, meta *metainfo.Service, sampler *metainfo.DeletionSampler, pieces PieceChecker) *Chore {
+	return &Chore{
+		log:      log,
+		config:   config,
+		Loop:     sync2.NewCycle(config.Interval),
+		metainfo: meta,
+		sampler:  sampler,
+		pieces:   pieces,
+	}
+}
+
+// Run starts the deletion verifier loop.
+func (chore *Chore) Run(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if !chore.config.Enabled {
+		return nil
+	}
+
+	return chore.Loop.Run(ctx, func(ctx context.Context) error {
+		_, err := chore.Verify(ctx)
+		if err != nil {
+			chore.log.Error("failed to verify deleted objects", zap.Error(err))
+		}
+		return nil
+	})
+}
+
+// Verify verifies the objects which were sampled at least Config.Delay ago.
+// The lingering segments and pieces are logged and reported.
+//
+// A segment created after the deletion belongs to a new upload of the same
+// object, hence it isn't lingering. A node which can't be checked, e.g.
+// because it's offline, is skipped, and so is an object whose segments can't
+// be listed.
+func (chore *Chore) Verify(ctx context.Context) (report Report, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	return chore.VerifyWithOptions(ctx, VerifyOptions{})
+}
+
+// VerifyWithOptions verifies the objects like Verify, only asking the nodes
+// of options.Nodes for their pieces, if any.
+func (chore *Chore) VerifyWithOptions(ctx context.Context, options VerifyOptions) (report Report, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var nodes map[storj.NodeID]struct{}
+	if len(options.Nodes) > 0 {
+		nodes = make(map[storj.NodeID]struct{}, len(options.Nodes))
+		for _, node := range options.Nodes {
+			nodes[node] = struct{}{}
+		}
+	}
+
+	samples := chore.sampler.TakeBefore(time.Now().Add(-chore.config.Delay))
+
+	for _, sample := range samples {
+		segments, err := chore.metainfo.ListObjectSegments(ctx, sample.Location)
+		if err != nil {
+			// the other samples are still verified, since they have already
+			// been taken from the sampler.
+			mon.Meter("deleted_object_verify_failed").Mark(1)
+			chore.log.Warn("failed to verify deleted object",
+				zap.Stringer("project_id", sample.Location.ProjectID),
+				zap.String("bucket_name", sample.Location.BucketName),
+				zap.Binary("encrypted_path", []byte(sample.Location.ObjectKey)),
+				zap.Error(err),
+			)
+			report.FailedObjects++
+			continue
+		}
+		report.Objects++
+
+		lingering := 0
+		for _, segment := range segments {
+			if !segment.Pointer.CreationDate.After(sample.Deleted) {
+				lingering++
+			}
+		}
+		if lingering > 0 {
+			mon.Meter("deleted_object_lingering").Mark(1)
+			chore.log.Error("deleted object still has segments",
+				zap.Stringer("project_id", sample.Location.ProjectID),
+				zap.String("bucket_name", sample.Location.BucketName),
+				zap.Binary("encrypted_path", []byte(sample.Location.ObjectKey)),
+				zap.Int("segments", lingering),
+			)
+			report.LingeringObjects = append(report.LingeringObjects, sample.Location)
+		}
+
+		if chore.pieces == nil {
+			continue
+		}
+		for _, piece := range sample.Pieces {
+			if nodes != nil {
+				if _, ok := nodes[piece.Node]; !ok {
+					continue
+				}
+			}
+			exists, err := chore.pieces.PieceExists(ctx, piece.Node, piece.PieceID)
+			if err != nil {
+				chore.log.Debug("failed to check deleted piece",
+					zap.Stringer("node_id", piece.Node),
+					zap.Stringer("piece_id", piece.PieceID),
+					zap.Error(err),
+				)
+				continue
+			}
+			if exists {
+				mon.Meter("deleted_piece_lingering").Mark(1)
+				chore.log.Error("deleted piece still stored by node",
+					zap.Stringer("node_id", piece.Node),
+					zap.Stringer("piece_id", piece.PieceID),
+				)
+				report.LingeringPieces = append(report.LingeringPieces, piece)
+			}
+		}
+	}
+
+	mon.IntVal("deletion_verifier_objects").Observe(int64(report.Objects))
+	return report, nil
+}
+
+// Close closes the deletion verifier.
+func (chore *Chore) Close() error {
+	chore.Loop.Close()
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package deletionverifier_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/deletionverifier"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage/teststore"
+)
+
+// storedPieces is a piece checker of the pieces which the nodes still store.
+type storedPieces map[storj.PieceID]bool
+
+func (pieces storedPieces) PieceExists(ctx context.Context, node storj.NodeID, pieceID storj.PieceID) (bool, error) {
+	return pieces[pieceID], nil
+}
+
+func TestChore_Verify(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	sampler := metainfo.NewDeletionSampler(metainfo.DeletionSamplingConfig{
+		Rate:            1,
+		MaxObjects:      10,
+		PiecesPerObject: 2,
+	})
+
+	projectID := testrand.UUID()
+	location := func(key string) metabase.ObjectLocation {
+		return metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(key),
+		}
+	}
+
+	remote := &pb.Pointer{
+		Type: pb.Pointer_REMOTE,
+		Remote: &pb.RemoteSegment{
+			RootPieceId: testrand.PieceID(),
+			Redundancy: &pb.RedundancyScheme{
+				Type:             pb.RedundancyScheme_RS,
+				MinReq:           1,
+				RepairThreshold:  2,
+				SuccessThreshold: 3,
+				Total:            3,
+				ErasureShareSize: 256,
+			},
+			RemotePieces: []*pb.RemotePiece{
+				{PieceNum: 0, NodeId: testrand.NodeID()},
+				{PieceNum: 1, NodeId: testrand.NodeID()},
+				{PieceNum: 2, NodeId: testrand.NodeID()},
+			},
+		},
+	}
+	inline := func() *pb.Pointer {
+		return &pb.Pointer{
+			Type:          pb.Pointer_INLINE,
+			InlineSegment: testrand.Bytes(10),
+		}
+	}
+
+	// the pieces of the deleted object, one of them is left on its node.
+	sampler.Add(location("gone"), []*pb.Pointer{remote})
+	lingeringPiece := remote.Remote.RootPieceId.Derive(remote.Remote.RemotePieces[1].NodeId, 1)
+
+	// the garbage segment of an object which has been left in pointerDB.
+	garbage := inline()
+	err := service.UnsynchronizedPut(ctx, location("garbage").LastSegment().Encode(), garbage)
+	require.NoError(t, err)
+	sampler.Add(location("garbage"), []*pb.Pointer{garbage})
+
+	// the object has been uploaded again after its deletion.
+	sampler.Add(location("uploaded-again"), []*pb.Pointer{inline()})
+	time.Sleep(time.Millisecond)
+	err = service.UnsynchronizedPut(ctx, location("uploaded-again").LastSegment().Encode(), inline())
+	require.NoError(t, err)
+
+	require.Equal(t, 3, sampler.Count())
+
+	chore := deletionverifier.NewChore(zaptest.NewLogger(t), deletionverifier.Config{
+		Interval: time.Hour,
+		Delay:    time.Hour,
+	}, service, sampler, storedPieces{lingeringPiece: true})
+
+	// the objects have just been deleted, so they aren't verified yet.
+	report, err := chore.Verify(ctx)
+	require.NoError(t, err)
+	require.Zero(t, report.Objects)
+	require.Equal(t, 3, sampler.Count())
+
+	chore = deletionverifier.NewChore(zaptest.NewLogger(t), deletionverifier.Config{
+		Interval: time.Hour,
+	}, service, sampler, storedPieces{lingeringPiece: true})
+
+	report, err = chore.Verify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Objects)
+	require.Equal(t, []metabase.ObjectLocation{location("garbage")}, report.LingeringObjects)
+	require.Equal(t, []metainfo.DeletedPiece{{
+		Node:    remote.Remote.RemotePieces[1].NodeId,
+		PieceID: lingeringPiece,
+	}}, report.LingeringPieces)
+	require.Zero(t, sampler.Count())
+}
+
+// queriedNodes is a piece checker which records the nodes it was asked.
+type queriedNodes map[storj.NodeID]int
+
+func (nodes queriedNodes) PieceExists(ctx context.Context, node storj.NodeID, pieceID storj.PieceID) (bool, error) {
+	nodes[node]++
+	return false, nil
+}
+
+func TestChore_VerifyNodes(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	sampler := metainfo.NewDeletionSampler(metainfo.DeletionSamplingConfig{
+		Rate:            1,
+		MaxObjects:      10,
+		PiecesPerObject: 1,
+	})
+
+	nodes := []storj.NodeID{testrand.NodeID(), testrand.NodeID(), testrand.NodeID(), testrand.NodeID()}
+	pointer := func() *pb.Pointer {
+		remote := &pb.Pointer{
+			Type: pb.Pointer_REMOTE,
+			Remote: &pb.RemoteSegment{
+				RootPieceId: testrand.PieceID(),
+			},
+		}
+		for i, node := range nodes {
+			remote.Remote.RemotePieces = append(remote.Remote.RemotePieces, &pb.RemotePiece{
+				PieceNum: int32(i),
+				NodeId:   node,
+			})
+		}
+		return remote
+	}
+	location := func(key string) metabase.ObjectLocation {
+		return metabase.ObjectLocation{
+			ProjectID:  testrand.UUID(),
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(key),
+		}
+	}
+
+	// the piece on the suspect node is sampled instead of the first one.
+	suspect := nodes[2]
+	sampler.SetNodes([]storj.NodeID{suspect})
+	sampler.Add(location("suspect"), []*pb.Pointer{pointer()})
+
+	sampler.SetNodes(nil)
+	sampler.Add(location("first"), []*pb.Pointer{pointer()})
+
+	queried := queriedNodes{}
+	chore := deletionverifier.NewChore(zaptest.NewLogger(t), deletionverifier.Config{
+		Interval: time.Hour,
+	}, service, sampler, queried)
+
+	report, err := chore.VerifyWithOptions(ctx, deletionverifier.VerifyOptions{
+		Nodes: []storj.NodeID{suspect},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Objects)
+	require.Empty(t, report.LingeringPieces)
+
+	// only the suspect node was asked, the piece of the first node was skipped.
+	require.Equal(t, queriedNodes{suspect: 1}, queried)
+}
+
+func TestChore_VerifyFailure(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	store := teststore.New()
+	service := metainfo.NewService(zaptest.NewLogger(t), store, nil)
+	sampler := metainfo.NewDeletionSampler(metainfo.DeletionSamplingConfig{
+		Rate:            1,
+		MaxObjects:      10,
+		PiecesPerObject: 1,
+	})
+
+	for _, key := range []string{"first", "second"} {
+		sampler.Add(metabase.ObjectLocation{
+			ProjectID:  testrand.UUID(),
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(key),
+		}, nil)
+	}
+
+	chore := deletionverifier.NewChore(zaptest.NewLogger(t), deletionverifier.Config{
+		Interval: time.Hour,
+	}, service, sampler, nil)
+
+	// the first object can't be listed, the second one is still verified.
+	store.ForceError = 1
+	report, err := chore.Verify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Objects)
+	require.Equal(t, 1, report.FailedObjects)
+	require.Zero(t, sampler.Count())
+}
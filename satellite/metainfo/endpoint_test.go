@@ -5,21 +5,33 @@ package metainfo_test
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"sort"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/spacemonkeygo/monkit/v3"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"storj.io/common/errs2"
 	"storj.io/common/memory"
 	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
 	"storj.io/common/storj"
 	"storj.io/common/testcontext"
 	"storj.io/common/testrand"
 	"storj.io/common/uuid"
 	"storj.io/storj/private/testplanet"
+	"storj.io/storj/satellite"
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/overlay"
 	"storj.io/storj/storage"
+	"storj.io/storj/storage/teststore"
 	"storj.io/uplink/private/testuplink"
 )
 
@@ -466,6 +478,793 @@ func TestEndpoint_DeleteObjectPieces_ObjectWithoutLastSegment(t *testing.T) {
 	})
 }
 
+func TestEndpoint_MissingLastSegmentMetric(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			// Reconfigure RS for ensuring that we don't have long-tail cancellations
+			// and the upload doesn't leave garbage in the SNs
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			metricsChore = satelliteSys.Metrics.Chore
+		)
+		metricsChore.Loop.Pause()
+
+		const segmentSize = 10 * memory.KiB
+
+		projectID, _ := uploadFirstObjectWithoutLastSegmentPointer(
+			ctx, t, uplnk, satelliteSys, segmentSize, "a-bucket", "missing-last", testrand.Bytes(2*segmentSize),
+		)
+
+		// complete objects aren't counted, whatever their number of segments.
+		uploadCtx := testuplink.WithMaxSegmentSize(ctx, segmentSize)
+		err := uplnk.Upload(uploadCtx, satelliteSys, "a-bucket", "complete", testrand.Bytes(3*segmentSize))
+		require.NoError(t, err)
+		err = uplnk.Upload(uploadCtx, satelliteSys, "a-bucket", "inline", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		metricsChore.Loop.TriggerWait()
+		require.EqualValues(t, 1, metricsChore.Counter.MissingLastSegment)
+		require.Equal(t, map[uuid.UUID]int64{projectID: 1}, metricsChore.Counter.MissingLastSegmentByProject)
+		require.EqualValues(t, 2, metricsChore.Counter.Total)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_Strict(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			// Reconfigure RS for ensuring that we don't have long-tail cancellations
+			// and the upload doesn't leave garbage in the SNs
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		{ // sparse object, the segments after the missing ones are left over
+			projectID, encryptedPath := uploadFirstObjectWithoutSomeSegmentsPointers(
+				ctx, t, uplnk, satelliteSys, segmentSize, bucketName, "sparse-object",
+				testrand.Bytes(10*segmentSize), []int64{-1, 3, 5, 6, 9},
+			)
+
+			_, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+				ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+					Strict: true,
+				},
+			)
+			require.Error(t, err)
+			require.True(t, metainfo.ErrLeftoverSegments.Has(err), err)
+			require.Contains(t, err.Error(), "3 segments")
+
+			// the leftover segments are cleaned up anyway
+			keys, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, metabase.ObjectLocation{
+				ProjectID:  projectID,
+				BucketName: bucketName,
+				ObjectKey:  metabase.ObjectKey(encryptedPath),
+			})
+			require.NoError(t, err)
+			require.Empty(t, keys)
+		}
+
+		{ // complete object
+			err := uplnk.Upload(testuplink.WithMaxSegmentSize(ctx, segmentSize), satelliteSys, bucketName, "complete-object", testrand.Bytes(5*segmentSize))
+			require.NoError(t, err)
+
+			projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+			result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+				ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+					Strict: true,
+				},
+			)
+			require.NoError(t, err)
+			require.Len(t, result.Deleted, 1)
+			// the nodes past the success threshold may not have answered yet.
+			require.Zero(t, result.FailedPieces)
+
+			keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+			require.NoError(t, err)
+			require.Empty(t, keys)
+		}
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_ExpectedSize(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const bucketName = "a-bucket"
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		objectSize := func() int64 {
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				Recursive: true,
+				Fields:    metainfo.ListObjectsFieldSize,
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Items, 1)
+			return result.Items[0].Size
+		}
+
+		oldSize := objectSize()
+
+		// the object is overwritten with a different size.
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(20*memory.KiB))
+		require.NoError(t, err)
+
+		newSize := objectSize()
+		require.NotEqual(t, oldSize, newSize)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+				ExpectedSize: &oldSize,
+			},
+		)
+		require.Error(t, err)
+		require.True(t, metainfo.ErrSizeMismatch.Has(err), err)
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+
+		// the object hasn't been deleted.
+		require.Equal(t, newSize, objectSize())
+
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+				ExpectedSize: &newSize,
+			},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+
+		// a missing object can't have the expected size.
+		_, err = satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+				ExpectedSize: &newSize,
+			},
+		)
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_Latency(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		// the latencies are collected by the package, other tests may add
+		// samples at the same time.
+		samples := func(bucket string) float64 {
+			stats := monkit.Collect(monkit.ScopeNamed("storj.io/storj/satellite/metainfo"))
+			return stats["delete_object_latency,segments="+bucket+" count"]
+		}
+		before := samples("2-9")
+
+		err := uplnk.Upload(testuplink.WithMaxSegmentSize(ctx, segmentSize), satelliteSys, bucketName, "object", testrand.Bytes(3*segmentSize))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+
+		require.Greater(t, samples("2-9"), before)
+	})
+}
+
+func TestEndpoint_BeginDeleteObject_DeadlineShare(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				testplanet.ReconfigureRS(2, 2, 4, 4)(log, index, config)
+				config.Metainfo.DeleteDeadlineMetabaseShare = 0.5
+				// only the deadline of the delete stops waiting for the
+				// nodes.
+				config.Metainfo.PieceDeletion.DialTimeout = time.Minute
+				config.Metainfo.PieceDeletion.RequestTimeout = time.Minute
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const bucketName = "a-bucket"
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+		_, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		// two of the nodes are moved to an address which never answers,
+		// hence the success threshold can't be reached.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ctx.Check(listener.Close)
+		ctx.Go(func() error {
+			var conns []net.Conn
+			defer func() {
+				for _, conn := range conns {
+					_ = conn.Close()
+				}
+			}()
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return nil
+				}
+				conns = append(conns, conn)
+			}
+		})
+
+		for _, node := range planet.StorageNodes[:2] {
+			node.Contact.Chore.Pause(ctx)
+
+			dossier, err := satelliteSys.Overlay.Service.Get(ctx, node.ID())
+			require.NoError(t, err)
+			err = satelliteSys.Overlay.Service.UpdateCheckIn(ctx, overlay.NodeCheckInInfo{
+				NodeID: node.ID(),
+				Address: &pb.NodeAddress{
+					Address:   listener.Addr().String(),
+					Transport: pb.NodeTransport_TCP_TLS_GRPC,
+				},
+				LastNet:    dossier.LastNet,
+				LastIPPort: listener.Addr().String(),
+				IsUp:       true,
+				Operator:   &dossier.Operator,
+				Capacity:   &dossier.Capacity,
+				Version:    &dossier.Version,
+			}, time.Now())
+			require.NoError(t, err)
+		}
+
+		exceeded := func() float64 {
+			stats := monkit.Collect(monkit.ScopeNamed("storj.io/storj/satellite/metainfo"))
+			return stats["delete_deadline_exceeded_pieces total"]
+		}
+		before := exceeded()
+
+		deleteCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		// the object is deleted, and the delete returns at its deadline
+		// rather than waiting for the nodes which don't answer.
+		start := time.Now()
+		response, err := satelliteSys.Metainfo.Endpoint2.BeginDeleteObject(deleteCtx, &pb.ObjectBeginDeleteRequest{
+			Header: &pb.RequestHeader{
+				ApiKey: uplnk.APIKey[satelliteSys.ID()].SerializeRaw(),
+			},
+			Bucket:        []byte(bucketName),
+			EncryptedPath: encryptedPath,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, response.Object)
+		require.Less(t, int64(time.Since(start)), int64(10*time.Second))
+		require.Greater(t, exceeded(), before)
+
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_LatencySLO(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 2, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				testplanet.ReconfigureRS(2, 2, 4, 4)(log, index, config)
+				if index == 0 {
+					config.Metainfo.DeleteLatencySLO = time.Hour
+				} else {
+					config.Metainfo.DeleteLatencySLO = time.Nanosecond
+				}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+
+		const bucketName = "a-bucket"
+
+		// the violations are counted by the package, other tests may add
+		// some at the same time.
+		violations := func() float64 {
+			stats := monkit.Collect(monkit.ScopeNamed("storj.io/storj/satellite/metainfo"))
+			return stats["delete_object_slo_violations value"]
+		}
+
+		deleteObject := func(satelliteSys *testplanet.Satellite, key string) {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, key, testrand.Bytes(10*memory.KiB))
+			require.NoError(t, err)
+
+			projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+			result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+				ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+			)
+			require.NoError(t, err)
+			require.Len(t, result.Deleted, 1)
+		}
+
+		// the object is deleted within the SLO of the first satellite.
+		before := violations()
+		deleteObject(planet.Satellites[0], "fast")
+		require.Equal(t, before, violations())
+
+		// no deletion is fast enough for the SLO of the second satellite.
+		deleteObject(planet.Satellites[1], "slow")
+		require.Greater(t, violations(), before)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_SegmentTypes(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			// Reconfigure RS for ensuring that we don't have long-tail cancellations
+			// and the upload doesn't leave garbage in the SNs
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		// two remote segments and an inline last segment.
+		err := uplnk.Upload(testuplink.WithMaxSegmentSize(ctx, segmentSize), satelliteSys, bucketName, "mixed-object", testrand.Bytes(2*segmentSize+memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+		location := metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: bucketName,
+			ObjectKey:  metabase.ObjectKey(encryptedPath),
+		}
+
+		segments, err := satelliteSys.Metainfo.Service.ListObjectSegments(ctx, location)
+		require.NoError(t, err)
+		require.Len(t, segments, 3)
+		require.Equal(t, pb.Pointer_INLINE, segments[2].Pointer.Type)
+
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+				SegmentTypes: metainfo.SegmentTypeRemote,
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.DeletedSegments)
+		require.Empty(t, result.Deleted)
+		require.Zero(t, result.FailedPieces)
+
+		// the inline segment is left.
+		segments, err = satelliteSys.Metainfo.Service.ListObjectSegments(ctx, location)
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+		require.Equal(t, metabase.LastSegmentIndex, segments[0].Index)
+		require.Equal(t, pb.Pointer_INLINE, segments[0].Pointer.Type)
+
+		// the space of the remote segments is reclaimed.
+		for _, sn := range planet.StorageNodes {
+			piecesTotal, _, err := sn.Storage2.Store.SpaceUsedForPieces(ctx)
+			require.NoError(t, err)
+			require.Zero(t, piecesTotal, sn.ID())
+		}
+
+		result, err = satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+				SegmentTypes: metainfo.SegmentTypeInline,
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.DeletedSegments)
+
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_SucceededNodes(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			// Reconfigure RS for ensuring that we don't have long-tail cancellations
+			// and the upload doesn't leave garbage in the SNs
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const bucketName = "a-bucket"
+
+		for _, key := range []string{"all", "down"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, key, testrand.Bytes(10*memory.KiB))
+			require.NoError(t, err)
+		}
+
+		projectID := planet.Uplinks[0].Projects[0].ID
+		objects, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, objects.Items, 2)
+
+		var allNodes []storj.NodeID
+		for _, node := range planet.StorageNodes {
+			allNodes = append(allNodes, node.ID())
+		}
+
+		// the threshold may be reached before the last node confirms the
+		// deletion.
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), objects.Items[0].EncryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+		require.Subset(t, allNodes, result.NodeResults.SucceededNodes)
+		require.GreaterOrEqual(t, result.SucceededNodes, 3)
+
+		// the node which is down doesn't confirm the deletion, so all the
+		// others have to for reaching the threshold.
+		require.NoError(t, planet.StopPeer(planet.StorageNodes[0]))
+
+		result, err = satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), objects.Items[1].EncryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+		require.Equal(t, 3, result.SucceededNodes)
+		require.ElementsMatch(t, allNodes[1:], result.NodeResults.SucceededNodes)
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_NodeResults(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const bucketName = "a-bucket"
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		var onlineNodes []storj.NodeID
+		for _, node := range planet.StorageNodes[1:] {
+			onlineNodes = append(onlineNodes, node.ID())
+		}
+
+		require.NoError(t, planet.StopPeer(planet.StorageNodes[0]))
+
+		// the results are returned also when the threshold is reached.
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+
+		// the threshold may be reached before the node which is down fails.
+		nodes := result.NodeResults
+		require.Subset(t, onlineNodes, nodes.SucceededNodes)
+		require.GreaterOrEqual(t, len(nodes.SucceededNodes), 3)
+		require.Empty(t, nodes.FailedNodes)
+		if len(nodes.OfflineNodes) > 0 {
+			require.Equal(t, []storj.NodeID{planet.StorageNodes[0].ID()}, nodes.OfflineNodes)
+		}
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_CompletedWithWarnings(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const bucketName = "a-bucket"
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		for _, sn := range planet.StorageNodes {
+			require.NoError(t, planet.StopPeer(sn))
+		}
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+
+		// all the nodes are down, so none of the pieces has been deleted.
+		require.NotZero(t, result.FailedPieces)
+		require.Eventually(t, func() bool {
+			count, err := satelliteSys.API.Metainfo.PieceDeletion.Retries().Count(ctx)
+			return err == nil && count == int64(result.FailedPieces)
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Zero(t, result.UnrecoverablePieces)
+	})
+}
+
+func TestSegmentDeleter_ForceDeleteSegment(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		usedSpace := func() int64 {
+			var total int64
+			for _, sn := range planet.StorageNodes {
+				piecesTotal, _, err := sn.Storage2.Store.SpaceUsedForPieces(ctx)
+				require.NoError(t, err)
+				total += piecesTotal
+			}
+			return total
+		}
+
+		projectID, encryptedPath := uploadFirstObjectWithoutLastSegmentPointer(
+			ctx, t, uplnk, satelliteSys, segmentSize, bucketName, "zombie-object", testrand.Bytes(2*segmentSize),
+		)
+
+		location, err := metainfo.CreatePath(ctx, projectID, 0, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+
+		before := usedSpace()
+		require.NotZero(t, before)
+
+		deletedPieces, err := satelliteSys.Admin.Metainfo.SegmentDeleter.ForceDeleteSegment(ctx, location.Encode())
+		require.NoError(t, err)
+		require.NotZero(t, deletedPieces)
+
+		planet.WaitForStorageNodeDeleters(ctx)
+
+		_, err = satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.True(t, storj.ErrObjectNotFound.Has(err), err)
+
+		// the other segment of the zombie object keeps its pieces
+		after := usedSpace()
+		require.Less(t, after, before)
+		require.NotZero(t, after)
+
+		_, err = satelliteSys.Admin.Metainfo.SegmentDeleter.ForceDeleteSegment(ctx, location.Encode())
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+
+		_, err = satelliteSys.Admin.Metainfo.SegmentDeleter.ForceDeleteSegment(ctx, metabase.SegmentKey("invalid"))
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+	})
+}
+
+func TestEndpoint_GetObjectWithHealth(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 3, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		uploadCtx := testuplink.WithMaxSegmentSize(ctx, segmentSize)
+		err := uplnk.Upload(uploadCtx, satelliteSys, bucketName, "object", testrand.Bytes(segmentSize+5*memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		result, err := endpoint.GetObjectWithHealth(ctx, projectID, []byte(bucketName), encryptedPath, metainfo.GetObjectHealthOptions{})
+		require.NoError(t, err)
+		require.NotNil(t, result.Object)
+		require.Len(t, result.Segments, 2)
+		require.False(t, result.RepairNeeded())
+
+		// drop a piece of the first segment, which leaves it at the repair
+		// threshold.
+		location, err := metainfo.CreatePath(ctx, projectID, 0, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		pointer, err := satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+		require.Len(t, pointer.Remote.RemotePieces, 4)
+		pointer.Remote.RemotePieces = pointer.Remote.RemotePieces[1:]
+		err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), pointer)
+		require.NoError(t, err)
+
+		for _, checkNodes := range []bool{false, true} {
+			result, err = endpoint.GetObjectWithHealth(ctx, projectID, []byte(bucketName), encryptedPath, metainfo.GetObjectHealthOptions{
+				CheckNodes: checkNodes,
+			})
+			require.NoError(t, err)
+			require.True(t, result.RepairNeeded())
+
+			require.Equal(t, []metainfo.SegmentHealth{
+				{Index: 0, Pieces: 3, HealthyPieces: 3, MinimumPieces: 2, RepairThreshold: 3, RepairNeeded: true},
+				{Index: metabase.LastSegmentIndex, Pieces: 4, HealthyPieces: 4, MinimumPieces: 2, RepairThreshold: 3},
+			}, result.Segments)
+		}
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_RequireDownloadable(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 3, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+		)
+
+		const bucketName = "a-bucket"
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "unhealthy", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		// leave a single piece, while two are needed for downloading.
+		location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		pointer, err := satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+		pointer.Remote.RemotePieces = pointer.Remote.RemotePieces[:1]
+		err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), pointer)
+		require.NoError(t, err)
+
+		opts := metainfo.DeleteObjectPiecesOptions{RequireDownloadable: true}
+		_, err = endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, opts)
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+		require.True(t, metainfo.ErrObjectNotDownloadable.Has(err), err)
+
+		_, err = satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+
+		// the deletion isn't checked without the option.
+		_, err = endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{})
+		require.NoError(t, err)
+
+		// a downloadable object is deleted with the option.
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "healthy", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+		projectID, encryptedPath = getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		_, err = endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, opts)
+		require.NoError(t, err)
+	})
+}
+
+func TestEndpoint_OverwriteInlineWithRemote(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(10*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+		)
+
+		const (
+			bucketName = "a-bucket"
+			objectName = "object"
+		)
+
+		pointerTypes := func() map[string]pb.Pointer_DataType {
+			keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+			require.NoError(t, err)
+
+			types := map[string]pb.Pointer_DataType{}
+			for _, key := range keys {
+				segment, err := metabase.ParseSegmentKey(metabase.SegmentKey(key))
+				require.NoError(t, err)
+
+				pointer, err := satelliteSys.Metainfo.Service.Get(ctx, metabase.SegmentKey(key))
+				require.NoError(t, err)
+
+				index := "l"
+				if !segment.IsLast() {
+					index = fmt.Sprintf("s%d", segment.Index)
+				}
+				types[index] = pointer.Type
+			}
+			return types
+		}
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+		require.Equal(t, map[string]pb.Pointer_DataType{
+			"l": pb.Pointer_INLINE,
+		}, pointerTypes())
+
+		// the new object spans multiple remote segments, the inline segment
+		// of the previous object must not be left behind.
+		data := testrand.Bytes(25 * memory.KiB)
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, objectName, data)
+		require.NoError(t, err)
+		require.Equal(t, map[string]pb.Pointer_DataType{
+			"s0": pb.Pointer_REMOTE,
+			"s1": pb.Pointer_REMOTE,
+			"l":  pb.Pointer_REMOTE,
+		}, pointerTypes())
+
+		downloaded, err := uplnk.Download(ctx, satelliteSys, bucketName, objectName)
+		require.NoError(t, err)
+		require.Equal(t, data, downloaded)
+	})
+}
+
 func TestDeleteBucket(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		Reconfigure: testplanet.Reconfigure{
@@ -476,47 +1275,1334 @@ func TestDeleteBucket(t *testing.T) {
 		},
 		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
 	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
-		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
-		satelliteSys := planet.Satellites[0]
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		satelliteSys := planet.Satellites[0]
+		uplnk := planet.Uplinks[0]
+		projectID := uplnk.Projects[0].ID
+
+		expectedBucketName := "remote-segments-bucket"
+
+		err := uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "single-segment-object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+		err = uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "multi-segment-object", testrand.Bytes(50*memory.KiB))
+		require.NoError(t, err)
+		err = uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "remote-segment-inline-object", testrand.Bytes(33*memory.KiB))
+		require.NoError(t, err)
+
+		listed, err := satelliteSys.API.Metainfo.Endpoint2.ListObjectsTyped(ctx, projectID, []byte(expectedBucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, listed.Objects, 3)
+		require.False(t, listed.More)
+		for _, object := range listed.Objects {
+			require.False(t, object.IsPrefix)
+			require.NotEmpty(t, object.ObjectKey)
+		}
+
+		delResp, err := satelliteSys.API.Metainfo.Endpoint2.DeleteBucket(ctx, &pb.BucketDeleteRequest{
+			Header: &pb.RequestHeader{
+				ApiKey: apiKey.SerializeRaw(),
+			},
+			Name:      []byte(expectedBucketName),
+			DeleteAll: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), delResp.DeletedObjectsCount)
+
+		listed, err = satelliteSys.API.Metainfo.Endpoint2.ListObjectsTyped(ctx, projectID, []byte(expectedBucketName), metainfo.ListObjectsOptions{
+			Recursive:          true,
+			AllowMissingBucket: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, listed.Objects)
+
+		// confirm the bucket is deleted
+		buckets, err := satelliteSys.Metainfo.Endpoint2.ListBuckets(ctx, &pb.BucketListRequest{
+			Header: &pb.RequestHeader{
+				ApiKey: apiKey.SerializeRaw(),
+			},
+			Direction: int32(storj.Forward),
+		})
+		require.NoError(t, err)
+		require.Len(t, buckets.GetItems(), 0)
+	})
+}
+
+func TestDeleteBucket_ExpectEmpty(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+			projectID    = uplnk.Projects[0].ID
+		)
+
+		const bucketName = "a-bucket"
+		err := uplnk.CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		// an object is added between the emptiness check and the deletion.
+		satelliteSys.API.Metainfo.Service.OnTestingDeleteEmptyBucketHook = func() {
+			satelliteSys.API.Metainfo.Service.OnTestingDeleteEmptyBucketHook = nil
+			require.NoError(t, uplnk.Upload(ctx, satelliteSys, bucketName, "concurrent", testrand.Bytes(memory.KiB)))
+		}
+
+		_, err = endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{ExpectEmpty: true})
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+
+		// the bucket and its object are kept.
+		_, err = satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+		require.NoError(t, err)
+		data, err := uplnk.Download(ctx, satelliteSys, bucketName, "concurrent")
+		require.NoError(t, err)
+		require.Len(t, data, memory.KiB.Int())
+
+		// the objects aren't deleted with the bucket.
+		_, err = endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{ExpectEmpty: true})
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+
+		err = uplnk.DeleteObject(ctx, satelliteSys, bucketName, "concurrent")
+		require.NoError(t, err)
+
+		_, err = endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{ExpectEmpty: true})
+		require.NoError(t, err)
+
+		_, err = satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+		require.True(t, storj.ErrBucketNotFound.Has(err), err)
+
+		_, err = endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{ExpectEmpty: true})
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+	})
+}
+
+func TestEndpoint_DeleteBucketCorruptObjects(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const segmentSize = 10 * memory.KiB
+
+		// seedBucket uploads normal objects and an object without the last and
+		// the second segment, whose third segment can't be found from the
+		// first one.
+		seedBucket := func(bucketName string) metabase.ObjectLocation {
+			err := uplnk.Upload(testuplink.WithMaxSegmentSize(ctx, segmentSize), satelliteSys, bucketName, "corrupt", testrand.Bytes(4*segmentSize))
+			require.NoError(t, err)
+
+			listed, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				Recursive: true,
+			})
+			require.NoError(t, err)
+			require.Len(t, listed.Items, 1)
+
+			corrupt := metabase.ObjectLocation{
+				ProjectID:  projectID,
+				BucketName: bucketName,
+				ObjectKey:  metabase.ObjectKey(listed.Items[0].EncryptedPath),
+			}
+			for _, index := range []int64{metabase.LastSegmentIndex, 1} {
+				segment, err := corrupt.Segment(index)
+				require.NoError(t, err)
+				require.NoError(t, satelliteSys.Metainfo.Service.UnsynchronizedDelete(ctx, segment.Encode()))
+			}
+
+			for _, objectName := range []string{"a", "b"} {
+				err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+				require.NoError(t, err)
+			}
+			return corrupt
+		}
+
+		bucketExists := func(bucketName string) bool {
+			_, err := satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+			if storj.ErrBucketNotFound.Has(err) {
+				return false
+			}
+			require.NoError(t, err)
+			return true
+		}
+
+		{ // skip and log
+			corrupt := seedBucket("skip-bucket")
+
+			result, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte("skip-bucket"), metainfo.DeleteBucketOptions{
+				CorruptObjects: metainfo.CorruptObjectsSkip,
+			})
+			require.NoError(t, err)
+			require.Equal(t, metainfo.DeleteBucketResult{DeletedObjects: 2, CorruptObjects: 1}, result)
+			require.False(t, bucketExists("skip-bucket"))
+
+			// the segment after the missing one is left
+			keys, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, corrupt)
+			require.NoError(t, err)
+			require.Len(t, keys, 1)
+		}
+
+		{ // abort
+			corrupt := seedBucket("abort-bucket")
+
+			result, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte("abort-bucket"), metainfo.DeleteBucketOptions{
+				CorruptObjects: metainfo.CorruptObjectsAbort,
+			})
+			require.Error(t, err)
+			require.True(t, metainfo.ErrCorruptObject.Has(err), err)
+			require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition))
+			require.Equal(t, metainfo.DeleteBucketResult{DeletedObjects: 2, CorruptObjects: 1}, result)
+			require.True(t, bucketExists("abort-bucket"))
+
+			// the corrupt object isn't touched
+			keys, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, corrupt)
+			require.NoError(t, err)
+			require.Len(t, keys, 2)
+		}
+
+		{ // best-effort clean
+			corrupt := seedBucket("clean-bucket")
+
+			result, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte("clean-bucket"), metainfo.DeleteBucketOptions{
+				CorruptObjects: metainfo.CorruptObjectsClean,
+			})
+			require.NoError(t, err)
+			require.Equal(t, metainfo.DeleteBucketResult{DeletedObjects: 2, CorruptObjects: 1}, result)
+			require.False(t, bucketExists("clean-bucket"))
+
+			keys, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, corrupt)
+			require.NoError(t, err)
+			require.Empty(t, keys)
+		}
+	})
+}
+
+func TestEndpoint_DeleteBucketVerifyEmpty(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const (
+			bucketName  = "verified-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		// an object without the last and the second segment, whose third
+		// segment is left when the corrupt objects are skipped.
+		err := uplnk.Upload(testuplink.WithMaxSegmentSize(ctx, segmentSize), satelliteSys, bucketName, "corrupt", testrand.Bytes(4*segmentSize))
+		require.NoError(t, err)
+
+		listed, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, listed.Items, 1)
+
+		corrupt := metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: bucketName,
+			ObjectKey:  metabase.ObjectKey(listed.Items[0].EncryptedPath),
+		}
+		for _, index := range []int64{metabase.LastSegmentIndex, 1} {
+			segment, err := corrupt.Segment(index)
+			require.NoError(t, err)
+			require.NoError(t, satelliteSys.Metainfo.Service.UnsynchronizedDelete(ctx, segment.Encode()))
+		}
+
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		bucketExists := func() bool {
+			_, err := satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+			if storj.ErrBucketNotFound.Has(err) {
+				return false
+			}
+			require.NoError(t, err)
+			return true
+		}
+
+		// the residual segment fails the verification, the bucket is kept.
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			CorruptObjects: metainfo.CorruptObjectsSkip,
+			VerifyEmpty:    true,
+		})
+		require.Error(t, err)
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition))
+		require.True(t, metainfo.ErrBucketNotEmpty.Has(err), err)
+		require.Equal(t, metainfo.DeleteBucketResult{
+			DeletedObjects:   1,
+			CorruptObjects:   1,
+			ResidualSegments: 1,
+		}, result)
+		require.True(t, bucketExists())
+
+		keys, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, corrupt)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		// once the residual segment is deleted, the bucket is verified and
+		// deleted.
+		require.NoError(t, satelliteSys.Metainfo.Service.UnsynchronizedDelete(ctx, keys[0]))
+
+		result, err = satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			VerifyEmpty: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, metainfo.DeleteBucketResult{Verified: true}, result)
+		require.False(t, bucketExists())
+	})
+}
+
+func TestEndpoint_DeleteBucketKeepBucket(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "kept-bucket"
+		for _, objectName := range []string{"a", "b", "c/d"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+			require.NoError(t, err)
+		}
+
+		_, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			KeepBucket:  true,
+			ExpectEmpty: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			KeepBucket: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, result.DeletedObjects)
+
+		// the objects are gone, but the bucket is still listed.
+		listed, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, listed.Items)
+
+		apiKey := uplnk.APIKey[satelliteSys.ID()]
+		buckets, err := satelliteSys.Metainfo.Endpoint2.ListBuckets(ctx, &pb.BucketListRequest{
+			Header: &pb.RequestHeader{
+				ApiKey: apiKey.SerializeRaw(),
+			},
+			Direction: int32(storj.Forward),
+		})
+		require.NoError(t, err)
+		require.Len(t, buckets.GetItems(), 1)
+		require.Equal(t, []byte(bucketName), buckets.GetItems()[0].Name)
+
+		// the bucket can be used again.
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "a", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.DeleteBucketWithOptions(ctx, projectID, []byte("missing-bucket"), metainfo.DeleteBucketOptions{
+			KeepBucket: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.NotFound), err)
+	})
+}
+
+func TestEndpoint_DeleteBucketContinueOnError(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		endpoint := satelliteSys.Metainfo.Endpoint2
+
+		const (
+			bucketName  = "purged-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		uploadCtx := testuplink.WithMaxSegmentSize(ctx, segmentSize)
+		err := uplnk.Upload(uploadCtx, satelliteSys, bucketName, "corrupt", testrand.Bytes(segmentSize+memory.KiB))
+		require.NoError(t, err)
+
+		// the number of segments of the object can't be read anymore, which
+		// fails its deletion.
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+		location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		pointer, err := satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+		pointer.Metadata = []byte{0xff}
+		err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), pointer)
+		require.NoError(t, err)
+
+		for _, objectName := range []string{"a", "b"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+			require.NoError(t, err)
+		}
+
+		// the deletion stops at the failure by default.
+		_, err = endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{})
+		require.True(t, errs2.IsRPC(err, rpcstatus.Internal), err)
+
+		result, err := endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			ContinueOnError: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition), err)
+		require.Equal(t, 2, result.DeletedObjects)
+		require.Equal(t, 1, result.FailedObjects)
+		require.Len(t, result.Failures, 1)
+		require.Equal(t, encryptedPath, result.Failures[0].EncryptedPath)
+		require.Error(t, result.Failures[0].Err)
+
+		// only the object which failed is left, with the bucket.
+		segments, err := satelliteSys.Metainfo.Service.ObjectSegments(ctx, location.Object())
+		require.NoError(t, err)
+		require.Len(t, segments, 2)
+
+		_, err = satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+		require.NoError(t, err)
+	})
+}
+
+func TestEndpoint_DeleteBucketLimit(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		endpoint := satelliteSys.Metainfo.Endpoint2
+
+		const (
+			bucketName  = "huge-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		// the objects without a last segment are deleted last.
+		projectID, _ := uploadFirstObjectWithoutLastSegmentPointer(
+			ctx, t, uplnk, satelliteSys, segmentSize, bucketName, "zombie", testrand.Bytes(2*segmentSize),
+		)
+		for _, objectName := range []string{"a", "b", "c", "d", "e"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+			require.NoError(t, err)
+		}
+
+		_, err := endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), metainfo.DeleteBucketOptions{
+			Limit: -1,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+
+		var calls, deleted int
+		opts := metainfo.DeleteBucketOptions{Limit: 2}
+		for {
+			calls++
+			result, err := endpoint.DeleteBucketWithOptions(ctx, projectID, []byte(bucketName), opts)
+			require.NoError(t, err)
+			require.LessOrEqual(t, result.DeletedObjects, 2)
+			deleted += result.DeletedObjects
+			if !result.More {
+				break
+			}
+
+			// the bucket is kept until all the objects are deleted.
+			_, err = satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+			require.NoError(t, err)
+			opts.Cursor = result.Cursor
+		}
+		require.Equal(t, 3, calls)
+		require.Equal(t, 5, deleted)
+
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		_, err = satelliteSys.Metainfo.Service.GetBucket(ctx, []byte(bucketName), projectID)
+		require.True(t, storj.ErrBucketNotFound.Has(err), err)
+	})
+}
+
+func TestEndpoint_ListObjectsModifiedAfter(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "sync-bucket"
+		for _, objectName := range []string{"a", "b", "c"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+			require.NoError(t, err)
+		}
+
+		before, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, before.Items, 3)
+
+		cutoff := time.Now()
+
+		// modify one of the objects
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "b", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		modified, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:     true,
+			ModifiedAfter: cutoff,
+		})
+		require.NoError(t, err)
+		require.Len(t, modified.Items, 1)
+		require.False(t, modified.More)
+		require.True(t, modified.Items[0].CreatedAt.After(cutoff))
+
+		unchanged := 0
+		for _, item := range before.Items {
+			if string(item.EncryptedPath) != string(modified.Items[0].EncryptedPath) {
+				unchanged++
+			}
+		}
+		require.Equal(t, 2, unchanged)
+
+		// a limit smaller than the number of skipped objects still finds the modified one
+		modified, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:     true,
+			Limit:         1,
+			ModifiedAfter: cutoff,
+		})
+		require.NoError(t, err)
+		require.Len(t, modified.Items, 1)
+	})
+}
+
+func TestEndpoint_ListObjectsScanLimit(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Metainfo.ListMaxScannedKeys = 2
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "scan-bucket"
+		for _, objectName := range []string{"a", "b"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(memory.KiB))
+			require.NoError(t, err)
+		}
+		cutoff := time.Now()
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "c", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		// the page stops after scanning the unchanged objects.
+		page, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:     true,
+			Limit:         1,
+			ModifiedAfter: cutoff,
+		})
+		require.NoError(t, err)
+		require.Empty(t, page.Items)
+		require.True(t, page.More)
+		require.Equal(t, metainfo.ListTruncatedScanLimit, page.Truncation)
+
+		page, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:       true,
+			Limit:           1,
+			ModifiedAfter:   cutoff,
+			EncryptedCursor: page.Cursor,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		require.False(t, page.More)
+	})
+
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Metainfo.ListMaxScannedKeys = 0
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, "scan-bucket")
+		require.NoError(t, err)
+
+		// the listings without an index are disabled.
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte("scan-bucket"), metainfo.ListObjectsOptions{
+			Recursive:  true,
+			Descending: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.FailedPrecondition))
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte("scan-bucket"), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestEndpoint_ListObjectsTruncation(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const (
+			bucketName  = "truncated-bucket"
+			objectCount = 1002
+		)
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		for i := 0; i < objectCount; i++ {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(fmt.Sprintf("object-%04d", i)))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		list := func(opts metainfo.ListObjectsOptions) metainfo.ListObjectsResult {
+			opts.Recursive = true
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), opts)
+			require.NoError(t, err)
+			return result
+		}
+
+		{ // requested limit
+			result := list(metainfo.ListObjectsOptions{Limit: 10})
+			require.Len(t, result.Items, 10)
+			require.True(t, result.More)
+			require.Equal(t, metainfo.ListTruncatedLimit, result.Truncation)
+			require.Equal(t, result.Items[9].EncryptedPath, result.Cursor)
+		}
+
+		{ // server cap without a limit or with a higher one
+			for _, limit := range []int32{0, objectCount} {
+				result := list(metainfo.ListObjectsOptions{Limit: limit})
+				require.Len(t, result.Items, 1000)
+				require.True(t, result.More)
+				require.Equal(t, metainfo.ListTruncatedServerCap, result.Truncation)
+			}
+		}
+
+		{ // time budget spent while skipping the filtered out items
+			result := list(metainfo.ListObjectsOptions{
+				Limit:         10,
+				ModifiedAfter: time.Now(),
+				TimeBudget:    time.Nanosecond,
+			})
+			require.Empty(t, result.Items)
+			require.True(t, result.More)
+			require.Equal(t, metainfo.ListTruncatedTimeBudget, result.Truncation)
+			require.Equal(t, []byte("object-0009"), result.Cursor)
+		}
+
+		{ // not truncated
+			result := list(metainfo.ListObjectsOptions{
+				EncryptedCursor: []byte("object-0999"),
+			})
+			require.Len(t, result.Items, 2)
+			require.False(t, result.More)
+			require.Equal(t, metainfo.ListNotTruncated, result.Truncation)
+			require.Empty(t, result.Cursor)
+		}
+	})
+}
+
+func TestEndpoint_ListObjectsMissingBucket(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, "empty-bucket")
+		require.NoError(t, err)
+
+		result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte("empty-bucket"), metainfo.ListObjectsOptions{})
+		require.NoError(t, err)
+		require.Empty(t, result.Items)
+		require.False(t, result.More)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte("missing-bucket"), metainfo.ListObjectsOptions{})
+		require.Error(t, err)
+		require.True(t, storj.ErrBucketNotFound.Has(err), err)
+
+		result, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte("missing-bucket"), metainfo.ListObjectsOptions{
+			AllowMissingBucket: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Items)
+	})
+}
+
+func TestEndpoint_ListObjectsDescending(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "descending-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		for _, key := range []string{"a", "b/1", "b/2", "c", "d/x/1", "d/y", "e"} {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		// listAll pages through the whole listing and checks that the pages
+		// don't overlap.
+		listAll := func(opts metainfo.ListObjectsOptions) (keys []string) {
+			seen := map[string]bool{}
+			for {
+				result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), opts)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(result.Items), int(opts.Limit))
+
+				for _, item := range result.Items {
+					key := string(item.EncryptedPath)
+					require.False(t, seen[key], "listed twice: %q", key)
+					seen[key] = true
+					keys = append(keys, key)
+				}
+				if !result.More {
+					return keys
+				}
+				require.Equal(t, metainfo.ListTruncatedLimit, result.Truncation)
+				opts.EncryptedCursor = result.Cursor
+			}
+		}
+
+		reversed := func(keys []string) []string {
+			reversed := make([]string, len(keys))
+			for i, key := range keys {
+				reversed[len(keys)-1-i] = key
+			}
+			return reversed
+		}
+
+		for _, tt := range []struct {
+			name     string
+			opts     metainfo.ListObjectsOptions
+			expected []string
+		}{
+			{
+				name:     "recursive",
+				opts:     metainfo.ListObjectsOptions{Recursive: true},
+				expected: []string{"a", "b/1", "b/2", "c", "d/x/1", "d/y", "e"},
+			},
+			{
+				name:     "non-recursive",
+				opts:     metainfo.ListObjectsOptions{},
+				expected: []string{"a", "b/", "c", "d/", "e"},
+			},
+			{
+				name:     "delimiter",
+				opts:     metainfo.ListObjectsOptions{EncryptedDelimiter: []byte("/")},
+				expected: []string{"a", "b/", "c", "d/", "e"},
+			},
+			{
+				name:     "prefix",
+				opts:     metainfo.ListObjectsOptions{EncryptedPrefix: []byte("d/"), EncryptedDelimiter: []byte("/")},
+				expected: []string{"x/", "y"},
+			},
+		} {
+			for _, limit := range []int32{1, 2, 10} {
+				opts := tt.opts
+				opts.Limit = limit
+
+				ascending := listAll(opts)
+				require.Equal(t, tt.expected, ascending, "%s, limit %d", tt.name, limit)
+
+				opts.Descending = true
+				descending := listAll(opts)
+				require.Equal(t, reversed(tt.expected), descending, "%s, limit %d", tt.name, limit)
+			}
+		}
+
+		{ // continue before a cursor
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				EncryptedCursor:    []byte("d/"),
+				EncryptedDelimiter: []byte("/"),
+				Limit:              2,
+				Descending:         true,
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Items, 2)
+			require.Equal(t, []byte("c"), result.Items[0].EncryptedPath)
+			require.Equal(t, []byte("b/"), result.Items[1].EncryptedPath)
+			require.True(t, result.Items[1].IsPrefix)
+			require.True(t, result.More)
+			require.Equal(t, []byte("b/"), result.Cursor)
+		}
+	})
+}
+
+func TestEndpoint_ListObjectsCursorInclusive(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		endpoint := satelliteSys.Metainfo.Endpoint2
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "cursor-bucket"
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		for _, key := range []string{"a", "b", "c/1", "c/2", "d"} {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.Put(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+			})
+			require.NoError(t, err)
+		}
+
+		list := func(opts metainfo.ListObjectsOptions) []string {
+			opts.Fields = metainfo.ListObjectsFieldKey
+			result, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), opts)
+			require.NoError(t, err)
+
+			var keys []string
+			for _, item := range result.Items {
+				keys = append(keys, string(item.EncryptedPath))
+			}
+			return keys
+		}
+
+		for _, tt := range []struct {
+			name      string
+			opts      metainfo.ListObjectsOptions
+			exclusive []string
+			inclusive []string
+		}{
+			{
+				name:      "recursive",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("b"), Recursive: true},
+				exclusive: []string{"c/1", "c/2", "d"},
+				inclusive: []string{"b", "c/1", "c/2", "d"},
+			},
+			{
+				name:      "missing cursor",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("bb"), Recursive: true},
+				exclusive: []string{"c/1", "c/2", "d"},
+				inclusive: []string{"c/1", "c/2", "d"},
+			},
+			{
+				name:      "limit",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("b"), Recursive: true, Limit: 2},
+				exclusive: []string{"c/1", "c/2"},
+				inclusive: []string{"b", "c/1"},
+			},
+			{
+				name:      "prefix",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("c/")},
+				exclusive: []string{"d"},
+				inclusive: []string{"c/", "d"},
+			},
+			{
+				name:      "delimiter",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("c/"), EncryptedDelimiter: []byte("/")},
+				exclusive: []string{"d"},
+				inclusive: []string{"c/", "d"},
+			},
+			{
+				name:      "descending",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("b"), Recursive: true, Descending: true},
+				exclusive: []string{"a"},
+				inclusive: []string{"b", "a"},
+			},
+			{
+				name:      "descending delimiter",
+				opts:      metainfo.ListObjectsOptions{EncryptedCursor: []byte("c/"), EncryptedDelimiter: []byte("/"), Descending: true},
+				exclusive: []string{"b", "a"},
+				inclusive: []string{"c/", "b", "a"},
+			},
+		} {
+			opts := tt.opts
+			require.Equal(t, tt.exclusive, list(opts), tt.name)
+
+			opts.CursorInclusive = true
+			require.Equal(t, tt.inclusive, list(opts), tt.name)
+		}
+	})
+}
+
+func TestEndpoint_QueryTimeout(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				// every query is slower than the timeouts.
+				config.Metainfo.ListQueryTimeout = time.Nanosecond
+				config.Metainfo.DeleteQueryTimeout = time.Nanosecond
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "slow-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte("object"))
+		require.NoError(t, err)
+
+		err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+			Type:          pb.Pointer_INLINE,
+			InlineSegment: testrand.Bytes(memory.B),
+			CreationDate:  time.Now(),
+		})
+		require.NoError(t, err)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.Error(t, err)
+		require.True(t, metainfo.ErrQueryTimeout.Has(err), err)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.DeleteObjectPieces(ctx, projectID, []byte(bucketName), []byte("object"))
+		require.Error(t, err)
+		require.True(t, metainfo.ErrQueryTimeout.Has(err), err)
+		require.True(t, errs2.IsRPC(err, rpcstatus.DeadlineExceeded), err)
+
+		// the object is still there.
+		_, err = satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+		require.NoError(t, err)
+	})
+}
+
+func TestEndpoint_ObjectRevisionTag(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "revision-bucket"
+
+		data := testrand.Bytes(10 * memory.KiB)
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", data)
+		require.NoError(t, err)
+
+		revisionTag := func() string {
+			list, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				Recursive: true,
+				Fields:    metainfo.ListObjectsFieldKey | metainfo.ListObjectsFieldRevisionTag,
+			})
+			require.NoError(t, err)
+			require.Len(t, list.Items, 1)
+
+			return list.Items[0].RevisionTag
+		}
+
+		listed := revisionTag()
+		require.NotEmpty(t, listed)
+
+		// the tag is stable across reads.
+		require.Equal(t, listed, revisionTag())
+
+		// the tag changes when the object is uploaded again, even with the
+		// same content.
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "object", data)
+		require.NoError(t, err)
+
+		reuploaded := revisionTag()
+		require.NotEqual(t, listed, reuploaded)
+	})
+}
+
+func TestEndpoint_ListObjectsEncryptedDelimiter(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "delimiter-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		// the keys are stored as they are, like with the null path cipher.
+		for _, key := range []string{"a-1", "a-2", "a-3", "b-1", "b-2-x", "c", "d"} {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		type listed struct {
+			path     string
+			isPrefix bool
+		}
+		expected := []listed{
+			{"a-", true},
+			{"b-", true},
+			{"c", false},
+			{"d", false},
+		}
+
+		list := func(limit int32) []listed {
+			var items []listed
+			var cursor []byte
+			for {
+				result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+					EncryptedDelimiter: []byte("-"),
+					EncryptedCursor:    cursor,
+					Limit:              limit,
+				})
+				require.NoError(t, err)
+
+				for _, item := range result.Items {
+					items = append(items, listed{string(item.EncryptedPath), item.IsPrefix})
+				}
+				if !result.More {
+					return items
+				}
+				cursor = result.Items[len(result.Items)-1].EncryptedPath
+			}
+		}
+
+		require.Equal(t, expected, list(0))
+		// paging doesn't list the prefixes twice
+		require.Equal(t, expected, list(1))
+		require.Equal(t, expected, list(3))
+
+		// the prefixes are returned apart from the objects.
+		result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			EncryptedDelimiter: []byte("-"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("a-"), []byte("b-")}, result.CommonPrefixes())
+
+		var objects []string
+		for _, item := range result.Objects() {
+			objects = append(objects, string(item.EncryptedPath))
+		}
+		require.Equal(t, []string{"c", "d"}, objects)
+	})
+}
+
+func TestEndpoint_ListObjectsCursorStable(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "cursor-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		put := func(key string) {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+		for _, key := range []string{"b", "d", "f/g", "h"} {
+			put(key)
+		}
+
+		var listed []string
+		var cursor []byte
+		for page := 0; ; page++ {
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				EncryptedCursor: cursor,
+				Recursive:       true,
+				Limit:           2,
+			})
+			require.NoError(t, err)
+
+			for _, item := range result.Items {
+				listed = append(listed, string(item.EncryptedPath))
+			}
+			if !result.More {
+				break
+			}
+			cursor = result.Items[len(result.Items)-1].EncryptedPath
+
+			// the uploads before the cursor aren't listed, the ones after it
+			// are, without listing or skipping the other objects.
+			if page == 0 {
+				put("a")
+				put("e")
+			}
+		}
+
+		require.Equal(t, []string{"b", "d", "e", "f/g", "h"}, listed)
+	})
+}
+
+func TestEndpoint_TracingSpans(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "traced-bucket"
+		for _, objectName := range []string{"a", "b", "c"} {
+			err := uplnk.Upload(ctx, satelliteSys, bucketName, objectName, testrand.Bytes(10*memory.KiB))
+			require.NoError(t, err)
+		}
+
+		recorder := newSpanRecorder()
+		cancel := monkit.Default.ObserveTraces(func(trace *monkit.Trace) {
+			trace.ObserveSpans(recorder)
+		})
+		defer cancel()
+
+		const (
+			endpointFunc        = "storj.io/storj/satellite/metainfo.(*Endpoint)."
+			metabaseListFunc    = "storj.io/storj/satellite/metainfo.(*Service).List"
+			deletePointersFunc  = "storj.io/storj/satellite/metainfo/objectdeletion.(*Service).Delete"
+			deletePiecesFunc    = "storj.io/storj/satellite/metainfo/piecedeletion.(*Service).Delete"
+			deleteObjectsPieces = endpointFunc + "deleteObjectsPieces"
+		)
+
+		{ // list objects
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				Recursive: true,
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Items, 3)
+
+			spans := recorder.trace(endpointFunc + "ListObjectsWithOptions")
+			require.Equal(t, endpointFunc+"ListObjectsWithOptions", spans.parent(metabaseListFunc))
+			require.Equal(t, "3", spans.annotation(endpointFunc+"ListObjectsWithOptions", "items"))
+		}
+
+		{ // delete a single object
+			_, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+			report, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPieces(ctx, projectID, []byte(bucketName), encryptedPath)
+			require.NoError(t, err)
+			require.Len(t, report.Deleted, 1)
+
+			spans := recorder.trace(endpointFunc + "DeleteObjectPieces")
+			require.Equal(t, endpointFunc+"DeleteObjectPieces", spans.parent(deleteObjectsPieces))
+			require.Equal(t, deleteObjectsPieces, spans.parent(deletePointersFunc))
+			require.Equal(t, deleteObjectsPieces, spans.parent(deletePiecesFunc))
+			require.Equal(t, "1", spans.annotation(deleteObjectsPieces, "objects"))
+			require.Equal(t, "1", spans.annotation(deleteObjectsPieces, "segments"))
+		}
+
+		{ // delete the bucket with the remaining objects
+			resp, err := satelliteSys.Metainfo.Endpoint2.DeleteBucket(ctx, &pb.BucketDeleteRequest{
+				Header: &pb.RequestHeader{
+					ApiKey: apiKey.SerializeRaw(),
+				},
+				Name:      []byte(bucketName),
+				DeleteAll: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int64(2), resp.DeletedObjectsCount)
+
+			spans := recorder.trace(endpointFunc + "DeleteBucket")
+			require.Equal(t, endpointFunc+"DeleteBucket", spans.parent(endpointFunc+"deleteBucketNotEmpty"))
+			require.Equal(t, endpointFunc+"deleteBucketNotEmpty", spans.parent(endpointFunc+"deleteByPrefix"))
+			require.Equal(t, endpointFunc+"deleteByPrefix", spans.parent(metabaseListFunc))
+			require.Equal(t, endpointFunc+"deleteByPrefix", spans.parent(deleteObjectsPieces))
+			require.Equal(t, deleteObjectsPieces, spans.parent(deletePiecesFunc))
+			require.Equal(t, "2", spans.annotation(endpointFunc+"deleteBucketNotEmpty", "deleted_objects"))
+		}
+	})
+}
+
+// spanRecorder collects the finished spans of the observed traces.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*monkit.Span
+}
+
+func newSpanRecorder() *spanRecorder { return &spanRecorder{} }
+
+// Start implements monkit.SpanObserver.
+func (recorder *spanRecorder) Start(s *monkit.Span) {}
+
+// Finish implements monkit.SpanObserver.
+func (recorder *spanRecorder) Finish(s *monkit.Span, err error, panicked bool, finish time.Time) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.spans = append(recorder.spans, s)
+}
+
+// trace returns the spans of the last trace started by the root function.
+func (recorder *spanRecorder) trace(root string) recordedSpans {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	var rootSpan *monkit.Span
+	for _, s := range recorder.spans {
+		if s.Parent() == nil && s.Func().FullName() == root {
+			rootSpan = s
+		}
+	}
+	if rootSpan == nil {
+		return nil
+	}
+
+	var spans recordedSpans
+	for _, s := range recorder.spans {
+		if s.Trace() == rootSpan.Trace() {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+type recordedSpans []*monkit.Span
+
+// parent returns the function name of the parent of the first span of fn.
+func (spans recordedSpans) parent(fn string) string {
+	for _, s := range spans {
+		if s.Func().FullName() == fn && s.Parent() != nil {
+			return s.Parent().Func().FullName()
+		}
+	}
+	return ""
+}
+
+// annotation returns the value of the annotation of the first span of fn.
+func (spans recordedSpans) annotation(fn, name string) string {
+	for _, s := range spans {
+		if s.Func().FullName() != fn {
+			continue
+		}
+		for _, annotation := range s.Annotations() {
+			if annotation.Name == name {
+				return annotation.Value
+			}
+		}
+	}
+	return ""
+}
+
+func TestEndpoint_ListObjectsFields(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(13*memory.KiB),
+			),
+		},
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
 		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
 
-		expectedBucketName := "remote-segments-bucket"
-
-		err := uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "single-segment-object", testrand.Bytes(10*memory.KiB))
-		require.NoError(t, err)
-		err = uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "multi-segment-object", testrand.Bytes(50*memory.KiB))
+		const bucketName = "fields-bucket"
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "inline-object", testrand.Bytes(memory.KiB))
 		require.NoError(t, err)
-		err = uplnk.Upload(ctx, planet.Satellites[0], expectedBucketName, "remote-segment-inline-object", testrand.Bytes(33*memory.KiB))
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "multi-segment-object", testrand.Bytes(50*memory.KiB))
 		require.NoError(t, err)
 
-		listResp, err := satelliteSys.API.Metainfo.Endpoint2.ListObjects(ctx, &pb.ObjectListRequest{
-			Header: &pb.RequestHeader{
-				ApiKey: apiKey.SerializeRaw(),
-			},
-			Bucket: []byte(expectedBucketName),
-		})
+		// sum up the segment sizes of the objects from pointerDB
+		expectedSizes := map[string]int64{}
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
 		require.NoError(t, err)
-		require.Len(t, listResp.GetItems(), 3)
+		for _, key := range keys {
+			location, err := metabase.ParseSegmentKey(metabase.SegmentKey(key))
+			require.NoError(t, err)
+			pointer, err := satelliteSys.Metainfo.Service.Get(ctx, location.Encode())
+			require.NoError(t, err)
+			expectedSizes[string(location.ObjectKey)] += pointer.SegmentSize
+		}
+		require.Len(t, expectedSizes, 2)
 
-		delResp, err := satelliteSys.API.Metainfo.Endpoint2.DeleteBucket(ctx, &pb.BucketDeleteRequest{
-			Header: &pb.RequestHeader{
-				ApiKey: apiKey.SerializeRaw(),
-			},
-			Name:      []byte(expectedBucketName),
-			DeleteAll: true,
-		})
-		require.NoError(t, err)
-		require.Equal(t, int64(3), delResp.DeletedObjectsCount)
+		list := func(fields metainfo.ListObjectsFields) []metainfo.ListObjectsItem {
+			result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+				Recursive: true,
+				Fields:    fields,
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Items, 2)
+			return result.Items
+		}
 
-		// confirm the bucket is deleted
-		buckets, err := satelliteSys.Metainfo.Endpoint2.ListBuckets(ctx, &pb.BucketListRequest{
-			Header: &pb.RequestHeader{
-				ApiKey: apiKey.SerializeRaw(),
-			},
-			Direction: int32(storj.Forward),
-		})
-		require.NoError(t, err)
-		require.Len(t, buckets.GetItems(), 0)
+		for _, item := range list(metainfo.ListObjectsFieldKey) {
+			require.NotEmpty(t, item.EncryptedPath)
+			require.True(t, item.CreatedAt.IsZero())
+			require.Nil(t, item.EncryptedMetadata)
+			require.Zero(t, item.Size)
+		}
+
+		for _, item := range list(metainfo.ListObjectsFieldKey | metainfo.ListObjectsFieldCreated) {
+			require.NotEmpty(t, item.EncryptedPath)
+			require.False(t, item.CreatedAt.IsZero())
+			require.Nil(t, item.EncryptedMetadata)
+			require.Zero(t, item.Size)
+		}
+
+		for _, item := range list(metainfo.ListObjectsFieldKey | metainfo.ListObjectsFieldSize) {
+			require.NotEmpty(t, item.EncryptedPath)
+			require.True(t, item.CreatedAt.IsZero())
+			require.Nil(t, item.EncryptedMetadata)
+			require.Equal(t, expectedSizes[string(item.EncryptedPath)], item.Size)
+		}
+
+		// the default is everything except the size
+		for _, item := range list(0) {
+			require.NotEmpty(t, item.EncryptedPath)
+			require.False(t, item.CreatedAt.IsZero())
+			require.NotNil(t, item.EncryptedMetadata)
+			require.Zero(t, item.Size)
+		}
 	})
 }
 
@@ -579,3 +2665,552 @@ func uploadFirstObjectWithoutSomeSegmentsPointers(
 
 	return projectID, encryptedPath
 }
+
+func TestEndpoint_ListObjectsReplica(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+			projectID    = uplnk.Projects[0].ID
+		)
+
+		const bucketName = "replica-bucket"
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "replicated", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		replicated, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, replicated.Items, 1)
+
+		// the replica lags behind: it only has the objects uploaded so far.
+		replica := teststore.New()
+		defer ctx.Check(replica.Close)
+
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		for _, key := range keys {
+			value, err := satelliteSys.Metainfo.Database.Get(ctx, key)
+			require.NoError(t, err)
+			require.NoError(t, replica.Put(ctx, key, value))
+		}
+
+		err = uplnk.Upload(ctx, satelliteSys, bucketName, "lagging", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		listed, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:  true,
+			AllowStale: true,
+		})
+		require.NoError(t, err)
+		require.False(t, listed.Stale, "no replica is configured")
+		require.Len(t, listed.Items, 2)
+
+		satelliteSys.Metainfo.Service.SetListReplica(replica)
+
+		listed, err = endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:  true,
+			AllowStale: true,
+		})
+		require.NoError(t, err)
+		require.True(t, listed.Stale)
+		require.Len(t, listed.Items, 1)
+		require.Equal(t, replicated.Items[0].EncryptedPath, listed.Items[0].EncryptedPath)
+
+		descending, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:  true,
+			AllowStale: true,
+			Descending: true,
+		})
+		require.NoError(t, err)
+		require.True(t, descending.Stale)
+		require.Len(t, descending.Items, 1)
+
+		listed, err = endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.False(t, listed.Stale)
+		require.Len(t, listed.Items, 2)
+
+		// the listings of the clients don't opt in.
+		response, err := endpoint.ListObjects(ctx, &pb.ObjectListRequest{
+			Header: &pb.RequestHeader{
+				ApiKey: uplnk.APIKey[satelliteSys.ID()].SerializeRaw(),
+			},
+			Bucket:    []byte(bucketName),
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Items, 2)
+
+		for _, object := range []string{"replicated", "lagging"} {
+			require.NoError(t, uplnk.DeleteObject(ctx, satelliteSys, bucketName, object))
+		}
+
+		keys, err = satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		// the replica hasn't caught up with the deletes yet.
+		listed, err = endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:  true,
+			AllowStale: true,
+		})
+		require.NoError(t, err)
+		require.True(t, listed.Stale)
+		require.Len(t, listed.Items, 1)
+	})
+}
+
+func TestEndpoint_ListObjectsCompression(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		endpoint := satelliteSys.Metainfo.Endpoint2
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const (
+			bucketName  = "compressed-bucket"
+			objectCount = 1000
+		)
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		for i := 0; i < objectCount; i++ {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(fmt.Sprintf("object-%04d", i)))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		// clients which don't accept a compressed listing only get the items.
+		plain, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, plain.Items, objectCount)
+		require.Nil(t, plain.Payload)
+
+		uncompressed, err := pb.Marshal(&pb.ObjectListResponse{
+			Items: plain.ProtoItems(),
+			More:  plain.More,
+		})
+		require.NoError(t, err)
+
+		compressed, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:   true,
+			Compression: metainfo.ListCompressionGzip,
+		})
+		require.NoError(t, err)
+		require.Len(t, compressed.Items, objectCount)
+		require.Equal(t, metainfo.ListCompressionGzip, compressed.PayloadCompression)
+		require.Less(t, len(compressed.Payload), len(uncompressed)/2)
+		t.Logf("listing of %d objects: %d bytes, %d bytes compressed", objectCount, len(uncompressed), len(compressed.Payload))
+
+		decoded, err := metainfo.DecodeListPayload(compressed.Payload, compressed.PayloadCompression)
+		require.NoError(t, err)
+		require.Len(t, decoded.Items, objectCount)
+		for i, item := range decoded.Items {
+			require.Equal(t, plain.Items[i].EncryptedPath, item.EncryptedPath)
+		}
+
+		// small listings aren't worth compressing.
+		small, err := endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive:   true,
+			Limit:       2,
+			Compression: metainfo.ListCompressionGzip,
+		})
+		require.NoError(t, err)
+		require.Equal(t, metainfo.ListCompressionNone, small.PayloadCompression)
+
+		decoded, err = metainfo.DecodeListPayload(small.Payload, small.PayloadCompression)
+		require.NoError(t, err)
+		require.Len(t, decoded.Items, 2)
+		require.True(t, decoded.More)
+
+		_, err = endpoint.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Compression: metainfo.ListCompression(100),
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument))
+	})
+}
+
+func TestEndpoint_DeleteObjectPieces_DryRun(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 2, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		var (
+			uplnk        = planet.Uplinks[0]
+			satelliteSys = planet.Satellites[0]
+			endpoint     = satelliteSys.Metainfo.Endpoint2
+		)
+
+		const (
+			bucketName  = "a-bucket"
+			segmentSize = 10 * memory.KiB
+		)
+
+		var allNodes storj.NodeIDList
+		for _, node := range planet.StorageNodes {
+			allNodes = append(allNodes, node.ID())
+		}
+		sort.Sort(allNodes)
+
+		countSegments := func() int {
+			keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+			require.NoError(t, err)
+			return len(keys)
+		}
+
+		// the segments of an object without its last segment are deleted
+		// with it.
+		projectID, encryptedPath := uploadFirstObjectWithoutLastSegmentPointer(
+			ctx, t, uplnk, satelliteSys, segmentSize, bucketName, "zombie", testrand.Bytes(4*segmentSize),
+		)
+		segments := countSegments()
+		require.NotZero(t, segments)
+
+		for _, opts := range []metainfo.DeleteObjectPiecesOptions{
+			{DryRun: true},
+			{DryRun: true, Strict: true},
+		} {
+			result, err := endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, opts)
+			require.NoError(t, err)
+			require.Empty(t, result.Deleted)
+			require.Zero(t, result.Nodes)
+			require.Equal(t, segments, result.Plan.Segments)
+			require.Equal(t, 4*segments, result.Plan.Pieces)
+			require.Equal(t, []storj.NodeID(allNodes), result.Plan.Nodes)
+		}
+		require.Equal(t, segments, countSegments())
+
+		// an upload can't be deleted while keeping the concurrent uploads.
+		result, err := endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+			DryRun:                true,
+			KeepConcurrentUploads: true,
+		})
+		require.NoError(t, err)
+		require.Zero(t, result.Plan.Segments)
+		require.Empty(t, result.Plan.Nodes)
+
+		_, err = endpoint.DeleteObjectPieces(ctx, projectID, []byte(bucketName), encryptedPath)
+		require.NoError(t, err)
+		require.Zero(t, countSegments())
+
+		// only the segments of the types are deleted.
+		uploadCtx := testuplink.WithMaxSegmentSize(ctx, segmentSize)
+		err = uplnk.Upload(uploadCtx, satelliteSys, bucketName, "object", testrand.Bytes(2*segmentSize+memory.KiB))
+		require.NoError(t, err)
+		projectID, encryptedPath = getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		result, err = endpoint.DeleteObjectPiecesWithOptions(ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{
+			DryRun:       true,
+			SegmentTypes: metainfo.SegmentTypeInline,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Plan.Segments)
+		require.Zero(t, result.Plan.Pieces)
+		require.Empty(t, result.Plan.Nodes)
+		require.Equal(t, 3, countSegments())
+	})
+}
+
+func TestEndpoint_ZeroByteObject(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		projectID := uplnk.Projects[0].ID
+
+		const bucketName = "empty-bucket"
+
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "empty", []byte{})
+		require.NoError(t, err)
+
+		// the object is a single inline segment.
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		_, encryptedPath := parsePath(ctx, t, keys[0].String())
+
+		pointer, err := satelliteSys.Metainfo.Service.Get(ctx, metabase.SegmentKey(keys[0]))
+		require.NoError(t, err)
+		require.Equal(t, pb.Pointer_INLINE, pointer.Type)
+
+		list, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			Recursive: true,
+			Fields:    metainfo.ListObjectsFieldKey | metainfo.ListObjectsFieldSize,
+		})
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Equal(t, encryptedPath, list.Items[0].EncryptedPath)
+		// only the encryption overhead is stored.
+		require.Equal(t, pointer.SegmentSize, list.Items[0].Size)
+
+		project, err := uplnk.GetProject(ctx, satelliteSys)
+		require.NoError(t, err)
+		defer ctx.Check(project.Close)
+
+		object, err := project.StatObject(ctx, bucketName, "empty")
+		require.NoError(t, err)
+		require.Zero(t, object.System.ContentLength)
+
+		data, err := uplnk.Download(ctx, satelliteSys, bucketName, "empty")
+		require.NoError(t, err)
+		require.Empty(t, data)
+
+		// no node stores a piece of the object.
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, result.Deleted, 1)
+		require.Zero(t, result.Nodes)
+
+		keys, err = satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+}
+
+// serializationFailure is the error of a transaction which conflicted with
+// a concurrent one.
+type serializationFailure struct{}
+
+func (serializationFailure) Error() string    { return "restart transaction: serialization failure" }
+func (serializationFailure) SQLState() string { return "40001" }
+
+// conflictingPointerDB fails the first commits of the last segments and the
+// first deletes with a serialization failure.
+type conflictingPointerDB struct {
+	metainfo.PointerDB
+
+	mu             sync.Mutex
+	commitFailures int
+	deleteFailures int
+}
+
+func (db *conflictingPointerDB) fail(failures *int) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if *failures <= 0 {
+		return false
+	}
+	*failures--
+	return true
+}
+
+func (db *conflictingPointerDB) Put(ctx context.Context, key storage.Key, value storage.Value) error {
+	location, err := metabase.ParseSegmentKey(metabase.SegmentKey(key))
+	if err == nil && location.Index == metabase.LastSegmentIndex && db.fail(&db.commitFailures) {
+		return serializationFailure{}
+	}
+	return db.PointerDB.Put(ctx, key, value)
+}
+
+func (db *conflictingPointerDB) DeleteMultiple(ctx context.Context, keys []storage.Key) (storage.Items, error) {
+	if db.fail(&db.deleteFailures) {
+		return nil, serializationFailure{}
+	}
+	return db.PointerDB.DeleteMultiple(ctx, keys)
+}
+
+func TestEndpoint_TransactionRetry(t *testing.T) {
+	pointerDB := &conflictingPointerDB{commitFailures: 2, deleteFailures: 2}
+
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			SatellitePointerDB: func(log *zap.Logger, index int, db metainfo.PointerDB) (metainfo.PointerDB, error) {
+				pointerDB.PointerDB = db
+				return pointerDB, nil
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+
+		const bucketName = "retry-bucket"
+
+		// the commit is retried until it succeeds.
+		err := uplnk.Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(10*memory.KiB))
+		require.NoError(t, err)
+		require.Zero(t, pointerDB.commitFailures)
+
+		projectID, encryptedPath := getProjectIDAndEncPathFirstObject(ctx, t, satelliteSys)
+
+		// the delete is retried until it succeeds.
+		result, err := satelliteSys.Metainfo.Endpoint2.DeleteObjectPiecesWithOptions(
+			ctx, projectID, []byte(bucketName), encryptedPath, metainfo.DeleteObjectPiecesOptions{},
+		)
+		require.NoError(t, err)
+		require.Zero(t, pointerDB.deleteFailures)
+		require.Len(t, result.Deleted, 1)
+		require.Empty(t, result.Failed)
+
+		keys, err := satelliteSys.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+}
+
+func TestEndpoint_ListObjectsCaseInsensitive(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Metainfo.CaseInsensitiveListing = true
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "case-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		// the keys are stored as they are, like with the null path cipher.
+		for _, key := range []string{"B", "a", "C", "Photos/x", "photos/Y", "photos/z", "PHOTOS/w"} {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.Bytes(memory.B),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		list := func(opts metainfo.ListObjectsOptions) (paths []string) {
+			opts.Fields = metainfo.ListObjectsFieldKey
+			for {
+				result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), opts)
+				require.NoError(t, err)
+
+				for _, item := range result.Items {
+					paths = append(paths, string(item.EncryptedPath))
+				}
+				if !result.More {
+					return paths
+				}
+				opts.EncryptedCursor = result.Cursor
+			}
+		}
+
+		// the keys are case-sensitive by default.
+		require.Equal(t, []string{"B", "C", "PHOTOS/w", "Photos/x", "a", "photos/Y", "photos/z"},
+			list(metainfo.ListObjectsOptions{Recursive: true}))
+		require.Equal(t, []string{"Y", "z"},
+			list(metainfo.ListObjectsOptions{Recursive: true, EncryptedPrefix: []byte("photos/")}))
+
+		// the keys are ordered and matched regardless of case, also across
+		// pages.
+		for _, limit := range []int32{0, 1, 2} {
+			require.Equal(t, []string{"a", "B", "C", "PHOTOS/w", "Photos/x", "photos/Y", "photos/z"},
+				list(metainfo.ListObjectsOptions{Recursive: true, CaseInsensitive: true, Limit: limit}), limit)
+			require.Equal(t, []string{"PHOTOS/w", "Photos/x", "photos/Y", "photos/z"},
+				list(metainfo.ListObjectsOptions{Recursive: true, CaseInsensitive: true, Limit: limit, EncryptedPrefix: []byte("photos/")}), limit)
+			// the prefixes which only differ by their case are collapsed.
+			require.Equal(t, []string{"a", "B", "C", "PHOTOS/"},
+				list(metainfo.ListObjectsOptions{CaseInsensitive: true, Limit: limit}), limit)
+		}
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			CaseInsensitive: true,
+			Descending:      true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+	})
+}
+
+func TestEndpoint_ListObjectsByteBudget(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "budget-bucket"
+
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		sizes := map[string]int{"a": 100, "b": 200, "c": 300, "d": 400, "e": 500}
+		for key, size := range sizes {
+			location, err := metainfo.CreatePath(ctx, projectID, metabase.LastSegmentIndex, []byte(bucketName), []byte(key))
+			require.NoError(t, err)
+
+			err = satelliteSys.Metainfo.Service.UnsynchronizedPut(ctx, location.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: testrand.BytesInt(size),
+				SegmentSize:   int64(size),
+				CreationDate:  time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		list := func(budget int64) (pages [][]string) {
+			opts := metainfo.ListObjectsOptions{
+				Recursive:  true,
+				Fields:     metainfo.ListObjectsFieldKey,
+				ByteBudget: budget,
+			}
+			for {
+				result, err := satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), opts)
+				require.NoError(t, err)
+
+				var page []string
+				for _, item := range result.Items {
+					require.EqualValues(t, sizes[string(item.EncryptedPath)], item.Size)
+					page = append(page, string(item.EncryptedPath))
+				}
+				pages = append(pages, page)
+				if !result.More {
+					return pages
+				}
+				require.Equal(t, metainfo.ListTruncatedByteBudget, result.Truncation)
+				opts.EncryptedCursor = result.Cursor
+			}
+		}
+
+		// the object which reaches the budget ends the page.
+		require.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}, {"e"}}, list(250))
+		require.Equal(t, [][]string{{"a", "b", "c"}, {"d", "e"}}, list(600))
+		// the budget is reached exactly by the last object.
+		require.Equal(t, [][]string{{"a", "b", "c", "d", "e"}}, list(1500))
+		require.Equal(t, [][]string{{"a", "b", "c", "d", "e"}}, list(10000))
+		// an object larger than the budget is listed alone.
+		require.Equal(t, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}, list(1))
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			ByteBudget: -1,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+
+		_, err = satelliteSys.Metainfo.Endpoint2.ListObjectsWithOptions(ctx, projectID, []byte(bucketName), metainfo.ListObjectsOptions{
+			ByteBudget: 100,
+			Descending: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.InvalidArgument), err)
+	})
+}
@@ -13,6 +13,7 @@ import (
 
 	"storj.io/common/sync2"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
 )
 
 var (
@@ -25,6 +26,15 @@ var (
 type Config struct {
 	Interval time.Duration `help:"the time between each attempt to go through the db and clean up expired segments" releaseDefault:"120h" devDefault:"10m"`
 	Enabled  bool          `help:"set if expired segment cleanup is enabled or not" releaseDefault:"true" devDefault:"true"`
+
+	DeletePieces      bool `help:"delete the pieces of the expired segments from the storage nodes" default:"false"`
+	MaxPiecesPerBatch int  `help:"maximum number of pieces of expired segments deleted together" default:"10000"`
+}
+
+// PieceDeleter deletes pieces from the storage nodes, see
+// piecedeletion.Service.
+type PieceDeleter interface {
+	Delete(ctx context.Context, requests []piecedeletion.Request, successThreshold float64) error
 }
 
 // Chore implements the expired segment cleanup chore
@@ -35,18 +45,26 @@ type Chore struct {
 	config Config
 	Loop   *sync2.Cycle
 
-	metainfo     *metainfo.Service
-	metainfoLoop *metainfo.Loop
+	metainfo       *metainfo.Service
+	inlineContents metainfo.InlineContentsDB
+	sharedSegments metainfo.SharedSegmentsDB
+	metainfoLoop   *metainfo.Loop
+	deleter        PieceDeleter
 }
 
-// NewChore creates a new instance of the expireddeletion chore.
-func NewChore(log *zap.Logger, config Config, meta *metainfo.Service, loop *metainfo.Loop) *Chore {
+// NewChore creates a new instance of the expireddeletion chore. The pieces of
+// the expired segments are deleted with deleter, when it isn't nil, otherwise
+// they are left to garbage collection.
+func NewChore(log *zap.Logger, config Config, meta *metainfo.Service, inlineContents metainfo.InlineContentsDB, sharedSegments metainfo.SharedSegmentsDB, loop *metainfo.Loop, deleter PieceDeleter) *Chore {
 	return &Chore{
-		log:          log,
-		config:       config,
-		Loop:         sync2.NewCycle(config.Interval),
-		metainfo:     meta,
-		metainfoLoop: loop,
+		log:            log,
+		config:         config,
+		Loop:           sync2.NewCycle(config.Interval),
+		metainfo:       meta,
+		inlineContents: inlineContents,
+		sharedSegments: sharedSegments,
+		metainfoLoop:   loop,
+		deleter:        deleter,
 	}
 }
 
@@ -62,12 +80,18 @@ func (chore *Chore) Run(ctx context.Context) (err error) {
 		defer mon.Task()(&ctx)(&err)
 
 		deleter := &expiredDeleter{
-			log:      chore.log.Named("expired deleter observer"),
-			metainfo: chore.metainfo,
+			log:            chore.log.Named("expired deleter observer"),
+			metainfo:       chore.metainfo,
+			inlineContents: chore.inlineContents,
+			sharedSegments: chore.sharedSegments,
+			deleter:        chore.deleter,
+			maxPieces:      chore.config.MaxPiecesPerBatch,
 		}
 
 		// delete expired segments
 		err = chore.metainfoLoop.Join(ctx, deleter)
+		// the segments are deleted, whether the loop completed or not.
+		deleter.flush(ctx)
 		if err != nil {
 			chore.log.Error("error joining metainfoloop", zap.Error(err))
 			return nil
@@ -13,17 +13,32 @@ import (
 	"storj.io/common/storj"
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
 	"storj.io/storj/storage"
 )
 
+// deletePiecesSuccessThreshold is the success threshold of the deletions of
+// the pieces of the expired segments.
+const deletePiecesSuccessThreshold = 0.75
+
 var _ metainfo.Observer = (*expiredDeleter)(nil)
 
 // expiredDeleter implements the metainfo loop observer interface for expired segment cleanup
 //
 // architecture: Observer
 type expiredDeleter struct {
-	log      *zap.Logger
-	metainfo *metainfo.Service
+	log            *zap.Logger
+	metainfo       *metainfo.Service
+	inlineContents metainfo.InlineContentsDB
+	sharedSegments metainfo.SharedSegmentsDB
+
+	deleter   PieceDeleter
+	maxPieces int
+	// expired are the deleted remote segments whose references haven't been
+	// released yet, and pieces is their number of pieces.
+	expired []*pb.Pointer
+	pieces  int
 }
 
 // RemoteSegment deletes the segment if it is expired.
@@ -59,8 +74,70 @@ func (ed *expiredDeleter) deleteSegmentIfExpired(ctx context.Context, location m
 		} else if storage.ErrValueChanged.Has(err) {
 			// segment was replaced
 			return nil
+		} else if err != nil {
+			return err
 		}
-		return err
+		metainfo.ReleaseInlineContents(ctx, ed.log, ed.inlineContents, []*pb.Pointer{pointer})
+		ed.queueSegment(ctx, pointer)
 	}
 	return nil
 }
+
+// queueSegment queues the deleted segment, when it's a remote one. The queue
+// is flushed once its segments have maxPieces pieces.
+func (ed *expiredDeleter) queueSegment(ctx context.Context, pointer *pb.Pointer) {
+	if pointer.Type != pb.Pointer_REMOTE || pointer.Remote == nil {
+		return
+	}
+
+	ed.expired = append(ed.expired, pointer)
+	ed.pieces += len(pointer.Remote.RemotePieces)
+	if ed.pieces >= ed.maxPieces {
+		ed.flush(ctx)
+	}
+}
+
+// flush releases the references of the queued segments together and sends
+// the pieces of the ones which aren't shared with copies for deletion, the
+// ones stored on the same node in a single request. Without a deleter, the
+// pieces are left to garbage collection.
+func (ed *expiredDeleter) flush(ctx context.Context) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	if len(ed.expired) == 0 {
+		return
+	}
+	expired := ed.expired
+	mon.IntVal("expired_deletion_pieces").Observe(int64(ed.pieces))
+	ed.expired, ed.pieces = nil, 0
+
+	unshared, err := metainfo.ReleaseSharedSegments(ctx, ed.sharedSegments, expired)
+	if err != nil {
+		ed.log.Error("failed to release shared segments", zap.Error(err))
+	}
+	if ed.deleter == nil || len(unshared) == 0 {
+		return
+	}
+
+	err = ed.deleter.Delete(ctx, pieceDeletionRequests(unshared), deletePiecesSuccessThreshold)
+	if err != nil {
+		ed.log.Error("failed to delete the pieces of expired segments", zap.Error(err))
+	}
+}
+
+// pieceDeletionRequests returns the requests for deleting the pieces of the
+// pointers, one per node.
+func pieceDeletionRequests(pointers []*pb.Pointer) []piecedeletion.Request {
+	sizes := objectdeletion.GroupPieceSizesByNodeID(pointers)
+
+	var requests []piecedeletion.Request
+	for node, pieces := range objectdeletion.GroupPiecesByNodeID(pointers) {
+		requests = append(requests, piecedeletion.Request{
+			Node:   storj.NodeURL{ID: node},
+			Pieces: pieces,
+			Bytes:  sizes[node],
+		})
+	}
+	return requests
+}
@@ -117,3 +117,63 @@ func TestExpiredDeletion(t *testing.T) {
 		require.EqualValues(t, i, 2)
 	})
 }
+
+func TestExpiredDeletion_DeletePieces(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				func(log *zap.Logger, index int, config *satellite.Config) {
+					config.ExpiredDeletion.Interval = 500 * time.Millisecond
+					config.ExpiredDeletion.DeletePieces = true
+				},
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellite := planet.Satellites[0]
+		upl := planet.Uplinks[0]
+		expiredChore := satellite.Core.ExpiredDeletion.Chore
+
+		for i := 0; i < 2; i++ {
+			err := upl.Upload(ctx, satellite, "testbucket", "test/path/"+strconv.Itoa(i), testrand.Bytes(8*memory.KiB))
+			require.NoError(t, err)
+		}
+
+		// Expire all the segments
+		items, err := satellite.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		for _, key := range items {
+			value, err := satellite.Metainfo.Database.Get(ctx, key)
+			require.NoError(t, err)
+
+			pointer := &pb.Pointer{}
+			err = pb.Unmarshal(value, pointer)
+			require.NoError(t, err)
+			require.NotNil(t, pointer.Remote)
+			pointer.ExpirationDate = time.Now().Add(-24 * time.Hour)
+			newPointerBytes, err := pb.Marshal(pointer)
+			require.NoError(t, err)
+			err = satellite.Metainfo.Database.CompareAndSwap(ctx, key, value, newPointerBytes)
+			require.NoError(t, err)
+		}
+
+		// Wait for next iteration of expired cleanup to finish
+		expiredChore.Loop.Restart()
+		expiredChore.Loop.TriggerWait()
+
+		items, err = satellite.Metainfo.Database.List(ctx, storage.Key{}, 0)
+		require.NoError(t, err)
+		require.Empty(t, items)
+
+		// Verify that the pieces have been deleted from the nodes rather than
+		// left to garbage collection
+		planet.WaitForStorageNodeDeleters(ctx)
+		for _, node := range planet.StorageNodes {
+			piecesTotal, _, err := node.Storage2.Store.SpaceUsedForPieces(ctx)
+			require.NoError(t, err)
+			require.Zero(t, piecesTotal)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/pb"
+)
+
+// inlineReferencePrefix starts the inline data of the pointers whose content
+// is stored in InlineContentsDB. It's followed by the SHA-256 hash of the
+// content.
+var inlineReferencePrefix = []byte("\x00storj-inline-ref\x00")
+
+// inlineReferenceSize is the size of the inline data of a pointer which
+// references its content.
+var inlineReferenceSize = len(inlineReferencePrefix) + sha256.Size
+
+// inlineReference returns the hash of the referenced content when data is a
+// reference.
+func inlineReference(data []byte) (hash []byte, ok bool) {
+	if len(data) != inlineReferenceSize || !bytes.HasPrefix(data, inlineReferencePrefix) {
+		return nil, false
+	}
+	return data[len(inlineReferencePrefix):], true
+}
+
+// InlineSegmentSize returns the size of the inline data of the pointer, also
+// when the pointer only references its deduplicated content.
+func InlineSegmentSize(pointer *pb.Pointer) int64 {
+	if _, ok := inlineReference(pointer.InlineSegment); ok {
+		return pointer.SegmentSize
+	}
+	return int64(len(pointer.InlineSegment))
+}
+
+// deduplicateInline returns the pointer to store for the inline segment
+// pointer: when deduplication is enabled, its content is stored in
+// InlineContentsDB and the returned copy only references it. Contents which
+// aren't larger than a reference are stored as they are.
+func (endpoint *Endpoint) deduplicateInline(ctx context.Context, pointer *pb.Pointer) (_ *pb.Pointer, err error) {
+	return deduplicateInline(ctx, endpoint.inlineContents, endpoint.config.DeduplicateInlineSegments, pointer)
+}
+
+// deduplicateInline returns the pointer to store for the inline segment
+// pointer, see Endpoint.deduplicateInline.
+func deduplicateInline(ctx context.Context, inlineContents InlineContentsDB, enabled bool, pointer *pb.Pointer) (_ *pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if !enabled || pointer.Type != pb.Pointer_INLINE || len(pointer.InlineSegment) <= inlineReferenceSize {
+		return pointer, nil
+	}
+	if _, ok := inlineReference(pointer.InlineSegment); ok {
+		return pointer, nil
+	}
+
+	hash := sha256.Sum256(pointer.InlineSegment)
+	err = inlineContents.Reference(ctx, hash[:], pointer.InlineSegment)
+	if err != nil {
+		return nil, err
+	}
+	mon.Meter("inline_segment_deduplicated").Mark(1)
+
+	deduplicated := *pointer
+	deduplicated.InlineSegment = append(append([]byte{}, inlineReferencePrefix...), hash[:]...)
+	return &deduplicated, nil
+}
+
+// resolveInline replaces the reference of the pointer to its deduplicated
+// content with the content.
+func (endpoint *Endpoint) resolveInline(ctx context.Context, pointer *pb.Pointer) (err error) {
+	return resolveInline(ctx, endpoint.inlineContents, pointer)
+}
+
+// resolveInline replaces the reference of the pointer to its deduplicated
+// content with the content of inlineContents.
+func resolveInline(ctx context.Context, inlineContents InlineContentsDB, pointer *pb.Pointer) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	hash, ok := inlineReference(pointer.InlineSegment)
+	if !ok {
+		return nil
+	}
+
+	pointer.InlineSegment, err = inlineContents.Get(ctx, hash)
+	return err
+}
+
+// releaseInlineContents releases the references of the deleted pointers to
+// their deduplicated contents, see ReleaseInlineContents.
+func (endpoint *Endpoint) releaseInlineContents(ctx context.Context, pointers []*pb.Pointer) {
+	ReleaseInlineContents(ctx, endpoint.log, endpoint.inlineContents, pointers)
+}
+
+// ReleaseInlineContents releases the references of the deleted pointers to
+// their deduplicated contents. Every path which deletes pointers must call it,
+// otherwise the contents are never deleted. It's best effort: a reference
+// which isn't released keeps its content stored.
+func ReleaseInlineContents(ctx context.Context, log *zap.Logger, inlineContents InlineContentsDB, pointers []*pb.Pointer) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	for _, pointer := range pointers {
+		if pointer == nil {
+			continue
+		}
+		hash, ok := inlineReference(pointer.InlineSegment)
+		if !ok {
+			continue
+		}
+
+		var deleted bool
+		deleted, err = inlineContents.Release(ctx, hash)
+		if err != nil {
+			log.Warn("failed to release deduplicated inline content", zap.Binary("hash", hash), zap.Error(err))
+			continue
+		}
+		if deleted {
+			mon.Meter("inline_content_deleted").Mark(1)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// foldedKey is a key listed case-insensitively, with its case folded form
+// which it's ordered by.
+type foldedKey struct {
+	folded  string
+	key     string
+	segment *pb.ListResponse_Item
+}
+
+// less orders the keys by their folded form, and the keys which only differ
+// by their case by their actual form.
+func (key foldedKey) less(other foldedKey) bool {
+	if key.folded != other.folded {
+		return key.folded < other.folded
+	}
+	return key.key < other.key
+}
+
+// validateCaseInsensitive checks the options of a case-insensitive listing,
+// see ListObjectsOptions.CaseInsensitive.
+func (endpoint *Endpoint) validateCaseInsensitive(opts ListObjectsOptions) error {
+	if !opts.CaseInsensitive {
+		return nil
+	}
+	if !endpoint.config.CaseInsensitiveListing {
+		return rpcstatus.Error(rpcstatus.FailedPrecondition, "case-insensitive listing is disabled")
+	}
+	if opts.Descending || len(opts.EncryptedDelimiter) > 0 || opts.CursorInclusive {
+		return rpcstatus.Error(rpcstatus.InvalidArgument, "case-insensitive listing doesn't support descending order, delimiters or inclusive cursors")
+	}
+	return nil
+}
+
+// listObjectsCaseInsensitive lists the objects of the bucket whose key
+// starts with the prefix regardless of case, in case-insensitive key order,
+// see ListObjectsOptions.CaseInsensitive.
+//
+// pointerDB has no index of the case folded keys, hence every page scans all
+// the keys of the bucket and keeps the first ones after the cursor. It fails
+// when the bucket has more keys than Config.ListMaxScannedKeys.
+func (endpoint *Endpoint) listObjectsCaseInsensitive(ctx context.Context, projectID uuid.UUID, bucket []byte, lister Lister, opts ListObjectsOptions,
+	limit int32, limitTruncation ListTruncation, fields ListObjectsFields, metaFlags uint32, pages *int) (result ListObjectsResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	bucketPrefix, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, bucket, nil)
+	if err != nil {
+		return ListObjectsResult{}, err
+	}
+	objectPrefix := string(listedPathPrefix(opts.EncryptedPrefix))
+
+	var cursor foldedKey
+	if len(opts.EncryptedCursor) > 0 {
+		cursor = foldedKey{folded: strings.ToLower(string(opts.EncryptedCursor)), key: string(opts.EncryptedCursor)}
+	}
+	// the keys of the prefix the cursor points to have been already listed
+	// as part of it.
+	cursorPrefix := ""
+	if !opts.Recursive && strings.HasSuffix(cursor.folded, "/") {
+		cursorPrefix = cursor.folded
+	}
+
+	// first keeps the first limit+1 keys after the cursor, the extra one
+	// tells whether there are more. It's trimmed once it doubled, rather than
+	// sorted on every key.
+	var first []foldedKey
+	trim := func() {
+		sort.Slice(first, func(i, k int) bool { return first[i].less(first[k]) })
+		if int32(len(first)) > limit+1 {
+			first = first[:limit+1]
+		}
+	}
+	seenPrefixes := map[string]bool{}
+	add := func(key foldedKey) {
+		if cursor.key != "" && !cursor.less(key) {
+			return
+		}
+		first = append(first, key)
+		if int32(len(first)) > 2*(limit+1) {
+			trim()
+		}
+	}
+
+	startAfter := ""
+	scanned := 0
+	for {
+		*pages++
+		var segments []*pb.ListResponse_Item
+		var more bool
+		err := withQueryTimeout(ctx, endpoint.config.ListQueryTimeout, func(ctx context.Context) (err error) {
+			segments, more, err = lister.List(ctx, bucketPrefix.Encode(), startAfter, true, 0, metaFlags)
+			return err
+		})
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+		scanned += len(segments)
+
+		for _, segment := range segments {
+			key := segment.Path
+			if len(key) < len(objectPrefix) || !strings.EqualFold(key[:len(objectPrefix)], objectPrefix) {
+				continue
+			}
+
+			if !opts.Recursive {
+				if i := strings.IndexByte(key[len(objectPrefix):], '/'); i >= 0 {
+					prefix := key[:len(objectPrefix)+i+1]
+					folded := strings.ToLower(prefix)
+					if seenPrefixes[folded] || (cursorPrefix != "" && folded == cursorPrefix) {
+						continue
+					}
+					seenPrefixes[folded] = true
+					add(foldedKey{folded: folded, key: prefix})
+					continue
+				}
+			}
+			if cursorPrefix != "" && strings.HasPrefix(strings.ToLower(key), cursorPrefix) {
+				continue
+			}
+
+			if !opts.ModifiedAfter.IsZero() && segment.Pointer != nil && !segment.Pointer.CreationDate.After(opts.ModifiedAfter) {
+				continue
+			}
+			add(foldedKey{folded: strings.ToLower(key), key: key, segment: segment})
+		}
+
+		if !more {
+			break
+		}
+		if scanLimitReached(endpoint.config.ListMaxScannedKeys, scanned) {
+			return ListObjectsResult{}, errScanLimit(endpoint.config.ListMaxScannedKeys)
+		}
+		startAfter = segments[len(segments)-1].Path
+	}
+
+	trim()
+	if int32(len(first)) > limit {
+		first = first[:limit]
+		result.More = true
+	}
+
+	for _, key := range first {
+		if key.segment == nil {
+			result.Items = append(result.Items, ListObjectsItem{
+				ObjectListItem: &pb.ObjectListItem{
+					EncryptedPath: []byte(key.key),
+				},
+				IsPrefix: true,
+			})
+			continue
+		}
+
+		item, err := endpoint.listObjectsItem(ctx, projectID, bucket, nil, fields, key.segment)
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if result.More {
+		result.Truncation = limitTruncation
+		result.Cursor = result.Items[len(result.Items)-1].EncryptedPath
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"storj.io/common/pb"
+)
+
+// ListCompression is the compression of the payload of a listing, see
+// ListObjectsOptions.Compression.
+type ListCompression int
+
+const (
+	// ListCompressionNone leaves the payload uncompressed.
+	ListCompressionNone ListCompression = iota
+	// ListCompressionGzip compresses the payload with gzip.
+	ListCompressionGzip
+)
+
+// String returns the name of the compression.
+func (compression ListCompression) String() string {
+	switch compression {
+	case ListCompressionNone:
+		return "none"
+	case ListCompressionGzip:
+		return "gzip"
+	default:
+		return "unknown"
+	}
+}
+
+// IsValid returns whether the compression is known.
+func (compression ListCompression) IsValid() bool {
+	return compression == ListCompressionNone || compression == ListCompressionGzip
+}
+
+// setListPayload sets the payload of the result, the listed items marshaled
+// as an ObjectListResponse, compressed with compression unless it's smaller
+// than Config.ListCompressionMinSize.
+func (endpoint *Endpoint) setListPayload(result *ListObjectsResult, compression ListCompression) error {
+	payload, err := pb.Marshal(&pb.ObjectListResponse{
+		Items: result.ProtoItems(),
+		More:  result.More,
+	})
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	if compression == ListCompressionNone || int64(len(payload)) < endpoint.config.ListCompressionMinSize.Int64() {
+		result.Payload = payload
+		result.PayloadCompression = ListCompressionNone
+		return nil
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(payload); err != nil {
+		return Error.Wrap(err)
+	}
+	if err := writer.Close(); err != nil {
+		return Error.Wrap(err)
+	}
+
+	mon.IntVal("list_payload_bytes").Observe(int64(len(payload)))
+	mon.IntVal("list_payload_compressed_bytes").Observe(int64(compressed.Len()))
+
+	result.Payload = compressed.Bytes()
+	result.PayloadCompression = compression
+	return nil
+}
+
+// DecodeListPayload returns the listing of ListObjectsResult.Payload, which
+// is compressed with compression.
+func DecodeListPayload(payload []byte, compression ListCompression) (_ *pb.ObjectListResponse, err error) {
+	switch compression {
+	case ListCompressionNone:
+	case ListCompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		payload, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+	default:
+		return nil, Error.New("unknown list compression %d", compression)
+	}
+
+	response := &pb.ObjectListResponse{}
+	if err := pb.Unmarshal(payload, response); err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return response, nil
+}
@@ -0,0 +1,841 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+	"storj.io/uplink/private/storage/meta"
+)
+
+// ListObjectsFields is a mask of the fields which are populated in the listed
+// items. The encrypted key is always populated.
+type ListObjectsFields int
+
+const (
+	// ListObjectsFieldKey requests only the encrypted key of the objects.
+	// Listing only keys doesn't need to read the pointers of the objects.
+	ListObjectsFieldKey ListObjectsFields = 1 << iota
+	// ListObjectsFieldCreated requests the creation time of the objects.
+	ListObjectsFieldCreated
+	// ListObjectsFieldExpires requests the expiration time of the objects.
+	ListObjectsFieldExpires
+	// ListObjectsFieldMetadata requests the encrypted metadata of the objects.
+	ListObjectsFieldMetadata
+	// ListObjectsFieldSize requests the total encrypted size of the objects.
+	// It requires reading all the segments of multi-segment objects.
+	ListObjectsFieldSize
+	// ListObjectsFieldRevisionTag requests the tag of the upload of the
+	// objects, see ObjectRevisionTag.
+	ListObjectsFieldRevisionTag
+
+	// ListObjectsFieldsDefault are the fields populated when no mask is
+	// specified.
+	ListObjectsFieldsDefault = ListObjectsFieldKey | ListObjectsFieldCreated | ListObjectsFieldExpires | ListObjectsFieldMetadata
+)
+
+// Has returns whether field is part of the mask.
+func (fields ListObjectsFields) Has(field ListObjectsFields) bool {
+	return fields&field != 0
+}
+
+// ListObjectsOptions defines the server-side options for listing the objects
+// of a bucket. The public ListObjects request only carries the prefix, the
+// cursor, Recursive and Limit, the other options are server-internal.
+type ListObjectsOptions struct {
+	// EncryptedPrefix restricts the listing to the keys under it.
+	EncryptedPrefix []byte
+	// EncryptedCursor is the key after which the listing continues, relative
+	// to the prefix, usually the last returned item or
+	// ListObjectsResult.Cursor. The keys are listed in key order, so the
+	// pages of a listing are stable across concurrent uploads and deletes:
+	// no object is listed twice or skipped, only the objects committed
+	// before the cursor meanwhile aren't listed, and the ones deleted after
+	// it meanwhile aren't listed anymore.
+	EncryptedCursor []byte
+	// Recursive lists all the keys under the prefix, otherwise the nested
+	// keys are collapsed at "/" into prefix items.
+	Recursive bool
+	Limit     int32
+
+	// Fields defines which fields of the items are populated. When zero,
+	// ListObjectsFieldsDefault is used.
+	Fields ListObjectsFields
+
+	// EncryptedDelimiter, when set, collapses the keys which contain it after
+	// the prefix into a single prefix item, which ends with the delimiter.
+	// The listing is always recursive then, otherwise the keys would be
+	// already collapsed at "/".
+	//
+	// The satellite only sees encrypted keys, hence the delimiter has to be
+	// defined in the encrypted space by the client: with path encryption,
+	// the components of a key are encrypted one by one and joined with "/",
+	// so "/" is the only delimiter which survives the encryption; with the
+	// null path cipher the encrypted key is the plain key, so the plain
+	// delimiter can be used as is.
+	//
+	// To continue after a prefix item, pass it as EncryptedCursor.
+	EncryptedDelimiter []byte
+
+	// ModifiedAfter, when set, skips the objects whose last segment was
+	// committed at or before this time. Prefixes are always returned since
+	// they don't have a modification time.
+	//
+	// There is no modification time index in pointerDB, so the filter is
+	// applied while scanning the keys: a page may scan every key of the
+	// bucket to find a single changed object. A page scans at most
+	// Config.ListMaxScannedKeys keys and is then returned with
+	// ListTruncatedScanLimit, the filter is refused when it's 0.
+	ModifiedAfter time.Time
+
+	// TimeBudget, when set, limits how long the listing keeps scanning for
+	// more items after some of them have been filtered out. When it's spent,
+	// the items found so far are returned with More set.
+	TimeBudget time.Duration
+
+	// Descending lists the items in descending key order. EncryptedCursor
+	// is then the key before which the listing continues, the listing starts
+	// from the end of the bucket without it. Prefix items are collapsed as
+	// in ascending order, to continue before a prefix item, pass it as
+	// EncryptedCursor.
+	//
+	// pointerDB can only be iterated in ascending order, hence every page
+	// scans all the keys before the cursor and keeps the last ones. The time
+	// budget doesn't apply, since the page is only known at the end of the
+	// scan. The listing fails with rpcstatus.ResourceExhausted when there are
+	// more than Config.ListMaxScannedKeys keys before the cursor, and it's
+	// refused when that's 0.
+	Descending bool
+
+	// AllowMissingBucket lists a bucket which doesn't exist as an empty one.
+	// Otherwise storj.ErrBucketNotFound is returned, so clients don't
+	// mistake a missing bucket for an empty one.
+	AllowMissingBucket bool
+
+	// CursorInclusive lists the item at EncryptedCursor itself, when it
+	// exists, rather than starting after it. In descending order, the items
+	// before the cursor are listed after it. When the cursor is a prefix
+	// item, the prefix itself is listed again.
+	//
+	// Only the first page is affected: the returned Cursor is always meant
+	// to be used without it.
+	CursorInclusive bool
+
+	// AllowStale lets the listing be served from the read replica of
+	// pointerDB, when one is configured, see Config.ListReplicaDatabaseURL.
+	// The replica lags behind the primary, so recently committed objects may
+	// be missing and recently deleted ones may still be listed. Listings
+	// whose result is acted upon, e.g. deleting the listed objects, must not
+	// set it.
+	AllowStale bool
+
+	// Compression, when set, also returns the listed items marshaled as an
+	// ObjectListResponse and compressed with it in ListObjectsResult.Payload,
+	// for clients which accept a compressed listing. Payloads smaller than
+	// Config.ListCompressionMinSize are returned uncompressed, see
+	// ListObjectsResult.PayloadCompression.
+	Compression ListCompression
+
+	// CaseInsensitive lists the objects whose key starts with
+	// EncryptedPrefix regardless of case, in case-insensitive key order, for
+	// gateways which expose case-insensitive keys. It has to be enabled with
+	// Config.CaseInsensitiveListing. The matched prefix may differ from
+	// EncryptedPrefix in case, hence the items and the cursor are the full
+	// keys of the objects rather than relative to the prefix. Keys which
+	// only differ by their case are all listed.
+	//
+	// The satellite only sees encrypted keys, hence the keys are only folded
+	// meaningfully with the null path cipher, where the encrypted key is the
+	// plain key, or when the client encrypts keys which are already folded.
+	// With path encryption, keys which only differ by their case are
+	// unrelated.
+	//
+	// pointerDB has no index of the case folded keys, so every page scans
+	// the whole bucket. The listing fails with rpcstatus.ResourceExhausted
+	// when the bucket has more than Config.ListMaxScannedKeys keys, and it's
+	// refused when that's 0. Descending order, delimiters and inclusive
+	// cursors aren't supported.
+	CaseInsensitive bool
+
+	// ByteBudget, when set, stops the listing once the total encrypted size
+	// of the listed objects reaches it, rather than only after Limit items,
+	// for clients which page by data volume. The object which reaches the
+	// budget is listed, so a page always contains at least one object when
+	// there are any, and the listing continues after it with the returned
+	// Cursor. Prefixes don't count against the budget. The sizes of the
+	// items are populated, as with ListObjectsFieldSize.
+	//
+	// Descending order and case-insensitive listings aren't supported.
+	ByteBudget int64
+}
+
+// ListObjectsItem is an item of ListObjectsResult.
+type ListObjectsItem struct {
+	*pb.ObjectListItem
+
+	// IsPrefix is set when the item is a prefix of nested objects, i.e. with
+	// a non-recursive listing.
+	IsPrefix bool
+
+	// Size is the total encrypted size of the segments of the object. It's
+	// only populated when requested with ListObjectsFieldSize.
+	Size int64
+
+	// RevisionTag is the tag of the upload of the object, see
+	// ObjectRevisionTag. It's only populated when requested with
+	// ListObjectsFieldRevisionTag.
+	RevisionTag string
+}
+
+// ListTruncation is the reason why a listing didn't return all the items.
+type ListTruncation int
+
+const (
+	// ListNotTruncated means that all the items have been listed.
+	ListNotTruncated ListTruncation = iota
+	// ListTruncatedLimit means that the requested limit has been reached.
+	ListTruncatedLimit
+	// ListTruncatedServerCap means that the maximum number of items the
+	// satellite returns at once has been reached, because no limit or a
+	// higher one was requested.
+	ListTruncatedServerCap
+	// ListTruncatedTimeBudget means that the time budget of the listing
+	// has been spent.
+	ListTruncatedTimeBudget
+	// ListTruncatedByteBudget means that the listed objects have reached the
+	// byte budget of the listing, see ListObjectsOptions.ByteBudget.
+	ListTruncatedByteBudget
+	// ListTruncatedScanLimit means that the page has scanned the maximum
+	// number of keys while filtering out items, see
+	// Config.ListMaxScannedKeys.
+	ListTruncatedScanLimit
+)
+
+// String returns a string representation of the truncation reason.
+func (truncation ListTruncation) String() string {
+	switch truncation {
+	case ListNotTruncated:
+		return "not truncated"
+	case ListTruncatedLimit:
+		return "limit"
+	case ListTruncatedServerCap:
+		return "server cap"
+	case ListTruncatedTimeBudget:
+		return "time budget"
+	case ListTruncatedByteBudget:
+		return "byte budget"
+	case ListTruncatedScanLimit:
+		return "scan limit"
+	default:
+		return "unknown"
+	}
+}
+
+// ListObjectsResult is the result of listing the objects of a bucket.
+type ListObjectsResult struct {
+	Items []ListObjectsItem
+	More  bool
+
+	// Truncation tells why the listing has been truncated when More is set.
+	Truncation ListTruncation
+	// Cursor is the cursor for continuing the listing when More is set. It's
+	// the last scanned key, which is after the last returned item when the
+	// items after it have been filtered out, e.g. with a time budget.
+	Cursor []byte
+
+	// Stale is set when the listing has been served from the read replica,
+	// see ListObjectsOptions.AllowStale.
+	Stale bool
+
+	// Payload is set when ListObjectsOptions.Compression is: it contains the
+	// items marshaled as an ObjectListResponse, compressed with
+	// PayloadCompression, see DecodeListPayload.
+	Payload            []byte
+	PayloadCompression ListCompression
+}
+
+// CommonPrefixes returns the encrypted paths of the listed prefix items, like
+// the common prefixes of S3, see ListObjectsOptions.EncryptedDelimiter. They
+// are in the order of the listing.
+func (result ListObjectsResult) CommonPrefixes() [][]byte {
+	var prefixes [][]byte
+	for _, item := range result.Items {
+		if item.IsPrefix {
+			prefixes = append(prefixes, item.EncryptedPath)
+		}
+	}
+	return prefixes
+}
+
+// Objects returns the listed items which aren't prefixes, see CommonPrefixes.
+func (result ListObjectsResult) Objects() []ListObjectsItem {
+	var objects []ListObjectsItem
+	for _, item := range result.Items {
+		if !item.IsPrefix {
+			objects = append(objects, item)
+		}
+	}
+	return objects
+}
+
+// ProtoItems returns the listed items for the protobuf response.
+func (result ListObjectsResult) ProtoItems() []*pb.ObjectListItem {
+	items := make([]*pb.ObjectListItem, len(result.Items))
+	for i, item := range result.Items {
+		items[i] = item.ObjectListItem
+	}
+	return items
+}
+
+// ListObjectsTypedResult is the result of ListObjectsTyped.
+type ListObjectsTypedResult struct {
+	Objects []metabase.ObjectEntry
+	More    bool
+
+	// Cursor is the cursor for continuing the listing when More is set. Like
+	// ListObjectsOptions.EncryptedCursor, it's relative to the listed prefix.
+	Cursor []byte
+
+	// Stale is set when the listing has been served from the read replica,
+	// see ListObjectsOptions.AllowStale.
+	Stale bool
+}
+
+// ListObjectsTyped lists the objects of the bucket according to opts, like
+// ListObjectsWithOptions, but it returns the objects as metabase entries with
+// their full keys rather than protobuf items.
+func (endpoint *Endpoint) ListObjectsTyped(ctx context.Context, projectID uuid.UUID, bucket []byte, opts ListObjectsOptions) (_ ListObjectsTypedResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	result, err := endpoint.ListObjectsWithOptions(ctx, projectID, bucket, opts)
+	if err != nil {
+		return ListObjectsTypedResult{}, err
+	}
+
+	objectPrefix := listedPathPrefix(opts.EncryptedPrefix)
+	typed := ListObjectsTypedResult{
+		Objects: make([]metabase.ObjectEntry, len(result.Items)),
+		More:    result.More,
+		Cursor:  result.Cursor,
+		Stale:   result.Stale,
+	}
+	for i, item := range result.Items {
+		typed.Objects[i] = metabase.ObjectEntry{
+			ObjectKey:          metabase.ObjectKey(append(append([]byte{}, objectPrefix...), item.EncryptedPath...)),
+			IsPrefix:           item.IsPrefix,
+			CreatedAt:          item.CreatedAt,
+			ExpiresAt:          item.ExpiresAt,
+			EncryptedMetadata:  item.EncryptedMetadata,
+			TotalEncryptedSize: item.Size,
+			RevisionTag:        item.RevisionTag,
+		}
+	}
+	return typed, nil
+}
+
+// protoObjectListItem returns the protobuf item of the listed entry, whose
+// path is relative to objectPrefix.
+func protoObjectListItem(entry metabase.ObjectEntry, objectPrefix []byte) *pb.ObjectListItem {
+	return &pb.ObjectListItem{
+		EncryptedPath:     []byte(entry.ObjectKey)[len(objectPrefix):],
+		CreatedAt:         entry.CreatedAt,
+		ExpiresAt:         entry.ExpiresAt,
+		EncryptedMetadata: entry.EncryptedMetadata,
+	}
+}
+
+// ListObjectsWithOptions lists the objects of the bucket according to opts.
+// It doesn't perform any authorization check. A missing bucket fails with
+// storj.ErrBucketNotFound, unless opts.AllowMissingBucket is set. A query
+// exceeding the configured ListQueryTimeout fails with ErrQueryTimeout.
+//
+// The listing doesn't take a snapshot of the bucket: each page reads the
+// committed keys after the cursor, which is the last returned key, also when
+// continuing internally after filtered out items. Hence, while objects are
+// concurrently deleted or uploaded, the objects which aren't modified during
+// the listing are returned exactly once and in order, the modified ones may
+// or may not be returned, and the listing never goes back.
+func (endpoint *Endpoint) ListObjectsWithOptions(ctx context.Context, projectID uuid.UUID, bucket []byte, opts ListObjectsOptions) (result ListObjectsResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if !opts.Compression.IsValid() {
+		return ListObjectsResult{}, rpcstatus.Errorf(rpcstatus.InvalidArgument, "unknown list compression %d", opts.Compression)
+	}
+	if err := endpoint.validateCaseInsensitive(opts); err != nil {
+		return ListObjectsResult{}, err
+	}
+	if opts.ByteBudget < 0 {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.InvalidArgument, "byte budget can't be negative")
+	}
+	if opts.ByteBudget > 0 && (opts.Descending || opts.CaseInsensitive) {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.InvalidArgument, "byte budget isn't supported with descending order or case-insensitive listings")
+	}
+	// the scan of these listings is only bounded by the scan limit.
+	unindexed := !opts.ModifiedAfter.IsZero() || opts.Descending || opts.CaseInsensitive
+	if unindexed && endpoint.config.ListMaxScannedKeys <= 0 {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.FailedPrecondition, "listings without an index are disabled")
+	}
+
+	result, err = endpoint.listObjects(ctx, projectID, bucket, opts)
+	if err != nil || opts.Compression == ListCompressionNone {
+		return result, err
+	}
+
+	if err := endpoint.setListPayload(&result, opts.Compression); err != nil {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	return result, nil
+}
+
+// listObjects lists the objects of the bucket according to opts, see
+// ListObjectsWithOptions.
+func (endpoint *Endpoint) listObjects(ctx context.Context, projectID uuid.UUID, bucket []byte, opts ListObjectsOptions) (result ListObjectsResult, err error) {
+	prefix, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, bucket, opts.EncryptedPrefix)
+	if err != nil {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
+	if !opts.AllowMissingBucket {
+		// TODO this needs to be optimized to avoid DB call on each request
+		_, err = endpoint.metainfo.GetBucket(ctx, bucket, projectID)
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+	}
+
+	limit := opts.Limit
+	limitTruncation := ListTruncatedLimit
+	if limit <= 0 || limit > listLimit {
+		limit = listLimit
+		limitTruncation = ListTruncatedServerCap
+	}
+
+	var deadline time.Time
+	if opts.TimeBudget > 0 {
+		deadline = time.Now().Add(opts.TimeBudget)
+	}
+
+	fields := opts.Fields
+	if fields == 0 {
+		fields = ListObjectsFieldsDefault
+	}
+	if opts.ByteBudget > 0 {
+		// the budget is spent by the sizes of the objects.
+		fields |= ListObjectsFieldSize
+	}
+
+	metaFlags := uint32(meta.None)
+	if fields.Has(ListObjectsFieldCreated) || !opts.ModifiedAfter.IsZero() {
+		metaFlags |= meta.Modified
+	}
+	if fields.Has(ListObjectsFieldExpires) {
+		metaFlags |= meta.Expiration
+	}
+	if fields.Has(ListObjectsFieldMetadata) {
+		metaFlags |= meta.UserDefined
+	}
+	if fields.Has(ListObjectsFieldSize) {
+		// the stream metadata contains the number of segments.
+		metaFlags |= meta.Size | meta.UserDefined
+	}
+	if fields.Has(ListObjectsFieldRevisionTag) {
+		metaFlags |= revisionTagMetaFlags
+	}
+
+	objectPrefix := listedPathPrefix(opts.EncryptedPrefix)
+
+	recursive := opts.Recursive
+	collapse := len(opts.EncryptedDelimiter) > 0
+	var lastPrefix []byte
+	if collapse {
+		recursive = true
+		// the keys of the prefix the cursor points to have been already
+		// listed as part of it.
+		if !opts.CursorInclusive && bytes.HasSuffix(opts.EncryptedCursor, opts.EncryptedDelimiter) {
+			lastPrefix = opts.EncryptedCursor
+		}
+	}
+
+	cursor := string(opts.EncryptedCursor)
+	inclusive := opts.CursorInclusive && cursor != ""
+	var listedBytes int64
+	scanned := 0
+	pages := 0
+	defer func() {
+		endpoint.annotateSpan(ctx, "pages", pages)
+		endpoint.annotateSpan(ctx, "items", len(result.Items))
+	}()
+
+	lister, stale := endpoint.lister(opts)
+	if stale {
+		mon.Meter("list_objects_replica").Mark(1)
+	}
+	result.Stale = stale
+
+	if opts.CaseInsensitive {
+		result, err = endpoint.listObjectsCaseInsensitive(ctx, projectID, bucket, lister, opts, limit, limitTruncation, fields, metaFlags, &pages)
+		result.Stale = stale
+		return result, err
+	}
+
+	if opts.Descending {
+		result, err = endpoint.listObjectsDescending(ctx, projectID, bucket, lister, opts, recursive, limit, limitTruncation, fields, metaFlags, &pages)
+		result.Stale = stale
+		return result, err
+	}
+
+	for {
+		pages++
+		var segments []*pb.ListResponse_Item
+		var more bool
+		err := withQueryTimeout(ctx, endpoint.config.ListQueryTimeout, func(ctx context.Context) (err error) {
+			if inclusive {
+				segments, more, err = lister.ListFrom(ctx, prefix.Encode(), cursor, recursive, limit-int32(len(result.Items)), metaFlags)
+			} else {
+				segments, more, err = lister.List(ctx, prefix.Encode(), cursor, recursive, limit-int32(len(result.Items)), metaFlags)
+			}
+			return err
+		})
+		inclusive = false
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+		scanned += len(segments)
+
+		skipped := false
+		budgetSpent := false
+		for _, segment := range segments {
+			if collapse {
+				if lastPrefix != nil && bytes.HasPrefix([]byte(segment.Path), lastPrefix) {
+					skipped = true
+					continue
+				}
+				if i := bytes.Index([]byte(segment.Path), opts.EncryptedDelimiter); i >= 0 {
+					lastPrefix = []byte(segment.Path[:i+len(opts.EncryptedDelimiter)])
+					result.Items = append(result.Items, ListObjectsItem{
+						ObjectListItem: &pb.ObjectListItem{
+							EncryptedPath: lastPrefix,
+						},
+						IsPrefix: true,
+					})
+					continue
+				}
+			}
+
+			if !opts.ModifiedAfter.IsZero() && segment.Pointer != nil && !segment.Pointer.CreationDate.After(opts.ModifiedAfter) {
+				skipped = true
+				continue
+			}
+
+			item, err := endpoint.listObjectsItem(ctx, projectID, bucket, objectPrefix, fields, segment)
+			if err != nil {
+				return ListObjectsResult{}, err
+			}
+			result.Items = append(result.Items, item)
+
+			if opts.ByteBudget > 0 && !item.IsPrefix {
+				listedBytes += item.Size
+				if listedBytes >= opts.ByteBudget {
+					budgetSpent = true
+					cursor = segment.Path
+					break
+				}
+			}
+		}
+
+		if budgetSpent {
+			// the listing is only done when the object which spent the
+			// budget was the last one.
+			result.More = more || cursor != segments[len(segments)-1].Path
+			if result.More {
+				result.Truncation = ListTruncatedByteBudget
+				result.Cursor = []byte(cursor)
+			}
+			return result, nil
+		}
+
+		result.More = more
+		if !more {
+			return result, nil
+		}
+
+		// some of the items may have been filtered out, continue where the
+		// page ended.
+		cursor = segments[len(segments)-1].Path
+
+		if !skipped || int32(len(result.Items)) >= limit {
+			result.Truncation = limitTruncation
+			result.Cursor = resultCursor(cursor, lastPrefix)
+			return result, nil
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			result.Truncation = ListTruncatedTimeBudget
+			result.Cursor = resultCursor(cursor, lastPrefix)
+			return result, nil
+		}
+		if scanLimitReached(endpoint.config.ListMaxScannedKeys, scanned) {
+			mon.Meter("list_objects_scan_limit").Mark(1)
+			result.Truncation = ListTruncatedScanLimit
+			result.Cursor = resultCursor(cursor, lastPrefix)
+			return result, nil
+		}
+	}
+}
+
+// scanLimitReached returns whether a listing page which has scanned the
+// keys can't scan any further, see Config.ListMaxScannedKeys.
+func scanLimitReached(maxScanned, scanned int) bool {
+	return maxScanned > 0 && scanned >= maxScanned
+}
+
+// errScanLimit is returned by the listings which have to scan all the keys
+// before returning a page, when there are more than maxScanned of them.
+func errScanLimit(maxScanned int) error {
+	mon.Meter("list_objects_scan_limit").Mark(1)
+	return rpcstatus.Errorf(rpcstatus.ResourceExhausted, "the listing would scan more than %d keys, narrow it down with a prefix", maxScanned)
+}
+
+// lister returns the lister of the listing and whether it's the read replica.
+func (endpoint *Endpoint) lister(opts ListObjectsOptions) (_ Lister, stale bool) {
+	if opts.AllowStale {
+		if replica := endpoint.metainfo.ListReplica(); replica != nil {
+			return replica, true
+		}
+	}
+	return endpoint.metainfo, false
+}
+
+// listObjectsDescending lists the objects of the bucket in descending key
+// order, see ListObjectsOptions.Descending.
+func (endpoint *Endpoint) listObjectsDescending(ctx context.Context, projectID uuid.UUID, bucket []byte, lister Lister, opts ListObjectsOptions,
+	recursive bool, limit int32, limitTruncation ListTruncation, fields ListObjectsFields, metaFlags uint32, pages *int) (result ListObjectsResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	prefix, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, bucket, opts.EncryptedPrefix)
+	if err != nil {
+		return ListObjectsResult{}, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+	objectPrefix := listedPathPrefix(opts.EncryptedPrefix)
+	collapse := len(opts.EncryptedDelimiter) > 0
+
+	// listed is a segment or a collapsed prefix found while scanning.
+	type listed struct {
+		segment *pb.ListResponse_Item
+		prefix  []byte
+	}
+
+	// last keeps the last limit items before the cursor in ascending order.
+	var last []listed
+	var lastPrefix []byte
+	startAfter := ""
+	scanned := 0
+scan:
+	for {
+		*pages++
+		var segments []*pb.ListResponse_Item
+		var more bool
+		err := withQueryTimeout(ctx, endpoint.config.ListQueryTimeout, func(ctx context.Context) (err error) {
+			segments, more, err = lister.List(ctx, prefix.Encode(), startAfter, recursive, 0, metaFlags)
+			return err
+		})
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+		scanned += len(segments)
+
+		for _, segment := range segments {
+			if afterCursor(segment.Path, opts) {
+				break scan
+			}
+
+			entry := listed{segment: segment}
+			if collapse {
+				if lastPrefix != nil && bytes.HasPrefix([]byte(segment.Path), lastPrefix) {
+					continue
+				}
+				if i := bytes.Index([]byte(segment.Path), opts.EncryptedDelimiter); i >= 0 {
+					lastPrefix = []byte(segment.Path[:i+len(opts.EncryptedDelimiter)])
+					entry = listed{prefix: lastPrefix}
+				}
+			}
+
+			if entry.prefix == nil && !opts.ModifiedAfter.IsZero() && segment.Pointer != nil && !segment.Pointer.CreationDate.After(opts.ModifiedAfter) {
+				continue
+			}
+
+			last = append(last, entry)
+			if int32(len(last)) > limit {
+				last = last[1:]
+				result.More = true
+			}
+		}
+
+		if !more {
+			break
+		}
+		if scanLimitReached(endpoint.config.ListMaxScannedKeys, scanned) {
+			return ListObjectsResult{}, errScanLimit(endpoint.config.ListMaxScannedKeys)
+		}
+		startAfter = segments[len(segments)-1].Path
+	}
+
+	for i := len(last) - 1; i >= 0; i-- {
+		if last[i].prefix != nil {
+			result.Items = append(result.Items, ListObjectsItem{
+				ObjectListItem: &pb.ObjectListItem{
+					EncryptedPath: last[i].prefix,
+				},
+				IsPrefix: true,
+			})
+			continue
+		}
+
+		item, err := endpoint.listObjectsItem(ctx, projectID, bucket, objectPrefix, fields, last[i].segment)
+		if err != nil {
+			return ListObjectsResult{}, err
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if result.More {
+		result.Truncation = limitTruncation
+		result.Cursor = result.Items[len(result.Items)-1].EncryptedPath
+	}
+	return result, nil
+}
+
+// afterCursor returns whether the key is past the cursor of a descending
+// listing, i.e. whether the scan of the keys before the cursor is done.
+func afterCursor(key string, opts ListObjectsOptions) bool {
+	cursor := string(opts.EncryptedCursor)
+	switch {
+	case cursor == "":
+		return false
+	case !opts.CursorInclusive:
+		return key >= cursor
+	case len(opts.EncryptedDelimiter) > 0 && strings.HasSuffix(cursor, string(opts.EncryptedDelimiter)):
+		// the keys of the prefix are collapsed into the listed prefix item.
+		return key > cursor && !strings.HasPrefix(key, cursor)
+	default:
+		return key > cursor
+	}
+}
+
+// listObjectsItem returns the listed item of the segment with the requested
+// fields populated.
+func (endpoint *Endpoint) listObjectsItem(ctx context.Context, projectID uuid.UUID, bucket, objectPrefix []byte, fields ListObjectsFields, segment *pb.ListResponse_Item) (item ListObjectsItem, err error) {
+	item = ListObjectsItem{
+		ObjectListItem: &pb.ObjectListItem{
+			EncryptedPath: []byte(segment.Path),
+		},
+		IsPrefix: segment.IsPrefix,
+	}
+	if segment.Pointer == nil {
+		return item, nil
+	}
+
+	if fields.Has(ListObjectsFieldCreated) {
+		item.CreatedAt = segment.Pointer.CreationDate
+	}
+	if fields.Has(ListObjectsFieldExpires) {
+		item.ExpiresAt = segment.Pointer.ExpirationDate
+	}
+	if fields.Has(ListObjectsFieldMetadata) {
+		item.EncryptedMetadata = segment.Pointer.Metadata
+	}
+	if fields.Has(ListObjectsFieldSize) {
+		encryptedPath := append(append([]byte{}, objectPrefix...), segment.Path...)
+		item.Size, err = endpoint.objectSize(ctx, projectID, bucket, encryptedPath, segment.Pointer)
+		if err != nil {
+			return ListObjectsItem{}, err
+		}
+	}
+	if fields.Has(ListObjectsFieldRevisionTag) {
+		item.RevisionTag = ObjectRevisionTag(segment.Pointer)
+	}
+	return item, nil
+}
+
+// resultCursor returns the cursor for continuing a listing whose last scanned
+// key is lastKey. The keys of a collapsed prefix continue after the prefix.
+func resultCursor(lastKey string, lastPrefix []byte) []byte {
+	if lastPrefix != nil && bytes.HasPrefix([]byte(lastKey), lastPrefix) {
+		return lastPrefix
+	}
+	return []byte(lastKey)
+}
+
+// listedPathPrefix returns the prefix of the listed paths, which are relative
+// to the listing prefix.
+func listedPathPrefix(encryptedPrefix []byte) []byte {
+	if len(encryptedPrefix) > 0 && encryptedPrefix[len(encryptedPrefix)-1] != storage.Delimiter {
+		return append(append([]byte{}, encryptedPrefix...), storage.Delimiter)
+	}
+	return encryptedPrefix
+}
+
+// objectSize returns the total encrypted size of the segments of the object,
+// where lastSegment is the pointer of its last segment.
+func (endpoint *Endpoint) objectSize(ctx context.Context, projectID uuid.UUID, bucket, encryptedPath []byte, lastSegment *pb.Pointer) (size int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	size = lastSegment.SegmentSize
+
+	streamMeta := &pb.StreamMeta{}
+	err = pb.Unmarshal(lastSegment.Metadata, streamMeta)
+	if err != nil {
+		return 0, err
+	}
+
+	// NumberOfSegments == 0 - pointer with encrypted num of segments, so we
+	// have to list the segments of the object.
+	if streamMeta.NumberOfSegments == 0 {
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: string(bucket),
+			ObjectKey:  metabase.ObjectKey(encryptedPath),
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, segment := range segments {
+			if segment.Index != metabase.LastSegmentIndex {
+				size += segment.Pointer.SegmentSize
+			}
+		}
+		return size, nil
+	}
+
+	var keys []metabase.SegmentKey
+	for index := int64(0); index < streamMeta.NumberOfSegments-1; index++ {
+		location, err := CreatePath(ctx, projectID, index, bucket, encryptedPath)
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, location.Encode())
+	}
+	if len(keys) == 0 {
+		return size, nil
+	}
+
+	pointers, err := endpoint.metainfo.GetItems(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	for _, pointer := range pointers {
+		if pointer != nil {
+			size += pointer.SegmentSize
+		}
+	}
+
+	return size, nil
+}
@@ -0,0 +1,27 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metabase
+
+import "time"
+
+// ObjectEntry is an object, or a prefix of nested objects, as listed in a
+// bucket. The fields other than ObjectKey and IsPrefix are only populated
+// when requested by the listing.
+type ObjectEntry struct {
+	// ObjectKey is the full encrypted key of the object, including the
+	// listed prefix.
+	ObjectKey ObjectKey
+	// IsPrefix is set when the entry is a prefix of nested objects.
+	IsPrefix bool
+
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+	EncryptedMetadata []byte
+
+	// TotalEncryptedSize is the total encrypted size of the segments.
+	TotalEncryptedSize int64
+	// RevisionTag is the tag of the upload of the object, it isn't a hash
+	// of the content.
+	RevisionTag string
+}
@@ -8,6 +8,8 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/spacemonkeygo/monkit/v3"
@@ -27,6 +29,7 @@ import (
 	"storj.io/storj/satellite/accounting"
 	"storj.io/storj/satellite/attribution"
 	"storj.io/storj/satellite/console"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
 	"storj.io/storj/satellite/metainfo/metabase"
 	"storj.io/storj/satellite/metainfo/objectdeletion"
 	"storj.io/storj/satellite/metainfo/piecedeletion"
@@ -35,6 +38,7 @@ import (
 	"storj.io/storj/satellite/overlay"
 	"storj.io/storj/satellite/revocation"
 	"storj.io/storj/satellite/rewards"
+	"storj.io/storj/storage"
 	"storj.io/uplink/private/eestream"
 	"storj.io/uplink/private/storage/meta"
 )
@@ -52,6 +56,33 @@ var (
 	Error = errs.Class("metainfo error")
 	// ErrNodeAlreadyExists pointer already has a piece for a node err.
 	ErrNodeAlreadyExists = errs.Class("metainfo error: node already exists")
+	// ErrLeftoverSegments is returned by a strict delete when some segments of
+	// the object were left in pointerDB.
+	ErrLeftoverSegments = errs.Class("leftover segments")
+	// ErrCorruptObject is returned when deleting a bucket stops at a corrupt
+	// object or when copying a corrupt object.
+	ErrCorruptObject = errs.Class("corrupt object")
+	// ErrSizeMismatch is returned when a delete is refused because the
+	// object doesn't have the expected size.
+	ErrSizeMismatch = errs.Class("object size mismatch")
+	// ErrQueryTimeout is returned when a pointerDB query of a listing or of a
+	// deletion exceeds its configured timeout.
+	ErrQueryTimeout = errs.Class("query timeout")
+	// ErrInlineContentNotFound is returned when the deduplicated content of
+	// an inline segment isn't stored.
+	ErrInlineContentNotFound = errs.Class("inline content not found")
+	// ErrDeletionBacklogFull is returned when a delete is refused because
+	// the deletion backlog is full. The message tells when to retry.
+	ErrDeletionBacklogFull = errs.Class("deletion backlog full")
+	// ErrBucketDeletionStalled is returned when a bucket deletion has been
+	// failed by the watchdog because it made no progress.
+	ErrBucketDeletionStalled = errs.Class("bucket deletion stalled")
+	// ErrTooManyConcurrentLists is returned when a listing is refused because
+	// the project already runs the maximum number of concurrent listings.
+	ErrTooManyConcurrentLists = errs.Class("too many concurrent listings")
+	// ErrObjectNotDownloadable is returned when a deletion requiring the
+	// object to be downloadable is refused.
+	ErrObjectNotDownloadable = errs.Class("object not downloadable")
 )
 
 // APIKeys is api keys store methods used by endpoint.
@@ -65,6 +96,10 @@ type APIKeys interface {
 //
 // architecture: Endpoint
 type Endpoint struct {
+	// pendingObjects is the number of objects whose deletion is in progress.
+	// It's the first field to keep it 64-bit aligned for atomic access.
+	pendingObjects int64
+
 	log                  *zap.Logger
 	metainfo             *Service
 	deletePieces         *piecedeletion.Service
@@ -82,6 +117,13 @@ type Endpoint struct {
 	limiterCache         *lrucache.ExpiringLRU
 	encInlineSegmentSize int64 // max inline segment size + encryption overhead
 	revocations          revocation.DB
+	inlineContents       InlineContentsDB
+	deletedStubs         DeletedObjectStubsDB
+	sharedSegments       SharedSegmentsDB
+	bucketDeletions      *BucketDeletionLimiter
+	deletionSampler      *DeletionSampler
+	deleteWebhooks       *deletewebhook.Service
+	listLimiter          *ProjectConcurrencyLimiter
 	config               Config
 }
 
@@ -90,7 +132,9 @@ func NewEndpoint(log *zap.Logger, metainfo *Service, deletePieces *piecedeletion
 	orders *orders.Service, cache *overlay.Service, attributions attribution.DB,
 	partners *rewards.PartnersService, peerIdentities overlay.PeerIdentities,
 	apiKeys APIKeys, projectUsage *accounting.Service, projects console.Projects,
-	satellite signing.Signer, revocations revocation.DB, config Config) (*Endpoint, error) {
+	satellite signing.Signer, revocations revocation.DB,
+	inlineContents InlineContentsDB, deletedStubs DeletedObjectStubsDB, sharedSegments SharedSegmentsDB,
+	config Config) (*Endpoint, error) {
 	// TODO do something with too many params
 
 	encInlineSegmentSize, err := encryption.CalcEncryptedSize(config.MaxInlineSegmentSize.Int64(), storj.EncryptionParameters{
@@ -125,6 +169,12 @@ func NewEndpoint(log *zap.Logger, metainfo *Service, deletePieces *piecedeletion
 		}),
 		encInlineSegmentSize: encInlineSegmentSize,
 		revocations:          revocations,
+		inlineContents:       inlineContents,
+		deletedStubs:         deletedStubs,
+		sharedSegments:       sharedSegments,
+		bucketDeletions:      NewBucketDeletionLimiter(config.MaxConcurrentBucketDeletions, config.BucketDeletionStallTimeout),
+		deletionSampler:      NewDeletionSampler(config.DeletionSampling),
+		listLimiter:          NewProjectConcurrencyLimiter(config.MaxConcurrentListsPerProject),
 		config:               config,
 	}, nil
 }
@@ -402,12 +452,17 @@ func (endpoint *Endpoint) DeleteBucket(ctx context.Context, req *pb.BucketDelete
 				return nil, rpcstatus.Error(rpcstatus.FailedPrecondition, err.Error())
 			}
 
-			_, deletedObjCount, err := endpoint.deleteBucketNotEmpty(ctx, keyInfo.ProjectID, req.Name)
+			err = endpoint.checkDeletionBacklog()
+			if err != nil {
+				return nil, err
+			}
+
+			result, err := endpoint.DeleteBucketWithOptions(ctx, keyInfo.ProjectID, req.Name, DeleteBucketOptions{})
 			if err != nil {
 				return nil, err
 			}
 
-			return &pb.BucketDeleteResponse{Bucket: convBucket, DeletedObjectsCount: int64(deletedObjCount)}, nil
+			return &pb.BucketDeleteResponse{Bucket: convBucket, DeletedObjectsCount: int64(result.DeletedObjects)}, nil
 		}
 		if storj.ErrBucketNotFound.Has(err) {
 			return &pb.BucketDeleteResponse{Bucket: convBucket}, nil
@@ -418,49 +473,358 @@ func (endpoint *Endpoint) DeleteBucket(ctx context.Context, req *pb.BucketDelete
 	return &pb.BucketDeleteResponse{Bucket: convBucket}, nil
 }
 
+// CorruptObjectPolicy defines what deleting a bucket does with its corrupt
+// objects. An object is corrupt when some of its segments are missing, e.g.
+// an object without a last segment. The missing segments of objects with an
+// encrypted number of segments can't be detected.
+type CorruptObjectPolicy int
+
+const (
+	// CorruptObjectsSkip deletes the segments of a corrupt object until the
+	// first missing one, logs the object and continues. The segments after
+	// the missing one are left to the garbage collector.
+	CorruptObjectsSkip CorruptObjectPolicy = iota
+	// CorruptObjectsAbort stops deleting the bucket at the first corrupt
+	// object. The objects are deleted in pages, so the objects of the page
+	// where a missing middle segment is detected are deleted anyway, while
+	// the objects without a last segment are detected before deleting them.
+	CorruptObjectsAbort
+	// CorruptObjectsClean looks up all the segments of a corrupt object and
+	// deletes them, including the ones after a missing segment.
+	CorruptObjectsClean
+)
+
+// DeleteBucketOptions defines the server-side options for deleting a bucket
+// with its objects.
+type DeleteBucketOptions struct {
+	CorruptObjects CorruptObjectPolicy
+
+	// ExpectEmpty deletes the bucket only when it's empty, without deleting
+	// any object. It fails with FailedPrecondition when the bucket has
+	// objects, also when they are committed while the bucket is deleted,
+	// see Service.DeleteEmptyBucket.
+	ExpectEmpty bool
+	// VerifyEmpty scans the bucket for residual segments after deleting its
+	// objects, e.g. the segments left by CorruptObjectsSkip, and only deletes
+	// the bucket when there's none. Otherwise it fails with
+	// FailedPrecondition and keeps the bucket. It's ignored with ExpectEmpty,
+	// which doesn't delete any object.
+	VerifyEmpty bool
+	// KeepBucket deletes the objects of the bucket, but keeps the bucket,
+	// e.g. for emptying it before using it again. It fails with NotFound when
+	// the bucket doesn't exist and it can't be combined with ExpectEmpty.
+	KeepBucket bool
+	// ContinueOnError keeps deleting the other objects when some objects
+	// fail to be deleted, rather than failing at the first one, e.g. for
+	// scheduled purges which must make progress. The objects which failed
+	// are reported in DeleteBucketResult, the bucket is kept and
+	// FailedPrecondition is returned. The corrupt objects are handled
+	// according to CorruptObjects regardless.
+	ContinueOnError bool
+	// Limit, when set, deletes at most Limit objects, e.g. for deleting a
+	// huge bucket incrementally with several calls. When objects are left,
+	// the bucket is kept and DeleteBucketResult.More is set: the deletion
+	// continues by calling again with DeleteBucketResult.Cursor. The
+	// objects without a last segment are deleted after all the others.
+	Limit int
+	// Cursor is where the deletion continues, see Limit.
+	Cursor DeleteBucketCursor
+}
+
+// DeleteBucketCursor is the position of a bucket deletion limited with
+// DeleteBucketOptions.Limit. The zero value starts from the beginning.
+type DeleteBucketCursor struct {
+	// Zombies is set when only the objects without a last segment are left.
+	Zombies bool
+	// StartAfter is the encrypted path after which the objects are deleted.
+	// The deleted objects aren't listed anymore, so it's only set with
+	// DeleteBucketOptions.ContinueOnError for skipping the objects which
+	// failed to be deleted.
+	StartAfter string
+}
+
+// maxReportedDeleteBucketFailures is the maximum number of objects which
+// failed to be deleted which are reported in DeleteBucketResult.Failures.
+const maxReportedDeleteBucketFailures = 1000
+
+// DeleteBucketFailure is an object which failed to be deleted with
+// DeleteBucketOptions.ContinueOnError.
+type DeleteBucketFailure struct {
+	EncryptedPath []byte
+	Err           error
+}
+
+// DeleteBucketResult is the result of deleting a bucket with its objects.
+type DeleteBucketResult struct {
+	// DeletedObjects is the number of deleted objects which had a last
+	// segment.
+	DeletedObjects int
+	// CorruptObjects is the number of corrupt objects which were found.
+	CorruptObjects int
+	// Verified is set when the bucket was verified to be empty before being
+	// deleted, see DeleteBucketOptions.VerifyEmpty.
+	Verified bool
+	// ResidualSegments is the number of segments found by the verification
+	// after deleting the objects.
+	ResidualSegments int
+	// FailedObjects is the number of objects which failed to be deleted with
+	// DeleteBucketOptions.ContinueOnError.
+	FailedObjects int
+	// Failures are the first maxReportedDeleteBucketFailures objects which
+	// failed to be deleted.
+	Failures []DeleteBucketFailure
+	// More is set when DeleteBucketOptions.Limit was reached before all the
+	// objects were deleted, the bucket is kept. The objects which failed to
+	// be deleted so far are only reported, without any error.
+	More bool
+	// Cursor is where the next call continues the deletion when More is set.
+	Cursor DeleteBucketCursor
+}
+
+// addFailures adds the objects which failed to be deleted.
+func (result *DeleteBucketResult) addFailures(failures []DeleteBucketFailure) {
+	result.FailedObjects += len(failures)
+	for _, failure := range failures {
+		if len(result.Failures) >= maxReportedDeleteBucketFailures {
+			return
+		}
+		result.Failures = append(result.Failures, failure)
+	}
+}
+
+// DeleteBucketWithOptions deletes the bucket with all its objects according
+// to opts. It doesn't perform any authorization check.
+//
+// DeleteBucket calls it with the zero options when the request asks for
+// deleting all the objects. The other options are server-internal: the
+// public request carries none of them.
+func (endpoint *Endpoint) DeleteBucketWithOptions(ctx context.Context, projectID uuid.UUID, bucketName []byte, opts DeleteBucketOptions) (result DeleteBucketResult, err error) {
+	defer mon.Task()(&ctx, projectID.String())(&err)
+
+	if opts.Limit < 0 {
+		return result, rpcstatus.Errorf(rpcstatus.InvalidArgument, "limit must not be negative, got %d", opts.Limit)
+	}
+
+	if opts.KeepBucket {
+		if opts.ExpectEmpty {
+			return result, rpcstatus.Error(rpcstatus.InvalidArgument, "keeping the bucket can't be combined with expecting it empty")
+		}
+
+		_, err = endpoint.metainfo.GetBucket(ctx, bucketName, projectID)
+		if err != nil {
+			if storj.ErrBucketNotFound.Has(err) {
+				return result, rpcstatus.Error(rpcstatus.NotFound, err.Error())
+			}
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+	}
+
+	if opts.ExpectEmpty {
+		err = endpoint.metainfo.DeleteEmptyBucket(ctx, bucketName, projectID)
+		switch {
+		case err == nil:
+			return result, nil
+		case ErrBucketNotEmpty.Has(err):
+			return result, rpcstatus.Wrap(rpcstatus.FailedPrecondition, err)
+		case storj.ErrBucketNotFound.Has(err):
+			return result, rpcstatus.Wrap(rpcstatus.NotFound, err)
+		default:
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+	}
+
+	_, result, err = endpoint.deleteBucketNotEmpty(ctx, projectID, bucketName, opts)
+	return result, err
+}
+
 // deleteBucketNotEmpty deletes all objects that're complete or have first segment.
 // On success, it returns only the number of complete objects that has been deleted
 // since from the user's perspective, objects without last segment are invisible.
-func (endpoint *Endpoint) deleteBucketNotEmpty(ctx context.Context, projectID uuid.UUID, bucketName []byte) ([]byte, int, error) {
-	// Delete all objects that has last segment.
-	deletedCount, err := endpoint.deleteByPrefix(ctx, projectID, bucketName, metabase.LastSegmentIndex)
+func (endpoint *Endpoint) deleteBucketNotEmpty(ctx context.Context, projectID uuid.UUID, bucketName []byte, opts DeleteBucketOptions) (_ []byte, result DeleteBucketResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	ctx, job, err := endpoint.bucketDeletions.Start(ctx, projectID, bucketName)
 	if err != nil {
-		return nil, 0, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		return nil, result, rpcstatus.Error(rpcstatus.Canceled, err.Error())
+	}
+	defer func() {
+		err = job.Finish(err)
+		if ErrBucketDeletionStalled.Has(err) {
+			err = rpcstatus.Wrap(rpcstatus.DeadlineExceeded, err)
+		}
+	}()
+
+	page := &deleteBucketPage{
+		limited:    opts.Limit > 0,
+		remaining:  opts.Limit,
+		startAfter: opts.Cursor.StartAfter,
+	}
+
+	if !opts.Cursor.Zombies {
+		// Delete all objects that has last segment.
+		deletedCount, corruptCount, failures, err := endpoint.deleteByPrefix(ctx, job, projectID, bucketName, metabase.LastSegmentIndex, opts, page)
+		result.DeletedObjects = deletedCount
+		result.CorruptObjects = corruptCount
+		result.addFailures(failures)
+		if err != nil {
+			if ErrCorruptObject.Has(err) {
+				return nil, result, err
+			}
+			return nil, result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		endpoint.annotateSpan(ctx, "deleted_objects", deletedCount)
+
+		if page.more {
+			mon.Meter("delete_bucket_more").Mark(1)
+			result.More = true
+			result.Cursor = DeleteBucketCursor{StartAfter: page.startAfter}
+			return nil, result, nil
+		}
+		page.startAfter = ""
 	}
+
 	// Delete all zombie objects that have first segment.
-	_, err = endpoint.deleteByPrefix(ctx, projectID, bucketName, metabase.FirstSegmentIndex)
-	if err != nil {
-		return nil, deletedCount, rpcstatus.Error(rpcstatus.Internal, err.Error())
+	zombieCount, corruptCount, failures, err := endpoint.deleteByPrefix(ctx, job, projectID, bucketName, metabase.FirstSegmentIndex, opts, page)
+	result.CorruptObjects += corruptCount
+	result.addFailures(failures)
+	endpoint.annotateSpan(ctx, "deleted_zombie_objects", zombieCount)
+	endpoint.annotateSpan(ctx, "corrupt_objects", result.CorruptObjects)
+	if err != nil {
+		if ErrCorruptObject.Has(err) {
+			return nil, result, err
+		}
+		return nil, result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+
+	if page.more {
+		mon.Meter("delete_bucket_more").Mark(1)
+		result.More = true
+		result.Cursor = DeleteBucketCursor{Zombies: true, StartAfter: page.startAfter}
+		return nil, result, nil
+	}
+
+	if result.FailedObjects > 0 {
+		mon.Meter("delete_bucket_failed_objects").Mark(result.FailedObjects)
+		endpoint.log.Warn("some objects failed to be deleted while deleting bucket",
+			zap.Stringer("project_id", projectID),
+			zap.Int("failed_objects", result.FailedObjects),
+		)
+		return nil, result, rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+			ErrBucketNotEmpty.New("%d objects failed to be deleted", result.FailedObjects))
+	}
+
+	if opts.VerifyEmpty {
+		residual, err := endpoint.metainfo.countBucketSegments(ctx, projectID, bucketName)
+		if err != nil {
+			return nil, result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		result.ResidualSegments = residual.Segments
+		if residual.Segments > 0 {
+			mon.Meter("delete_bucket_residual_segments").Mark(1)
+			endpoint.log.Warn("bucket not empty after deleting its objects",
+				zap.Stringer("project_id", projectID),
+				zap.Int("segments", residual.Segments),
+			)
+			return nil, result, rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+				ErrBucketNotEmpty.New("%d segments left after deleting the objects", residual.Segments))
+		}
+		result.Verified = true
+	}
+
+	if opts.KeepBucket {
+		mon.Meter("delete_bucket_kept").Mark(1)
+		return bucketName, result, nil
 	}
 
 	err = endpoint.metainfo.DeleteBucket(ctx, bucketName, projectID)
 	if err != nil {
 		if ErrBucketNotEmpty.Has(err) {
-			return nil, deletedCount, rpcstatus.Error(rpcstatus.FailedPrecondition, "cannot delete the bucket because it's being used by another process")
+			return nil, result, rpcstatus.Error(rpcstatus.FailedPrecondition, "cannot delete the bucket because it's being used by another process")
 		}
 		if storj.ErrBucketNotFound.Has(err) {
-			return bucketName, 0, nil
+			return bucketName, DeleteBucketResult{}, nil
 		}
-		return nil, deletedCount, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		return nil, result, rpcstatus.Error(rpcstatus.Internal, err.Error())
 	}
 
-	return bucketName, deletedCount, nil
+	return bucketName, result, nil
+}
+
+// deleteBucketPage is the position of a bucket deletion, which is limited
+// with DeleteBucketOptions.Limit.
+type deleteBucketPage struct {
+	// limited tells whether remaining is the number of objects which can
+	// still be deleted.
+	limited   bool
+	remaining int
+	// startAfter is the key after which the objects are listed.
+	startAfter string
+	// more is set when objects are left once the limit is reached.
+	more bool
 }
 
-// deleteByPrefix deletes all objects that matches with a prefix.
-func (endpoint *Endpoint) deleteByPrefix(ctx context.Context, projectID uuid.UUID, bucketName []byte, segmentIdx int64) (deletedCount int, err error) {
+// deleteByPrefix deletes all objects that matches with a prefix, from the
+// position of the page and up to its limit. It returns the number of deleted
+// objects, the number of corrupt objects and the objects which failed to be
+// deleted with ContinueOnError. Every deleted batch is reported as progress
+// of the job.
+func (endpoint *Endpoint) deleteByPrefix(ctx context.Context, job *BucketDeletionJob, projectID uuid.UUID, bucketName []byte, segmentIdx int64, opts DeleteBucketOptions, page *deleteBucketPage) (deletedCount, corruptCount int, failures []DeleteBucketFailure, err error) {
 	defer mon.Task()(&ctx)(&err)
 
 	location, err := CreatePath(ctx, projectID, segmentIdx, bucketName, []byte{})
 	if err != nil {
-		return deletedCount, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+		return deletedCount, corruptCount, failures, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
 	}
 
 	prefix := location.Encode()
+	// the objects which failed to be deleted are listed again, hence the
+	// listing continues after them with ContinueOnError.
+	startAfter := page.startAfter
+	defer func() { page.startAfter = startAfter }()
+	// the listed objects of all the pages are annotated once.
+	listedCount := 0
+	defer func() { endpoint.annotateSpan(ctx, "listed_objects", listedCount) }()
 	for {
-		segments, more, err := endpoint.metainfo.List(ctx, prefix, "", true, 0, meta.None)
+		var limit int32
+		if page.limited {
+			if page.remaining <= 0 {
+				// the limit is reached, only whether objects are left is
+				// looked up.
+				var left []*pb.ListResponse_Item
+				err := withQueryTimeout(ctx, endpoint.config.DeleteQueryTimeout, func(ctx context.Context) (err error) {
+					left, _, err = endpoint.metainfo.List(ctx, prefix, startAfter, true, 1, meta.None)
+					return err
+				})
+				if err != nil {
+					return deletedCount, corruptCount, failures, err
+				}
+				page.more = len(left) > 0
+				return deletedCount, corruptCount, failures, nil
+			}
+			// the listing is capped to the lookup limit anyway.
+			if page.remaining < storage.DefaultLookupLimit {
+				limit = int32(page.remaining)
+			}
+		}
+
+		var segments []*pb.ListResponse_Item
+		var more bool
+		err := withQueryTimeout(ctx, endpoint.config.DeleteQueryTimeout, func(ctx context.Context) (err error) {
+			segments, more, err = endpoint.metainfo.List(ctx, prefix, startAfter, true, limit, meta.None)
+			return err
+		})
 		if err != nil {
-			return deletedCount, err
+			return deletedCount, corruptCount, failures, err
+		}
+		page.remaining -= len(segments)
+		if opts.ContinueOnError && len(segments) > 0 {
+			startAfter = segments[len(segments)-1].Path
+		}
+
+		// the objects which are listed without a last segment are corrupt.
+		if segmentIdx != metabase.LastSegmentIndex && len(segments) > 0 && opts.CorruptObjects == CorruptObjectsAbort {
+			return deletedCount, corruptCount + 1, failures, rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+				ErrCorruptObject.New("object without last segment"))
 		}
 
 		deleteReqs := make([]*metabase.ObjectLocation, len(segments))
@@ -471,18 +835,109 @@ func (endpoint *Endpoint) deleteByPrefix(ctx context.Context, projectID uuid.UUI
 				ObjectKey:  metabase.ObjectKey(segment.Path),
 			}
 		}
-		rep, err := endpoint.deleteObjectsPieces(ctx, deleteReqs...)
+		rep, _, err := endpoint.deleteObjectsPieces(ctx, deleteReqs...)
 		if err != nil {
-			return deletedCount, err
+			if !opts.ContinueOnError {
+				return deletedCount, corruptCount, failures, err
+			}
+			// the objects are deleted one at a time for finding the ones
+			// which fail.
+			var failed []DeleteBucketFailure
+			rep, failed = endpoint.deleteObjectsOneByOne(ctx, deleteReqs)
+			failures = append(failures, failed...)
+		}
+		if opts.ContinueOnError {
+			for _, state := range rep.Failed {
+				failures = append(failures, DeleteBucketFailure{
+					EncryptedPath: []byte(state.ObjectKey),
+					Err:           Error.New("object not deleted"),
+				})
+			}
 		}
 
 		deletedCount += len(rep.Deleted)
+		listedCount += len(segments)
+		job.Progress()
+
+		aborted := false
+		for _, state := range rep.Deleted {
+			if !isCorruptObject(state) {
+				continue
+			}
+			corruptCount++
+
+			endpoint.log.Warn("corrupt object found while deleting bucket",
+				zap.Stringer("project_id", projectID),
+				zap.Int("deleted_segments", len(state.OtherSegments)),
+				zap.Bool("has_last_segment", state.LastSegment != nil),
+			)
+
+			switch opts.CorruptObjects {
+			case CorruptObjectsAbort:
+				aborted = true
+			case CorruptObjectsClean:
+				location := state.ObjectLocation
+				if _, _, err := endpoint.deleteLeftoverSegments(ctx, &location); err != nil {
+					if !opts.ContinueOnError {
+						return deletedCount, corruptCount, failures, err
+					}
+					failures = append(failures, DeleteBucketFailure{
+						EncryptedPath: []byte(location.ObjectKey),
+						Err:           err,
+					})
+				}
+			}
+		}
+		if aborted {
+			return deletedCount, corruptCount, failures, rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+				ErrCorruptObject.New("object with missing segments"))
+		}
 
 		if !more {
 			break
 		}
 	}
-	return deletedCount, nil
+	return deletedCount, corruptCount, failures, nil
+}
+
+// deleteObjectsOneByOne deletes the objects one at a time, like
+// deleteObjectsPieces, and returns the report of the deleted ones and the
+// objects which failed to be deleted.
+func (endpoint *Endpoint) deleteObjectsOneByOne(ctx context.Context, reqs []*metabase.ObjectLocation) (report objectdeletion.Report, failures []DeleteBucketFailure) {
+	for _, req := range reqs {
+		rep, _, err := endpoint.deleteObjectsPieces(ctx, req)
+		if err != nil {
+			endpoint.log.Warn("failed to delete object while deleting bucket",
+				zap.Stringer("project_id", req.ProjectID),
+				zap.Error(err),
+			)
+			failures = append(failures, DeleteBucketFailure{
+				EncryptedPath: []byte(req.ObjectKey),
+				Err:           err,
+			})
+			continue
+		}
+		report.Deleted = append(report.Deleted, rep.Deleted...)
+		report.Failed = append(report.Failed, rep.Failed...)
+	}
+	return report, failures
+}
+
+// isCorruptObject returns whether some segments of the deleted object were
+// missing.
+func isCorruptObject(state *objectdeletion.ObjectState) bool {
+	if state.LastSegment == nil {
+		return true
+	}
+
+	streamMeta := &pb.StreamMeta{}
+	if err := pb.Unmarshal(state.LastSegment.Metadata, streamMeta); err != nil {
+		return true
+	}
+
+	// NumberOfSegments == 0 - pointer with encrypted num of segments, the
+	// missing segments aren't known.
+	return streamMeta.NumberOfSegments > 0 && int64(len(state.OtherSegments))+1 < streamMeta.NumberOfSegments
 }
 
 // ListBuckets returns buckets in a project where the bucket name matches the request cursor.
@@ -689,6 +1144,9 @@ func (endpoint *Endpoint) BeginObject(ctx context.Context, req *pb.ObjectBeginRe
 	canDelete := err == nil
 
 	if canDelete {
+		// all the segments of the previous object are deleted, inline ones
+		// included, hence nothing is left behind when the new object has a
+		// different layout, e.g. remote segments instead of an inline one.
 		_, err = endpoint.DeleteObjectPieces(ctx, keyInfo.ProjectID, req.Bucket, req.EncryptedPath)
 		if err != nil {
 			return nil, err
@@ -765,6 +1223,8 @@ func (endpoint *Endpoint) commitObject(ctx context.Context, req *pb.ObjectCommit
 	}
 
 	lastSegmentPointer := pointer
+	var lastSegmentKey metabase.SegmentKey
+	var lastSegmentPointerBytes []byte
 	if pointer == nil {
 		lastSegmentIndex := streamMeta.NumberOfSegments - 1
 		lastSegmentLocation, err := CreatePath(ctx, keyInfo.ProjectID, lastSegmentIndex, streamID.Bucket, streamID.EncryptedPath)
@@ -772,8 +1232,7 @@ func (endpoint *Endpoint) commitObject(ctx context.Context, req *pb.ObjectCommit
 			return nil, rpcstatus.Errorf(rpcstatus.InvalidArgument, "unable to create segment path: %s", err.Error())
 		}
 
-		var lastSegmentPointerBytes []byte
-		lastSegmentKey := lastSegmentLocation.Encode()
+		lastSegmentKey = lastSegmentLocation.Encode()
 		lastSegmentPointerBytes, lastSegmentPointer, err = endpoint.metainfo.GetWithBytes(ctx, lastSegmentKey)
 		if err != nil {
 			endpoint.log.Error("unable to get pointer", zap.ByteString("segmentPath", lastSegmentKey), zap.Error(err))
@@ -782,12 +1241,6 @@ func (endpoint *Endpoint) commitObject(ctx context.Context, req *pb.ObjectCommit
 		if lastSegmentPointer == nil {
 			return nil, rpcstatus.Errorf(rpcstatus.NotFound, "unable to find object: %q/%q", streamID.Bucket, streamID.EncryptedPath)
 		}
-
-		err = endpoint.metainfo.Delete(ctx, lastSegmentKey, lastSegmentPointerBytes)
-		if err != nil {
-			endpoint.log.Error("unable to delete pointer", zap.ByteString("segmentPath", lastSegmentKey), zap.Error(err))
-			return nil, rpcstatus.Error(rpcstatus.Internal, "unable to commit object")
-		}
 	}
 
 	if lastSegmentPointer.Remote == nil {
@@ -797,14 +1250,42 @@ func (endpoint *Endpoint) commitObject(ctx context.Context, req *pb.ObjectCommit
 	lastSegmentPointer.Remote.Redundancy = streamID.Redundancy
 	lastSegmentPointer.Metadata = req.EncryptedMetadata
 
+	// the size is checked before deleting the stored segment, so a rejected
+	// commit keeps it.
+	err = endpoint.validatePointerSize(ctx, lastSegmentPointer)
+	if err != nil {
+		return nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
+	if lastSegmentPointerBytes != nil {
+		err = endpoint.withTransactionRetry(ctx, func(ctx context.Context) error {
+			return endpoint.metainfo.Delete(ctx, lastSegmentKey, lastSegmentPointerBytes)
+		})
+		if err != nil {
+			endpoint.log.Error("unable to delete pointer", zap.ByteString("segmentPath", lastSegmentKey), zap.Error(err))
+			return nil, rpcstatus.Error(rpcstatus.Internal, "unable to commit object")
+		}
+	}
+
 	lastSegmentLocation, err := CreatePath(ctx, keyInfo.ProjectID, int64(metabase.LastSegmentIndex), streamID.Bucket, streamID.EncryptedPath)
 	if err != nil {
 		endpoint.log.Error("unable to create path", zap.Error(err))
 		return nil, rpcstatus.Error(rpcstatus.Internal, "unable to commit object")
 	}
 
-	err = endpoint.metainfo.UnsynchronizedPut(ctx, lastSegmentLocation.Encode(), lastSegmentPointer)
+	stored, err := endpoint.deduplicateInline(ctx, lastSegmentPointer)
+	if err != nil {
+		endpoint.log.Error("unable to deduplicate inline segment", zap.Error(err))
+		return nil, rpcstatus.Error(rpcstatus.Internal, "unable to commit object")
+	}
+
+	err = endpoint.withTransactionRetry(ctx, func(ctx context.Context) error {
+		return endpoint.metainfo.UnsynchronizedPut(ctx, lastSegmentLocation.Encode(), stored)
+	})
 	if err != nil {
+		if stored != lastSegmentPointer {
+			endpoint.releaseInlineContents(ctx, []*pb.Pointer{stored})
+		}
 		endpoint.log.Error("unable to put pointer", zap.Error(err))
 		return nil, rpcstatus.Error(rpcstatus.Internal, "unable to commit object")
 	}
@@ -893,28 +1374,20 @@ func (endpoint *Endpoint) getObject(ctx context.Context, projectID uuid.UUID, bu
 		// when the remote file's last segment is an inline segment, we end up
 		// missing an RS scheme. This loop will search for RS in segments other than the last one.
 
-		index := int64(0)
-		for {
-			location, err := CreatePath(ctx, projectID, index, bucket, encryptedPath)
-			if err != nil {
-				endpoint.log.Error("unable to get pointer path", zap.Error(err))
-				return nil, rpcstatus.Error(rpcstatus.Internal, "unable to get object")
-			}
-
-			pointer, err = endpoint.metainfo.Get(ctx, location.Encode())
-			if err != nil {
-				if storj.ErrObjectNotFound.Has(err) {
-					break
-				}
-
-				endpoint.log.Error("unable to get pointer", zap.Error(err))
-				return nil, rpcstatus.Error(rpcstatus.Internal, "unable to get object")
-			}
-			if pointer.Remote != nil {
-				object.RedundancyScheme = pointer.Remote.Redundancy
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: string(bucket),
+			ObjectKey:  metabase.ObjectKey(encryptedPath),
+		})
+		if err != nil {
+			endpoint.log.Error("unable to get pointers", zap.Error(err))
+			return nil, rpcstatus.Error(rpcstatus.Internal, "unable to get object")
+		}
+		for _, segment := range segments {
+			if segment.Pointer.Remote != nil {
+				object.RedundancyScheme = segment.Pointer.Remote.Redundancy
 				break
 			}
-			index++
 		}
 	}
 
@@ -940,46 +1413,40 @@ func (endpoint *Endpoint) ListObjects(ctx context.Context, req *pb.ObjectListReq
 		return nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
 	}
 
-	// TODO this needs to be optimized to avoid DB call on each request
-	_, err = endpoint.metainfo.GetBucket(ctx, req.Bucket, keyInfo.ProjectID)
-	if err != nil {
-		if storj.ErrBucketNotFound.Has(err) {
-			return nil, rpcstatus.Error(rpcstatus.NotFound, err.Error())
-		}
-
-		endpoint.log.Error("unable to check bucket", zap.Error(err))
-		return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
-	}
-
-	prefix, err := CreatePath(ctx, keyInfo.ProjectID, metabase.LastSegmentIndex, req.Bucket, req.EncryptedPrefix)
-	if err != nil {
-		return nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	release, ok := endpoint.listLimiter.TryAcquire(keyInfo.ProjectID)
+	if !ok {
+		mon.Meter("list_objects_concurrency_exceeded").Mark(1)
+		endpoint.log.Warn("refusing listing: too many concurrent listings",
+			zap.Stringer("Project ID", keyInfo.ProjectID),
+			zap.Int("max", endpoint.config.MaxConcurrentListsPerProject),
+		)
+		return nil, rpcstatus.Wrap(rpcstatus.ResourceExhausted,
+			ErrTooManyConcurrentLists.New("at most %d listings per project", endpoint.config.MaxConcurrentListsPerProject))
 	}
+	defer release()
 
-	metaflags := meta.All
-	// TODO use flags
-	segments, more, err := endpoint.metainfo.List(ctx, prefix.Encode(), string(req.EncryptedCursor), req.Recursive, req.Limit, metaflags)
+	result, err := endpoint.ListObjectsTyped(ctx, keyInfo.ProjectID, req.Bucket, ListObjectsOptions{
+		EncryptedPrefix: req.EncryptedPrefix,
+		EncryptedCursor: req.EncryptedCursor,
+		Recursive:       req.Recursive,
+		Limit:           req.Limit,
+	})
 	if err != nil {
-		return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		return nil, queryError(err)
 	}
 
-	items := make([]*pb.ObjectListItem, len(segments))
-	for i, segment := range segments {
-		items[i] = &pb.ObjectListItem{
-			EncryptedPath: []byte(segment.Path),
-		}
-		if segment.Pointer != nil {
-			items[i].EncryptedMetadata = segment.Pointer.Metadata
-			items[i].CreatedAt = segment.Pointer.CreationDate
-			items[i].ExpiresAt = segment.Pointer.ExpirationDate
-		}
-	}
 	endpoint.log.Info("Object List", zap.Stringer("Project ID", keyInfo.ProjectID), zap.String("operation", "list"), zap.String("type", "object"))
 	mon.Meter("req_list_object").Mark(1)
 
+	objectPrefix := listedPathPrefix(req.EncryptedPrefix)
+	items := make([]*pb.ObjectListItem, len(result.Objects))
+	for i, entry := range result.Objects {
+		items[i] = protoObjectListItem(entry, objectPrefix)
+	}
+
 	return &pb.ObjectListResponse{
 		Items: items,
-		More:  more,
+		More:  result.More,
 	}, nil
 }
 
@@ -1020,7 +1487,12 @@ func (endpoint *Endpoint) BeginDeleteObject(ctx context.Context, req *pb.ObjectB
 	})
 	canList := err == nil
 
-	report, err := endpoint.DeleteObjectPieces(ctx, keyInfo.ProjectID, req.Bucket, req.EncryptedPath)
+	err = endpoint.checkDeletionBacklog()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := endpoint.DeleteObjectPiecesWithOptions(ctx, keyInfo.ProjectID, req.Bucket, req.EncryptedPath, DeleteObjectPiecesOptions{})
 	if err != nil {
 		if !canRead && !canList {
 			// No error info is returned if neither Read, nor List permission is granted
@@ -1028,6 +1500,7 @@ func (endpoint *Endpoint) BeginDeleteObject(ctx context.Context, req *pb.ObjectB
 		}
 		return nil, err
 	}
+	report := result.Report
 
 	var object *pb.Object
 	if canRead || canList {
@@ -1131,17 +1604,30 @@ func (endpoint *Endpoint) GetObjectIPs(ctx context.Context, req *pb.ObjectGetIPs
 		return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
 	}
 
-	numSegmentsKnown := streamMeta.NumberOfSegments > 0
+	// If we do not know the number of segments from the streamMeta, we list
+	// all the segments of the object.
+	if streamMeta.NumberOfSegments == 0 {
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, metabase.ObjectLocation{
+			ProjectID:  keyInfo.ProjectID,
+			BucketName: string(req.Bucket),
+			ObjectKey:  metabase.ObjectKey(req.EncryptedPath),
+		})
+		if err != nil {
+			return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		for _, segment := range segments {
+			if segment.Index != metabase.LastSegmentIndex {
+				addPointerToNodeIDs(segment.Pointer)
+			}
+		}
+	}
+
 	numberOfSegmentsToFetch := int(streamMeta.NumberOfSegments) - 1 // remove last segment since we've already fetch manually
 
-	// If we do not know the number of segments from the streamMeta, we want to
-	// continue to run this loop until it cannot find another segment (the
-	// break condition in the loop).
-	//
 	// If we do know the number of segments, we want to run the loop as long as
 	// the numberOfSegmentsToFetch is > 0 and until we have fetched that many
 	// segments.
-	for i := metabase.FirstSegmentIndex; !numSegmentsKnown || (numSegmentsKnown && numberOfSegmentsToFetch > 0 && i < numberOfSegmentsToFetch); i++ {
+	for i := metabase.FirstSegmentIndex; i < numberOfSegmentsToFetch; i++ {
 		location, err := CreatePath(ctx, keyInfo.ProjectID, int64(i), req.Bucket, req.EncryptedPath)
 		if err != nil {
 			return nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
@@ -1356,6 +1842,11 @@ func (endpoint *Endpoint) commitSegment(ctx context.Context, req *pb.SegmentComm
 		piece.Hash = nil
 	}
 
+	err = endpoint.validatePointerSize(ctx, pointer)
+	if err != nil {
+		return nil, nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
 	segmentSize, totalStored := calculateSpaceUsed(pointer)
 
 	// ToDo: Replace with hash & signature validation
@@ -1434,6 +1925,10 @@ func (endpoint *Endpoint) makeInlineSegment(ctx context.Context, req *pb.Segment
 	if inlineUsed > endpoint.encInlineSegmentSize {
 		return nil, nil, rpcstatus.Error(rpcstatus.InvalidArgument, fmt.Sprintf("inline segment size cannot be larger than %s", endpoint.config.MaxInlineSegmentSize))
 	}
+	// such data would be mistaken for a reference to a deduplicated content.
+	if _, ok := inlineReference(req.EncryptedInlineData); ok {
+		return nil, nil, rpcstatus.Error(rpcstatus.InvalidArgument, "invalid inline segment data")
+	}
 
 	exceeded, limit, err := endpoint.projectUsage.ExceedsStorageUsage(ctx, keyInfo.ProjectID)
 	if err != nil {
@@ -1467,16 +1962,29 @@ func (endpoint *Endpoint) makeInlineSegment(ctx context.Context, req *pb.Segment
 		Metadata:       metadata,
 	}
 
+	err = endpoint.validatePointerSize(ctx, pointer)
+	if err != nil {
+		return nil, nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
 	if savePointer {
 		location, err := CreatePath(ctx, keyInfo.ProjectID, int64(req.Position.Index), streamID.Bucket, streamID.EncryptedPath)
 		if err != nil {
 			return nil, nil, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
 		}
 
-		err = endpoint.metainfo.UnsynchronizedPut(ctx, location.Encode(), pointer)
+		stored, err := endpoint.deduplicateInline(ctx, pointer)
 		if err != nil {
 			return nil, nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
 		}
+
+		err = endpoint.metainfo.UnsynchronizedPut(ctx, location.Encode(), stored)
+		if err != nil {
+			if stored != pointer {
+				endpoint.releaseInlineContents(ctx, []*pb.Pointer{stored})
+			}
+			return nil, nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
 	}
 
 	bucket := metabase.BucketLocation{ProjectID: keyInfo.ProjectID, BucketName: string(streamID.Bucket)}
@@ -1821,7 +2329,13 @@ func (endpoint *Endpoint) DownloadSegment(ctx context.Context, req *pb.SegmentDo
 	}
 
 	if pointer.Type == pb.Pointer_INLINE {
-		err := endpoint.orders.UpdateGetInlineOrder(ctx, bucket, int64(len(pointer.InlineSegment)))
+		err := endpoint.resolveInline(ctx, pointer)
+		if err != nil {
+			endpoint.log.Error("unable to get deduplicated inline segment", zap.Error(err))
+			return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+
+		err = endpoint.orders.UpdateGetInlineOrder(ctx, bucket, int64(len(pointer.InlineSegment)))
 		if err != nil {
 			return nil, rpcstatus.Error(rpcstatus.Internal, err.Error())
 		}
@@ -2015,13 +2529,272 @@ func (endpoint *Endpoint) DeleteObjectPieces(
 ) (report objectdeletion.Report, err error) {
 	defer mon.Task()(&ctx, projectID.String(), bucket, encryptedPath)(&err)
 
+	result, err := endpoint.deleteObjectPieces(ctx, projectID, bucket, encryptedPath, DeleteObjectPiecesOptions{})
+	return result.Report, err
+}
+
+// DeleteObjectPiecesOptions defines the options for DeleteObjectPiecesWithOptions.
+type DeleteObjectPiecesOptions struct {
+	// Strict verifies that no segment of the object is left in pointerDB
+	// after the deletion, which happens with sparse objects. The leftover
+	// segments are deleted too, but ErrLeftoverSegments is returned.
+	Strict bool
+
+	// KeepConcurrentUploads deletes the segments of the object only when
+	// they haven't changed since the deletion read them, so an upload of the
+	// same key which is committed meanwhile isn't deleted, e.g. for pipelines
+	// which delete and upload again the same keys rapidly. The object isn't
+	// deleted when it has been replaced meanwhile, nor when it isn't
+	// committed. Strict is ignored, since the leftover segments may belong to
+	// an upload. See Service.DeleteObjectUnchanged.
+	KeepConcurrentUploads bool
+
+	// ExpectedSize, when set, refuses the deletion with ErrSizeMismatch when
+	// the total encrypted size of the object differs from it, e.g. because
+	// the object has been overwritten since the client looked at it. The
+	// size is the one returned by ListObjectsWithOptions for
+	// ListObjectsFieldSize.
+	//
+	// The size is checked right before the deletion, an overwrite in between
+	// isn't detected.
+	ExpectedSize *int64
+
+	// SegmentTypes, when set, deletes only the segments of the object of
+	// these types and leaves the others in place, e.g. for reclaiming the
+	// space on the storage nodes while moving the data in stages. The object
+	// isn't listed anymore once its last segment is deleted. Strict is
+	// ignored, since segments are meant to be left.
+	SegmentTypes SegmentTypes
+
+	// RequireDownloadable refuses the deletion with ErrObjectNotDownloadable
+	// when some segments of the object don't have enough pieces on healthy
+	// nodes for being reconstructed, e.g. for not deleting the only copy of
+	// data which can't be fetched again during a migration. The nodes are
+	// looked up in the overlay, like GetObjectWithHealth does with
+	// CheckNodes.
+	RequireDownloadable bool
+
+	// KeepStub records a stub of the object once it's deleted: its key,
+	// size, number of segments, and creation and deletion times, e.g. for
+	// keeping track of the objects which existed. The stubs aren't listed
+	// with the objects, they're only available to the administrators.
+	// Deleting only some segments with SegmentTypes doesn't leave a stub.
+	KeepStub bool
+
+	// DryRun doesn't delete anything, it only reports in
+	// DeleteObjectPiecesResult.Plan what would be deleted, e.g. for
+	// validating cleanup scripts before running them. ExpectedSize and
+	// RequireDownloadable are still verified, while KeepStub has no
+	// effect.
+	DryRun bool
+}
+
+// SegmentTypes is a mask of segment types.
+type SegmentTypes int
+
+const (
+	// SegmentTypeInline selects the inline segments.
+	SegmentTypeInline SegmentTypes = 1 << iota
+	// SegmentTypeRemote selects the remote segments.
+	SegmentTypeRemote
+
+	// SegmentTypesAll selects all the segments.
+	SegmentTypesAll = SegmentTypeInline | SegmentTypeRemote
+)
+
+// Has returns whether the type of the pointer is part of the mask.
+func (types SegmentTypes) Has(pointer *pb.Pointer) bool {
+	switch pointer.Type {
+	case pb.Pointer_INLINE:
+		return types&SegmentTypeInline != 0
+	case pb.Pointer_REMOTE:
+		return types&SegmentTypeRemote != 0
+	default:
+		return false
+	}
+}
+
+// DeleteObjectPiecesResult is the result of DeleteObjectPiecesWithOptions.
+type DeleteObjectPiecesResult struct {
+	objectdeletion.Report
+
+	// FailedPieces is the number of pieces which failed to be deleted and
+	// which are retried later.
+	FailedPieces int
+	// UnrecoverablePieces is the number of pieces which failed to be deleted
+	// and which are left to the garbage collector.
+	UnrecoverablePieces int
+	// DeletedSegments is the number of segments deleted with
+	// DeleteObjectPiecesOptions.SegmentTypes. The deletion of whole objects
+	// reports the objects in Deleted instead.
+	DeletedSegments int
+	// Nodes is the number of nodes whose pieces were sent for deletion.
+	Nodes int
+	// SucceededNodes is the number of nodes which deleted their pieces by the
+	// time the success threshold was reached.
+	SucceededNodes int
+	// PendingNodes is the number of nodes which hadn't answered by the time
+	// the success threshold was reached.
+	PendingNodes int
+	// DecommissioningPieces is the number of pieces of decommissioning nodes,
+	// which haven't been sent to them, see Endpoint.DecommissioningNodes.
+	DecommissioningPieces int
+	// DeadlineExceeded tells whether the deadline of the request was reached
+	// before enough nodes deleted their pieces. The object is deleted and
+	// the pieces of the nodes which haven't answered yet are still deleted
+	// in the background.
+	DeadlineExceeded bool
+	// NodeResults are the results of the nodes whose pieces were sent for
+	// deletion, also when the success threshold was reached.
+	NodeResults DeleteObjectResult
+	// Plan is what would be deleted with DeleteObjectPiecesOptions.DryRun.
+	Plan DeletePlan
+}
+
+// DeleteObjectResult are the per-node results of deleting the pieces of an
+// object. The nodes which haven't answered by the time the result was
+// returned, and the ones whose pieces are published or belong to
+// decommissioning nodes, are in none of the lists.
+type DeleteObjectResult struct {
+	// SucceededNodes are the nodes which confirmed the deletion of their
+	// pieces, hence which have freed capacity, e.g. for orchestrating the
+	// next uploads on them.
+	SucceededNodes []storj.NodeID
+	// FailedNodes are the nodes which were contacted, but failed to delete
+	// their pieces.
+	FailedNodes []storj.NodeID
+	// OfflineNodes are the nodes which couldn't be contacted.
+	OfflineNodes []storj.NodeID
+}
+
+// addPieces adds the piece failures of result.
+func (result *DeleteObjectPiecesResult) addPieces(pieces piecedeletion.Result) {
+	nodes := &result.NodeResults
+	nodes.SucceededNodes = appendMissingNodeIDs(nodes.SucceededNodes, pieces.SucceededNodeIDs)
+	nodes.FailedNodes = appendMissingNodeIDs(nodes.FailedNodes, pieces.FailedNodeIDs)
+	nodes.OfflineNodes = appendMissingNodeIDs(nodes.OfflineNodes, pieces.OfflineNodeIDs)
+	result.FailedPieces += pieces.FailedPieces
+	result.UnrecoverablePieces += pieces.UnrecoverablePieces
+	result.Nodes += pieces.Nodes
+	result.SucceededNodes += pieces.SucceededNodes
+	result.PendingNodes += len(pieces.PendingNodeIDs)
+	result.DecommissioningPieces += pieces.DecommissioningPieces
+	result.DeadlineExceeded = result.DeadlineExceeded || pieces.DeadlineExceeded
+}
+
+// containsNodeID returns whether nodes contains node.
+func containsNodeID(nodes []storj.NodeID, node storj.NodeID) bool {
+	for _, other := range nodes {
+		if other == node {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMissingNodeIDs appends the nodes of others which nodes doesn't contain.
+func appendMissingNodeIDs(nodes, others []storj.NodeID) []storj.NodeID {
+	for _, node := range others {
+		if !containsNodeID(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// segmentCount returns the number of segments which have been deleted.
+func (result *DeleteObjectPiecesResult) segmentCount() int {
+	count := result.DeletedSegments
+	for _, state := range result.Deleted {
+		count += len(state.OtherSegments)
+		if state.LastSegment != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteObjectPiecesWithOptions deletes all the pieces of the storage nodes
+// that belongs to the specified object according to opts.
+//
+// Only the pieces whose deletion failed before reaching the success threshold
+// are reported in the result, the nodes which hadn't answered by then are
+// counted in PendingNodes.
+//
+// BeginDeleteObject calls it with the zero options. The options are
+// server-internal: the public request carries none of them.
+func (endpoint *Endpoint) DeleteObjectPiecesWithOptions(
+	ctx context.Context, projectID uuid.UUID, bucket, encryptedPath []byte, opts DeleteObjectPiecesOptions,
+) (result DeleteObjectPiecesResult, err error) {
+	defer mon.Task()(&ctx, projectID.String())(&err)
+
+	return endpoint.deleteObjectPieces(ctx, projectID, bucket, encryptedPath, opts)
+}
+
+func (endpoint *Endpoint) deleteObjectPieces(
+	ctx context.Context, projectID uuid.UUID, bucket, encryptedPath []byte, opts DeleteObjectPiecesOptions,
+) (result DeleteObjectPiecesResult, err error) {
+	start := time.Now()
+	defer func() {
+		if err == nil && !opts.DryRun {
+			latency := time.Since(start)
+			allDeleteLatencies.Observe(result.segmentCount(), latency)
+			endpoint.checkDeleteLatencySLO(latency)
+		}
+	}()
+
 	req := &metabase.ObjectLocation{
 		ProjectID:  projectID,
 		BucketName: string(bucket),
 		ObjectKey:  metabase.ObjectKey(encryptedPath),
 	}
 
-	report, err = endpoint.deleteObjectsPieces(ctx, req)
+	if opts.ExpectedSize != nil {
+		err := endpoint.verifyObjectSize(ctx, projectID, bucket, encryptedPath, *opts.ExpectedSize)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if opts.RequireDownloadable {
+		health, err := endpoint.GetObjectWithHealth(ctx, projectID, bucket, encryptedPath, GetObjectHealthOptions{
+			CheckNodes: true,
+		})
+		if err != nil {
+			return result, err
+		}
+		if !health.Downloadable() {
+			mon.Meter("delete_refused_not_downloadable").Mark(1)
+			return result, rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+				ErrObjectNotDownloadable.New("some segments don't have enough healthy pieces"))
+		}
+	}
+
+	if opts.DryRun {
+		result.Plan, err = endpoint.planObjectDeletion(ctx, req, opts)
+		if err != nil {
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		return result, nil
+	}
+
+	if opts.SegmentTypes != 0 && opts.SegmentTypes != SegmentTypesAll {
+		deleted, pieces, err := endpoint.deleteObjectSegmentsOfTypes(ctx, req, opts.SegmentTypes)
+		result.DeletedSegments = deleted
+		result.addPieces(pieces)
+		if err != nil {
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		return result, nil
+	}
+
+	deletePointers := endpoint.deleteObjects.Delete
+	if opts.KeepConcurrentUploads {
+		deletePointers = endpoint.deleteUnchangedObjects
+	}
+	report, pieces, err := endpoint.deleteObjectsPiecesWith(ctx, deletePointers, req)
+	result.Report = report
+	result.addPieces(pieces)
 	if err != nil {
 		endpoint.log.Error("failed to delete pointers",
 			zap.Stringer("project_id", projectID),
@@ -2031,50 +2804,381 @@ func (endpoint *Endpoint) DeleteObjectPieces(
 		)
 		// Only return an error if we failed to delete the pointers. If we failed
 		// to delete pieces, let garbage collector take care of it.
+		if ErrQueryTimeout.Has(err) {
+			return result, rpcstatus.Wrap(rpcstatus.DeadlineExceeded, err)
+		}
 		if objectdeletion.Error.Has(err) {
-			return report, rpcstatus.Error(rpcstatus.Internal, err.Error())
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
 		}
 	}
 
-	return report, nil
+	if opts.KeepStub {
+		if err := endpoint.addDeletedStubs(ctx, report.Deleted); err != nil {
+			endpoint.log.Error("failed to record the stub of a deleted object",
+				zap.Stringer("project_id", projectID),
+				zap.Error(err),
+			)
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+	}
+
+	if opts.Strict && !opts.KeepConcurrentUploads {
+		leftover, pieces, err := endpoint.deleteLeftoverSegments(ctx, req)
+		result.addPieces(pieces)
+		if err != nil {
+			return result, rpcstatus.Error(rpcstatus.Internal, err.Error())
+		}
+		if leftover > 0 {
+			endpoint.log.Warn("object segments were left after deletion",
+				zap.Stringer("project_id", projectID),
+				zap.Int("segments", leftover),
+			)
+			return result, rpcstatus.Wrap(rpcstatus.Internal,
+				ErrLeftoverSegments.New("%d segments of the object were left after deletion", leftover))
+		}
+	}
+
+	return result, nil
 }
 
-func (endpoint *Endpoint) deleteObjectsPieces(ctx context.Context, reqs ...*metabase.ObjectLocation) (report objectdeletion.Report, err error) {
+// verifyObjectSize returns an error when the object doesn't exist or when its
+// total encrypted size isn't expectedSize.
+func (endpoint *Endpoint) verifyObjectSize(ctx context.Context, projectID uuid.UUID, bucket, encryptedPath []byte, expectedSize int64) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	location, err := CreatePath(ctx, projectID, metabase.LastSegmentIndex, bucket, encryptedPath)
+	if err != nil {
+		return rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
+	pointer, err := endpoint.metainfo.Get(ctx, location.Encode())
+	if err != nil {
+		if storj.ErrObjectNotFound.Has(err) {
+			return rpcstatus.Error(rpcstatus.NotFound, err.Error())
+		}
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+
+	size, err := endpoint.objectSize(ctx, projectID, bucket, encryptedPath, pointer)
+	if err != nil {
+		return rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+
+	if size != expectedSize {
+		mon.Meter("delete_size_mismatch").Mark(1)
+		return rpcstatus.Wrap(rpcstatus.FailedPrecondition,
+			ErrSizeMismatch.New("the object has %d bytes, expected %d", size, expectedSize))
+	}
+	return nil
+}
+
+// deleteObjectSegmentsOfTypes deletes the segments of the object of the types
+// and their pieces. It returns the number of deleted segments and the
+// failures of the piece deletions.
+func (endpoint *Endpoint) deleteObjectSegmentsOfTypes(ctx context.Context, location *metabase.ObjectLocation, types SegmentTypes) (deleted int, pieces piecedeletion.Result, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	endpoint.addPendingObjects(1)
+	defer endpoint.addPendingObjects(-1)
+
 	// We should ignore client cancelling and always try to delete segments.
 	ctx = context2.WithoutCancellation(ctx)
 
-	results, err := endpoint.deleteObjects.Delete(ctx, reqs...)
+	var keys []metabase.SegmentKey
+	var pointers []*pb.Pointer
+	err = withQueryTimeout(ctx, endpoint.config.DeleteQueryTimeout, func(ctx context.Context) error {
+		segments, err := endpoint.metainfo.ListObjectSegments(ctx, *location)
+		if err != nil {
+			return err
+		}
+
+		for _, segment := range segments {
+			if types.Has(segment.Pointer) {
+				keys = append(keys, segment.Key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		keys, pointers, err = endpoint.metainfo.UnsynchronizedGetDel(ctx, keys)
+		return err
+	})
+	if err != nil || len(keys) == 0 {
+		return 0, pieces, err
+	}
+	endpoint.releaseInlineContents(ctx, pointers)
+
+	unshared, keptPieces := endpoint.releaseSharedSegments(ctx, pointers)
+	requests := newPieceDeletionRequests(unshared)
+
+	pieces, err = endpoint.deletePieces.DeleteWithResult(ctx, requests, deleteObjectPiecesSuccessThreshold)
 	if err != nil {
-		return report, err
+		endpoint.log.Error("failed to delete pieces", zap.Error(err))
+		// none of the nodes is known to have deleted its pieces.
+		pieces.Nodes = len(requests)
 	}
+	pieces.UnrecoverablePieces += keptPieces
 
-	var requests []piecedeletion.Request
-	for _, r := range results {
-		pointers := r.DeletedPointers()
-		report.Deleted = append(report.Deleted, r.Deleted...)
-		report.Failed = append(report.Failed, r.Failed...)
+	return len(keys), pieces, nil
+}
 
-		nodesPieces := objectdeletion.GroupPiecesByNodeID(pointers)
+// deleteLeftoverSegments deletes the segments of the object which are still in
+// pointerDB and their pieces. It returns the number of segments found and the
+// failures of the piece deletions.
+func (endpoint *Endpoint) deleteLeftoverSegments(ctx context.Context, location *metabase.ObjectLocation) (_ int, pieces piecedeletion.Result, err error) {
+	defer mon.Task()(&ctx)(&err)
 
-		if len(nodesPieces) == 0 {
-			continue
+	var keys []metabase.SegmentKey
+	var pointers []*pb.Pointer
+	err = withQueryTimeout(ctx, endpoint.config.DeleteQueryTimeout, func(ctx context.Context) (err error) {
+		keys, pointers, err = endpoint.metainfo.DeleteObjectSegments(ctx, *location)
+		return err
+	})
+	if err != nil {
+		return 0, pieces, err
+	}
+	if len(keys) == 0 {
+		return 0, pieces, nil
+	}
+	endpoint.releaseInlineContents(ctx, pointers)
+
+	unshared, keptPieces := endpoint.releaseSharedSegments(ctx, pointers)
+	requests := newPieceDeletionRequests(unshared)
+
+	pieces, err = endpoint.deletePieces.DeleteWithResult(ctx, requests, deleteObjectPiecesSuccessThreshold)
+	if err != nil {
+		endpoint.log.Error("failed to delete pieces", zap.Error(err))
+		// none of the nodes is known to have deleted its pieces.
+		pieces.Nodes = len(requests)
+	}
+	pieces.UnrecoverablePieces += keptPieces
+
+	return len(keys), pieces, nil
+}
+
+// SetDeleteWebhooks makes the deletions of the objects notify the webhooks
+// of their projects through service, see deletewebhook.Service.
+//
+// It must be called before the endpoint serves requests.
+func (endpoint *Endpoint) SetDeleteWebhooks(service *deletewebhook.Service) {
+	endpoint.deleteWebhooks = service
+}
+
+// DecommissioningNodes returns the set of the nodes which are being
+// decommissioned. The piece deletions of these nodes aren't sent to them, but
+// queued until they're flushed or skipped, according to their mode.
+func (endpoint *Endpoint) DecommissioningNodes() *piecedeletion.Decommissioning {
+	return endpoint.deletePieces.Decommissioning()
+}
+
+// DeletionSampler returns the sampler of the deleted objects, whose removal
+// is verified by the deletion verifier.
+func (endpoint *Endpoint) DeletionSampler() *DeletionSampler {
+	return endpoint.deletionSampler
+}
+
+// checkDeletionBacklog returns a ResourceExhausted error, which tells the
+// client when to retry, when the number of pieces waiting for deletion
+// exceeds Config.MaxDeletionBacklog. It slows down the clients rather than
+// queueing their deletions without a bound.
+func (endpoint *Endpoint) checkDeletionBacklog() error {
+	if endpoint.config.MaxDeletionBacklog <= 0 {
+		return nil
+	}
+
+	backlog := endpoint.deletePieces.Backlog()
+	pieces := int64(backlog.RetryPieces) + backlog.PendingPieces
+	if pieces <= int64(endpoint.config.MaxDeletionBacklog) {
+		return nil
+	}
+
+	mon.Meter("delete_backlog_full").Mark(1)
+	endpoint.log.Warn("deletion backlog is full, refusing delete",
+		zap.Int64("pieces", pieces),
+		zap.Int("max", endpoint.config.MaxDeletionBacklog),
+	)
+	return rpcstatus.Wrap(rpcstatus.ResourceExhausted,
+		ErrDeletionBacklogFull.New("retry after %s", endpoint.config.DeletionRetryAfter))
+}
+
+// addPendingObjects adds delta to the number of objects whose deletion is
+// in progress.
+func (endpoint *Endpoint) addPendingObjects(delta int) {
+	pending := atomic.AddInt64(&endpoint.pendingObjects, int64(delta))
+	mon.IntVal("deletion_pending_objects").Observe(pending)
+}
+
+func (endpoint *Endpoint) deleteObjectsPieces(ctx context.Context, reqs ...*metabase.ObjectLocation) (report objectdeletion.Report, pieces piecedeletion.Result, err error) {
+	return endpoint.deleteObjectsPiecesWith(ctx, endpoint.deleteObjects.Delete, reqs...)
+}
+
+// deletePointersFunc deletes the pointers of the objects, like
+// objectdeletion.Service.Delete.
+type deletePointersFunc func(ctx context.Context, reqs ...*metabase.ObjectLocation) ([]objectdeletion.Report, error)
+
+// deleteObjectsPiecesWith is like deleteObjectsPieces, but it deletes the
+// pointers of the objects with deletePointers.
+func (endpoint *Endpoint) deleteObjectsPiecesWith(ctx context.Context, deletePointers deletePointersFunc, reqs ...*metabase.ObjectLocation) (report objectdeletion.Report, pieces piecedeletion.Result, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	endpoint.addPendingObjects(len(reqs))
+	defer endpoint.addPendingObjects(-len(reqs))
+
+	// The deadline is kept for sharing it between deleting the pointers and
+	// deleting the pieces.
+	budget := newDeleteBudget(ctx, endpoint.config.DeleteDeadlineMetabaseShare)
+
+	// We should ignore client cancelling and always try to delete segments.
+	ctx = context2.WithoutCancellation(ctx)
+
+	var results []objectdeletion.Report
+	metabaseCtx, cancel := budget.metabase(ctx)
+	err = endpoint.withTransactionRetry(metabaseCtx, func(ctx context.Context) error {
+		return withQueryTimeout(ctx, endpoint.config.DeleteQueryTimeout, func(ctx context.Context) error {
+			// the batches deleted before a failure aren't deleted again.
+			retried, err := deletePointers(ctx, reqs[handledRequests(results):]...)
+			results = append(results, retried...)
+			return err
+		})
+	})
+	exhausted := errors.Is(metabaseCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	if err != nil {
+		if !exhausted {
+			return report, pieces, err
+		}
+		mon.Meter("delete_deadline_exceeded_pointers").Mark(1)
+		if len(results) == 0 {
+			return report, pieces, ErrQueryTimeout.New("deadline exceeded while deleting the pointers")
 		}
+		// the pieces of the objects deleted so far are deleted with the
+		// time left, the other objects are reported as failed.
+		endpoint.log.Warn("deadline exceeded while deleting the pointers, continuing with the deleted objects",
+			zap.Int("objects", len(reqs)),
+			zap.Error(err),
+		)
+	}
 
-		for node, pieces := range nodesPieces {
-			requests = append(requests, piecedeletion.Request{
-				Node: storj.NodeURL{
-					ID: node,
-				},
-				Pieces: pieces,
+	var pointers []*pb.Pointer
+	for _, r := range results {
+		pointers = append(pointers, r.DeletedPointers()...)
+		report.Deleted = append(report.Deleted, r.Deleted...)
+		report.Failed = append(report.Failed, r.Failed...)
+	}
+	if err != nil {
+		for _, req := range reqs[handledRequests(results):] {
+			report.Failed = append(report.Failed, &objectdeletion.ObjectState{
+				ObjectLocation: *req,
 			})
 		}
 	}
+	deleted := make([]metabase.ObjectLocation, 0, len(report.Deleted))
+	for _, state := range report.Deleted {
+		endpoint.deletionSampler.Add(state.ObjectLocation, append([]*pb.Pointer{state.LastSegment}, state.OtherSegments...))
+		deleted = append(deleted, state.ObjectLocation)
+	}
+	endpoint.notifyDeleteWebhooks(report.Deleted)
+	endpoint.releaseInlineContents(ctx, pointers)
+	segmentCount := len(pointers)
+	unshared, keptPieces := endpoint.releaseSharedSegments(ctx, pointers)
+	requests := newPieceDeletionRequests(unshared)
 
-	if err := endpoint.deletePieces.Delete(ctx, requests, deleteObjectPiecesSuccessThreshold); err != nil {
+	endpoint.annotateSpan(ctx, "objects", len(reqs))
+	endpoint.annotateSpan(ctx, "deleted_objects", len(report.Deleted))
+	endpoint.annotateSpan(ctx, "failed_objects", len(report.Failed))
+	endpoint.annotateSpan(ctx, "segments", segmentCount)
+	endpoint.annotateSpan(ctx, "nodes", len(requests))
+
+	nodesCtx, cancel := budget.nodes(ctx)
+	defer cancel()
+
+	pieces, err = endpoint.deletePieces.DeleteWithResult(nodesCtx, requests, deleteObjectPiecesSuccessThreshold)
+	if err != nil {
 		endpoint.log.Error("failed to delete pieces", zap.Error(err))
+		// none of the nodes is known to have deleted its pieces.
+		pieces.Nodes = len(requests)
+		pieces.DeadlineExceeded = errors.Is(nodesCtx.Err(), context.DeadlineExceeded)
 	}
+	pieces.UnrecoverablePieces += keptPieces
+	if pieces.DeadlineExceeded {
+		mon.Meter("delete_deadline_exceeded_pieces").Mark(1)
+	}
+
+	return report, pieces, nil
+}
+
+// handledRequests returns the number of requests handled by the reports,
+// which are the reports of the first requests.
+func handledRequests(reports []objectdeletion.Report) int {
+	handled := 0
+	for _, r := range reports {
+		handled += len(r.Deleted) + len(r.Failed)
+	}
+	return handled
+}
 
-	return report, nil
+// newPieceDeletionRequests returns the requests for deleting the pieces of
+// the pointers, one per node, including the expected size of the pieces.
+func newPieceDeletionRequests(pointers []*pb.Pointer) []piecedeletion.Request {
+	sizes := objectdeletion.GroupPieceSizesByNodeID(pointers)
+
+	var requests []piecedeletion.Request
+	for node, pieces := range objectdeletion.GroupPiecesByNodeID(pointers) {
+		requests = append(requests, piecedeletion.Request{
+			Node: storj.NodeURL{
+				ID: node,
+			},
+			Pieces: pieces,
+			Bytes:  sizes[node],
+		})
+	}
+	return requests
+}
+
+// withQueryTimeout calls fn, which queries pointerDB, with a context which
+// times out after timeout, when it's set. When the timeout is exceeded, the
+// error is returned as ErrQueryTimeout.
+func withQueryTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(queryCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+		mon.Meter("pointerdb_query_timeout").Mark(1)
+		return ErrQueryTimeout.Wrap(err)
+	}
+	return err
+}
+
+// queryError converts an error of a pointerDB query or of a listing into an
+// RPC error. Errors which are already RPC errors, e.g. the invalid arguments
+// of a listing, are returned as they are.
+func queryError(err error) error {
+	switch {
+	case rpcstatus.Code(err) != rpcstatus.Unknown:
+		return err
+	case ErrQueryTimeout.Has(err):
+		return rpcstatus.Wrap(rpcstatus.DeadlineExceeded, err)
+	case storj.ErrBucketNotFound.Has(err):
+		return rpcstatus.Wrap(rpcstatus.NotFound, err)
+	}
+	return rpcstatus.Error(rpcstatus.Internal, err.Error())
+}
+
+// annotateSpan adds a count to the span of ctx when detailed tracing is
+// enabled. Only counts are added, never any bucket names or paths.
+func (endpoint *Endpoint) annotateSpan(ctx context.Context, name string, count int) {
+	if !endpoint.config.DetailedTracing {
+		return
+	}
+	if span := monkit.SpanFromCtx(ctx); span != nil {
+		span.Annotate(name, strconv.Itoa(count))
+	}
 }
 
 func (endpoint *Endpoint) redundancyScheme() *pb.RedundancyScheme {
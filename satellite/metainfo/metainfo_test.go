@@ -4,11 +4,16 @@
 package metainfo_test
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,6 +34,7 @@ import (
 	"storj.io/common/testrand"
 	"storj.io/storj/private/testplanet"
 	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
 	"storj.io/storj/satellite/metainfo/metabase"
 	"storj.io/uplink"
 	"storj.io/uplink/private/metainfo"
@@ -960,6 +966,271 @@ func TestInlineSegment(t *testing.T) {
 	})
 }
 
+func TestInlineSegmentDeduplication(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Metainfo.DeduplicateInlineSegments = true
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		const bucketName = "dedup-bucket"
+		err := planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		metainfoClient, err := planet.Uplinks[0].DialMetainfo(ctx, satelliteSys, apiKey)
+		require.NoError(t, err)
+		defer ctx.Check(metainfoClient.Close)
+
+		data := testrand.Bytes(memory.KiB)
+		hash := sha256.Sum256(data)
+
+		const objectCount = 5
+		for i := 0; i < objectCount; i++ {
+			beginObjectResp, err := metainfoClient.BeginObject(ctx, metainfo.BeginObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte(fmt.Sprintf("object-%d", i)),
+			})
+			require.NoError(t, err)
+
+			err = metainfoClient.MakeInlineSegment(ctx, metainfo.MakeInlineSegmentParams{
+				StreamID:            beginObjectResp.StreamID,
+				EncryptedInlineData: data,
+			})
+			require.NoError(t, err)
+
+			metadata, err := pb.Marshal(&pb.StreamMeta{
+				NumberOfSegments: 1,
+			})
+			require.NoError(t, err)
+			err = metainfoClient.CommitObject(ctx, metainfo.CommitObjectParams{
+				StreamID:          beginObjectResp.StreamID,
+				EncryptedMetadata: metadata,
+			})
+			require.NoError(t, err)
+		}
+
+		// the pointers only reference the content, which is stored once.
+		var references [][]byte
+		for i := 0; i < objectCount; i++ {
+			pointer, err := satelliteSys.Metainfo.Service.Get(ctx, metabase.ObjectLocation{
+				ProjectID:  projectID,
+				BucketName: bucketName,
+				ObjectKey:  metabase.ObjectKey(fmt.Sprintf("object-%d", i)),
+			}.LastSegment().Encode())
+			require.NoError(t, err)
+			require.Less(t, len(pointer.InlineSegment), len(data))
+			require.Equal(t, int64(len(data)), pointer.SegmentSize)
+			references = append(references, pointer.InlineSegment)
+		}
+		for _, reference := range references {
+			require.Equal(t, references[0], reference)
+		}
+
+		stored, err := satelliteSys.DB.InlineContents().Get(ctx, hash[:])
+		require.NoError(t, err)
+		require.Equal(t, data, stored)
+
+		download := func(i int) []byte {
+			object, err := metainfoClient.GetObject(ctx, metainfo.GetObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte(fmt.Sprintf("object-%d", i)),
+			})
+			require.NoError(t, err)
+
+			info, limits, err := metainfoClient.DownloadSegment(ctx, metainfo.DownloadSegmentParams{
+				StreamID: object.StreamID,
+				Position: storj.SegmentPosition{Index: -1},
+			})
+			require.NoError(t, err)
+			require.Nil(t, limits)
+			return info.EncryptedInlineData
+		}
+		require.Equal(t, data, download(0))
+
+		deleteObject := func(i int) {
+			_, err := metainfoClient.BeginDeleteObject(ctx, metainfo.BeginDeleteObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte(fmt.Sprintf("object-%d", i)),
+			})
+			require.NoError(t, err)
+		}
+
+		// the content is kept as long as an object references it.
+		for i := 0; i < objectCount-1; i++ {
+			deleteObject(i)
+		}
+		require.Equal(t, data, download(objectCount-1))
+
+		deleteObject(objectCount - 1)
+		_, err = satelliteSys.DB.InlineContents().Get(ctx, hash[:])
+		require.Error(t, err)
+	})
+}
+
+func TestDeleteWebhooks(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.DeleteWebhook.Enabled = true
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		satelliteSys := planet.Satellites[0]
+		projectID := planet.Uplinks[0].Projects[0].ID
+
+		var mu sync.Mutex
+		notified := map[string]int{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload deletewebhook.Payload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			notified[string(payload.EncryptedObjectKey)]++
+		}))
+		defer server.Close()
+
+		notifications := func() map[string]int {
+			mu.Lock()
+			defer mu.Unlock()
+			copied := map[string]int{}
+			for key, count := range notified {
+				copied[key] = count
+			}
+			return copied
+		}
+
+		err := satelliteSys.DB.DeleteWebhooks().Set(ctx, deletewebhook.Webhook{
+			ProjectID: projectID,
+			URL:       server.URL,
+			Secret:    testrand.BytesInt(32),
+		})
+		require.NoError(t, err)
+
+		const bucketName = "webhook-bucket"
+		err = planet.Uplinks[0].CreateBucket(ctx, satelliteSys, bucketName)
+		require.NoError(t, err)
+
+		metainfoClient, err := planet.Uplinks[0].DialMetainfo(ctx, satelliteSys, apiKey)
+		require.NoError(t, err)
+		defer ctx.Check(metainfoClient.Close)
+
+		paths := []string{"deleted-0", "deleted-1", "deleted-2"}
+		for _, path := range paths {
+			beginObjectResp, err := metainfoClient.BeginObject(ctx, metainfo.BeginObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte(path),
+			})
+			require.NoError(t, err)
+
+			err = metainfoClient.MakeInlineSegment(ctx, metainfo.MakeInlineSegmentParams{
+				StreamID:            beginObjectResp.StreamID,
+				EncryptedInlineData: testrand.Bytes(memory.KiB),
+			})
+			require.NoError(t, err)
+
+			metadata, err := pb.Marshal(&pb.StreamMeta{
+				NumberOfSegments: 1,
+			})
+			require.NoError(t, err)
+			err = metainfoClient.CommitObject(ctx, metainfo.CommitObjectParams{
+				StreamID:          beginObjectResp.StreamID,
+				EncryptedMetadata: metadata,
+			})
+			require.NoError(t, err)
+		}
+
+		for _, path := range paths {
+			_, err = metainfoClient.BeginDeleteObject(ctx, metainfo.BeginDeleteObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte(path),
+			})
+			require.NoError(t, err)
+		}
+
+		// every deleted object is notified exactly once.
+		expected := map[string]int{}
+		for _, path := range paths {
+			expected[path] = 1
+		}
+		require.Eventually(t, func() bool {
+			return len(notifications()) == len(paths)
+		}, 10*time.Second, 10*time.Millisecond)
+		require.Never(t, func() bool {
+			return len(notifications()) != len(paths)
+		}, 500*time.Millisecond, 10*time.Millisecond)
+		require.Equal(t, expected, notifications())
+	})
+}
+
+func TestDeleteBackpressure(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Metainfo.MaxDeletionBacklog = 2
+				config.Metainfo.DeletionRetryAfter = 5 * time.Second
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		satelliteSys := planet.Satellites[0]
+
+		const bucketName = "backpressure-bucket"
+		err := planet.Uplinks[0].Upload(ctx, satelliteSys, bucketName, "object", testrand.Bytes(memory.KiB))
+		require.NoError(t, err)
+
+		metainfoClient, err := planet.Uplinks[0].DialMetainfo(ctx, satelliteSys, apiKey)
+		require.NoError(t, err)
+		defer ctx.Check(metainfoClient.Close)
+
+		// saturate the backlog with pieces waiting for being retried.
+		nodeID := testrand.NodeID()
+		pieces := []storj.PieceID{testrand.PieceID(), testrand.PieceID(), testrand.PieceID()}
+		retries := satelliteSys.API.Metainfo.PieceDeletion.Retries()
+		require.NoError(t, retries.Add(ctx, nodeID, pieces, 0))
+		// the size of the queue is updated by the retry chore.
+		satelliteSys.API.Metainfo.PieceDeletionRetry.Loop.TriggerWait()
+
+		for i := 0; i < 2; i++ {
+			_, err = metainfoClient.BeginDeleteObject(ctx, metainfo.BeginDeleteObjectParams{
+				Bucket:        []byte(bucketName),
+				EncryptedPath: []byte("object"),
+			})
+			require.Error(t, err)
+			require.True(t, errs2.IsRPC(err, rpcstatus.ResourceExhausted))
+			require.Contains(t, err.Error(), "retry after 5s")
+		}
+
+		_, err = metainfoClient.DeleteBucket(ctx, metainfo.DeleteBucketParams{
+			Name:      []byte(bucketName),
+			DeleteAll: true,
+		})
+		require.True(t, errs2.IsRPC(err, rpcstatus.ResourceExhausted))
+
+		// the refused deletes didn't delete anything.
+		_, err = planet.Uplinks[0].Download(ctx, satelliteSys, bucketName, "object")
+		require.NoError(t, err)
+
+		// deletes are accepted again once the backlog drains.
+		require.NoError(t, retries.Remove(ctx, nodeID, pieces))
+		satelliteSys.API.Metainfo.PieceDeletionRetry.Loop.TriggerWait()
+
+		err = planet.Uplinks[0].DeleteObject(ctx, satelliteSys, bucketName, "object")
+		require.NoError(t, err)
+	})
+}
+
 func TestRemoteSegment(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
@@ -1701,6 +1972,119 @@ func TestCommitObjectMetadataSize(t *testing.T) {
 
 }
 
+// TestCommitObjectPointerSize ensures that CommitObject rejects a pointer which is too large.
+func TestCommitObjectPointerSize(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.MaxPointerSize(1 * memory.KiB),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		apiKey := planet.Uplinks[0].APIKey[planet.Satellites[0].ID()]
+		metainfoService := planet.Satellites[0].Metainfo.Service
+
+		bucket := storj.Bucket{
+			Name:      "initial-bucket",
+			ProjectID: planet.Uplinks[0].Projects[0].ID,
+		}
+		_, err := metainfoService.CreateBucket(ctx, bucket)
+		require.NoError(t, err)
+
+		metainfoClient, err := planet.Uplinks[0].DialMetainfo(ctx, planet.Satellites[0], apiKey)
+		require.NoError(t, err)
+		defer ctx.Check(metainfoClient.Close)
+
+		params := metainfo.BeginObjectParams{
+			Bucket:        []byte(bucket.Name),
+			EncryptedPath: []byte("encrypted-path"),
+			Redundancy: storj.RedundancyScheme{
+				Algorithm:      storj.ReedSolomon,
+				ShareSize:      256,
+				RequiredShares: 1,
+				RepairShares:   1,
+				OptimalShares:  3,
+				TotalShares:    4,
+			},
+			EncryptionParameters: storj.EncryptionParameters{},
+			ExpiresAt:            time.Now().Add(24 * time.Hour),
+		}
+		beginObjectResponse, err := metainfoClient.BeginObject(ctx, params)
+		require.NoError(t, err)
+
+		segmentID, limits, _, err := metainfoClient.BeginSegment(ctx, metainfo.BeginSegmentParams{
+			StreamID: beginObjectResponse.StreamID,
+			Position: storj.SegmentPosition{
+				Index: 0,
+			},
+			MaxOrderLimit: memory.MiB.Int64(),
+		})
+		require.NoError(t, err)
+
+		fullIDMap := make(map[storj.NodeID]*identity.FullIdentity)
+		for _, node := range planet.StorageNodes {
+			fullIDMap[node.ID()] = node.Identity
+		}
+
+		makeResult := func(num int32) *pb.SegmentPieceUploadResult {
+			nodeID := limits[num].Limit.StorageNodeId
+			hash := &pb.PieceHash{
+				PieceId:   limits[num].Limit.PieceId,
+				PieceSize: 1048832,
+				Timestamp: time.Now(),
+			}
+
+			fullID := fullIDMap[nodeID]
+			require.NotNil(t, fullID)
+			signer := signing.SignerFromFullIdentity(fullID)
+			signedHash, err := signing.SignPieceHash(ctx, signer, hash)
+			require.NoError(t, err)
+
+			return &pb.SegmentPieceUploadResult{
+				PieceNum: num,
+				NodeId:   nodeID,
+				Hash:     signedHash,
+			}
+		}
+		err = metainfoClient.CommitSegment(ctx, metainfo.CommitSegmentParams{
+			SegmentID: segmentID,
+
+			SizeEncryptedData: memory.MiB.Int64(),
+			UploadResult: []*pb.SegmentPieceUploadResult{
+				makeResult(0),
+				makeResult(1),
+				makeResult(2),
+			},
+		})
+		require.NoError(t, err)
+
+		// 1.5KiB metadata is allowed by itself, but the pointer is too large.
+		metadata, err := pb.Marshal(&pb.StreamMeta{
+			EncryptedStreamInfo: testrand.Bytes(1536),
+			NumberOfSegments:    1,
+		})
+		require.NoError(t, err)
+		err = metainfoClient.CommitObject(ctx, metainfo.CommitObjectParams{
+			StreamID:          beginObjectResponse.StreamID,
+			EncryptedMetadata: metadata,
+		})
+		require.Error(t, err)
+		assertInvalidArgument(t, err, true)
+
+		// the rejected commit keeps the committed segment, so the object can
+		// be committed with a smaller pointer.
+		metadata, err = pb.Marshal(&pb.StreamMeta{
+			EncryptedStreamInfo: testrand.Bytes(128),
+			NumberOfSegments:    1,
+		})
+		require.NoError(t, err)
+		err = metainfoClient.CommitObject(ctx, metainfo.CommitObjectParams{
+			StreamID:          beginObjectResponse.StreamID,
+			EncryptedMetadata: metadata,
+		})
+		require.NoError(t, err)
+	})
+}
+
 func TestDeleteRightsOnUpload(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 1,
@@ -0,0 +1,296 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/storage"
+)
+
+// nodeObjectsIterateLimit is the number of pointers read at once while
+// looking for the objects of a node.
+const nodeObjectsIterateLimit = 10000
+
+// ErrTooManyNodeObjectsSearches is returned when a search of the objects on a
+// node can't be started because MaxConcurrentSearches are running.
+var ErrTooManyNodeObjectsSearches = errs.Class("too many node objects searches")
+
+// NodeObjectsConfig defines the configuration of the searches of the objects
+// which have pieces on a node.
+type NodeObjectsConfig struct {
+	MaxConcurrentSearches int     `help:"maximum number of concurrent searches" default:"1"`
+	MaxFinishedSearches   int     `help:"maximum number of finished searches kept" default:"10"`
+	MaxTrackedObjects     int     `help:"maximum number of objects kept per search" default:"1000000"`
+	RateLimit             float64 `help:"maximum number of pointers read per second by a search (0 means no limit)" default:"0"`
+}
+
+// NodeObjects is the progress of the search of the objects which have at
+// least one piece on a node.
+type NodeObjects struct {
+	NodeID     storj.NodeID
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Done tells whether the search has finished, the counts are final then.
+	Done bool
+	// Err is the reason why the search failed, if it did.
+	Err error
+	// Pointers is the number of pointers read so far.
+	Pointers int64
+	// Segments is the number of segments with a piece on the node found so
+	// far.
+	Segments int64
+	// Count is the number of objects found so far. It's a lower bound when
+	// Truncated is set.
+	Count int64
+	// Truncated tells whether some objects haven't been tracked because of
+	// MaxTrackedObjects.
+	Truncated bool
+	// Objects are at most the requested number of the objects, in no
+	// particular order.
+	Objects []metabase.ObjectLocation
+}
+
+// NodeObjectsSearches runs the searches of the objects which have at least
+// one piece on a node in the background, e.g. for assessing the impact of
+// removing the node.
+//
+// pointerDB has no index by node, so a search reads all the pointers, which
+// takes as long as a metainfo loop. The objects are kept in memory while
+// reading them, since the segments of an object aren't stored next to each
+// other, up to MaxTrackedObjects of them.
+//
+// architecture: Service
+type NodeObjectsSearches struct {
+	log      *zap.Logger
+	metainfo *Service
+	config   NodeObjectsConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	searches map[storj.NodeID]*nodeObjectsSearch
+}
+
+// NewNodeObjectsSearches creates a new service running the searches of the
+// objects on the nodes.
+func NewNodeObjectsSearches(log *zap.Logger, metainfo *Service, config NodeObjectsConfig) *NodeObjectsSearches {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &NodeObjectsSearches{
+		log:      log,
+		metainfo: metainfo,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+		searches: map[storj.NodeID]*nodeObjectsSearch{},
+	}
+}
+
+// Start starts searching the objects of the node in the background. Nothing
+// is started when the node is already being searched. The previous search of
+// the node is replaced when it's finished.
+func (searches *NodeObjectsSearches) Start(nodeID storj.NodeID) (err error) {
+	searches.mu.Lock()
+	defer searches.mu.Unlock()
+
+	if searches.ctx.Err() != nil {
+		return Error.New("node objects searches are closed")
+	}
+
+	running := 0
+	var finished []*nodeObjectsSearch
+	for _, search := range searches.searches {
+		if !search.isDone() {
+			if search.nodeID == nodeID {
+				return nil
+			}
+			running++
+			continue
+		}
+		if search.nodeID != nodeID {
+			finished = append(finished, search)
+		}
+	}
+	if running >= searches.config.MaxConcurrentSearches {
+		return ErrTooManyNodeObjectsSearches.New("%d searches are running", running)
+	}
+
+	// the oldest finished searches are dropped for making room.
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].startedAt.Before(finished[k].startedAt)
+	})
+	for len(finished) > 0 && len(finished) >= searches.config.MaxFinishedSearches {
+		delete(searches.searches, finished[0].nodeID)
+		finished = finished[1:]
+	}
+
+	search := &nodeObjectsSearch{
+		nodeID:     nodeID,
+		startedAt:  time.Now(),
+		maxObjects: searches.config.MaxTrackedObjects,
+		objects:    map[metabase.ObjectLocation]struct{}{},
+	}
+	searches.searches[nodeID] = search
+
+	searches.wg.Add(1)
+	go func() {
+		defer searches.wg.Done()
+		searches.run(searches.ctx, search)
+	}()
+	return nil
+}
+
+// Get returns the progress of the search of the node with at most limit of
+// its objects. It returns false when the node hasn't been searched.
+func (searches *NodeObjectsSearches) Get(nodeID storj.NodeID, limit int) (NodeObjects, bool) {
+	searches.mu.Lock()
+	search, ok := searches.searches[nodeID]
+	searches.mu.Unlock()
+	if !ok {
+		return NodeObjects{}, false
+	}
+	return search.progress(limit), true
+}
+
+// Close cancels the running searches and waits for them to stop.
+func (searches *NodeObjectsSearches) Close() error {
+	searches.cancel()
+	searches.wg.Wait()
+	return nil
+}
+
+// run reads all the pointers for the search.
+func (searches *NodeObjectsSearches) run(ctx context.Context, search *nodeObjectsSearch) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if searches.config.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(searches.config.RateLimit), 1)
+	}
+
+	err = searches.metainfo.db.IterateWithoutLookupLimit(ctx, storage.IterateOptions{
+		Recurse: true,
+		Limit:   nodeObjectsIterateLimit,
+	}, func(ctx context.Context, it storage.Iterator) error {
+		var item storage.ListItem
+		for it.Next(ctx, &item) {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+
+			location, err := metabase.ParseSegmentKey(metabase.SegmentKey(item.Key))
+			if err != nil {
+				// not a segment key.
+				continue
+			}
+
+			pointer := &pb.Pointer{}
+			if err := pb.Unmarshal(item.Value, pointer); err != nil {
+				return err
+			}
+			search.add(location, pointer)
+		}
+		return nil
+	})
+	if err != nil {
+		err = Error.Wrap(err)
+		searches.log.Error("failed to search the objects of the node", zap.Stringer("Node ID", search.nodeID), zap.Error(err))
+	}
+	search.finish(err)
+}
+
+// nodeObjectsSearch is the state of the search of the objects of a node.
+type nodeObjectsSearch struct {
+	nodeID     storj.NodeID
+	startedAt  time.Time
+	maxObjects int
+
+	mu         sync.Mutex
+	finishedAt time.Time
+	done       bool
+	err        error
+	pointers   int64
+	segments   int64
+	truncated  bool
+	objects    map[metabase.ObjectLocation]struct{}
+}
+
+// add adds the object of the segment when it has a piece on the node.
+func (search *nodeObjectsSearch) add(location metabase.SegmentLocation, pointer *pb.Pointer) {
+	search.mu.Lock()
+	defer search.mu.Unlock()
+
+	search.pointers++
+	for _, piece := range pointer.GetRemote().GetRemotePieces() {
+		if piece.NodeId != search.nodeID {
+			continue
+		}
+		search.segments++
+
+		object := location.Object()
+		if _, ok := search.objects[object]; !ok {
+			if len(search.objects) >= search.maxObjects {
+				search.truncated = true
+				return
+			}
+			search.objects[object] = struct{}{}
+		}
+		return
+	}
+}
+
+// finish marks the search as done.
+func (search *nodeObjectsSearch) finish(err error) {
+	search.mu.Lock()
+	defer search.mu.Unlock()
+
+	search.finishedAt = time.Now()
+	search.done = true
+	search.err = err
+}
+
+// isDone returns whether the search has finished.
+func (search *nodeObjectsSearch) isDone() bool {
+	search.mu.Lock()
+	defer search.mu.Unlock()
+	return search.done
+}
+
+// progress returns the progress of the search with at most limit objects.
+func (search *nodeObjectsSearch) progress(limit int) NodeObjects {
+	search.mu.Lock()
+	defer search.mu.Unlock()
+
+	progress := NodeObjects{
+		NodeID:     search.nodeID,
+		StartedAt:  search.startedAt,
+		FinishedAt: search.finishedAt,
+		Done:       search.done,
+		Err:        search.err,
+		Pointers:   search.pointers,
+		Segments:   search.segments,
+		Count:      int64(len(search.objects)),
+		Truncated:  search.truncated,
+	}
+	for object := range search.objects {
+		if len(progress.Objects) >= limit {
+			break
+		}
+		progress.Objects = append(progress.Objects, object)
+	}
+	return progress
+}
@@ -14,6 +14,7 @@ import (
 	"storj.io/common/pb"
 	"storj.io/common/storj"
 	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/uplink/private/eestream"
 )
 
 var (
@@ -118,6 +119,68 @@ func (service *Service) Delete(ctx context.Context, requests ...*metabase.Object
 func (service *Service) DeletePointers(ctx context.Context, requests []*metabase.ObjectLocation) (_ []*pb.Pointer, _ []metabase.SegmentKey, err error) {
 	defer mon.Task()(&ctx, len(requests))(&err)
 
+	keysToDel, states, err := service.segmentKeysToDelete(ctx, requests)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Delete pointers and fetch the piece ids.
+	//
+	// The deletion may fail in the database for an arbitrary reason.
+	// In that case we return an error and the pointers are left intact.
+	//
+	// The deletion may succeed in the database, but the connection may drop
+	// while the database is sending a response -- in that case we won't send
+	// the piecedeletion requests and and let garbage collection clean up those
+	// pieces.
+	keys, pointers, err := service.pointers.UnsynchronizedGetDel(ctx, keysToDel)
+	if err != nil {
+		return nil, nil, Error.Wrap(err)
+	}
+
+	// if object is missing, we can consider it as a successful delete
+	objectMissingKeys := service.extractSegmentKeysForMissingObjects(ctx, states)
+	for _, p := range objectMissingKeys {
+		keys = append(keys, p)
+		pointers = append(pointers, nil)
+	}
+
+	return pointers, keys, nil
+}
+
+// FindPointers returns the pointers and their keys that DeletePointers would
+// delete, without deleting them. The missing objects aren't returned.
+func (service *Service) FindPointers(ctx context.Context, requests []*metabase.ObjectLocation) (pointers []*pb.Pointer, keys []metabase.SegmentKey, err error) {
+	defer mon.Task()(&ctx, len(requests))(&err)
+
+	keysToGet, _, err := service.segmentKeysToDelete(ctx, requests)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(keysToGet) == 0 {
+		return nil, nil, nil
+	}
+
+	found, err := service.pointers.GetItems(ctx, keysToGet)
+	if err != nil {
+		return nil, nil, Error.Wrap(err)
+	}
+
+	for i, pointer := range found {
+		if pointer == nil {
+			continue
+		}
+		pointers = append(pointers, pointer)
+		keys = append(keys, keysToGet[i])
+	}
+	return pointers, keys, nil
+}
+
+// segmentKeysToDelete returns the keys of the segments of the objects to
+// delete, and the states of the objects.
+func (service *Service) segmentKeysToDelete(ctx context.Context, requests []*metabase.ObjectLocation) (_ []metabase.SegmentKey, _ map[metabase.ObjectLocation]*ObjectState, err error) {
+	defer mon.Task()(&ctx)(&err)
+
 	// get first and last segment to determine the object state
 	lastAndFirstSegmentsKey := []metabase.SegmentKey{}
 	for _, req := range requests {
@@ -149,28 +212,7 @@ func (service *Service) DeletePointers(ctx context.Context, requests []*metabase
 	}
 	keysToDel = append(keysToDel, zombieKeys...)
 
-	// Delete pointers and fetch the piece ids.
-	//
-	// The deletion may fail in the database for an arbitrary reason.
-	// In that case we return an error and the pointers are left intact.
-	//
-	// The deletion may succeed in the database, but the connection may drop
-	// while the database is sending a response -- in that case we won't send
-	// the piecedeletion requests and and let garbage collection clean up those
-	// pieces.
-	keys, pointers, err := service.pointers.UnsynchronizedGetDel(ctx, keysToDel)
-	if err != nil {
-		return nil, nil, Error.Wrap(err)
-	}
-
-	// if object is missing, we can consider it as a successful delete
-	objectMissingKeys := service.extractSegmentKeysForMissingObjects(ctx, states)
-	for _, p := range objectMissingKeys {
-		keys = append(keys, p)
-		pointers = append(pointers, nil)
-	}
-
-	return pointers, keys, nil
+	return keysToDel, states, nil
 }
 
 // GroupPiecesByNodeID returns a map that contains pieces with node id as the key.
@@ -195,6 +237,32 @@ func GroupPiecesByNodeID(pointers []*pb.Pointer) map[storj.NodeID][]storj.PieceI
 	return piecesToDelete
 }
 
+// GroupPieceSizesByNodeID returns a map that contains the expected total size
+// of the pieces with node id as the key. The pointers with an invalid
+// redundancy scheme are skipped.
+func GroupPieceSizesByNodeID(pointers []*pb.Pointer) map[storj.NodeID]int64 {
+	sizes := map[storj.NodeID]int64{}
+	for _, p := range pointers {
+		if p == nil {
+			continue
+		}
+		if p.Type != pb.Pointer_REMOTE {
+			continue
+		}
+
+		redundancy, err := eestream.NewRedundancyStrategyFromProto(p.GetRemote().GetRedundancy())
+		if err != nil {
+			continue
+		}
+		pieceSize := eestream.CalcPieceSize(p.GetSegmentSize(), redundancy)
+		for _, piece := range p.GetRemote().GetRemotePieces() {
+			sizes[piece.NodeId] += pieceSize
+		}
+	}
+
+	return sizes
+}
+
 // generateSegmentKeysForCompleteObjects collects segment keys for objects that has last segment found in pointerDB.
 func (service *Service) generateSegmentKeysForCompleteObjects(ctx context.Context, states map[metabase.ObjectLocation]*ObjectState) (_ []metabase.SegmentKey, err error) {
 	defer mon.Task()(&ctx)(&err)
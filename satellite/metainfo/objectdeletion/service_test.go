@@ -14,6 +14,7 @@ import (
 	"go.uber.org/zap/zaptest"
 
 	"storj.io/common/pb"
+	"storj.io/common/storj"
 	"storj.io/common/testcontext"
 	"storj.io/common/testrand"
 	"storj.io/storj/satellite/metainfo/metabase"
@@ -88,6 +89,102 @@ func TestService_Delete_SingleObject(t *testing.T) {
 	}
 }
 
+func TestService_FindPointers(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	item := &metabase.ObjectLocation{
+		ProjectID:  testrand.UUID(),
+		BucketName: "bucketname",
+		ObjectKey:  "encrypted",
+	}
+
+	config := objectdeletion.Config{
+		MaxObjectsPerRequest:     100,
+		ZombieSegmentsPerRequest: 3,
+		MaxConcurrentRequests:    200,
+	}
+
+	for _, segmentType := range []string{"single-segment", "multi-segment", "inline-segment", "mixed-segment", "zombie-segment"} {
+		segmentType := segmentType
+		t.Run(segmentType, func(t *testing.T) {
+			pointerDBMock, err := newPointerDB([]*metabase.ObjectLocation{item}, segmentType, 5, 2, false)
+			require.NoError(t, err)
+
+			service, err := objectdeletion.NewService(zaptest.NewLogger(t), pointerDBMock, config)
+			require.NoError(t, err)
+
+			pointers, keys, err := service.FindPointers(ctx, []*metabase.ObjectLocation{item})
+			require.NoError(t, err)
+			require.Len(t, pointers, len(keys))
+			for _, pointer := range pointers {
+				require.NotNil(t, pointer)
+			}
+
+			// the mock doesn't delete the pointers.
+			_, deletedKeys, err := service.DeletePointers(ctx, []*metabase.ObjectLocation{item})
+			require.NoError(t, err)
+			require.ElementsMatch(t, deletedKeys, keys)
+
+			// the missing objects aren't returned.
+			pointers, keys, err = service.FindPointers(ctx, []*metabase.ObjectLocation{{
+				ProjectID:  testrand.UUID(),
+				BucketName: "object-not-found",
+				ObjectKey:  "object-missing",
+			}})
+			require.NoError(t, err)
+			require.Empty(t, pointers)
+			require.Empty(t, keys)
+		})
+	}
+}
+
+func TestGroupPieceSizesByNodeID(t *testing.T) {
+	node1, node2 := testrand.NodeID(), testrand.NodeID()
+	redundancy := &pb.RedundancyScheme{
+		Type:             pb.RedundancyScheme_RS,
+		MinReq:           2,
+		Total:            4,
+		RepairThreshold:  3,
+		SuccessThreshold: 4,
+		ErasureShareSize: 256,
+	}
+
+	pointers := []*pb.Pointer{
+		nil,
+		{Type: pb.Pointer_INLINE, InlineSegment: []byte{1}},
+		{
+			Type:        pb.Pointer_REMOTE,
+			SegmentSize: 1020,
+			Remote: &pb.RemoteSegment{
+				Redundancy: redundancy,
+				RemotePieces: []*pb.RemotePiece{
+					{PieceNum: 0, NodeId: node1},
+					{PieceNum: 1, NodeId: node2},
+				},
+			},
+		},
+		{
+			Type:        pb.Pointer_REMOTE,
+			SegmentSize: 2044,
+			Remote: &pb.RemoteSegment{
+				Redundancy: redundancy,
+				RemotePieces: []*pb.RemotePiece{
+					{PieceNum: 0, NodeId: node1},
+				},
+			},
+		},
+	}
+
+	// the encoded data includes 4 bytes of padding size and it's split in
+	// stripes of 512 bytes, each of them contributing 256 bytes per piece.
+	sizes := objectdeletion.GroupPieceSizesByNodeID(pointers)
+	require.Equal(t, map[storj.NodeID]int64{
+		node1: 512 + 1024,
+		node2: 512,
+	}, sizes)
+}
+
 func TestService_Delete_SingleObject_Failure(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -51,6 +51,15 @@ type Promise interface {
 	Failure()
 }
 
+// OfflinePromise is a Promise which distinguishes the failures of the jobs
+// whose node couldn't be contacted, e.g. because it's offline.
+type OfflinePromise interface {
+	Promise
+	// Offline is called instead of Failure when the node of the job couldn't
+	// be contacted.
+	Offline()
+}
+
 // Combiner combines multiple concurrent deletion requests into batches.
 type Combiner struct {
 	// ctx context to pass down to the handler.
@@ -95,6 +104,12 @@ func (combiner *Combiner) Close() {
 	combiner.workers.Close()
 }
 
+// Wait waits for the workers to finish, e.g. after Close, when the jobs
+// they didn't handle have been failed.
+func (combiner *Combiner) Wait() {
+	combiner.workers.Wait()
+}
+
 // Enqueue adds a deletion job to the queue.
 func (combiner *Combiner) Enqueue(node storj.NodeURL, job Job) {
 	combiner.mu.Lock()
@@ -156,6 +171,25 @@ func (worker *worker) start(combiner *Combiner) {
 	}
 }
 
+// FailOffline fails all the jobs in the queue because their node couldn't be
+// contacted, see OfflinePromise.
+func FailOffline(jobs Queue) {
+	for {
+		list, ok := jobs.PopAll()
+		if !ok {
+			return
+		}
+
+		for _, job := range list {
+			if promise, ok := job.Resolve.(OfflinePromise); ok {
+				promise.Offline()
+			} else {
+				job.Resolve.Failure()
+			}
+		}
+	}
+}
+
 // FailPending fails all the jobs in the queue.
 func FailPending(jobs Queue) {
 	for {
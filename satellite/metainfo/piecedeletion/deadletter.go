@@ -0,0 +1,115 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+)
+
+// deadLetterTimeout is the timeout for recording dead letters without a
+// context, see deadLetterRecorder.
+const deadLetterTimeout = 10 * time.Second
+
+// DeadLetterReason is the reason why the deletion of a piece has been given
+// up.
+type DeadLetterReason int
+
+const (
+	// DeadLetterQueueFull means that the deletion failed and the piece didn't
+	// fit into the buffer of the retry queue.
+	DeadLetterQueueFull DeadLetterReason = 1 + iota
+	// DeadLetterRetriesExhausted means that the deletion failed every time it
+	// has been retried, e.g. because the node is disqualified and gone.
+	DeadLetterRetriesExhausted
+	// DeadLetterDecommissioned means that the node is decommissioned with
+	// DecommissionSkip, so the deletion hasn't been sent.
+	DeadLetterDecommissioned
+	// DeadLetterShutdown means that the deletion was still queued for
+	// retrying when the satellite shut down. It's only found in the records
+	// made before the retry queue was kept in the database.
+	DeadLetterShutdown
+)
+
+// String returns a string representation of the reason.
+func (reason DeadLetterReason) String() string {
+	switch reason {
+	case DeadLetterQueueFull:
+		return "queue full"
+	case DeadLetterRetriesExhausted:
+		return "retries exhausted"
+	case DeadLetterDecommissioned:
+		return "decommissioned"
+	case DeadLetterShutdown:
+		return "shutdown"
+	default:
+		return "unknown"
+	}
+}
+
+// DeadLetter is a piece whose deletion has been given up. The piece is left
+// to the garbage collector, the record is kept for accounting.
+type DeadLetter struct {
+	NodeID    storj.NodeID
+	PieceID   storj.PieceID
+	Reason    DeadLetterReason
+	CreatedAt time.Time
+}
+
+// DeadLetters stores the pieces whose deletion has been given up.
+//
+// architecture: Database
+type DeadLetters interface {
+	// Add records the pieces of the node with the reason. A piece which is
+	// already recorded keeps its first record.
+	Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, reason DeadLetterReason) error
+	// List returns at most limit records of the node, or of every node when
+	// nodeID is zero, the oldest first.
+	List(ctx context.Context, nodeID storj.NodeID, limit int) ([]DeadLetter, error)
+	// DeleteBefore deletes the records created before the time and returns
+	// how many have been deleted.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// giveUp records the pieces of the node, whose deletion has been given up,
+// in the dead-letter store.
+func (service *Service) giveUp(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, reason DeadLetterReason) {
+	if len(pieces) == 0 {
+		return
+	}
+	mon.Meter("deletion_dead_letter_pieces").Mark(len(pieces))
+
+	err := service.deadLetters.Add(ctx, nodeID, pieces, reason)
+	if err != nil {
+		service.log.Error("unable to record the pieces whose deletion has been given up",
+			zap.Stringer("Node ID", nodeID),
+			zap.Int("pieces", len(pieces)),
+			zap.Stringer("reason", reason),
+			zap.Error(err),
+		)
+	}
+}
+
+// giveUpDetached is like giveUp, but for the callers without a context. The
+// pieces are recorded in the background, unless MaxDeadLetterPieces is zero,
+// hence they may be missing from Pending for a moment.
+func (service *Service) giveUpDetached(nodeID storj.NodeID, pieces []storj.PieceID, reason DeadLetterReason) {
+	if len(pieces) == 0 {
+		return
+	}
+	if service.config.MaxDeadLetterPieces == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+		defer cancel()
+
+		service.giveUp(ctx, nodeID, pieces, reason)
+		return
+	}
+	mon.Meter("deletion_dead_letter_pieces").Mark(len(pieces))
+
+	service.deadLetterRecorder.Add(nodeID, pieces, reason)
+}
@@ -0,0 +1,190 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+)
+
+// deadLetterBatchSize is the maximum number of pieces recorded by a single
+// DeadLetters.Add call.
+const deadLetterBatchSize = 1000
+
+// deadLetterGroup are the dead letters which are recorded together.
+type deadLetterGroup struct {
+	node   storj.NodeID
+	reason DeadLetterReason
+}
+
+// deadLetterRecorder buffers the dead letters of the callers without a
+// context, e.g. the promises of the deletion workers, and records them in
+// batches in the background, so the workers don't wait for the database. It
+// also deletes the dead letters older than the retention.
+//
+// The dead letters which don't fit into the buffer are dropped, the pieces are
+// still collected by the garbage collector.
+type deadLetterRecorder struct {
+	log       *zap.Logger
+	store     DeadLetters
+	maxPieces int
+
+	retention       time.Duration
+	cleanupInterval time.Duration
+
+	mu      sync.Mutex
+	pending map[deadLetterGroup][]storj.PieceID
+	pieces  int
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	started bool
+}
+
+// newDeadLetterRecorder creates a recorder buffering at most maxPieces pieces.
+func newDeadLetterRecorder(log *zap.Logger, store DeadLetters, maxPieces int, retention, cleanupInterval time.Duration) *deadLetterRecorder {
+	return &deadLetterRecorder{
+		log:             log,
+		store:           store,
+		maxPieces:       maxPieces,
+		retention:       retention,
+		cleanupInterval: cleanupInterval,
+		pending:         make(map[deadLetterGroup][]storj.PieceID),
+		wake:            make(chan struct{}, 1),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Add buffers the pieces of the node for recording them in the background. It
+// returns false when they don't fit into the buffer and they are dropped.
+func (recorder *deadLetterRecorder) Add(nodeID storj.NodeID, pieces []storj.PieceID, reason DeadLetterReason) bool {
+	if len(pieces) == 0 {
+		return true
+	}
+
+	recorder.mu.Lock()
+	if recorder.pieces+len(pieces) > recorder.maxPieces {
+		recorder.mu.Unlock()
+		mon.Meter("deletion_dead_letter_dropped_pieces").Mark(len(pieces))
+		recorder.log.Warn("dead letter buffer is full, the pieces aren't recorded",
+			zap.Stringer("Node ID", nodeID),
+			zap.Int("pieces", len(pieces)),
+			zap.Stringer("reason", reason),
+		)
+		return false
+	}
+	group := deadLetterGroup{node: nodeID, reason: reason}
+	recorder.pending[group] = append(recorder.pending[group], pieces...)
+	recorder.pieces += len(pieces)
+	recorder.mu.Unlock()
+
+	select {
+	case recorder.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Start records the buffered dead letters and deletes the expired ones in the
+// background until Close is called or ctx is canceled.
+func (recorder *deadLetterRecorder) Start(ctx context.Context) {
+	recorder.started = true
+	go recorder.run(ctx)
+}
+
+// run is the background loop of the recorder.
+func (recorder *deadLetterRecorder) run(ctx context.Context) {
+	defer close(recorder.done)
+
+	var cleanup <-chan time.Time
+	if recorder.retention > 0 && recorder.cleanupInterval > 0 {
+		ticker := time.NewTicker(recorder.cleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case <-recorder.wake:
+			recorder.Flush()
+		case <-cleanup:
+			recorder.DeleteExpired(ctx)
+		case <-recorder.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush records the buffered dead letters.
+func (recorder *deadLetterRecorder) Flush() {
+	recorder.mu.Lock()
+	pending := recorder.pending
+	recorder.pending = make(map[deadLetterGroup][]storj.PieceID)
+	recorder.pieces = 0
+	recorder.mu.Unlock()
+
+	for group, pieces := range pending {
+		for len(pieces) > 0 {
+			batch := pieces
+			if len(batch) > deadLetterBatchSize {
+				batch = batch[:deadLetterBatchSize]
+			}
+			pieces = pieces[len(batch):]
+
+			recorder.record(group, batch)
+		}
+	}
+}
+
+// record records a batch of dead letters of the group.
+func (recorder *deadLetterRecorder) record(group deadLetterGroup, pieces []storj.PieceID) {
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+
+	err := recorder.store.Add(ctx, group.node, pieces, group.reason)
+	if err != nil {
+		recorder.log.Error("unable to record the pieces whose deletion has been given up",
+			zap.Stringer("Node ID", group.node),
+			zap.Int("pieces", len(pieces)),
+			zap.Stringer("reason", group.reason),
+			zap.Error(err),
+		)
+	}
+}
+
+// DeleteExpired deletes the dead letters older than the retention.
+func (recorder *deadLetterRecorder) DeleteExpired(ctx context.Context) {
+	var err error
+	defer mon.Task()(&ctx)(&err)
+
+	if recorder.retention <= 0 {
+		return
+	}
+
+	var deleted int64
+	deleted, err = recorder.store.DeleteBefore(ctx, time.Now().Add(-recorder.retention))
+	if err != nil {
+		recorder.log.Error("unable to delete the expired dead letters", zap.Error(err))
+		return
+	}
+	mon.Meter("deletion_dead_letter_expired").Mark64(deleted)
+}
+
+// Close stops the background loop and records the buffered dead letters.
+func (recorder *deadLetterRecorder) Close() {
+	if recorder.started {
+		close(recorder.stop)
+		<-recorder.done
+	}
+	recorder.Flush()
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"sync"
+
+	"storj.io/common/storj"
+)
+
+// DecommissionMode defines how the deletions of the pieces of a
+// decommissioning node are handled.
+type DecommissionMode int
+
+const (
+	// DecommissionQueue adds the pieces to the retry queue without
+	// contacting the node. They can be sent later with FlushNode, e.g. before
+	// the node is shut down.
+	DecommissionQueue DecommissionMode = iota
+	// DecommissionSkip drops the pieces without contacting the node, since
+	// its data is going away anyway. They are recorded as dead letters.
+	DecommissionSkip
+)
+
+// String returns a string representation of the decommission mode.
+func (mode DecommissionMode) String() string {
+	switch mode {
+	case DecommissionQueue:
+		return "queue"
+	case DecommissionSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// Decommissioning is the set of the nodes which are being decommissioned,
+// whose piece deletions aren't sent to them.
+//
+// The set is kept in memory, it's set up by the operator.
+type Decommissioning struct {
+	mu    sync.Mutex
+	nodes map[storj.NodeID]DecommissionMode
+}
+
+// NewDecommissioning returns an empty set of decommissioning nodes.
+func NewDecommissioning() *Decommissioning {
+	return &Decommissioning{
+		nodes: map[storj.NodeID]DecommissionMode{},
+	}
+}
+
+// Add adds the nodes to the set with the mode, replacing their previous mode.
+func (decommissioning *Decommissioning) Add(mode DecommissionMode, nodes ...storj.NodeID) {
+	decommissioning.mu.Lock()
+	defer decommissioning.mu.Unlock()
+
+	for _, node := range nodes {
+		decommissioning.nodes[node] = mode
+	}
+}
+
+// Remove removes the nodes from the set, their deletions are sent to them
+// again.
+func (decommissioning *Decommissioning) Remove(nodes ...storj.NodeID) {
+	decommissioning.mu.Lock()
+	defer decommissioning.mu.Unlock()
+
+	for _, node := range nodes {
+		delete(decommissioning.nodes, node)
+	}
+}
+
+// Mode returns the mode of the node and whether it's being decommissioned.
+func (decommissioning *Decommissioning) Mode(node storj.NodeID) (DecommissionMode, bool) {
+	decommissioning.mu.Lock()
+	defer decommissioning.mu.Unlock()
+
+	mode, ok := decommissioning.nodes[node]
+	return mode, ok
+}
+
+// Nodes returns the decommissioning nodes with their mode.
+func (decommissioning *Decommissioning) Nodes() map[storj.NodeID]DecommissionMode {
+	decommissioning.mu.Lock()
+	defer decommissioning.mu.Unlock()
+
+	nodes := make(map[storj.NodeID]DecommissionMode, len(decommissioning.nodes))
+	for node, mode := range decommissioning.nodes {
+		nodes[node] = mode
+	}
+	return nodes
+}
+
+// withoutDecommissioning handles the requests of the decommissioning nodes
+// according to their mode and returns the other requests, which are sent to
+// the nodes. It returns the number of handled pieces and of the pieces which
+// didn't fit into the buffer of the retry queue. The skipped pieces and the ones which
+// didn't fit are recorded as dead letters.
+func (service *Service) withoutDecommissioning(ctx context.Context, requests []Request) (_ []Request, handled, unrecoverable int) {
+	var kept []Request
+	for _, req := range requests {
+		mode, ok := service.decommissioning.Mode(req.Node.ID)
+		if !ok {
+			kept = append(kept, req)
+			continue
+		}
+
+		handled += len(req.Pieces)
+		if mode == DecommissionSkip {
+			mon.Meter("deletion_decommissioning_skipped_pieces").Mark(len(req.Pieces))
+			service.giveUp(ctx, req.Node.ID, req.Pieces, DeadLetterDecommissioned)
+			continue
+		}
+		if !service.retryRecorder.Add(req.Node.ID, req.Pieces, req.Bytes) {
+			unrecoverable += len(req.Pieces)
+			service.giveUp(ctx, req.Node.ID, req.Pieces, DeadLetterQueueFull)
+		}
+		mon.Meter("deletion_decommissioning_queued_pieces").Mark(len(req.Pieces))
+	}
+	return kept, handled, unrecoverable
+}
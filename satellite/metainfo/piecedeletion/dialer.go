@@ -15,7 +15,9 @@ import (
 	"storj.io/common/errs2"
 	"storj.io/common/pb"
 	"storj.io/common/rpc"
+	"storj.io/common/rpc/rpcstatus"
 	"storj.io/common/storj"
+	"storj.io/common/sync2"
 )
 
 // Dialer implements dialing piecestores and sending delete requests with batching and redial threshold.
@@ -27,12 +29,19 @@ type Dialer struct {
 	failThreshold    time.Duration
 	piecesPerRequest int
 
+	transientRetries int
+	transientBackoff time.Duration
+
 	mu         sync.RWMutex
 	dialFailed map[storj.NodeID]time.Time
 }
 
 // NewDialer returns a new Dialer.
-func NewDialer(log *zap.Logger, dialer rpc.Dialer, requestTimeout, failThreshold time.Duration, piecesPerRequest int) *Dialer {
+//
+// A request which fails with a transient error is retried transientRetries
+// times, waiting transientBackoff before the first retry and doubling it for
+// every further one.
+func NewDialer(log *zap.Logger, dialer rpc.Dialer, requestTimeout, failThreshold time.Duration, piecesPerRequest int, transientRetries int, transientBackoff time.Duration) *Dialer {
 	return &Dialer{
 		log:    log,
 		dialer: dialer,
@@ -41,6 +50,9 @@ func NewDialer(log *zap.Logger, dialer rpc.Dialer, requestTimeout, failThreshold
 		failThreshold:    failThreshold,
 		piecesPerRequest: piecesPerRequest,
 
+		transientRetries: transientRetries,
+		transientBackoff: transientBackoff,
+
 		dialFailed: map[storj.NodeID]time.Time{},
 	}
 }
@@ -51,6 +63,7 @@ func (dialer *Dialer) Handle(ctx context.Context, node storj.NodeURL, queue Queu
 	defer FailPending(queue)
 
 	if dialer.recentlyFailed(ctx, node) {
+		FailOffline(queue)
 		return
 	}
 
@@ -58,6 +71,7 @@ func (dialer *Dialer) Handle(ctx context.Context, node storj.NodeURL, queue Queu
 	if err != nil {
 		dialer.log.Debug("failed to dial", zap.Stringer("id", node.ID), zap.Error(err))
 		dialer.markFailed(ctx, node)
+		FailOffline(queue)
 		return
 	}
 	defer func() {
@@ -89,11 +103,7 @@ func (dialer *Dialer) Handle(ctx context.Context, node storj.NodeURL, queue Queu
 
 			jobs = rest
 
-			requestCtx, cancel := context.WithTimeout(ctx, dialer.requestTimeout)
-			resp, err := client.DeletePieces(requestCtx, &pb.DeletePiecesRequest{
-				PieceIds: batch,
-			})
-			cancel()
+			resp, err := dialer.deletePieces(ctx, client, batch)
 
 			for _, promise := range promises {
 				if err != nil {
@@ -124,6 +134,42 @@ func (dialer *Dialer) Handle(ctx context.Context, node storj.NodeURL, queue Queu
 	}
 }
 
+// piecesDeleter is the part of the piecestore client which deletes pieces.
+type piecesDeleter interface {
+	DeletePieces(ctx context.Context, in *pb.DeletePiecesRequest) (*pb.DeletePiecesResponse, error)
+}
+
+// deletePieces sends a delete request to the node, retrying it after
+// transient errors.
+func (dialer *Dialer) deletePieces(ctx context.Context, client piecesDeleter, batch []storj.PieceID) (resp *pb.DeletePiecesResponse, err error) {
+	backoff := dialer.transientBackoff
+	for attempt := 0; ; attempt++ {
+		requestCtx, cancel := context.WithTimeout(ctx, dialer.requestTimeout)
+		resp, err = client.DeletePieces(requestCtx, &pb.DeletePiecesRequest{
+			PieceIds: batch,
+		})
+		cancel()
+
+		if err == nil || attempt >= dialer.transientRetries || !isTransient(err) {
+			return resp, err
+		}
+
+		mon.Counter("deletion request transient retries").Inc(1)
+		if !sync2.Sleep(ctx, backoff) {
+			return resp, err
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient returns whether the request may succeed when it's sent again
+// shortly, e.g. when the node is temporarily overloaded.
+func isTransient(err error) bool {
+	return errs2.IsRPC(err, rpcstatus.Unavailable) ||
+		errs2.IsRPC(err, rpcstatus.ResourceExhausted) ||
+		errs2.IsRPC(err, rpcstatus.Aborted)
+}
+
 // markFailed marks node as something failed recently, so we shouldn't try again,
 // for some time.
 func (dialer *Dialer) markFailed(ctx context.Context, node storj.NodeURL) {
@@ -138,6 +184,14 @@ func (dialer *Dialer) markFailed(ctx context.Context, node storj.NodeURL) {
 	}
 }
 
+// clearFailed forgets that a request to the node failed recently.
+func (dialer *Dialer) clearFailed(nodeID storj.NodeID) {
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+
+	delete(dialer.dialFailed, nodeID)
+}
+
 // recentlyFailed checks whether a request to node recently failed.
 func (dialer *Dialer) recentlyFailed(ctx context.Context, node storj.NodeURL) bool {
 	dialer.mu.RLock()
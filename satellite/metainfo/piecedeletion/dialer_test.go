@@ -32,7 +32,7 @@ func TestDialer(t *testing.T) {
 	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
 		log := zaptest.NewLogger(t)
 
-		dialer := piecedeletion.NewDialer(log, planet.Satellites[0].Dialer, 5*time.Second, 5*time.Second, 100)
+		dialer := piecedeletion.NewDialer(log, planet.Satellites[0].Dialer, 5*time.Second, 5*time.Second, 100, 0, 0)
 		require.NotNil(t, dialer)
 
 		storageNode := planet.StorageNodes[0].NodeURL()
@@ -57,7 +57,7 @@ func TestDialer_DialTimeout(t *testing.T) {
 		rpcdial := planet.Satellites[0].Dialer
 		rpcdial.DialTimeout = dialTimeout
 
-		dialer := piecedeletion.NewDialer(log, rpcdial, 5*time.Second, 1*time.Minute, 100)
+		dialer := piecedeletion.NewDialer(log, rpcdial, 5*time.Second, 1*time.Minute, 100, 0, 0)
 		require.NotNil(t, dialer)
 
 		require.NoError(t, planet.StopPeer(planet.StorageNodes[0]))
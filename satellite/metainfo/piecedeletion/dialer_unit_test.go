@@ -0,0 +1,66 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/errs2"
+	"storj.io/common/pb"
+	"storj.io/common/rpc"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+)
+
+// failingDeleter fails the first requests with err.
+type failingDeleter struct {
+	failures int
+	err      error
+
+	requests [][]storj.PieceID
+}
+
+func (deleter *failingDeleter) DeletePieces(ctx context.Context, in *pb.DeletePiecesRequest) (*pb.DeletePiecesResponse, error) {
+	deleter.requests = append(deleter.requests, in.PieceIds)
+	if len(deleter.requests) <= deleter.failures {
+		return nil, deleter.err
+	}
+	return &pb.DeletePiecesResponse{}, nil
+}
+
+func TestDialer_TransientRetries(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	dialer := NewDialer(zaptest.NewLogger(t), rpc.Dialer{}, time.Second, time.Minute, 100, 2, time.Millisecond)
+	pieces := []storj.PieceID{testrand.PieceID(), testrand.PieceID()}
+
+	{ // transient failure once, then success within the same call
+		deleter := &failingDeleter{failures: 1, err: rpcstatus.Error(rpcstatus.Unavailable, "overloaded")}
+		_, err := dialer.deletePieces(ctx, deleter, pieces)
+		require.NoError(t, err)
+		require.Equal(t, [][]storj.PieceID{pieces, pieces}, deleter.requests)
+	}
+
+	{ // transient failures exceeding the retries
+		deleter := &failingDeleter{failures: 3, err: rpcstatus.Error(rpcstatus.Unavailable, "overloaded")}
+		_, err := dialer.deletePieces(ctx, deleter, pieces)
+		require.True(t, errs2.IsRPC(err, rpcstatus.Unavailable))
+		require.Len(t, deleter.requests, 3)
+	}
+
+	{ // permanent failures aren't retried
+		deleter := &failingDeleter{failures: 1, err: rpcstatus.Error(rpcstatus.PermissionDenied, "untrusted")}
+		_, err := dialer.deletePieces(ctx, deleter, pieces)
+		require.True(t, errs2.IsRPC(err, rpcstatus.PermissionDenied))
+		require.Len(t, deleter.requests, 1)
+	}
+}
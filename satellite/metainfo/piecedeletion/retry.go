@@ -0,0 +1,170 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"storj.io/common/storj"
+)
+
+// Retries stores the pieces whose deletion failed grouped by node, so they
+// are sent again later, e.g. when the node is back online.
+//
+// The queue is kept in the database, hence it survives restarts and it's
+// shared by the processes deleting pieces, e.g. the API and the admin peers.
+// The retries of a node are claimed before being sent, so two processes
+// don't send them at the same time.
+//
+// architecture: Database
+type Retries interface {
+	// Add queues the pieces of the node, pieceBytes is the expected size of
+	// each of them. The pieces which are already queued are skipped. The
+	// retry of a node which wasn't queued yet is due straight away.
+	Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, pieceBytes int64) error
+	// Due returns at most limit nodes whose retry is due at now.
+	Due(ctx context.Context, now time.Time, limit int) ([]storj.NodeID, error)
+	// Claim returns at most limit queued pieces of the node and postpones
+	// its retry until the lease, when the retry is due at now or force is
+	// set. Otherwise, e.g. when another process has claimed the retry, it
+	// returns no pieces.
+	Claim(ctx context.Context, nodeID storj.NodeID, now, lease time.Time, limit int, force bool) (RetryNode, error)
+	// Remove removes the pieces of the node from the queue. The failed
+	// attempts of the node are reset and its remaining pieces are due
+	// straight away.
+	Remove(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID) error
+	// Failed records a failed attempt of sending the pieces of the node and
+	// postpones its retry until retryAfter.
+	Failed(ctx context.Context, nodeID storj.NodeID, retryAfter time.Time) error
+	// Get returns the number and the size of the queued pieces of the node,
+	// without their IDs.
+	Get(ctx context.Context, nodeID storj.NodeID) (RetryNode, error)
+	// Count returns the number of queued pieces.
+	Count(ctx context.Context) (int64, error)
+	// QueuedAfter returns the pieces, grouped by node, which were queued
+	// after since.
+	QueuedAfter(ctx context.Context, since time.Time) (map[storj.NodeID][]storj.PieceID, error)
+}
+
+// RetryNode are the queued retries of a node.
+type RetryNode struct {
+	// Pieces are the claimed pieces, see Retries.Claim.
+	Pieces []storj.PieceID
+	// PieceCount is the number of pieces.
+	PieceCount int64
+	// Bytes is the expected total size of the pieces. The pieces whose size
+	// wasn't known when they were queued aren't included.
+	Bytes int64
+	// FailedAttempts is the number of consecutive failed attempts of sending
+	// the queued pieces of the node.
+	FailedAttempts int
+	// RetryAfter is when the pieces are sent again.
+	RetryAfter time.Time
+}
+
+// retryPromise adds the pieces of the job to the retry queue when the job
+// fails and then notifies the wrapped promise. The pieces which don't fit
+// into the buffer of the retry queue, or whose deletion was the last attempt,
+// are recorded as dead letters instead. It also keeps the number of pending
+// pieces of the service up to date.
+type retryPromise struct {
+	Promise
+	service     *Service
+	failures    *failures
+	node        storj.NodeID
+	pieces      []storj.PieceID
+	bytes       int64
+	lastAttempt bool
+	// retrying is set when the pieces are already in the retry queue, i.e.
+	// when they are retried.
+	retrying bool
+}
+
+// Success notifies the wrapped promise.
+func (promise *retryPromise) Success() {
+	promise.failures.success(promise.node)
+	promise.service.addPending(-len(promise.pieces))
+	promise.Promise.Success()
+}
+
+// Failure queues the pieces for retrying and notifies the wrapped promise.
+func (promise *retryPromise) Failure() {
+	promise.fail(false)
+}
+
+// Offline is like Failure, but it records the node as offline rather than
+// failed.
+func (promise *retryPromise) Offline() {
+	promise.fail(true)
+}
+
+// fail queues the pieces for retrying and notifies the wrapped promise.
+func (promise *retryPromise) fail(offline bool) {
+	var queued bool
+	switch {
+	case promise.lastAttempt:
+		promise.service.giveUpDetached(promise.node, promise.pieces, DeadLetterRetriesExhausted)
+	case promise.retrying:
+		queued = true
+	case promise.service.retryRecorder.Add(promise.node, promise.pieces, promise.bytes):
+		queued = true
+	default:
+		promise.service.giveUpDetached(promise.node, promise.pieces, DeadLetterQueueFull)
+	}
+	promise.failures.add(promise.node, len(promise.pieces), queued, offline)
+	promise.service.addPending(-len(promise.pieces))
+	promise.Promise.Failure()
+}
+
+// failures counts the failed pieces of a single delete call, it also keeps
+// the nodes which deleted their pieces and the ones which didn't.
+type failures struct {
+	mu            sync.Mutex
+	queued        int
+	unrecoverable int
+	succeeded     []storj.NodeID
+	failed        []storj.NodeID
+	offline       []storj.NodeID
+}
+
+// success records the node which deleted its pieces.
+func (failures *failures) success(node storj.NodeID) {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+
+	failures.succeeded = append(failures.succeeded, node)
+}
+
+// add adds the count of failed pieces of the node.
+func (failures *failures) add(node storj.NodeID, count int, queued, offline bool) {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+
+	if queued {
+		failures.queued += count
+	} else {
+		failures.unrecoverable += count
+	}
+	if offline {
+		failures.offline = append(failures.offline, node)
+	} else {
+		failures.failed = append(failures.failed, node)
+	}
+}
+
+// result returns the failures counted so far.
+func (failures *failures) result() Result {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+
+	return Result{
+		FailedPieces:        failures.queued,
+		UnrecoverablePieces: failures.unrecoverable,
+		SucceededNodeIDs:    append([]storj.NodeID(nil), failures.succeeded...),
+		FailedNodeIDs:       append([]storj.NodeID(nil), failures.failed...),
+		OfflineNodeIDs:      append([]storj.NodeID(nil), failures.offline...),
+	}
+}
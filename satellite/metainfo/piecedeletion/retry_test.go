@@ -0,0 +1,53 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+)
+
+func TestRetryRecorder(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	store := newMemoryRetries()
+	recorder := newRetryRecorder(zaptest.NewLogger(t), store, 3)
+
+	node := testrand.NodeID()
+	pieces := []storj.PieceID{testrand.PieceID(), testrand.PieceID()}
+	require.True(t, recorder.Add(node, pieces, 200))
+	// the buffer is full, so the pieces are refused.
+	require.False(t, recorder.Add(node, []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, 200))
+	require.Equal(t, len(pieces), recorder.Buffered())
+
+	// nothing is queued before flushing.
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	recorder.Flush()
+	require.Zero(t, recorder.Buffered())
+
+	queued, err := store.Get(ctx, node)
+	require.NoError(t, err)
+	require.EqualValues(t, len(pieces), queued.PieceCount)
+	require.EqualValues(t, 200, queued.Bytes)
+
+	// the buffer is empty again after flushing, and the queued pieces are
+	// only added once.
+	require.True(t, recorder.Add(node, append(pieces, testrand.PieceID()), 300))
+	recorder.Close()
+
+	queued, err = store.Get(ctx, node)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, queued.PieceCount)
+	require.EqualValues(t, 300, queued.Bytes)
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/sync2"
+)
+
+// RetryChore periodically sends the queued retries of the nodes whose retry
+// is due. The retries of a node are postponed after every failed attempt, see
+// Config.RetryInterval and Config.RetryMaxBackoff.
+//
+// architecture: Chore
+type RetryChore struct {
+	log     *zap.Logger
+	service *Service
+	Loop    *sync2.Cycle
+}
+
+// NewRetryChore creates a new chore retrying the failed deletions of service.
+func NewRetryChore(log *zap.Logger, service *Service, config Config) *RetryChore {
+	return &RetryChore{
+		log:     log,
+		service: service,
+		Loop:    sync2.NewCycle(config.RetryInterval),
+	}
+}
+
+// Run starts the retry loop. Nothing is retried when retrying is disabled,
+// see Config.MaxRetryPieces, or without a retry interval.
+func (chore *RetryChore) Run(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if chore.service.config.MaxRetryPieces <= 0 || chore.service.config.RetryInterval <= 0 {
+		return nil
+	}
+
+	return chore.Loop.Run(ctx, func(ctx context.Context) error {
+		err := chore.service.RetryDue(ctx)
+		if err != nil {
+			chore.log.Error("failed to retry the queued deletions", zap.Error(err))
+		}
+		return nil
+	})
+}
+
+// Close stops the chore.
+func (chore *RetryChore) Close() error {
+	chore.Loop.Close()
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/storj"
+)
+
+const (
+	// retryTimeout is the timeout for queueing retries without a context, see
+	// retryRecorder.
+	retryTimeout = 10 * time.Second
+	// retryBatchSize is the maximum number of pieces queued by a single
+	// Retries.Add call.
+	retryBatchSize = 1000
+)
+
+// retryGroup are the failed pieces which are queued together.
+type retryGroup struct {
+	node       storj.NodeID
+	pieceBytes int64
+}
+
+// retryRecorder buffers the failed pieces of the callers without a context,
+// e.g. the promises of the deletion workers, and adds them to the retry queue
+// in the background, so the workers don't wait for the database.
+//
+// The pieces which don't fit into the buffer are refused, the caller records
+// them as dead letters.
+type retryRecorder struct {
+	log       *zap.Logger
+	retries   Retries
+	maxPieces int
+
+	mu      sync.Mutex
+	pending map[retryGroup][]storj.PieceID
+	pieces  int
+
+	// flushMu makes Flush wait for the flush in progress, so the pieces
+	// buffered before calling it are queued once it returns.
+	flushMu sync.Mutex
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	started bool
+}
+
+// newRetryRecorder creates a recorder buffering at most maxPieces pieces.
+func newRetryRecorder(log *zap.Logger, retries Retries, maxPieces int) *retryRecorder {
+	return &retryRecorder{
+		log:       log,
+		retries:   retries,
+		maxPieces: maxPieces,
+		pending:   make(map[retryGroup][]storj.PieceID),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Add buffers the pieces of the node, whose expected total size is bytes, for
+// queueing them in the background. It returns false when they don't fit into
+// the buffer.
+func (recorder *retryRecorder) Add(nodeID storj.NodeID, pieces []storj.PieceID, bytes int64) bool {
+	if len(pieces) == 0 {
+		return true
+	}
+
+	recorder.mu.Lock()
+	if recorder.pieces+len(pieces) > recorder.maxPieces {
+		recorder.mu.Unlock()
+		mon.Counter("deletion_retry_queue_dropped_pieces").Inc(int64(len(pieces)))
+		return false
+	}
+	group := retryGroup{node: nodeID, pieceBytes: bytes / int64(len(pieces))}
+	recorder.pending[group] = append(recorder.pending[group], pieces...)
+	recorder.pieces += len(pieces)
+	recorder.mu.Unlock()
+
+	select {
+	case recorder.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Start queues the buffered pieces in the background until Close is called
+// or ctx is canceled.
+func (recorder *retryRecorder) Start(ctx context.Context) {
+	recorder.started = true
+	go recorder.run(ctx)
+}
+
+// run is the background loop of the recorder.
+func (recorder *retryRecorder) run(ctx context.Context) {
+	defer close(recorder.done)
+
+	for {
+		select {
+		case <-recorder.wake:
+			recorder.Flush()
+		case <-recorder.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush queues the buffered pieces.
+func (recorder *retryRecorder) Flush() {
+	recorder.flushMu.Lock()
+	defer recorder.flushMu.Unlock()
+
+	recorder.mu.Lock()
+	pending := recorder.pending
+	recorder.pending = make(map[retryGroup][]storj.PieceID)
+	recorder.pieces = 0
+	recorder.mu.Unlock()
+
+	for group, pieces := range pending {
+		for len(pieces) > 0 {
+			batch := pieces
+			if len(batch) > retryBatchSize {
+				batch = batch[:retryBatchSize]
+			}
+			pieces = pieces[len(batch):]
+
+			recorder.queue(group, batch)
+		}
+	}
+}
+
+// queue adds a batch of pieces of the group to the retry queue.
+func (recorder *retryRecorder) queue(group retryGroup, pieces []storj.PieceID) {
+	ctx, cancel := context.WithTimeout(context.Background(), retryTimeout)
+	defer cancel()
+
+	err := recorder.retries.Add(ctx, group.node, pieces, group.pieceBytes)
+	if err != nil {
+		// the pieces are still collected by the garbage collector.
+		mon.Counter("deletion_retry_queue_dropped_pieces").Inc(int64(len(pieces)))
+		recorder.log.Error("unable to queue the pieces for retrying their deletion",
+			zap.Stringer("Node ID", group.node),
+			zap.Int("pieces", len(pieces)),
+			zap.Error(err),
+		)
+	}
+}
+
+// Buffered returns the number of buffered pieces.
+func (recorder *retryRecorder) Buffered() int {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return recorder.pieces
+}
+
+// Close stops the background loop and queues the buffered pieces.
+func (recorder *retryRecorder) Close() {
+	if recorder.started {
+		close(recorder.stop)
+		<-recorder.done
+	}
+	recorder.Flush()
+}
@@ -5,6 +5,9 @@ package piecedeletion
 
 import (
 	"context"
+	"errors"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeebo/errs"
@@ -28,6 +31,22 @@ type Config struct {
 	DialTimeout    time.Duration `help:"timeout for dialing nodes (0 means satellite default)" default:"0"`
 	FailThreshold  time.Duration `help:"threshold for retrying a failed node" releaseDefault:"5m" devDefault:"2s"`
 	RequestTimeout time.Duration `help:"timeout for a single delete request" releaseDefault:"1m" devDefault:"2s"`
+
+	TransientRetries      int           `help:"number of retries of a request failing with a transient error" default:"2"`
+	TransientRetryBackoff time.Duration `help:"delay before retrying a request failing with a transient error" default:"50ms"`
+
+	MaxRetryPieces   int           `help:"maximum number of failed pieces buffered before they're queued for a retry (0 disables retrying)" default:"100000"`
+	MaxRetryAttempts int           `help:"number of retries of a node before its pieces are dead letters (0 means no limit)" default:"0"`
+	RetryInterval    time.Duration `help:"how often the due retries are sent (0 means only when flushed)" releaseDefault:"5m" devDefault:"10s"`
+	RetryMaxBackoff  time.Duration `help:"maximum delay between the retries of a node" releaseDefault:"6h" devDefault:"1m"`
+
+	QueueWhenAllOffline bool `help:"queue the pieces without contacting the nodes when all of them are offline" default:"false"`
+
+	ShutdownDrainTimeout time.Duration `help:"how long shutting down waits for the in-flight deletions (0 means not waiting)" default:"5s"`
+
+	MaxDeadLetterPieces       int           `help:"maximum number of dead letter pieces buffered before they're recorded (0 records them at once)" default:"100000"`
+	DeadLetterRetention       time.Duration `help:"how long the dead letters are kept (0 means forever)" default:"720h"`
+	DeadLetterCleanupInterval time.Duration `help:"how often the dead letters older than the retention are deleted" default:"1h"`
 }
 
 const (
@@ -59,6 +78,27 @@ func (config *Config) Verify() errs.Group {
 	if config.RequestTimeout < minTimeout || maxTimeout < config.RequestTimeout {
 		errlist.Add(Error.New("request timeout %v should be between %v and %v", config.RequestTimeout, minTimeout, maxTimeout))
 	}
+	if config.TransientRetries < 0 {
+		errlist.Add(Error.New("transient retries %d must not be negative", config.TransientRetries))
+	}
+	if config.MaxRetryAttempts < 0 {
+		errlist.Add(Error.New("max retry attempts %d must not be negative", config.MaxRetryAttempts))
+	}
+	if config.RetryInterval < 0 {
+		errlist.Add(Error.New("retry interval %v must not be negative", config.RetryInterval))
+	}
+	if config.RetryMaxBackoff < config.RetryInterval {
+		errlist.Add(Error.New("retry max backoff %v must not be less than the retry interval %v", config.RetryMaxBackoff, config.RetryInterval))
+	}
+	if config.ShutdownDrainTimeout < 0 {
+		errlist.Add(Error.New("shutdown drain timeout %v must not be negative", config.ShutdownDrainTimeout))
+	}
+	if config.MaxDeadLetterPieces < 0 {
+		errlist.Add(Error.New("max dead letter pieces %d must not be negative", config.MaxDeadLetterPieces))
+	}
+	if config.DeadLetterRetention < 0 {
+		errlist.Add(Error.New("dead letter retention %v must not be negative", config.DeadLetterRetention))
+	}
 	return errlist
 }
 
@@ -71,22 +111,37 @@ type Nodes interface {
 //
 // architecture: Service
 type Service struct {
+	// pending is the number of pieces which have been sent for deletion, but
+	// aren't resolved yet. It's the first field to keep it 64-bit aligned
+	// for atomic access.
+	pending int64
+	// queuedRetries is the number of pieces in the retry queue as of the
+	// last retry of the due nodes, see RetryDue.
+	queuedRetries int64
+
 	log    *zap.Logger
 	config Config
 
 	concurrentRequests *semaphore.Weighted
 
-	rpcDialer rpc.Dialer
-	nodesDB   Nodes
+	rpcDialer   rpc.Dialer
+	nodesDB     Nodes
+	retries     Retries
+	deadLetters DeadLetters
+
+	retryRecorder      *retryRecorder
+	deadLetterRecorder *deadLetterRecorder
 
 	running  sync2.Fence
 	combiner *Combiner
 	dialer   *Dialer
 	limited  *LimitedHandler
+
+	decommissioning *Decommissioning
 }
 
 // NewService creates a new service.
-func NewService(log *zap.Logger, dialer rpc.Dialer, nodesDB Nodes, config Config) (*Service, error) {
+func NewService(log *zap.Logger, dialer rpc.Dialer, nodesDB Nodes, retries Retries, deadLetters DeadLetters, config Config) (*Service, error) {
 	var errlist errs.Group
 	if log == nil {
 		errlist.Add(Error.New("log is nil"))
@@ -97,6 +152,12 @@ func NewService(log *zap.Logger, dialer rpc.Dialer, nodesDB Nodes, config Config
 	if nodesDB == nil {
 		errlist.Add(Error.New("nodesDB is nil"))
 	}
+	if retries == nil {
+		errlist.Add(Error.New("retries is nil"))
+	}
+	if deadLetters == nil {
+		errlist.Add(Error.New("deadLetters is nil"))
+	}
 	if errs := config.Verify(); len(errs) > 0 {
 		errlist.Add(errs...)
 	}
@@ -115,6 +176,11 @@ func NewService(log *zap.Logger, dialer rpc.Dialer, nodesDB Nodes, config Config
 		concurrentRequests: semaphore.NewWeighted(int64(config.MaxConcurrentPieces)),
 		rpcDialer:          dialerClone,
 		nodesDB:            nodesDB,
+		retries:            retries,
+		deadLetters:        deadLetters,
+		retryRecorder:      newRetryRecorder(log.Named("retries"), retries, config.MaxRetryPieces),
+		deadLetterRecorder: newDeadLetterRecorder(log.Named("dead letters"), deadLetters, config.MaxDeadLetterPieces, config.DeadLetterRetention, config.DeadLetterCleanupInterval),
+		decommissioning:    NewDecommissioning(),
 	}, nil
 }
 
@@ -128,39 +194,384 @@ func (service *Service) Run(ctx context.Context) error {
 	defer service.running.Release()
 
 	config := service.config
-	service.dialer = NewDialer(service.log.Named("dialer"), service.rpcDialer, config.RequestTimeout, config.FailThreshold, config.MaxPiecesPerRequest)
+	service.dialer = NewDialer(service.log.Named("dialer"), service.rpcDialer, config.RequestTimeout, config.FailThreshold, config.MaxPiecesPerRequest, config.TransientRetries, config.TransientRetryBackoff)
 	service.limited = NewLimitedHandler(service.dialer, config.MaxConcurrency)
 	service.combiner = NewCombiner(ctx, service.limited, service.newQueue)
+	service.retryRecorder.Start(ctx)
+	service.deadLetterRecorder.Start(ctx)
 
 	return nil
 }
 
 // Close shuts down the service.
+//
+// It waits up to ShutdownDrainTimeout for the in-flight deletions to finish,
+// the ones which haven't finished by then are failed into the retry queue,
+// which is kept in the database, so they are retried after the restart.
 func (service *Service) Close() error {
 	<-service.running.Done()
+
+	service.drain(service.config.ShutdownDrainTimeout)
 	service.combiner.Close()
+	// the failed jobs are added to the retry queue by their promises.
+	service.combiner.Wait()
+	service.retryRecorder.Close()
+	service.deadLetterRecorder.Close()
 	return nil
 }
 
+// drainPollInterval is how often drain checks whether the in-flight
+// deletions have finished.
+const drainPollInterval = 10 * time.Millisecond
+
+// drain waits until there are no pending pieces or the timeout passes.
+func (service *Service) drain(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&service.pending) > 0 {
+		if !time.Now().Before(deadline) {
+			mon.Meter("deletion_drain_timeout").Mark(1)
+			service.log.Warn("piece deletions didn't finish before shutdown",
+				zap.Int64("pending pieces", atomic.LoadInt64(&service.pending)),
+				zap.Duration("timeout", timeout),
+			)
+			return
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
 // Delete deletes the pieces specified in the requests waiting until success threshold is reached.
+//
+// The pieces which fail to be deleted are added to the retry queue, or recorded
+// as dead letters when they don't fit into its buffer. The pieces of decommissioning
+// nodes are queued or skipped according to their mode, see Decommissioning.
 func (service *Service) Delete(ctx context.Context, requests []Request, successThreshold float64) (err error) {
 	defer mon.Task()(&ctx, len(requests), requestsPieceCount(requests), successThreshold)(&err)
 
+	_, err = service.validateAndDelete(ctx, requests, successThreshold)
+	return err
+}
+
+// Result is the outcome of the pieces whose deletion failed by the time the
+// success threshold has been reached. The failures of the pieces which are
+// still being deleted afterwards aren't included, their nodes are in
+// PendingNodeIDs instead.
+type Result struct {
+	// FailedPieces is the number of pieces whose deletion failed and which
+	// have been added to the retry queue.
+	FailedPieces int
+	// UnrecoverablePieces is the number of pieces whose deletion failed and
+	// which didn't fit into the retry queue. They are recorded as dead letters
+	// and left to the garbage collector.
+	UnrecoverablePieces int
+	// Nodes is the number of nodes whose pieces were sent for deletion.
+	Nodes int
+	// SucceededNodes is the number of nodes which had deleted their pieces
+	// by the time the success threshold was reached or all the nodes had
+	// answered.
+	SucceededNodes int
+	// SucceededNodeIDs are the nodes which confirmed the deletion of their
+	// pieces by the time the result was returned, hence they may be more than
+	// SucceededNodes.
+	SucceededNodeIDs []storj.NodeID
+	// FailedNodeIDs are the nodes which were contacted, but failed to delete
+	// their pieces by the time the result was returned.
+	FailedNodeIDs []storj.NodeID
+	// OfflineNodeIDs are the nodes which couldn't be contacted by the time
+	// the result was returned, e.g. because they are offline or they failed
+	// to be dialed recently.
+	OfflineNodeIDs []storj.NodeID
+	// PendingNodeIDs are the nodes which hadn't answered by the time the
+	// result was returned, e.g. after the success threshold was reached.
+	// Their failures are retried like the others, but they aren't counted.
+	PendingNodeIDs []storj.NodeID
+	// DecommissioningPieces is the number of pieces of decommissioning nodes,
+	// which have been queued or skipped according to their mode rather than
+	// sent. Their nodes aren't included in Nodes.
+	DecommissioningPieces int
+	// DeadlineExceeded tells whether the deadline of the context was reached
+	// before the success threshold. The nodes which haven't answered yet
+	// keep deleting their pieces in the background, the failed ones are
+	// retried like the others.
+	DeadlineExceeded bool
+}
+
+// Reached returns whether enough nodes deleted their pieces for
+// successThreshold, which is a fraction of the nodes.
+func (result Result) Reached(successThreshold float64) bool {
+	return result.SucceededNodes >= int(math.Ceil(float64(result.Nodes)*successThreshold))
+}
+
+// HasFailures returns whether the deletion of some pieces failed.
+func (result Result) HasFailures() bool {
+	return result.FailedPieces > 0 || result.UnrecoverablePieces > 0
+}
+
+// DeleteWithResult is like Delete, but it also returns how many pieces failed
+// to be deleted.
+func (service *Service) DeleteWithResult(ctx context.Context, requests []Request, successThreshold float64) (result Result, err error) {
+	defer mon.Task()(&ctx, len(requests), requestsPieceCount(requests), successThreshold)(&err)
+
+	return service.validateAndDelete(ctx, requests, successThreshold)
+}
+
+// validateAndDelete validates the requests and deletes their pieces.
+func (service *Service) validateAndDelete(ctx context.Context, requests []Request, successThreshold float64) (Result, error) {
 	if len(requests) == 0 {
-		return nil
+		return Result{}, nil
 	}
 
 	// wait for combiner and dialer to set themselves up.
 	if !service.running.Wait(ctx) {
-		return Error.Wrap(ctx.Err())
+		return Result{}, Error.Wrap(ctx.Err())
 	}
 
 	for i, req := range requests {
 		if !req.IsValid() {
-			return Error.New("request #%d is invalid", i)
+			return Result{}, Error.New("request #%d is invalid", i)
 		}
 	}
 
+	requests, decommissioning, unrecoverable := service.withoutDecommissioning(ctx, requests)
+	if len(requests) == 0 {
+		return Result{
+			UnrecoverablePieces:   unrecoverable,
+			DecommissioningPieces: decommissioning,
+		}, nil
+	}
+
+	threshold, failed, err := service.delete(ctx, requests, successThreshold, deleteOptions{
+		queueWhenAllOffline: service.config.QueueWhenAllOffline,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := failed.result()
+	result.UnrecoverablePieces += unrecoverable
+	result.DecommissioningPieces = decommissioning
+	result.Nodes = len(requests)
+	result.SucceededNodes = threshold.SuccessCount()
+	result.PendingNodeIDs = pendingNodes(requests, result)
+	result.DeadlineExceeded = errors.Is(ctx.Err(), context.DeadlineExceeded) && !result.Reached(successThreshold)
+	return result, nil
+}
+
+// pendingNodes returns the nodes of the requests which are in none of the
+// node lists of result.
+func pendingNodes(requests []Request, result Result) (pending []storj.NodeID) {
+	answered := make(map[storj.NodeID]struct{}, len(requests))
+	for _, nodes := range [][]storj.NodeID{result.SucceededNodeIDs, result.FailedNodeIDs, result.OfflineNodeIDs} {
+		for _, node := range nodes {
+			answered[node] = struct{}{}
+		}
+	}
+	for _, req := range requests {
+		if _, ok := answered[req.Node.ID]; !ok {
+			pending = append(pending, req.Node.ID)
+		}
+	}
+	return pending
+}
+
+// retryLease is how long the claimed retries of a node are left alone by
+// the other processes, see Retries.Claim. It's long enough for sending them.
+const retryLease = 10 * time.Minute
+
+// retryNodesPerCycle is the maximum number of nodes retried by RetryDue.
+const retryNodesPerCycle = 100
+
+// FlushNode sends the queued retries of the node straight away, e.g. when the
+// node is back after an outage. It returns the number of pieces which have
+// been deleted.
+//
+// The pieces are kept in the retry queue when the deletion fails again,
+// unless it failed MaxRetryAttempts times in a row: the pieces are given up
+// and recorded as dead letters then, e.g. when the node is gone for good.
+func (service *Service) FlushNode(ctx context.Context, nodeID storj.NodeID) (flushed int, err error) {
+	defer mon.Task()(&ctx, nodeID.String())(&err)
+
+	// wait for combiner and dialer to set themselves up.
+	if !service.running.Wait(ctx) {
+		return 0, Error.Wrap(ctx.Err())
+	}
+
+	// the operator knows that the node is back, so don't wait for the fail
+	// threshold to pass, nor skip the node when the overlay hasn't noticed
+	// it yet.
+	service.dialer.clearFailed(nodeID)
+	// the failed pieces buffered by this process are sent too.
+	service.retryRecorder.Flush()
+
+	for {
+		deleted, more, err := service.retryNode(ctx, nodeID, true)
+		flushed += deleted
+		if err != nil || !more {
+			return flushed, err
+		}
+	}
+}
+
+// RetryDue sends the queued retries of the nodes whose retry is due, see
+// RetryChore. The retries of the decommissioning nodes are kept until they
+// are flushed with FlushNode.
+func (service *Service) RetryDue(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	// wait for combiner and dialer to set themselves up.
+	if !service.running.Wait(ctx) {
+		return Error.Wrap(ctx.Err())
+	}
+
+	nodes, err := service.retries.Due(ctx, time.Now(), retryNodesPerCycle)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	for _, nodeID := range nodes {
+		if _, ok := service.decommissioning.Mode(nodeID); ok {
+			continue
+		}
+
+		deleted, _, err := service.retryNode(ctx, nodeID, false)
+		if err != nil {
+			service.log.Debug("failed to retry the deletions of the node",
+				zap.Stringer("Node ID", nodeID),
+				zap.Error(err),
+			)
+			continue
+		}
+		mon.Meter("deletion_retried_pieces").Mark(deleted)
+	}
+
+	count, err := service.retries.Count(ctx)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	atomic.StoreInt64(&service.queuedRetries, count)
+	mon.IntVal("deletion_retry_queue_pieces").Observe(count)
+	return nil
+}
+
+// retryNode claims a batch of the queued retries of the node and sends them.
+// The claimed pieces are removed from the queue once they're deleted, or given
+// up after the last attempt, otherwise the next retry of the node is
+// postponed by its backoff. It returns the number of pieces which have been
+// deleted and whether the node has more queued pieces.
+func (service *Service) retryNode(ctx context.Context, nodeID storj.NodeID, force bool) (deleted int, more bool, err error) {
+	now := time.Now()
+	node, err := service.retries.Claim(ctx, nodeID, now, now.Add(retryLease), service.config.MaxPiecesPerBatch, force)
+	if err != nil {
+		return 0, false, Error.Wrap(err)
+	}
+	if len(node.Pieces) == 0 {
+		return 0, false, nil
+	}
+
+	maxAttempts := service.config.MaxRetryAttempts
+	lastAttempt := maxAttempts > 0 && node.FailedAttempts+1 >= maxAttempts
+
+	threshold, _, err := service.delete(ctx, []Request{{
+		Node:   storj.NodeURL{ID: nodeID},
+		Pieces: node.Pieces,
+		Bytes:  node.Bytes,
+	}}, 1, deleteOptions{lastAttempt: lastAttempt, retrying: true})
+	if err != nil {
+		// the pieces haven't been sent, they're retried once the lease
+		// expires.
+		return 0, false, err
+	}
+
+	succeeded := threshold.SuccessCount() > 0
+	if !succeeded && !lastAttempt {
+		retryAfter := time.Now().Add(service.retryBackoff(node.FailedAttempts + 1))
+		if err := service.retries.Failed(ctx, nodeID, retryAfter); err != nil {
+			return 0, false, Error.Wrap(err)
+		}
+		return 0, false, Error.New("failed to delete the pieces of node %s", nodeID)
+	}
+
+	// the pieces have been deleted, or given up and recorded as dead letters.
+	if err := service.retries.Remove(ctx, nodeID, node.Pieces); err != nil {
+		return 0, false, Error.Wrap(err)
+	}
+	if !succeeded {
+		return 0, false, Error.New("failed to delete the pieces of node %s, they're given up", nodeID)
+	}
+	return len(node.Pieces), node.PieceCount > int64(len(node.Pieces)), nil
+}
+
+// retryBackoff returns how long the retry of a node waits after its failed
+// attempts: RetryInterval doubled for every attempt after the first one, up
+// to RetryMaxBackoff.
+func (service *Service) retryBackoff(failedAttempts int) time.Duration {
+	backoff := service.config.RetryInterval
+	for i := 1; i < failedAttempts && backoff < service.config.RetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > service.config.RetryMaxBackoff {
+		backoff = service.config.RetryMaxBackoff
+	}
+	return backoff
+}
+
+// Retries returns the queue of the pieces which failed to be deleted.
+func (service *Service) Retries() Retries { return service.retries }
+
+// DeadLetters returns the store of the pieces whose deletion has been given
+// up.
+func (service *Service) DeadLetters() DeadLetters { return service.deadLetters }
+
+// Decommissioning returns the set of the decommissioning nodes, whose piece
+// deletions aren't sent to them.
+func (service *Service) Decommissioning() *Decommissioning { return service.decommissioning }
+
+// Backlog describes the piece deletions which haven't finished yet.
+type Backlog struct {
+	// RetryPieces is the number of pieces waiting in the retry queue as of
+	// the last retry of the due nodes, see RetryDue, and of the pieces
+	// waiting to be added to it.
+	RetryPieces int
+	// PendingPieces is the number of pieces which have been sent for deletion,
+	// but whose outcome isn't known yet. It includes the pieces which are
+	// still being deleted after Delete has reached the success threshold.
+	PendingPieces int64
+}
+
+// Backlog returns the current deletion backlog.
+func (service *Service) Backlog() Backlog {
+	return Backlog{
+		RetryPieces:   int(atomic.LoadInt64(&service.queuedRetries)) + service.retryRecorder.Buffered(),
+		PendingPieces: atomic.LoadInt64(&service.pending),
+	}
+}
+
+// addPending adds delta to the number of pending pieces.
+func (service *Service) addPending(delta int) {
+	pending := atomic.AddInt64(&service.pending, int64(delta))
+	mon.IntVal("deletion_pending_pieces").Observe(pending)
+}
+
+// deleteOptions defines how service.delete handles the failures.
+type deleteOptions struct {
+	// queueWhenAllOffline adds the pieces to the retry queue without
+	// contacting the nodes when none of them is online.
+	queueWhenAllOffline bool
+	// lastAttempt records the pieces which fail to be deleted as dead letters
+	// rather than adding them to the retry queue.
+	lastAttempt bool
+	// retrying doesn't add the pieces which fail to be deleted to the retry
+	// queue, since they are retried from it.
+	retrying bool
+}
+
+// delete sends the requests to the nodes and waits until the success
+// threshold is reached.
+func (service *Service) delete(ctx context.Context, requests []Request, successThreshold float64, opts deleteOptions) (_ *deletionThreshold, _ *failures, err error) {
+
 	// When number of pieces are more than the maximum limit, we let it overflow,
 	// so we don't have to split requests in to separate batches.
 	totalPieceCount := requestsPieceCount(requests)
@@ -169,7 +580,7 @@ func (service *Service) Delete(ctx context.Context, requests []Request, successT
 	}
 
 	if err := service.concurrentRequests.Acquire(ctx, int64(totalPieceCount)); err != nil {
-		return Error.Wrap(err)
+		return nil, nil, Error.Wrap(err)
 	}
 	defer service.concurrentRequests.Release(int64(totalPieceCount))
 
@@ -184,12 +595,14 @@ func (service *Service) Delete(ctx context.Context, requests []Request, successT
 		nodesReqs[req.Node.ID] = req
 	}
 
+	online := len(nodesReqs) - len(nodeIDs)
 	if len(nodeIDs) > 0 {
 		nodes, err := service.nodesDB.KnownReliable(ctx, nodeIDs)
 		if err != nil {
 			// Pieces will be collected by garbage collector
-			return Error.Wrap(err)
+			return nil, nil, Error.Wrap(err)
 		}
+		online += len(nodes)
 
 		for _, node := range nodes {
 			req := nodesReqs[node.Id]
@@ -200,31 +613,58 @@ func (service *Service) Delete(ctx context.Context, requests []Request, successT
 					Address: node.Address.Address,
 				},
 				Pieces: req.Pieces,
+				Bytes:  req.Bytes,
 			}
 		}
 	}
 
-	threshold, err := sync2.NewSuccessThreshold(len(nodesReqs), successThreshold)
+	threshold, err := newDeletionThreshold(len(nodesReqs), successThreshold)
 	if err != nil {
-		return Error.Wrap(err)
+		return nil, nil, Error.Wrap(err)
+	}
+
+	// dialing the nodes would only fail when none of them is online.
+	skipFanOut := opts.queueWhenAllOffline && online == 0
+	if skipFanOut {
+		mon.Meter("deletion_skipped_fan_out").Mark(1)
 	}
 
+	failed := &failures{}
 	for _, req := range nodesReqs {
+		service.addPending(len(req.Pieces))
+		promise := &retryPromise{
+			Promise:     threshold,
+			service:     service,
+			failures:    failed,
+			node:        req.Node.ID,
+			pieces:      req.Pieces,
+			bytes:       req.Bytes,
+			lastAttempt: opts.lastAttempt,
+			retrying:    opts.retrying,
+		}
+		if skipFanOut {
+			promise.Offline()
+			continue
+		}
 		service.combiner.Enqueue(req.Node, Job{
 			Pieces:  req.Pieces,
-			Resolve: threshold,
+			Resolve: promise,
 		})
 	}
 
 	threshold.Wait(ctx)
 
-	return nil
+	return threshold, failed, nil
 }
 
 // Request defines a deletion requests for a node.
 type Request struct {
 	Node   storj.NodeURL
 	Pieces []storj.PieceID
+	// Bytes is the expected total size of the pieces. It's optional and only
+	// recorded with the retries of the pieces which fail, for reporting the
+	// space the node reclaims once they are sent.
+	Bytes int64
 }
 
 // IsValid returns whether the request is valid.
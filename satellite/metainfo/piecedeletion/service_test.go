@@ -31,7 +31,7 @@ func TestService_New_Error(t *testing.T) {
 	log := zaptest.NewLogger(t)
 	dialer := rpc.NewDefaultDialer(nil)
 
-	_, err := piecedeletion.NewService(nil, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err := piecedeletion.NewService(nil, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      8,
 		MaxConcurrentPieces: 10,
 		MaxPiecesPerBatch:   0,
@@ -42,7 +42,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "log is nil")
 
-	_, err = piecedeletion.NewService(log, rpc.Dialer{}, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, rpc.Dialer{}, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      87,
 		MaxConcurrentPieces: 10,
 		DialTimeout:         time.Second,
@@ -50,7 +50,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "dialer is zero")
 
-	_, err = piecedeletion.NewService(log, dialer, nil, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, nil, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      8,
 		MaxConcurrentPieces: 10,
 		MaxPiecesPerBatch:   0,
@@ -61,7 +61,23 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "nodesDB is nil")
 
-	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, nil, &deadLetters{}, piecedeletion.Config{
+		MaxConcurrency:      8,
+		MaxConcurrentPieces: 10,
+		DialTimeout:         time.Second,
+	})
+	require.True(t, piecedeletion.Error.Has(err), err)
+	require.Contains(t, err.Error(), "retries is nil")
+
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, nil, piecedeletion.Config{
+		MaxConcurrency:      8,
+		MaxConcurrentPieces: 10,
+		DialTimeout:         time.Second,
+	})
+	require.True(t, piecedeletion.Error.Has(err), err)
+	require.Contains(t, err.Error(), "deadLetters is nil")
+
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      0,
 		MaxConcurrentPieces: 10,
 		DialTimeout:         time.Second,
@@ -69,7 +85,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "greater than 0")
 
-	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      -3,
 		MaxConcurrentPieces: 10,
 		DialTimeout:         time.Second,
@@ -77,7 +93,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "greater than 0")
 
-	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      3,
 		MaxConcurrentPieces: -10,
 		DialTimeout:         time.Second,
@@ -85,7 +101,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "greater than 0")
 
-	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      3,
 		MaxConcurrentPieces: 10,
 		DialTimeout:         time.Nanosecond,
@@ -93,7 +109,7 @@ func TestService_New_Error(t *testing.T) {
 	require.True(t, piecedeletion.Error.Has(err), err)
 	require.Contains(t, err.Error(), "dial timeout 1ns must be between 5ms and 5m0s")
 
-	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, piecedeletion.Config{
+	_, err = piecedeletion.NewService(log, dialer, &nodesDB{}, &retries{}, &deadLetters{}, piecedeletion.Config{
 		MaxConcurrency:      3,
 		MaxConcurrentPieces: 10,
 		DialTimeout:         time.Hour,
@@ -411,8 +427,147 @@ func TestService_DeletePieces_Timeout(t *testing.T) {
 	})
 }
 
+func TestService_FlushNode(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.Combine(
+				testplanet.ReconfigureRS(2, 2, 4, 4),
+				testplanet.MaxSegmentSize(15*memory.KiB),
+			),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplnk := planet.Uplinks[0]
+		satelliteSys := planet.Satellites[0]
+		service := satelliteSys.API.Metainfo.PieceDeletion
+
+		// the retries are only sent by flushing them.
+		satelliteSys.API.Metainfo.PieceDeletionRetry.Loop.Pause()
+
+		{
+			data := testrand.Bytes(10 * memory.KiB)
+			err := uplnk.Upload(ctx, satelliteSys, "a-bucket", "object-filename", data)
+			require.NoError(t, err)
+		}
+
+		unreachable := planet.StorageNodes[0]
+
+		var (
+			requests          []piecedeletion.Request
+			unreachablePieces int
+		)
+		for _, sn := range planet.StorageNodes {
+			// Get all the pieces of the storage node
+			nodePieces := piecedeletion.Request{Node: sn.NodeURL()}
+			err := sn.Storage2.Store.WalkSatellitePieces(ctx, satelliteSys.ID(),
+				func(store pieces.StoredPieceAccess) error {
+					nodePieces.Pieces = append(nodePieces.Pieces, store.PieceID())
+					return nil
+				},
+			)
+			require.NoError(t, err)
+
+			// simulate an outage by sending the requests to an address where
+			// nobody listens.
+			if sn.ID() == unreachable.ID() {
+				nodePieces.Node.Address = "127.0.0.1:1"
+				unreachablePieces = len(nodePieces.Pieces)
+			}
+
+			requests = append(requests, nodePieces)
+		}
+		require.NotZero(t, unreachablePieces)
+
+		err := service.Delete(ctx, requests, 1)
+		require.NoError(t, err)
+
+		planet.WaitForStorageNodeDeleters(ctx)
+
+		// the pieces of the unreachable node are queued for retrying in the
+		// background.
+		require.Eventually(t, func() bool {
+			node, err := service.Retries().Get(ctx, unreachable.ID())
+			return err == nil && node.PieceCount == int64(unreachablePieces)
+		}, 5*time.Second, 10*time.Millisecond)
+		count, err := service.Retries().Count(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, unreachablePieces, count)
+
+		piecesTotal, _, err := unreachable.Storage2.Store.SpaceUsedForPieces(ctx)
+		require.NoError(t, err)
+		require.NotZero(t, piecesTotal)
+
+		// flush the deletions now that the node is reachable again
+		flushed, err := service.FlushNode(ctx, unreachable.ID())
+		require.NoError(t, err)
+		require.Equal(t, unreachablePieces, flushed)
+		count, err = service.Retries().Count(ctx)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		planet.WaitForStorageNodeDeleters(ctx)
+
+		piecesTotal, _, err = unreachable.Storage2.Store.SpaceUsedForPieces(ctx)
+		require.NoError(t, err)
+		require.Zero(t, piecesTotal)
+
+		// flushing an empty queue is a no-op
+		flushed, err = service.FlushNode(ctx, unreachable.ID())
+		require.NoError(t, err)
+		require.Zero(t, flushed)
+	})
+}
+
 type nodesDB struct{}
 
 func (n *nodesDB) KnownReliable(ctx context.Context, nodesID storj.NodeIDList) ([]*pb.Node, error) {
 	return nil, nil
 }
+
+type retries struct{}
+
+func (r *retries) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, pieceBytes int64) error {
+	return nil
+}
+
+func (r *retries) Due(ctx context.Context, now time.Time, limit int) ([]storj.NodeID, error) {
+	return nil, nil
+}
+
+func (r *retries) Claim(ctx context.Context, nodeID storj.NodeID, now, lease time.Time, limit int, force bool) (piecedeletion.RetryNode, error) {
+	return piecedeletion.RetryNode{}, nil
+}
+
+func (r *retries) Remove(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID) error {
+	return nil
+}
+
+func (r *retries) Failed(ctx context.Context, nodeID storj.NodeID, retryAfter time.Time) error {
+	return nil
+}
+
+func (r *retries) Get(ctx context.Context, nodeID storj.NodeID) (piecedeletion.RetryNode, error) {
+	return piecedeletion.RetryNode{}, nil
+}
+
+func (r *retries) Count(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *retries) QueuedAfter(ctx context.Context, since time.Time) (map[storj.NodeID][]storj.PieceID, error) {
+	return nil, nil
+}
+
+type deadLetters struct{}
+
+func (d *deadLetters) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, reason piecedeletion.DeadLetterReason) error {
+	return nil
+}
+
+func (d *deadLetters) List(ctx context.Context, nodeID storj.NodeID, limit int) ([]piecedeletion.DeadLetter, error) {
+	return nil, nil
+}
+
+func (d *deadLetters) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
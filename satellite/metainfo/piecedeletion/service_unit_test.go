@@ -0,0 +1,743 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/common/pb"
+	"storj.io/common/rpc"
+	"storj.io/common/storj"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+)
+
+// offlineNodes reports every node as offline.
+type offlineNodes struct{}
+
+func (offlineNodes) KnownReliable(ctx context.Context, nodeIDs storj.NodeIDList) ([]*pb.Node, error) {
+	return nil, nil
+}
+
+// failingHandler fails every job and counts the handled nodes.
+type failingHandler struct {
+	mu    sync.Mutex
+	nodes []storj.NodeID
+}
+
+func (handler *failingHandler) Handle(ctx context.Context, node storj.NodeURL, queue Queue) {
+	handler.mu.Lock()
+	handler.nodes = append(handler.nodes, node.ID)
+	handler.mu.Unlock()
+
+	for {
+		jobs, ok := queue.PopAll()
+		if !ok {
+			return
+		}
+		for _, job := range jobs {
+			job.Resolve.Failure()
+		}
+	}
+}
+
+// memoryDeadLetters keeps the dead letters in memory.
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func (store *memoryDeadLetters) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, reason DeadLetterReason) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, piece := range pieces {
+		store.letters = append(store.letters, DeadLetter{
+			NodeID:    nodeID,
+			PieceID:   piece,
+			Reason:    reason,
+			CreatedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+func (store *memoryDeadLetters) List(ctx context.Context, nodeID storj.NodeID, limit int) (letters []DeadLetter, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, letter := range store.letters {
+		if len(letters) >= limit {
+			break
+		}
+		if nodeID.IsZero() || letter.NodeID == nodeID {
+			letters = append(letters, letter)
+		}
+	}
+	return letters, nil
+}
+
+func (store *memoryDeadLetters) DeleteBefore(ctx context.Context, before time.Time) (deleted int64, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	kept := store.letters[:0]
+	for _, letter := range store.letters {
+		if letter.CreatedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, letter)
+	}
+	store.letters = kept
+	return deleted, nil
+}
+
+// memoryRetries keeps the retry queue in memory.
+type memoryRetries struct {
+	mu       sync.Mutex
+	pieces   map[storj.NodeID]map[storj.PieceID]int64
+	queuedAt map[storj.PieceID]time.Time
+	nodes    map[storj.NodeID]RetryNode
+}
+
+func newMemoryRetries() *memoryRetries {
+	return &memoryRetries{
+		pieces:   make(map[storj.NodeID]map[storj.PieceID]int64),
+		queuedAt: make(map[storj.PieceID]time.Time),
+		nodes:    make(map[storj.NodeID]RetryNode),
+	}
+}
+
+func (store *memoryRetries) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, pieceBytes int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if len(pieces) == 0 {
+		return nil
+	}
+	if store.pieces[nodeID] == nil {
+		store.pieces[nodeID] = make(map[storj.PieceID]int64)
+	}
+	for _, piece := range pieces {
+		if _, ok := store.pieces[nodeID][piece]; !ok {
+			store.pieces[nodeID][piece] = pieceBytes
+			store.queuedAt[piece] = time.Now()
+		}
+	}
+	return nil
+}
+
+func (store *memoryRetries) Due(ctx context.Context, now time.Time, limit int) (nodes []storj.NodeID, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for nodeID := range store.pieces {
+		if len(nodes) >= limit {
+			break
+		}
+		if !store.nodes[nodeID].RetryAfter.After(now) {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	return nodes, nil
+}
+
+func (store *memoryRetries) Claim(ctx context.Context, nodeID storj.NodeID, now, lease time.Time, limit int, force bool) (RetryNode, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	node := store.nodes[nodeID]
+	if !force && node.RetryAfter.After(now) {
+		return RetryNode{}, nil
+	}
+	node.RetryAfter = lease
+	store.nodes[nodeID] = node
+
+	pieces := make([]storj.PieceID, 0, len(store.pieces[nodeID]))
+	for piece := range store.pieces[nodeID] {
+		pieces = append(pieces, piece)
+	}
+	sort.Slice(pieces, func(i, k int) bool { return bytes.Compare(pieces[i][:], pieces[k][:]) < 0 })
+
+	node.PieceCount = int64(len(pieces))
+	for _, piece := range pieces {
+		if len(node.Pieces) >= limit {
+			break
+		}
+		node.Pieces = append(node.Pieces, piece)
+		node.Bytes += store.pieces[nodeID][piece]
+	}
+	return node, nil
+}
+
+func (store *memoryRetries) Remove(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, piece := range pieces {
+		delete(store.pieces[nodeID], piece)
+		delete(store.queuedAt, piece)
+	}
+	if len(store.pieces[nodeID]) == 0 {
+		delete(store.pieces, nodeID)
+	}
+	delete(store.nodes, nodeID)
+	return nil
+}
+
+func (store *memoryRetries) Failed(ctx context.Context, nodeID storj.NodeID, retryAfter time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	node := store.nodes[nodeID]
+	node.FailedAttempts++
+	node.RetryAfter = retryAfter
+	store.nodes[nodeID] = node
+	return nil
+}
+
+func (store *memoryRetries) Get(ctx context.Context, nodeID storj.NodeID) (RetryNode, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	node := store.nodes[nodeID]
+	node.PieceCount = int64(len(store.pieces[nodeID]))
+	for _, pieceBytes := range store.pieces[nodeID] {
+		node.Bytes += pieceBytes
+	}
+	return node, nil
+}
+
+func (store *memoryRetries) Find(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID) (queued []storj.PieceID, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, piece := range pieces {
+		if _, ok := store.pieces[nodeID][piece]; ok {
+			queued = append(queued, piece)
+		}
+	}
+	return queued, nil
+}
+
+func (store *memoryRetries) Count(ctx context.Context) (count int64, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, pieces := range store.pieces {
+		count += int64(len(pieces))
+	}
+	return count, nil
+}
+
+func (store *memoryRetries) QueuedAfter(ctx context.Context, since time.Time) (map[storj.NodeID][]storj.PieceID, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	queued := make(map[storj.NodeID][]storj.PieceID)
+	for nodeID, pieces := range store.pieces {
+		for piece := range pieces {
+			if store.queuedAt[piece].After(since) {
+				queued[nodeID] = append(queued[nodeID], piece)
+			}
+		}
+	}
+	return queued, nil
+}
+
+// queuedRetries flushes the buffer of the retry queue and returns the number
+// of queued pieces of the node and its failed attempts.
+func queuedRetries(ctx context.Context, t *testing.T, service *Service, nodeID storj.NodeID) (pieces int, failedAttempts int) {
+	service.retryRecorder.Flush()
+
+	node, err := service.Retries().Get(ctx, nodeID)
+	require.NoError(t, err)
+	return int(node.PieceCount), node.FailedAttempts
+}
+
+func (handler *failingHandler) handled() int {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return len(handler.nodes)
+}
+
+func TestService_QueueWhenAllOffline(t *testing.T) {
+	for _, queueWhenAllOffline := range []bool{false, true} {
+		queueWhenAllOffline := queueWhenAllOffline
+		t.Run("", func(t *testing.T) {
+			ctx := testcontext.New(t)
+			defer ctx.Cleanup()
+
+			service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, newMemoryRetries(), &memoryDeadLetters{}, Config{
+				MaxConcurrency:      1,
+				MaxConcurrentPieces: 100,
+				MaxPiecesPerBatch:   10,
+				MaxPiecesPerRequest: 10,
+				RequestTimeout:      time.Second,
+				MaxRetryPieces:      100,
+				QueueWhenAllOffline: queueWhenAllOffline,
+			})
+			require.NoError(t, err)
+			require.NoError(t, service.Run(ctx))
+			defer ctx.Check(service.Close)
+
+			// replace the dialing handler for counting the contacted nodes.
+			handler := &failingHandler{}
+			service.combiner.Close()
+			service.combiner = NewCombiner(ctx, handler, service.newQueue)
+
+			requests := []Request{
+				{Node: storj.NodeURL{ID: testrand.NodeID()}, Pieces: []storj.PieceID{testrand.PieceID(), testrand.PieceID()}},
+				{Node: storj.NodeURL{ID: testrand.NodeID()}, Pieces: []storj.PieceID{testrand.PieceID()}},
+			}
+
+			result, err := service.DeleteWithResult(ctx, requests, 0.75)
+			require.NoError(t, err)
+			require.Equal(t, 3, result.FailedPieces)
+			service.retryRecorder.Flush()
+			count, err := service.Retries().Count(ctx)
+			require.NoError(t, err)
+			require.EqualValues(t, 3, count)
+
+			nodes := []storj.NodeID{requests[0].Node.ID, requests[1].Node.ID}
+			if queueWhenAllOffline {
+				require.Zero(t, handler.handled())
+				require.ElementsMatch(t, nodes, result.OfflineNodeIDs)
+				require.Empty(t, result.FailedNodeIDs)
+			} else {
+				require.Equal(t, 2, handler.handled())
+				require.ElementsMatch(t, nodes, result.FailedNodeIDs)
+				require.Empty(t, result.OfflineNodeIDs)
+			}
+		})
+	}
+}
+
+func TestService_Decommissioning(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, newMemoryRetries(), &memoryDeadLetters{}, Config{
+		MaxConcurrency:      1,
+		MaxConcurrentPieces: 100,
+		MaxPiecesPerBatch:   10,
+		MaxPiecesPerRequest: 10,
+		RequestTimeout:      time.Second,
+		MaxRetryPieces:      100,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+	defer ctx.Check(service.Close)
+
+	// replace the dialing handler for counting the contacted nodes.
+	handler := &failingHandler{}
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, handler, service.newQueue)
+
+	active, queued, skipped := testrand.NodeID(), testrand.NodeID(), testrand.NodeID()
+	service.Decommissioning().Add(DecommissionQueue, queued)
+	service.Decommissioning().Add(DecommissionSkip, skipped)
+	require.Equal(t, map[storj.NodeID]DecommissionMode{
+		queued:  DecommissionQueue,
+		skipped: DecommissionSkip,
+	}, service.Decommissioning().Nodes())
+
+	requests := func() []Request {
+		return []Request{
+			{Node: storj.NodeURL{ID: active}, Pieces: []storj.PieceID{testrand.PieceID()}},
+			{Node: storj.NodeURL{ID: queued}, Pieces: []storj.PieceID{testrand.PieceID(), testrand.PieceID()}},
+			{Node: storj.NodeURL{ID: skipped}, Pieces: []storj.PieceID{testrand.PieceID(), testrand.PieceID(), testrand.PieceID()}},
+		}
+	}
+
+	result, err := service.DeleteWithResult(ctx, requests(), 0.75)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Nodes)
+	require.Equal(t, 1, result.FailedPieces)
+	require.Equal(t, 5, result.DecommissioningPieces)
+	require.Equal(t, 1, handler.handled())
+
+	pieces, _ := queuedRetries(ctx, t, service, active)
+	require.Equal(t, 1, pieces)
+	pieces, _ = queuedRetries(ctx, t, service, queued)
+	require.Equal(t, 2, pieces)
+	pieces, _ = queuedRetries(ctx, t, service, skipped)
+	require.Zero(t, pieces)
+
+	skippedLetters, err := service.DeadLetters().List(ctx, skipped, 10)
+	require.NoError(t, err)
+	require.Len(t, skippedLetters, 3)
+	for _, letter := range skippedLetters {
+		require.Equal(t, DeadLetterDecommissioned, letter.Reason)
+	}
+
+	// the deletions are sent to the nodes again once they aren't
+	// decommissioning anymore.
+	service.Decommissioning().Remove(queued, skipped)
+	result, err = service.DeleteWithResult(ctx, requests(), 0.75)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Nodes)
+	require.Zero(t, result.DecommissioningPieces)
+	require.Equal(t, 4, handler.handled())
+}
+
+func TestService_DeadLetterRetriesExhausted(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	deadLetters := &memoryDeadLetters{}
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, newMemoryRetries(), deadLetters, Config{
+		MaxConcurrency:      1,
+		MaxConcurrentPieces: 100,
+		MaxPiecesPerBatch:   10,
+		MaxPiecesPerRequest: 10,
+		RequestTimeout:      time.Second,
+		MaxRetryPieces:      100,
+		MaxRetryAttempts:    2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+	defer ctx.Check(service.Close)
+
+	// the node is gone, so every deletion fails.
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, &failingHandler{}, service.newQueue)
+
+	gone := testrand.NodeID()
+	piece := testrand.PieceID()
+
+	result, err := service.DeleteWithResult(ctx, []Request{
+		{Node: storj.NodeURL{ID: gone}, Pieces: []storj.PieceID{piece}},
+	}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.FailedPieces)
+	pieces, failedAttempts := queuedRetries(ctx, t, service, gone)
+	require.Equal(t, 1, pieces)
+	require.Zero(t, failedAttempts)
+
+	// the first retry fails and keeps the piece queued.
+	_, err = service.FlushNode(ctx, gone)
+	require.Error(t, err)
+	pieces, failedAttempts = queuedRetries(ctx, t, service, gone)
+	require.Equal(t, 1, pieces)
+	require.Equal(t, 1, failedAttempts)
+
+	letters, err := service.DeadLetters().List(ctx, storj.NodeID{}, 10)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+
+	// the last retry gives the piece up.
+	_, err = service.FlushNode(ctx, gone)
+	require.Error(t, err)
+	pieces, failedAttempts = queuedRetries(ctx, t, service, gone)
+	require.Zero(t, pieces)
+	require.Zero(t, failedAttempts)
+
+	letters, err = service.DeadLetters().List(ctx, gone, 10)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, gone, letters[0].NodeID)
+	require.Equal(t, piece, letters[0].PieceID)
+	require.Equal(t, DeadLetterRetriesExhausted, letters[0].Reason)
+
+	// nothing is left for retrying.
+	flushed, err := service.FlushNode(ctx, gone)
+	require.NoError(t, err)
+	require.Zero(t, flushed)
+}
+
+// stallingHandler succeeds the jobs of the fast node and holds the jobs of
+// the others until the combiner is closed.
+type stallingHandler struct {
+	fast storj.NodeID
+}
+
+func (handler *stallingHandler) Handle(ctx context.Context, node storj.NodeURL, queue Queue) {
+	if node.ID != handler.fast {
+		// the worker fails the jobs left in the queue.
+		<-ctx.Done()
+		return
+	}
+
+	for {
+		jobs, ok := queue.PopAll()
+		if !ok {
+			return
+		}
+		for _, job := range jobs {
+			job.Resolve.Success()
+		}
+	}
+}
+
+func TestService_DeadlineExceeded(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, newMemoryRetries(), &memoryDeadLetters{}, Config{
+		MaxConcurrency:       2,
+		MaxConcurrentPieces:  100,
+		MaxPiecesPerBatch:    10,
+		MaxPiecesPerRequest:  10,
+		RequestTimeout:       time.Second,
+		MaxRetryPieces:       100,
+		ShutdownDrainTimeout: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+	defer ctx.Check(service.Close)
+
+	fast, stalled := testrand.NodeID(), testrand.NodeID()
+
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, &stallingHandler{fast: fast}, service.newQueue)
+
+	const deadline = 100 * time.Millisecond
+	deleteCtx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	// all the nodes are required, the deletion returns at the deadline with
+	// the fast node only, without an error.
+	start := time.Now()
+	result, err := service.DeleteWithResult(deleteCtx, []Request{
+		{Node: storj.NodeURL{ID: fast, Address: "fast.example.test:7777"}, Pieces: []storj.PieceID{testrand.PieceID()}},
+		{Node: storj.NodeURL{ID: stalled, Address: "stalled.example.test:7777"}, Pieces: []storj.PieceID{testrand.PieceID()}},
+	}, 1)
+	require.NoError(t, err)
+	require.True(t, time.Since(start) < 10*deadline, "the deletion didn't return at the deadline")
+	require.True(t, result.DeadlineExceeded)
+	require.Equal(t, 2, result.Nodes)
+	require.Equal(t, 1, result.SucceededNodes)
+	// the stalled node hasn't answered, so its outcome isn't known.
+	require.Equal(t, []storj.NodeID{stalled}, result.PendingNodeIDs)
+	require.Empty(t, result.FailedNodeIDs)
+
+	// reaching the threshold before the deadline isn't reported.
+	result, err = service.DeleteWithResult(ctx, []Request{
+		{Node: storj.NodeURL{ID: fast, Address: "fast.example.test:7777"}, Pieces: []storj.PieceID{testrand.PieceID()}},
+	}, 1)
+	require.NoError(t, err)
+	require.False(t, result.DeadlineExceeded)
+	require.Equal(t, 1, result.SucceededNodes)
+	require.Empty(t, result.PendingNodeIDs)
+}
+
+func TestService_CloseDrains(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const drainTimeout = 100 * time.Millisecond
+
+	retries, deadLetters := newMemoryRetries(), &memoryDeadLetters{}
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, retries, deadLetters, Config{
+		MaxConcurrency:       2,
+		MaxConcurrentPieces:  100,
+		MaxPiecesPerBatch:    10,
+		MaxPiecesPerRequest:  10,
+		RequestTimeout:       time.Second,
+		MaxRetryPieces:       100,
+		ShutdownDrainTimeout: drainTimeout,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+
+	fast, stalled, queued := testrand.NodeID(), testrand.NodeID(), testrand.NodeID()
+	stalledPieces := []storj.PieceID{testrand.PieceID(), testrand.PieceID()}
+
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, &stallingHandler{fast: fast}, service.newQueue)
+
+	// the deletion returns once the fast node is done, while the stalled
+	// node is still in flight.
+	result, err := service.DeleteWithResult(ctx, []Request{
+		{Node: storj.NodeURL{ID: fast, Address: "fast.example.test:7777"}, Pieces: []storj.PieceID{testrand.PieceID()}},
+		{Node: storj.NodeURL{ID: stalled, Address: "stalled.example.test:7777"}, Pieces: stalledPieces},
+	}, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.SucceededNodes)
+	require.EqualValues(t, len(stalledPieces), service.Backlog().PendingPieces)
+
+	queuedPiece := testrand.PieceID()
+	require.True(t, service.retryRecorder.Add(queued, []storj.PieceID{queuedPiece}, 0))
+
+	start := time.Now()
+	require.NoError(t, service.Close())
+	require.True(t, time.Since(start) >= drainTimeout, "close didn't wait for the in-flight deletions")
+
+	// nothing is left behind in memory.
+	backlog := service.Backlog()
+	require.Zero(t, backlog.PendingPieces)
+	require.Zero(t, backlog.RetryPieces)
+
+	// the unfinished deletions and the buffered ones are kept for retrying
+	// them after the restart, nothing is given up.
+	found, err := retries.Find(ctx, stalled, stalledPieces)
+	require.NoError(t, err)
+	require.ElementsMatch(t, stalledPieces, found)
+
+	found, err = retries.Find(ctx, queued, []storj.PieceID{queuedPiece})
+	require.NoError(t, err)
+	require.Equal(t, []storj.PieceID{queuedPiece}, found)
+
+	count, err := retries.Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, len(stalledPieces)+1, count)
+
+	letters, err := deadLetters.List(ctx, storj.NodeID{}, 10)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+}
+
+func TestDeadLetterRecorder(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	store := &memoryDeadLetters{}
+	recorder := newDeadLetterRecorder(zaptest.NewLogger(t), store, 3, time.Hour, 0)
+
+	node := testrand.NodeID()
+	pieces := []storj.PieceID{testrand.PieceID(), testrand.PieceID()}
+	require.True(t, recorder.Add(node, pieces, DeadLetterRetriesExhausted))
+	// the buffer is full, so the pieces are dropped.
+	require.False(t, recorder.Add(node, []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, DeadLetterQueueFull))
+
+	// nothing is recorded before flushing.
+	letters, err := store.List(ctx, node, 10)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+
+	recorder.Close()
+	letters, err = store.List(ctx, node, 10)
+	require.NoError(t, err)
+	require.Len(t, letters, len(pieces))
+	for _, letter := range letters {
+		require.Contains(t, pieces, letter.PieceID)
+		require.Equal(t, DeadLetterRetriesExhausted, letter.Reason)
+	}
+
+	// the buffer is empty again after flushing.
+	require.True(t, recorder.Add(node, []storj.PieceID{testrand.PieceID(), testrand.PieceID()}, DeadLetterQueueFull))
+	recorder.Flush()
+
+	// the letters older than the retention are deleted.
+	store.mu.Lock()
+	store.letters[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	store.mu.Unlock()
+
+	recorder.DeleteExpired(ctx)
+	letters, err = store.List(ctx, node, 10)
+	require.NoError(t, err)
+	require.Len(t, letters, 3)
+}
+
+func TestService_DeadLettersInBackground(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	deadLetters := &memoryDeadLetters{}
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, newMemoryRetries(), deadLetters, Config{
+		MaxConcurrency:      1,
+		MaxConcurrentPieces: 100,
+		MaxPiecesPerBatch:   10,
+		MaxPiecesPerRequest: 10,
+		RequestTimeout:      time.Second,
+		MaxRetryPieces:      100,
+		MaxRetryAttempts:    1,
+		MaxDeadLetterPieces: 100,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+	defer ctx.Check(service.Close)
+
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, &failingHandler{}, service.newQueue)
+
+	gone := testrand.NodeID()
+	_, err = service.DeleteWithResult(ctx, []Request{
+		{Node: storj.NodeURL{ID: gone}, Pieces: []storj.PieceID{testrand.PieceID()}},
+	}, 1)
+	require.NoError(t, err)
+
+	// the last retry gives the piece up, it's recorded in the background.
+	_, err = service.FlushNode(ctx, gone)
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		letters, err := deadLetters.List(ctx, gone, 10)
+		return err == nil && len(letters) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestService_RetryDue(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	retries := newMemoryRetries()
+	service, err := NewService(zaptest.NewLogger(t), rpc.Dialer{DialTimeout: time.Second}, offlineNodes{}, retries, &memoryDeadLetters{}, Config{
+		MaxConcurrency:      1,
+		MaxConcurrentPieces: 100,
+		MaxPiecesPerBatch:   10,
+		MaxPiecesPerRequest: 10,
+		RequestTimeout:      time.Second,
+		MaxRetryPieces:      100,
+		RetryInterval:       time.Minute,
+		RetryMaxBackoff:     3 * time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Run(ctx))
+	defer ctx.Check(service.Close)
+
+	handler := &failingHandler{}
+	service.combiner.Close()
+	service.combiner = NewCombiner(ctx, handler, service.newQueue)
+
+	down, decommissioning := testrand.NodeID(), testrand.NodeID()
+	require.NoError(t, retries.Add(ctx, down, []storj.PieceID{testrand.PieceID()}, 0))
+	require.NoError(t, retries.Add(ctx, decommissioning, []storj.PieceID{testrand.PieceID()}, 0))
+	service.Decommissioning().Add(DecommissionQueue, decommissioning)
+
+	// makeDue makes the retry of the node due straight away.
+	makeDue := func(nodeID storj.NodeID) {
+		retries.mu.Lock()
+		node := retries.nodes[nodeID]
+		node.RetryAfter = time.Now().Add(-time.Second)
+		retries.nodes[nodeID] = node
+		retries.mu.Unlock()
+	}
+
+	// the backoff doubles after every failed attempt, up to the maximum.
+	for attempt, backoff := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		makeDue(down)
+		start := time.Now()
+		require.NoError(t, service.RetryDue(ctx))
+		require.Equal(t, attempt+1, handler.handled())
+
+		node, err := retries.Get(ctx, down)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, node.PieceCount)
+		require.Equal(t, attempt+1, node.FailedAttempts)
+		require.WithinDuration(t, start.Add(backoff), node.RetryAfter, 10*time.Second)
+
+		// the node isn't retried before its backoff passes.
+		require.NoError(t, service.RetryDue(ctx))
+		require.Equal(t, attempt+1, handler.handled())
+	}
+
+	// the decommissioning node is left alone.
+	node, err := retries.Get(ctx, decommissioning)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, node.PieceCount)
+	require.Zero(t, node.FailedAttempts)
+
+	require.Equal(t, 2, service.Backlog().RetryPieces)
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package piecedeletion
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// deletionThreshold is like sync2.SuccessThreshold, but it also accepts a
+// single task, e.g. when all the other nodes of a deletion are decommissioning
+// or when the queued deletions of a node are flushed.
+type deletionThreshold struct {
+	toSucceed int64
+	pending   int64
+	successes int64
+
+	done chan struct{}
+	once sync.Once
+}
+
+// newDeletionThreshold returns a threshold which is done when the
+// successThreshold fraction of the tasks have succeeded or all of them have
+// finished.
+func newDeletionThreshold(tasks int, successThreshold float64) (*deletionThreshold, error) {
+	switch {
+	case tasks <= 0:
+		return nil, Error.New("invalid number of tasks %d, it must be greater than 0", tasks)
+	case successThreshold <= 0 || successThreshold > 1:
+		return nil, Error.New("invalid success threshold %f, it must be greater than 0 and less or equal to 1", successThreshold)
+	}
+
+	toSucceed := int64(math.Ceil(float64(tasks) * successThreshold))
+	// just in case of floating point issues.
+	if toSucceed > int64(tasks) {
+		toSucceed = int64(tasks)
+	}
+
+	return &deletionThreshold{
+		toSucceed: toSucceed,
+		pending:   int64(tasks),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Success records a successful task.
+func (threshold *deletionThreshold) Success() {
+	atomic.AddInt64(&threshold.successes, 1)
+
+	if atomic.AddInt64(&threshold.toSucceed, -1) <= 0 {
+		threshold.markAsDone()
+	}
+	if atomic.AddInt64(&threshold.pending, -1) <= 0 {
+		threshold.markAsDone()
+	}
+}
+
+// Failure records a failed task.
+func (threshold *deletionThreshold) Failure() {
+	if atomic.AddInt64(&threshold.pending, -1) <= 0 {
+		threshold.markAsDone()
+	}
+}
+
+// Wait waits until the threshold is reached, all the tasks have finished or
+// ctx is canceled.
+func (threshold *deletionThreshold) Wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-threshold.done:
+	}
+}
+
+// SuccessCount returns the number of successful tasks so far.
+func (threshold *deletionThreshold) SuccessCount() int {
+	return int(atomic.LoadInt64(&threshold.successes))
+}
+
+// markAsDone closes the done channel once.
+func (threshold *deletionThreshold) markAsDone() {
+	threshold.once.Do(func() {
+		close(threshold.done)
+	})
+}
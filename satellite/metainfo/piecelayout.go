@@ -0,0 +1,141 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// GetObjectPieceLayoutOptions defines the options for getting a page of the
+// piece layout of an object.
+type GetObjectPieceLayoutOptions struct {
+	// Cursor is the position of the first segment of the page, as returned
+	// by the previous page. Zero starts with the first segment.
+	Cursor int64
+	// Limit is the maximum number of segments of the page.
+	Limit int
+}
+
+// SegmentPieceLayout is the placement of the pieces of a segment.
+type SegmentPieceLayout struct {
+	// Index is the index of the segment, metabase.LastSegmentIndex for the
+	// last segment.
+	Index int64
+	// Inline is set for inline segments, which don't have pieces.
+	Inline bool
+	// Size is the encrypted size of the segment.
+	Size int64
+
+	RootPieceID storj.PieceID
+	Pieces      []*pb.RemotePiece
+}
+
+// ObjectPieceLayoutPage is a page of the piece layout of an object.
+type ObjectPieceLayoutPage struct {
+	// Segments are sorted by index, the last segment comes at the end of the
+	// last page.
+	Segments []SegmentPieceLayout
+	More     bool
+	// Cursor is the cursor of the next page when More is set.
+	Cursor int64
+}
+
+// GetObjectPieceLayout returns a page of the piece layout of the object:
+// which nodes store which pieces of each segment. The layout of objects with
+// many segments is returned in pages of at most opts.Limit segments, so
+// neither the satellite nor the caller hold it in memory at once.
+func (s *Service) GetObjectPieceLayout(ctx context.Context, location metabase.ObjectLocation, opts GetObjectPieceLayoutOptions) (page ObjectPieceLayoutPage, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if opts.Cursor < 0 {
+		return ObjectPieceLayoutPage{}, Error.New("negative cursor")
+	}
+	if opts.Limit <= 0 {
+		return ObjectPieceLayoutPage{}, Error.New("non-positive limit")
+	}
+
+	lastSegment, err := s.Get(ctx, location.LastSegment().Encode())
+	if err != nil {
+		return ObjectPieceLayoutPage{}, err
+	}
+
+	streamMeta := &pb.StreamMeta{}
+	err = pb.Unmarshal(lastSegment.Metadata, streamMeta)
+	if err != nil {
+		return ObjectPieceLayoutPage{}, Error.Wrap(err)
+	}
+	// when the number of segments is encrypted by the client, the segments
+	// are read until the first missing one.
+	segmentCount := streamMeta.NumberOfSegments
+
+	// one more key than the page tells whether there are further segments.
+	keys := make([]metabase.SegmentKey, 0, opts.Limit+1)
+	for index := opts.Cursor; len(keys) < opts.Limit+1; index++ {
+		if segmentCount > 0 && index >= segmentCount-1 {
+			break
+		}
+		segment, err := location.Segment(index)
+		if err != nil {
+			return ObjectPieceLayoutPage{}, Error.Wrap(err)
+		}
+		keys = append(keys, segment.Encode())
+	}
+
+	pointers, err := s.GetItems(ctx, keys)
+	if err != nil {
+		return ObjectPieceLayoutPage{}, err
+	}
+
+	for i, pointer := range pointers {
+		if pointer == nil {
+			if segmentCount > 0 {
+				return ObjectPieceLayoutPage{}, Error.New("segment %d is missing", opts.Cursor+int64(i))
+			}
+			pointers = pointers[:i]
+			break
+		}
+	}
+
+	if len(pointers) > opts.Limit {
+		for i, pointer := range pointers[:opts.Limit] {
+			page.Segments = append(page.Segments, segmentPieceLayout(opts.Cursor+int64(i), pointer))
+		}
+		page.More = true
+		page.Cursor = opts.Cursor + int64(opts.Limit)
+		return page, nil
+	}
+
+	for i, pointer := range pointers {
+		page.Segments = append(page.Segments, segmentPieceLayout(opts.Cursor+int64(i), pointer))
+	}
+
+	// the last segment comes after all the others, when it fits.
+	if len(page.Segments) < opts.Limit {
+		page.Segments = append(page.Segments, segmentPieceLayout(metabase.LastSegmentIndex, lastSegment))
+		return page, nil
+	}
+
+	page.More = true
+	page.Cursor = opts.Cursor + int64(len(page.Segments))
+	return page, nil
+}
+
+// segmentPieceLayout returns the piece layout of the segment.
+func segmentPieceLayout(index int64, pointer *pb.Pointer) SegmentPieceLayout {
+	layout := SegmentPieceLayout{
+		Index: index,
+		Size:  pointer.SegmentSize,
+	}
+	if pointer.Type == pb.Pointer_INLINE || pointer.Remote == nil {
+		layout.Inline = true
+		return layout
+	}
+	layout.RootPieceID = pointer.Remote.RootPieceId
+	layout.Pieces = pointer.Remote.RemotePieces
+	return layout
+}
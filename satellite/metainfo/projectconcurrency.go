@@ -0,0 +1,68 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"sync"
+
+	"storj.io/common/uuid"
+)
+
+// ProjectConcurrencyLimiter limits the number of operations which each
+// project runs at the same time. Unlike BucketDeletionLimiter, the operations
+// above the limit are refused rather than queued, so a single project can't
+// pile up heavy queries.
+type ProjectConcurrencyLimiter struct {
+	max int
+
+	mu      sync.Mutex
+	running map[uuid.UUID]int
+}
+
+// NewProjectConcurrencyLimiter returns a limiter which lets each project run
+// at most max operations at the same time. max <= 0 means no limit.
+func NewProjectConcurrencyLimiter(max int) *ProjectConcurrencyLimiter {
+	return &ProjectConcurrencyLimiter{
+		max:     max,
+		running: map[uuid.UUID]int{},
+	}
+}
+
+// TryAcquire starts an operation of the project when the project runs fewer
+// operations than the limit. The returned release func must be called when
+// the operation finishes. ok is false when the operation can't start.
+func (limiter *ProjectConcurrencyLimiter) TryAcquire(projectID uuid.UUID) (release func(), ok bool) {
+	if limiter.max <= 0 {
+		return func() {}, true
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if limiter.running[projectID] >= limiter.max {
+		return nil, false
+	}
+	limiter.running[projectID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+
+			limiter.running[projectID]--
+			if limiter.running[projectID] <= 0 {
+				delete(limiter.running, projectID)
+			}
+		})
+	}, true
+}
+
+// Running returns the number of operations the project runs.
+func (limiter *ProjectConcurrencyLimiter) Running(projectID uuid.UUID) int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.running[projectID]
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/satellite/metainfo"
+)
+
+func TestProjectConcurrencyLimiter(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const (
+		max      = 3
+		listings = 10
+	)
+
+	limiter := metainfo.NewProjectConcurrencyLimiter(max)
+	projectID := testrand.UUID()
+
+	var mu sync.Mutex
+	var releases []func()
+	rejected := 0
+
+	// all the listings try to start while the accepted ones are running.
+	var wg sync.WaitGroup
+	for i := 0; i < listings; i++ {
+		wg.Add(1)
+		ctx.Go(func() error {
+			defer wg.Done()
+
+			release, ok := limiter.TryAcquire(projectID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				releases = append(releases, release)
+			} else {
+				rejected++
+			}
+			return nil
+		})
+	}
+	wg.Wait()
+
+	require.Len(t, releases, max)
+	require.Equal(t, listings-max, rejected)
+	require.Equal(t, max, limiter.Running(projectID))
+
+	// the limit is per project.
+	otherRelease, ok := limiter.TryAcquire(testrand.UUID())
+	require.True(t, ok)
+	otherRelease()
+
+	// releasing twice frees a single slot.
+	releases[0]()
+	releases[0]()
+	require.Equal(t, max-1, limiter.Running(projectID))
+
+	release, ok := limiter.TryAcquire(projectID)
+	require.True(t, ok)
+	_, ok = limiter.TryAcquire(projectID)
+	require.False(t, ok)
+
+	release()
+	for _, release := range releases[1:] {
+		release()
+	}
+	require.Zero(t, limiter.Running(projectID))
+}
+
+func TestProjectConcurrencyLimiter_NoLimit(t *testing.T) {
+	limiter := metainfo.NewProjectConcurrencyLimiter(0)
+	projectID := testrand.UUID()
+
+	for i := 0; i < 100; i++ {
+		_, ok := limiter.TryAcquire(projectID)
+		require.True(t, ok)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"storj.io/common/sync2"
+	"storj.io/storj/private/dbutil/cockroachutil"
+	"storj.io/storj/private/dbutil/pgutil/pgerrcode"
+)
+
+// pgDeadlockDetected is the error code of a transaction aborted because of
+// a deadlock.
+const pgDeadlockDetected = "40P01"
+
+// needsTransactionRetry returns whether the pointerDB transaction failed
+// because of a serialization failure or a deadlock with concurrent ones,
+// which rolled it back, hence it can be retried.
+func needsTransactionRetry(err error) bool {
+	return cockroachutil.NeedsRetry(err) || pgerrcode.FromError(err) == pgDeadlockDetected
+}
+
+// withTransactionRetry calls fn, which runs a pointerDB transaction, and
+// calls it again at most Config.TransactionRetries times while it fails
+// with a serialization failure or a deadlock, after a random delay up to
+// Config.TransactionRetryJitter so that the concurrent transactions don't
+// collide again.
+func (endpoint *Endpoint) withTransactionRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	for retry := 0; retry < endpoint.config.TransactionRetries && err != nil && needsTransactionRetry(err); retry++ {
+		mon.Meter("pointerdb_transaction_retry").Mark(1)
+
+		var delay time.Duration
+		if endpoint.config.TransactionRetryJitter > 0 {
+			delay = time.Duration(rand.Int63n(int64(endpoint.config.TransactionRetryJitter)))
+		}
+		if !sync2.Sleep(ctx, delay) {
+			return err
+		}
+		err = fn(ctx)
+	}
+	return err
+}
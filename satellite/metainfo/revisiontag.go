@@ -0,0 +1,40 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"storj.io/common/pb"
+	"storj.io/uplink/private/storage/meta"
+)
+
+// revisionTagMetaFlags are the list flags which populate the fields of the
+// pointer which ObjectRevisionTag uses.
+const revisionTagMetaFlags = meta.Modified | meta.Size | meta.UserDefined
+
+// ObjectRevisionTag returns a tag of the upload of the object whose last
+// segment is lastSegment.
+//
+// It isn't a hash of the content: the satellite doesn't see the content of
+// the objects and pointerDB has no room for storing a content hash when the
+// object is committed. The tag is derived from the last segment instead: its
+// encrypted metadata, which is encrypted with a new nonce by every upload,
+// its size and its creation time. It's stable across reads and changes
+// whenever the object is uploaded again, even with the same content, hence
+// it can't be used as an S3 ETag.
+func ObjectRevisionTag(lastSegment *pb.Pointer) string {
+	var buf [8]byte
+
+	hash := sha256.New()
+	binary.BigEndian.PutUint64(buf[:], uint64(lastSegment.CreationDate.UnixNano()))
+	_, _ = hash.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(lastSegment.SegmentSize))
+	_, _ = hash.Write(buf[:])
+	_, _ = hash.Write(lastSegment.Metadata)
+
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
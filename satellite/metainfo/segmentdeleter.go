@@ -0,0 +1,78 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+)
+
+// SegmentDeleter deletes single segments regardless of the state of their
+// objects. It only needs pointerDB and the piece deletion service, hence it
+// can run in a different process than the metainfo endpoint, e.g. the admin
+// peer.
+type SegmentDeleter struct {
+	log            *zap.Logger
+	metainfo       *Service
+	deletePieces   *piecedeletion.Service
+	inlineContents InlineContentsDB
+	sharedSegments SharedSegmentsDB
+}
+
+// NewSegmentDeleter creates a new segment deleter.
+func NewSegmentDeleter(log *zap.Logger, metainfo *Service, deletePieces *piecedeletion.Service, inlineContents InlineContentsDB, sharedSegments SharedSegmentsDB) *SegmentDeleter {
+	return &SegmentDeleter{
+		log:            log,
+		metainfo:       metainfo,
+		deletePieces:   deletePieces,
+		inlineContents: inlineContents,
+		sharedSegments: sharedSegments,
+	}
+}
+
+// ForceDeleteSegment deletes the segment with the key and its pieces
+// regardless of the state of its object, e.g. for cleaning up the segments of
+// zombie objects. It returns the number of pieces which were sent for
+// deletion.
+//
+// It doesn't perform any authorization check, hence it's only meant for
+// administrative tools.
+func (deleter *SegmentDeleter) ForceDeleteSegment(ctx context.Context, key metabase.SegmentKey) (deletedPieces int, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if _, err := metabase.ParseSegmentKey(key); err != nil {
+		return 0, rpcstatus.Error(rpcstatus.InvalidArgument, err.Error())
+	}
+
+	keys, pointers, err := deleter.metainfo.UnsynchronizedGetDel(ctx, []metabase.SegmentKey{key})
+	if err != nil {
+		return 0, rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+	if len(keys) == 0 {
+		return 0, rpcstatus.Error(rpcstatus.NotFound, "segment not found")
+	}
+
+	ReleaseInlineContents(ctx, deleter.log, deleter.inlineContents, pointers)
+	unshared, err := ReleaseSharedSegments(ctx, deleter.sharedSegments, pointers)
+	if err != nil {
+		deleter.log.Error("failed to release shared segment, its pieces are left to garbage collection", zap.Error(err))
+	}
+	requests := newPieceDeletionRequests(unshared)
+	for _, req := range requests {
+		deletedPieces += len(req.Pieces)
+	}
+
+	deleter.log.Info("Segment Force Delete", zap.Int("pieces", deletedPieces))
+
+	if err := deleter.deletePieces.Delete(ctx, requests, deleteObjectPiecesSuccessThreshold); err != nil {
+		deleter.log.Error("failed to delete pieces", zap.Error(err))
+	}
+
+	return deletedPieces, nil
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"storj.io/common/pb"
+	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/storj"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+// GetObjectHealthOptions defines the server-side options for getting an
+// object with the health of its segments.
+type GetObjectHealthOptions struct {
+	// CheckNodes looks up the nodes of the pieces in the overlay: the pieces
+	// on offline or unreliable nodes aren't healthy, like for the repair
+	// checker. Otherwise all the pieces of the pointers count as healthy.
+	CheckNodes bool
+}
+
+// SegmentHealth is the health of a segment of an object.
+type SegmentHealth struct {
+	// Index is the index of the segment, metabase.LastSegmentIndex for the
+	// last segment.
+	Index int64
+	// Inline is set for inline segments, which don't have pieces and never
+	// need repair.
+	Inline bool
+
+	Pieces        int
+	HealthyPieces int
+	// MinimumPieces is the number of pieces needed for reconstructing the
+	// segment.
+	MinimumPieces   int
+	RepairThreshold int
+	// RepairNeeded is set when the segment is repaired by the repair checker
+	// rules: the healthy pieces are at or below the repair threshold, and
+	// below the success threshold.
+	RepairNeeded bool
+}
+
+// GetObjectHealthResult is the result of GetObjectWithHealth.
+type GetObjectHealthResult struct {
+	Object *pb.Object
+	// Segments is the health of the segments sorted by index, with the last
+	// segment at the end.
+	Segments []SegmentHealth
+}
+
+// RepairNeeded returns whether some segments of the object need repair.
+func (result GetObjectHealthResult) RepairNeeded() bool {
+	for _, segment := range result.Segments {
+		if segment.RepairNeeded {
+			return true
+		}
+	}
+	return false
+}
+
+// Downloadable returns whether all the segments of the object have enough
+// healthy pieces for being reconstructed.
+func (result GetObjectHealthResult) Downloadable() bool {
+	for _, segment := range result.Segments {
+		if !segment.Inline && segment.HealthyPieces < segment.MinimumPieces {
+			return false
+		}
+	}
+	return true
+}
+
+// GetObjectWithHealth returns the object, like GetObject, with the health of
+// each of its segments, which tells the operators which segments to repair
+// first. It doesn't perform any authorization check.
+func (endpoint *Endpoint) GetObjectWithHealth(ctx context.Context, projectID uuid.UUID, bucket, encryptedPath []byte, opts GetObjectHealthOptions) (result GetObjectHealthResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	result.Object, err = endpoint.getObject(ctx, projectID, bucket, encryptedPath, -1)
+	if err != nil {
+		return GetObjectHealthResult{}, err
+	}
+
+	segments, err := endpoint.metainfo.ListObjectSegments(ctx, metabase.ObjectLocation{
+		ProjectID:  projectID,
+		BucketName: string(bucket),
+		ObjectKey:  metabase.ObjectKey(encryptedPath),
+	})
+	if err != nil {
+		return GetObjectHealthResult{}, rpcstatus.Error(rpcstatus.Internal, err.Error())
+	}
+
+	unhealthyNodes := map[storj.NodeID]bool{}
+	if opts.CheckNodes {
+		var nodeIDs storj.NodeIDList
+		for _, segment := range segments {
+			for _, piece := range segment.Pointer.GetRemote().GetRemotePieces() {
+				nodeIDs = append(nodeIDs, piece.NodeId)
+			}
+		}
+		if len(nodeIDs) > 0 {
+			badNodes, err := endpoint.overlay.KnownUnreliableOrOffline(ctx, nodeIDs)
+			if err != nil {
+				return GetObjectHealthResult{}, rpcstatus.Error(rpcstatus.Internal, err.Error())
+			}
+			for _, nodeID := range badNodes {
+				unhealthyNodes[nodeID] = true
+			}
+		}
+	}
+
+	for _, segment := range segments {
+		health := SegmentHealth{Index: segment.Index}
+
+		remote := segment.Pointer.Remote
+		if segment.Pointer.Type == pb.Pointer_INLINE || remote == nil {
+			health.Inline = true
+			result.Segments = append(result.Segments, health)
+			continue
+		}
+
+		health.Pieces = len(remote.RemotePieces)
+		for _, piece := range remote.RemotePieces {
+			if !unhealthyNodes[piece.NodeId] {
+				health.HealthyPieces++
+			}
+		}
+
+		redundancy := remote.Redundancy
+		health.MinimumPieces = int(redundancy.GetMinReq())
+		health.RepairThreshold = int(redundancy.GetRepairThreshold())
+		health.RepairNeeded = health.HealthyPieces <= health.RepairThreshold && health.HealthyPieces < int(redundancy.GetSuccessThreshold())
+		if health.RepairNeeded {
+			mon.Meter("get_object_segment_repair_needed").Mark(1)
+		}
+
+		result.Segments = append(result.Segments, health)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/common/storj"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/uplink/private/storage/meta"
+)
+
+// ErrInvalidSegmentKeyCursor is returned when the cursor of a segment key
+// listing isn't a segment key of the listed bucket.
+var ErrInvalidSegmentKeyCursor = errs.Class("invalid segment key cursor")
+
+// ListSegmentKeys lists the raw keys of the segments of the bucket whose
+// encrypted object key starts with prefix, one key per segment, in pointerDB
+// order: by segment index first ("l", "s0", "s1", ...), then by object key.
+// Unlike object listings, it also lists the segments of objects without a
+// last segment, which helps diagnosing sparse and zombie segments.
+//
+// The listing continues after cursor, which is the last key of the previous
+// page, and returns at most limit keys. more is set when there are further
+// keys.
+func (s *Service) ListSegmentKeys(ctx context.Context, projectID uuid.UUID, bucket, prefix []byte, cursor metabase.SegmentKey, limit int) (keys []metabase.SegmentKey, more bool, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if limit <= 0 || limit > listLimit {
+		limit = listLimit
+	}
+
+	var cursorIndex, cursorKey string
+	if len(cursor) > 0 {
+		location, err := metabase.ParseSegmentKey(cursor)
+		if err != nil {
+			return nil, false, ErrInvalidSegmentKeyCursor.Wrap(err)
+		}
+		if location.ProjectID != projectID || location.BucketName != string(bucket) {
+			return nil, false, ErrInvalidSegmentKeyCursor.New("cursor of another bucket")
+		}
+		cursorIndex = strings.SplitN(string(cursor), "/", 3)[1]
+		cursorKey = string(location.ObjectKey)
+	}
+
+	projectPrefix := metabase.SegmentKey(projectID.String())
+	indexCursor := ""
+	for {
+		indexes, moreIndexes, err := s.List(ctx, projectPrefix, indexCursor, false, 0, meta.None)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, index := range indexes {
+			segmentIndex := strings.TrimSuffix(index.Path, "/")
+			if !index.IsPrefix || segmentIndex < cursorIndex {
+				continue
+			}
+
+			// one more key than the limit tells whether there are more.
+			keys, err = s.listIndexSegmentKeys(ctx, projectID, segmentIndex, bucket, prefix, cursorIndex, cursorKey, keys, limit+1)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(keys) > limit {
+				return keys[:limit], true, nil
+			}
+		}
+
+		if !moreIndexes {
+			return keys, false, nil
+		}
+		indexCursor = indexes[len(indexes)-1].Path
+	}
+}
+
+// listIndexSegmentKeys appends to keys the keys of the segments with the
+// segment index whose object key starts with prefix, until keys has max
+// elements. In the index of the cursor, the keys up to the cursor are skipped.
+func (s *Service) listIndexSegmentKeys(ctx context.Context, projectID uuid.UUID, segmentIndex string, bucket, prefix []byte,
+	cursorIndex, cursorKey string, keys []metabase.SegmentKey, max int) (_ []metabase.SegmentKey, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	bucketPrefix := metabase.SegmentKey(storj.JoinPaths(projectID.String(), segmentIndex, string(bucket)))
+
+	start, inclusive := string(prefix), true
+	if segmentIndex == cursorIndex && cursorKey >= start {
+		start, inclusive = cursorKey, false
+	}
+
+	for len(keys) < max {
+		list := s.List
+		if inclusive && start != "" {
+			list = s.ListFrom
+		}
+		segments, more, err := list(ctx, bucketPrefix, start, true, int32(max-len(keys)), meta.None)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, segment := range segments {
+			if !strings.HasPrefix(segment.Path, string(prefix)) {
+				return keys, nil
+			}
+			keys = append(keys, metabase.SegmentKey(storj.JoinPaths(string(bucketPrefix), segment.Path)))
+		}
+
+		if !more {
+			break
+		}
+		start, inclusive = segments[len(segments)-1].Path, false
+	}
+	return keys, nil
+}
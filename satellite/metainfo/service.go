@@ -4,7 +4,11 @@
 package metainfo
 
 import (
+	"bytes"
 	"context"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/zeebo/errs"
@@ -31,6 +35,12 @@ type Service struct {
 	logger    *zap.Logger
 	db        PointerDB
 	bucketsDB BucketsDB
+
+	listReplica Lister
+
+	strictSegmentKeys bool
+
+	OnTestingDeleteEmptyBucketHook func()
 }
 
 // NewService creates new metainfo service.
@@ -38,6 +48,33 @@ func NewService(logger *zap.Logger, db PointerDB, bucketsDB BucketsDB) *Service
 	return &Service{logger: logger, db: db, bucketsDB: bucketsDB}
 }
 
+// Lister lists the pointers of pointerDB, see Service.List.
+type Lister interface {
+	List(ctx context.Context, prefix metabase.SegmentKey, startAfter string, recursive bool, limit int32, metaFlags uint32) (items []*pb.ListResponse_Item, more bool, err error)
+	ListFrom(ctx context.Context, prefix metabase.SegmentKey, startAt string, recursive bool, limit int32, metaFlags uint32) (items []*pb.ListResponse_Item, more bool, err error)
+}
+
+// SetListReplica sets the read replica of pointerDB, which the listings that
+// tolerate staleness are served from. It has to be called before the service
+// is used. The replica is only listed, never written.
+func (s *Service) SetListReplica(replica PointerDB) {
+	s.listReplica = &Service{logger: s.logger.Named("list-replica"), db: replica, bucketsDB: s.bucketsDB}
+}
+
+// SetStrictSegmentKeys sets whether ListObjectSegments, and the deletions
+// depending on it, fail when the segment index of a segment key is
+// malformed, rather than skipping and logging the key. It has to be called
+// before the service is used.
+func (s *Service) SetStrictSegmentKeys(strict bool) {
+	s.strictSegmentKeys = strict
+}
+
+// ListReplica returns the lister of the read replica set with SetListReplica,
+// or nil when there is none.
+func (s *Service) ListReplica() Lister {
+	return s.listReplica
+}
+
 // Put puts pointer to db under specific path.
 func (s *Service) Put(ctx context.Context, key metabase.SegmentKey, pointer *pb.Pointer) (err error) {
 	defer mon.Task()(&ctx)(&err)
@@ -278,10 +315,59 @@ func (s *Service) GetWithBytes(ctx context.Context, key metabase.SegmentKey) (po
 	return pointerBytes, pointer, nil
 }
 
+// ReplaceRemote replaces the remote segment of the pointer under key, whose
+// stored encoding the caller received as oldPointerBytes, e.g. when its
+// pieces have been re-encoded with another redundancy scheme. Everything else
+// is kept. It fails with storage.ErrValueChanged when the pointer has been
+// replaced in the meantime.
+func (s *Service) ReplaceRemote(ctx context.Context, key metabase.SegmentKey, oldPointerBytes []byte, remote *pb.RemoteSegment) (pointer *pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	pointer = &pb.Pointer{}
+	err = pb.Unmarshal(oldPointerBytes, pointer)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	if pointer.Type != pb.Pointer_REMOTE {
+		return nil, Error.New("pointer %q isn't remote", key)
+	}
+	pointer.Remote = remote
+
+	newPointerBytes, err := pb.Marshal(pointer)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	err = s.db.CompareAndSwap(ctx, storage.Key(key), oldPointerBytes, newPointerBytes)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return pointer, nil
+}
+
 // List returns all Path keys in the pointers bucket.
+//
+// Pages are keyed by startAfter and not by an offset: a page starts right
+// after the given key, whether it still exists or not. Hence concurrently
+// deleted or added keys don't shift the pages, and keys which exist during
+// the whole listing are returned exactly once. Keys changed during the
+// listing may or may not be returned. There is no snapshot across pages.
 func (s *Service) List(ctx context.Context, prefix metabase.SegmentKey, startAfter string, recursive bool, limit int32,
 	metaFlags uint32) (items []*pb.ListResponse_Item, more bool, err error) {
 	defer mon.Task()(&ctx)(&err)
+	return s.list(ctx, prefix, startAfter, false, recursive, limit, metaFlags)
+}
+
+// ListFrom is like List, but the page starts at startAt: the startAt key
+// itself is listed, when it exists.
+func (s *Service) ListFrom(ctx context.Context, prefix metabase.SegmentKey, startAt string, recursive bool, limit int32,
+	metaFlags uint32) (items []*pb.ListResponse_Item, more bool, err error) {
+	defer mon.Task()(&ctx)(&err)
+	return s.list(ctx, prefix, startAt, true, recursive, limit, metaFlags)
+}
+
+func (s *Service) list(ctx context.Context, prefix metabase.SegmentKey, start string, inclusive, recursive bool, limit int32,
+	metaFlags uint32) (items []*pb.ListResponse_Item, more bool, err error) {
 
 	var prefixKey storage.Key
 	if len(prefix) != 0 {
@@ -292,11 +378,12 @@ func (s *Service) List(ctx context.Context, prefix metabase.SegmentKey, startAft
 	}
 
 	more, err = storage.ListV2Iterate(ctx, s.db, storage.ListOptions{
-		Prefix:       prefixKey,
-		StartAfter:   storage.Key(startAfter),
-		Recursive:    recursive,
-		Limit:        int(limit),
-		IncludeValue: metaFlags != meta.None,
+		Prefix:            prefixKey,
+		StartAfter:        storage.Key(start),
+		IncludeStartAfter: inclusive,
+		Recursive:         recursive,
+		Limit:             int(limit),
+		IncludeValue:      metaFlags != meta.None,
 	}, func(ctx context.Context, item *storage.ListItem) error {
 		items = append(items, s.createListItem(ctx, *item, metaFlags))
 		return nil
@@ -359,6 +446,259 @@ func (s *Service) setMetadata(item *pb.ListResponse_Item, data []byte, metaFlags
 	return nil
 }
 
+// ObjectSegments returns the keys of all the segments of the object which are
+// stored in pointerDB, including the ones after a missing segment.
+//
+// pointerDB doesn't have an index by object, so it lists the segment indexes
+// used in the project and looks up the segment of the object for each of them.
+func (s *Service) ObjectSegments(ctx context.Context, location metabase.ObjectLocation) (keys []metabase.SegmentKey, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	segments, err := s.ListObjectSegments(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = make([]metabase.SegmentKey, len(segments))
+	for i, segment := range segments {
+		keys[i] = segment.Key
+	}
+	return keys, nil
+}
+
+// DeleteObjectSegments deletes all the segments of the object which are stored
+// in pointerDB, including the ones of sparse and zombie objects, i.e. after a
+// missing segment or without the last segment. It returns the keys and the
+// pointers of the deleted segments, whose remote pieces have to be deleted from
+// the storage nodes by the caller.
+//
+// The segments are deleted without verifying whether they have changed
+// meanwhile, like UnsynchronizedGetDel.
+func (s *Service) DeleteObjectSegments(ctx context.Context, location metabase.ObjectLocation) (keys []metabase.SegmentKey, pointers []*pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	keys, err = s.ObjectSegments(ctx, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil, nil
+	}
+
+	return s.UnsynchronizedGetDel(ctx, keys)
+}
+
+// DeleteObjectUnchanged deletes the segments of a committed object, each of
+// them only when it hasn't changed since it was read, so a concurrent upload
+// of the same object isn't deleted with it. It returns the keys and the
+// pointers of the deleted segments, whose remote pieces have to be deleted
+// from the storage nodes by the caller.
+//
+// The last segment identifies the object, it's deleted first. When it has
+// changed, the object has been deleted or replaced meanwhile and nothing is
+// deleted. The other segments which have changed are left to the upload which
+// wrote them. Nothing is deleted when the object isn't committed, e.g. while
+// it's being uploaded.
+//
+// pointerDB doesn't tell which upload wrote a segment, hence the segments
+// written before they were read are deleted as the object's own. Beginning
+// an upload deletes the existing object, so it only happens with uploads
+// which began before the object was committed.
+func (s *Service) DeleteObjectUnchanged(ctx context.Context, location metabase.ObjectLocation) (keys []metabase.SegmentKey, pointers []*pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	segments, err := s.ListObjectSegments(ctx, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(segments) == 0 || segments[len(segments)-1].Index != metabase.LastSegmentIndex {
+		return nil, nil, nil
+	}
+
+	// the last segment is read and deleted first.
+	var read []storage.ListItem
+	var readPointers []*pb.Pointer
+	for i := len(segments) - 1; i >= 0; i-- {
+		value, err := s.db.Get(ctx, storage.Key(segments[i].Key))
+		if err != nil {
+			if storage.ErrKeyNotFound.Has(err) {
+				if i == len(segments)-1 {
+					return nil, nil, nil
+				}
+				continue
+			}
+			return nil, nil, Error.Wrap(err)
+		}
+
+		pointer := &pb.Pointer{}
+		if err := pb.Unmarshal(value, pointer); err != nil {
+			return nil, nil, Error.Wrap(err)
+		}
+
+		read = append(read, storage.ListItem{Key: storage.Key(segments[i].Key), Value: value})
+		readPointers = append(readPointers, pointer)
+	}
+
+	for i, item := range read {
+		err := s.db.CompareAndSwap(ctx, item.Key, item.Value, nil)
+		if err != nil {
+			if !storage.ErrValueChanged.Has(err) && !storage.ErrKeyNotFound.Has(err) {
+				return keys, pointers, Error.Wrap(err)
+			}
+			if i == 0 {
+				mon.Meter("delete_object_changed_concurrently").Mark(1)
+				return nil, nil, nil
+			}
+			mon.Meter("delete_object_segment_changed_concurrently").Mark(1)
+			continue
+		}
+
+		keys = append(keys, metabase.SegmentKey(item.Key))
+		pointers = append(pointers, readPointers[i])
+	}
+
+	return keys, pointers, nil
+}
+
+// ObjectSegment is a segment of an object stored in pointerDB.
+type ObjectSegment struct {
+	Key metabase.SegmentKey
+	// Index is the index of the segment, metabase.LastSegmentIndex for the
+	// last segment.
+	Index   int64
+	Pointer *pb.Pointer
+}
+
+// ListObjectSegments returns the segments of the object which are stored in
+// pointerDB sorted by index, with the last segment at the end. The indexes
+// of sparse objects have gaps, the missing segments are skipped.
+//
+// pointerDB doesn't have an index by object, so it lists the segment indexes
+// used in the project and looks up the segment of the object for each of them.
+func (s *Service) ListObjectSegments(ctx context.Context, location metabase.ObjectLocation) (segments []ObjectSegment, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var candidates []metabase.SegmentKey
+	prefix := storage.Key(location.ProjectID.String() + "/")
+	startAfter := storage.Key{}
+	for {
+		more, err := storage.ListV2Iterate(ctx, s.db, storage.ListOptions{
+			Prefix:     prefix,
+			StartAfter: startAfter,
+			Recursive:  false,
+		}, func(ctx context.Context, item *storage.ListItem) error {
+			startAfter = storage.CloneKey(item.Key)
+			if !item.IsPrefix {
+				return nil
+			}
+
+			candidates = append(candidates, metabase.SegmentKey(storj.JoinPaths(
+				location.ProjectID.String(),
+				strings.TrimSuffix(item.Key.String(), "/"),
+				location.BucketName,
+				string(location.ObjectKey),
+			)))
+			return nil
+		})
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		if !more {
+			break
+		}
+	}
+
+	for len(candidates) > 0 {
+		batch := candidates
+		if len(batch) > s.db.LookupLimit() {
+			batch = batch[:s.db.LookupLimit()]
+		}
+		candidates = candidates[len(batch):]
+
+		pointers, err := s.GetItems(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for i, pointer := range pointers {
+			if pointer == nil {
+				continue
+			}
+
+			segmentLocation, err := parseSegmentKey(batch[i])
+			if err != nil {
+				if s.strictSegmentKeys {
+					return nil, Error.Wrap(err)
+				}
+				mon.Meter("malformed_segment_key").Mark(1)
+				s.logger.Warn("skipping segment key with a malformed segment index",
+					zap.ByteString("Segment Key", batch[i]),
+					zap.Error(err),
+				)
+				continue
+			}
+			segments = append(segments, ObjectSegment{
+				Key:     batch[i],
+				Index:   segmentLocation.Index,
+				Pointer: pointer,
+			})
+		}
+	}
+
+	// the last segment is sorted after all the others.
+	order := func(index int64) int64 {
+		if index == metabase.LastSegmentIndex {
+			return math.MaxInt64
+		}
+		return index
+	}
+	sort.Slice(segments, func(i, k int) bool {
+		return order(segments[i].Index) < order(segments[k].Index)
+	})
+
+	return segments, nil
+}
+
+// parseSegmentKey decodes the segment key and checks that its segment index
+// is encoded like CreatePath encodes it, e.g. "s7" rather than "s007" or "7",
+// hence the segment can be found again by its index.
+func parseSegmentKey(key metabase.SegmentKey) (metabase.SegmentLocation, error) {
+	location, err := metabase.ParseSegmentKey(key)
+	if err != nil {
+		return metabase.SegmentLocation{}, err
+	}
+	if !bytes.Equal(location.Encode(), key) {
+		return metabase.SegmentLocation{}, Error.New("malformed segment index of %q", key)
+	}
+	return location, nil
+}
+
+// SeekObject returns the key of the first object of the bucket whose key is
+// at or after fromKey, e.g. for resuming a listing or a range deletion.
+// found is false when there is no such object.
+//
+// The objects are looked up through the keys of their last segment, which
+// are sorted in pointerDB, so only the first key at or after fromKey is read.
+func (s *Service) SeekObject(ctx context.Context, projectID uuid.UUID, bucketName string, fromKey metabase.ObjectKey) (key metabase.ObjectKey, found bool, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	prefix := storage.Key(storj.JoinPaths(projectID.String(), metabase.LastSegmentName, bucketName) + "/")
+	_, err = storage.ListV2Iterate(ctx, s.db, storage.ListOptions{
+		Prefix:            prefix,
+		StartAfter:        storage.Key(fromKey),
+		IncludeStartAfter: true,
+		Recursive:         true,
+		Limit:             1,
+	}, func(ctx context.Context, item *storage.ListItem) error {
+		key = metabase.ObjectKey(item.Key)
+		found = true
+		return nil
+	})
+	if err != nil {
+		return "", false, Error.Wrap(err)
+	}
+	return key, found, nil
+}
+
 // Delete deletes a pointer bytes when it matches oldPointerBytes, otherwise it'll fail.
 func (s *Service) Delete(ctx context.Context, key metabase.SegmentKey, oldPointerBytes []byte) (err error) {
 	defer mon.Task()(&ctx)(&err)
@@ -444,6 +784,55 @@ func (s *Service) DeleteBucket(ctx context.Context, bucketName []byte, projectID
 	return s.bucketsDB.DeleteBucket(ctx, bucketName, projectID)
 }
 
+// DeleteEmptyBucket deletes a bucket from the buckets db, like DeleteBucket,
+// but it also fails with ErrBucketNotEmpty when an object is committed while
+// the bucket is deleted: the bucket is checked again after its deletion and
+// restored when it's no longer empty. The restored bucket keeps its settings,
+// but not its creation time.
+func (s *Service) DeleteEmptyBucket(ctx context.Context, bucketName []byte, projectID uuid.UUID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	bucket, err := s.bucketsDB.GetBucket(ctx, bucketName, projectID)
+	if err != nil {
+		return err
+	}
+
+	empty, err := s.IsBucketEmpty(ctx, projectID, bucketName)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return ErrBucketNotEmpty.New("")
+	}
+
+	if s.OnTestingDeleteEmptyBucketHook != nil {
+		s.OnTestingDeleteEmptyBucketHook()
+	}
+
+	err = s.bucketsDB.DeleteBucket(ctx, bucketName, projectID)
+	if err != nil {
+		return err
+	}
+
+	empty, err = s.IsBucketEmpty(ctx, projectID, bucketName)
+	if err == nil && empty {
+		return nil
+	}
+
+	mon.Meter("delete_empty_bucket_restored").Mark(1)
+	if _, restoreErr := s.bucketsDB.CreateBucket(ctx, bucket); restoreErr != nil {
+		s.logger.Error("failed to restore bucket which is no longer empty",
+			zap.Stringer("project_id", projectID),
+			zap.Error(restoreErr),
+		)
+		return errs.Combine(err, restoreErr)
+	}
+	if err != nil {
+		return err
+	}
+	return ErrBucketNotEmpty.New("an object has been added concurrently")
+}
+
 // IsBucketEmpty returns whether bucket is empty.
 func (s *Service) IsBucketEmpty(ctx context.Context, projectID uuid.UUID, bucketName []byte) (bool, error) {
 	prefix, err := CreatePath(ctx, projectID, -1, bucketName, []byte{})
@@ -464,6 +853,12 @@ func (s *Service) ListBuckets(ctx context.Context, projectID uuid.UUID, listOpts
 	return s.bucketsDB.ListBuckets(ctx, projectID, listOpts, allowedBuckets)
 }
 
+// ListBucketsWithOptions returns the buckets of a project which match opts.
+func (s *Service) ListBucketsWithOptions(ctx context.Context, projectID uuid.UUID, opts ListBucketsOptions, allowedBuckets macaroon.AllowedBuckets) (bucketList storj.BucketList, err error) {
+	defer mon.Task()(&ctx)(&err)
+	return s.bucketsDB.ListBucketsWithOptions(ctx, projectID, opts, allowedBuckets)
+}
+
 // CountBuckets returns the number of buckets a project currently has.
 func (s *Service) CountBuckets(ctx context.Context, projectID uuid.UUID) (count int, err error) {
 	defer mon.Task()(&ctx)(&err)
@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zeebo/errs"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
 
 	"storj.io/common/memory"
 	"storj.io/common/pb"
@@ -21,11 +24,25 @@ import (
 	"storj.io/storj/private/testplanet"
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
 	"storj.io/storj/storage"
+	"storj.io/storj/storage/teststore"
+	"storj.io/uplink/private/storage/meta"
 )
 
 const lastSegmentIndex = -1
 
+// testRedundancy is a valid redundancy scheme for the pointers put into the
+// service, which checks it when the race detector is enabled.
+var testRedundancy = &pb.RedundancyScheme{
+	Type:             pb.RedundancyScheme_RS,
+	MinReq:           1,
+	RepairThreshold:  2,
+	SuccessThreshold: 3,
+	Total:            4,
+	ErasureShareSize: 256,
+}
+
 func TestIterate(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 1,
@@ -338,3 +355,660 @@ func TestFixOldStyleObject(t *testing.T) {
 		}
 	})
 }
+
+func TestListObjectSegments(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	projectID := testrand.UUID()
+
+	putSegments := func(objectKey string, indexes ...int64) metabase.ObjectLocation {
+		location := metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(objectKey),
+		}
+		for _, index := range indexes {
+			segment, err := location.Segment(index)
+			require.NoError(t, err)
+
+			err = service.UnsynchronizedPut(ctx, segment.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: []byte{byte(index)},
+			})
+			require.NoError(t, err)
+		}
+		return location
+	}
+
+	indexesOf := func(segments []metainfo.ObjectSegment) []int64 {
+		var indexes []int64
+		for _, segment := range segments {
+			indexes = append(indexes, segment.Index)
+			require.Equal(t, []byte{byte(segment.Index)}, segment.Pointer.InlineSegment)
+		}
+		return indexes
+	}
+
+	contiguous := putSegments("contiguous", lastSegmentIndex, 2, 0, 1)
+	sparse := putSegments("sparse", 5, lastSegmentIndex, 0, 3)
+	// segments of other objects with the same indexes aren't listed.
+	putSegments("contiguous/nested", lastSegmentIndex, 0, 1)
+
+	segments, err := service.ListObjectSegments(ctx, contiguous)
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 1, 2, lastSegmentIndex}, indexesOf(segments))
+
+	segments, err = service.ListObjectSegments(ctx, sparse)
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 3, 5, lastSegmentIndex}, indexesOf(segments))
+
+	keys, err := service.ObjectSegments(ctx, sparse)
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+
+	segments, err = service.ListObjectSegments(ctx, metabase.ObjectLocation{
+		ProjectID:  projectID,
+		BucketName: "bucket",
+		ObjectKey:  "missing",
+	})
+	require.NoError(t, err)
+	require.Empty(t, segments)
+}
+
+func TestDeleteObjectSegments_MalformedIndex(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	db := teststore.New()
+	service := metainfo.NewService(zaptest.NewLogger(t), db, nil)
+	location := metabase.ObjectLocation{
+		ProjectID:  testrand.UUID(),
+		BucketName: "bucket",
+		ObjectKey:  "object",
+	}
+
+	put := func(key metabase.SegmentKey) {
+		err := service.UnsynchronizedPut(ctx, key, &pb.Pointer{
+			Type:          pb.Pointer_INLINE,
+			InlineSegment: []byte{1},
+		})
+		require.NoError(t, err)
+	}
+
+	var valid []metabase.SegmentKey
+	for _, index := range []int64{0, 1, lastSegmentIndex} {
+		segment, err := location.Segment(index)
+		require.NoError(t, err)
+		put(segment.Encode())
+		valid = append(valid, segment.Encode())
+	}
+
+	rawKey := func(index string) metabase.SegmentKey {
+		return metabase.SegmentKey(location.ProjectID.String() + "/" + index + "/bucket/object")
+	}
+	malformed := []metabase.SegmentKey{rawKey("sX"), rawKey("s007"), rawKey("2")}
+	for _, key := range malformed {
+		put(key)
+	}
+
+	// the strict service refuses to delete the object.
+	service.SetStrictSegmentKeys(true)
+	_, _, err := service.DeleteObjectSegments(ctx, location)
+	require.Error(t, err)
+
+	service.SetStrictSegmentKeys(false)
+	keys, pointers, err := service.DeleteObjectSegments(ctx, location)
+	require.NoError(t, err)
+	require.ElementsMatch(t, valid, keys)
+	require.Len(t, pointers, len(valid))
+
+	// the malformed keys are skipped, rather than deleted.
+	for _, key := range malformed {
+		_, err := db.Get(ctx, storage.Key(key))
+		require.NoError(t, err)
+	}
+}
+
+func TestSeekObject(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	projectID := testrand.UUID()
+
+	put := func(bucketName, objectKey string, index int64) {
+		segment, err := metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: bucketName,
+			ObjectKey:  metabase.ObjectKey(objectKey),
+		}.Segment(index)
+		require.NoError(t, err)
+
+		err = service.UnsynchronizedPut(ctx, segment.Encode(), &pb.Pointer{
+			Type:          pb.Pointer_INLINE,
+			InlineSegment: []byte{1},
+		})
+		require.NoError(t, err)
+	}
+
+	for _, objectKey := range []string{"a", "c", "c/d", "e"} {
+		put("bucket", objectKey, lastSegmentIndex)
+	}
+	// segments without their last segment aren't objects.
+	put("bucket", "b", 0)
+	// objects of other buckets aren't found.
+	put("bucket2", "f", lastSegmentIndex)
+	put("other", "aa", lastSegmentIndex)
+
+	for _, tt := range []struct {
+		fromKey metabase.ObjectKey
+		key     metabase.ObjectKey
+		found   bool
+	}{
+		{fromKey: "", key: "a", found: true},
+		{fromKey: "a", key: "a", found: true},
+		{fromKey: "aa", key: "c", found: true},
+		{fromKey: "b", key: "c", found: true},
+		{fromKey: "c/", key: "c/d", found: true},
+		{fromKey: "d", key: "e", found: true},
+		{fromKey: "e", key: "e", found: true},
+		{fromKey: "e0", found: false},
+		{fromKey: "z", found: false},
+	} {
+		key, found, err := service.SeekObject(ctx, projectID, "bucket", tt.fromKey)
+		require.NoError(t, err)
+		require.Equal(t, tt.found, found, tt.fromKey)
+		require.Equal(t, tt.key, key, tt.fromKey)
+	}
+
+	_, found, err := service.SeekObject(ctx, projectID, "empty", "")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestAtRiskObjects(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	projectID := testrand.UUID()
+
+	location := func(objectKey string) metabase.ObjectLocation {
+		return metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(objectKey),
+		}
+	}
+	put := func(objectKey string, index int64, pieces int) {
+		segment, err := location(objectKey).Segment(index)
+		require.NoError(t, err)
+
+		pointer := &pb.Pointer{
+			Type: pb.Pointer_REMOTE,
+			Remote: &pb.RemoteSegment{
+				RootPieceId: testrand.PieceID(),
+				Redundancy: &pb.RedundancyScheme{
+					Type:             pb.RedundancyScheme_RS,
+					MinReq:           2,
+					RepairThreshold:  4,
+					SuccessThreshold: 6,
+					Total:            8,
+					ErasureShareSize: 256,
+				},
+			},
+		}
+		for i := 0; i < pieces; i++ {
+			pointer.Remote.RemotePieces = append(pointer.Remote.RemotePieces, &pb.RemotePiece{
+				PieceNum: int32(i),
+				NodeId:   testrand.NodeID(),
+			})
+		}
+		err = service.UnsynchronizedPut(ctx, segment.Encode(), pointer)
+		require.NoError(t, err)
+	}
+
+	// healthy objects.
+	put("healthy", lastSegmentIndex, 6)
+	put("above-threshold", 0, 5)
+	put("above-threshold", lastSegmentIndex, 6)
+	err := service.UnsynchronizedPut(ctx, location("inline").LastSegment().Encode(), &pb.Pointer{
+		Type:          pb.Pointer_INLINE,
+		InlineSegment: []byte{1},
+	})
+	require.NoError(t, err)
+
+	// at risk objects.
+	put("at-threshold", lastSegmentIndex, 4)
+	put("almost-lost", 0, 2)
+	put("almost-lost", 1, 3)
+	put("almost-lost", lastSegmentIndex, 6)
+
+	objects, err := service.AtRiskObjects(ctx, metainfo.AtRiskObjectsOptions{Limit: 10})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, objects.Count)
+	require.Equal(t, []metainfo.AtRiskObject{
+		{Location: location("almost-lost"), Segments: 2, Margin: 0},
+		{Location: location("at-threshold"), Segments: 1, Margin: 2},
+	}, objects.Objects)
+
+	// the objects are still counted without being returned.
+	objects, err = service.AtRiskObjects(ctx, metainfo.AtRiskObjectsOptions{Limit: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, objects.Count)
+	require.Equal(t, []metainfo.AtRiskObject{
+		{Location: location("almost-lost"), Segments: 2, Margin: 0},
+	}, objects.Objects)
+}
+
+func TestList_ConcurrentDelete(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	location := metabase.ObjectLocation{
+		ProjectID:  testrand.UUID(),
+		BucketName: "bucket",
+	}
+
+	// the kept and the deleted keys are interleaved, so every page is
+	// affected by the deletions.
+	const objectCount = 200
+	var kept []string
+	var deleted []metabase.SegmentKey
+	for i := 0; i < objectCount; i++ {
+		location.ObjectKey = metabase.ObjectKey(fmt.Sprintf("object-%03d", i))
+		segment, err := location.Segment(lastSegmentIndex)
+		require.NoError(t, err)
+
+		err = service.UnsynchronizedPut(ctx, segment.Encode(), &pb.Pointer{
+			Type:          pb.Pointer_INLINE,
+			InlineSegment: []byte{1},
+		})
+		require.NoError(t, err)
+
+		if i%2 == 0 {
+			kept = append(kept, string(location.ObjectKey))
+		} else {
+			deleted = append(deleted, segment.Encode())
+		}
+	}
+
+	prefix, err := metainfo.CreatePath(ctx, location.ProjectID, lastSegmentIndex, []byte(location.BucketName), nil)
+	require.NoError(t, err)
+
+	ctx.Go(func() error {
+		for _, key := range deleted {
+			if err := service.UnsynchronizedDelete(ctx, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	var listed []string
+	cursor := ""
+	for {
+		items, more, err := service.List(ctx, prefix.Encode(), cursor, true, 3, meta.None)
+		require.NoError(t, err)
+		for _, item := range items {
+			listed = append(listed, item.Path)
+		}
+		if !more {
+			break
+		}
+		cursor = items[len(items)-1].Path
+	}
+
+	// the deleted keys may or may not be listed, but the listing goes
+	// forward and all the kept keys are listed exactly once.
+	var listedKept []string
+	for i, path := range listed {
+		if i > 0 {
+			require.Less(t, listed[i-1], path)
+		}
+		if path[len(path)-1]%2 == 0 {
+			listedKept = append(listedKept, path)
+		}
+	}
+	require.Equal(t, kept, listedKept)
+}
+
+func TestDeleteObjectSegments(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const piecesPerSegment = 3
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	projectID := testrand.UUID()
+
+	locationOf := func(objectKey string) metabase.ObjectLocation {
+		return metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(objectKey),
+		}
+	}
+
+	// putSegments puts remote segments at the indexes and an inline one at
+	// inlineIndex, when it isn't nil.
+	putSegments := func(objectKey string, inlineIndex *int64, indexes ...int64) {
+		location := locationOf(objectKey)
+		for _, index := range indexes {
+			segment, err := location.Segment(index)
+			require.NoError(t, err)
+
+			pointer := &pb.Pointer{
+				Type: pb.Pointer_REMOTE,
+				Remote: &pb.RemoteSegment{
+					RootPieceId: testrand.PieceID(),
+					Redundancy:  testRedundancy,
+				},
+			}
+			for num := 0; num < piecesPerSegment; num++ {
+				pointer.Remote.RemotePieces = append(pointer.Remote.RemotePieces, &pb.RemotePiece{
+					PieceNum: int32(num),
+					NodeId:   testrand.NodeID(),
+				})
+			}
+			if inlineIndex != nil && *inlineIndex == index {
+				pointer = &pb.Pointer{
+					Type:          pb.Pointer_INLINE,
+					InlineSegment: []byte{1},
+				}
+			}
+
+			err = service.UnsynchronizedPut(ctx, segment.Encode(), pointer)
+			require.NoError(t, err)
+		}
+	}
+
+	inlineLast := int64(lastSegmentIndex)
+
+	for _, tt := range []struct {
+		name           string
+		inlineIndex    *int64
+		indexes        []int64
+		expectedPieces int
+	}{
+		{name: "inline", inlineIndex: &inlineLast, indexes: []int64{lastSegmentIndex}},
+		{name: "single segment", indexes: []int64{lastSegmentIndex}, expectedPieces: piecesPerSegment},
+		{name: "multiple segments", inlineIndex: &inlineLast, indexes: []int64{0, 1, lastSegmentIndex}, expectedPieces: 2 * piecesPerSegment},
+		{name: "without last segment", indexes: []int64{0, 1}, expectedPieces: 2 * piecesPerSegment},
+		{name: "without first segment", indexes: []int64{1, 2, lastSegmentIndex}, expectedPieces: 3 * piecesPerSegment},
+		{name: "sparse", indexes: []int64{0, 3, 7, lastSegmentIndex}, expectedPieces: 4 * piecesPerSegment},
+		{name: "missing"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			objectKey := "object-" + tt.name
+			putSegments(objectKey, tt.inlineIndex, tt.indexes...)
+			// the segments of other objects are kept.
+			putSegments(objectKey+"/nested", nil, 0, lastSegmentIndex)
+			putSegments(objectKey+"-suffix", nil, 0, lastSegmentIndex)
+
+			keys, pointers, err := service.DeleteObjectSegments(ctx, locationOf(objectKey))
+			require.NoError(t, err)
+			require.Len(t, keys, len(tt.indexes))
+			require.Len(t, pointers, len(tt.indexes))
+
+			pieces := 0
+			for _, nodePieces := range objectdeletion.GroupPiecesByNodeID(pointers) {
+				pieces += len(nodePieces)
+			}
+			require.Equal(t, tt.expectedPieces, pieces)
+
+			segments, err := service.ListObjectSegments(ctx, locationOf(objectKey))
+			require.NoError(t, err)
+			require.Empty(t, segments)
+
+			for _, other := range []string{objectKey + "/nested", objectKey + "-suffix"} {
+				segments, err := service.ListObjectSegments(ctx, locationOf(other))
+				require.NoError(t, err)
+				require.Len(t, segments, 2)
+			}
+		})
+	}
+}
+
+// hookStore calls the hooks after reading and after deleting a key.
+type hookStore struct {
+	*teststore.Client
+	afterGet    func(key storage.Key)
+	afterDelete func(key storage.Key)
+}
+
+func (store *hookStore) Get(ctx context.Context, key storage.Key) (storage.Value, error) {
+	value, err := store.Client.Get(ctx, key)
+	if store.afterGet != nil {
+		store.afterGet(key)
+	}
+	return value, err
+}
+
+func (store *hookStore) CompareAndSwap(ctx context.Context, key storage.Key, oldValue, newValue storage.Value) error {
+	err := store.Client.CompareAndSwap(ctx, key, oldValue, newValue)
+	if err == nil && newValue == nil && store.afterDelete != nil {
+		store.afterDelete(key)
+	}
+	return err
+}
+
+func TestDeleteObjectUnchanged_ConcurrentUpload(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	store := &hookStore{Client: teststore.New()}
+	service := metainfo.NewService(zaptest.NewLogger(t), store, nil)
+	location := metabase.ObjectLocation{
+		ProjectID:  testrand.UUID(),
+		BucketName: "bucket",
+		ObjectKey:  "object",
+	}
+	lastSegment := storage.Key(location.LastSegment().Encode())
+
+	indexes := []int64{0, 1, lastSegmentIndex}
+
+	// upload uploads the object again, it deletes the existing object first
+	// like beginning an upload does.
+	upload := func(version int) error {
+		if _, _, err := service.DeleteObjectSegments(ctx, location); err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			segment, err := location.Segment(index)
+			if err != nil {
+				return err
+			}
+			err = service.UnsynchronizedPut(ctx, segment.Encode(), &pb.Pointer{
+				Type:          pb.Pointer_INLINE,
+				InlineSegment: []byte{byte(version)},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// requireVersion checks that the object is whole and of the version.
+	requireVersion := func(version int) {
+		segments, err := service.ListObjectSegments(ctx, location)
+		require.NoError(t, err)
+		require.Len(t, segments, len(indexes))
+		for _, segment := range segments {
+			require.Equal(t, []byte{byte(version)}, segment.Pointer.InlineSegment)
+		}
+	}
+
+	// once is a hook which uploads the version when it's called for the
+	// last segment, once.
+	once := func(version int) func(key storage.Key) {
+		return func(key storage.Key) {
+			if !key.Equal(lastSegment) {
+				return
+			}
+			store.afterGet, store.afterDelete = nil, nil
+			require.NoError(t, upload(version))
+		}
+	}
+
+	t.Run("uploaded after reading", func(t *testing.T) {
+		require.NoError(t, upload(1))
+
+		// the other segments are read once they're the ones of the new
+		// upload, but the last segment has changed.
+		store.afterGet = once(2)
+		keys, pointers, err := service.DeleteObjectUnchanged(ctx, location)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+		require.Empty(t, pointers)
+
+		requireVersion(2)
+	})
+
+	t.Run("uploaded after deleting the last segment", func(t *testing.T) {
+		require.NoError(t, upload(3))
+
+		// only the last segment is deleted, the other segments have been
+		// replaced by the upload, which wrote the last segment again.
+		store.afterDelete = once(4)
+		keys, pointers, err := service.DeleteObjectUnchanged(ctx, location)
+		require.NoError(t, err)
+		require.Equal(t, []metabase.SegmentKey{metabase.SegmentKey(lastSegment)}, keys)
+		require.Len(t, pointers, 1)
+		require.Equal(t, []byte{3}, pointers[0].InlineSegment)
+
+		requireVersion(4)
+	})
+
+	t.Run("not committed", func(t *testing.T) {
+		require.NoError(t, service.UnsynchronizedDelete(ctx, location.LastSegment().Encode()))
+
+		keys, _, err := service.DeleteObjectUnchanged(ctx, location)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		segments, err := service.ListObjectSegments(ctx, location)
+		require.NoError(t, err)
+		require.Len(t, segments, len(indexes)-1)
+	})
+
+	t.Run("stress", func(t *testing.T) {
+		require.NoError(t, upload(5))
+
+		for version := 6; version < 106; version++ {
+			var group errgroup.Group
+			group.Go(func() error {
+				_, _, err := service.DeleteObjectUnchanged(ctx, location)
+				return err
+			})
+			version := version
+			group.Go(func() error {
+				return upload(version)
+			})
+			require.NoError(t, group.Wait())
+
+			// the deletion only deletes the new version when it has read
+			// it whole, otherwise the new version is left intact.
+			segments, err := service.ListObjectSegments(ctx, location)
+			require.NoError(t, err)
+			if len(segments) > 0 {
+				requireVersion(version)
+			} else {
+				require.NoError(t, upload(version))
+			}
+		}
+	})
+}
+
+func TestNodeObjectsSearches(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	service := metainfo.NewService(zaptest.NewLogger(t), teststore.New(), nil)
+	searches := metainfo.NewNodeObjectsSearches(zaptest.NewLogger(t), service, metainfo.NodeObjectsConfig{
+		MaxConcurrentSearches: 1,
+		MaxFinishedSearches:   1,
+		MaxTrackedObjects:     2,
+	})
+	defer ctx.Check(searches.Close)
+
+	node, other := testrand.NodeID(), testrand.NodeID()
+	projectID := testrand.UUID()
+
+	putObject := func(objectKey string, nodeID storj.NodeID) metabase.ObjectLocation {
+		location := metabase.ObjectLocation{
+			ProjectID:  projectID,
+			BucketName: "bucket",
+			ObjectKey:  metabase.ObjectKey(objectKey),
+		}
+		for _, index := range []int64{0, lastSegmentIndex} {
+			segment, err := location.Segment(index)
+			require.NoError(t, err)
+
+			err = service.UnsynchronizedPut(ctx, segment.Encode(), &pb.Pointer{
+				Type: pb.Pointer_REMOTE,
+				Remote: &pb.RemoteSegment{
+					RootPieceId: testrand.PieceID(),
+					Redundancy:  testRedundancy,
+					RemotePieces: []*pb.RemotePiece{
+						{PieceNum: 0, NodeId: nodeID},
+					},
+				},
+			})
+			require.NoError(t, err)
+		}
+		return location
+	}
+
+	first := putObject("a", node)
+	second := putObject("b", node)
+	putObject("c", other)
+
+	wait := func(nodeID storj.NodeID, limit int) (objects metainfo.NodeObjects) {
+		require.Eventually(t, func() bool {
+			var ok bool
+			objects, ok = searches.Get(nodeID, limit)
+			require.True(t, ok)
+			return objects.Done
+		}, 10*time.Second, 10*time.Millisecond)
+		require.NoError(t, objects.Err)
+		return objects
+	}
+
+	_, ok := searches.Get(node, 10)
+	require.False(t, ok)
+
+	require.NoError(t, searches.Start(node))
+	objects := wait(node, 10)
+	require.EqualValues(t, 6, objects.Pointers)
+	require.EqualValues(t, 4, objects.Segments)
+	require.EqualValues(t, 2, objects.Count)
+	require.False(t, objects.Truncated)
+	require.ElementsMatch(t, []metabase.ObjectLocation{first, second}, objects.Objects)
+
+	// the objects are still counted without being returned.
+	objects, ok = searches.Get(node, 0)
+	require.True(t, ok)
+	require.EqualValues(t, 2, objects.Count)
+	require.Empty(t, objects.Objects)
+
+	// the objects beyond the tracked ones are only counted by segment.
+	putObject("d", node)
+	require.NoError(t, searches.Start(node))
+	objects = wait(node, 10)
+	require.EqualValues(t, 6, objects.Segments)
+	require.EqualValues(t, 2, objects.Count)
+	require.True(t, objects.Truncated)
+
+	// the oldest finished search is dropped for the new one.
+	require.NoError(t, searches.Start(other))
+	objects = wait(other, 10)
+	require.EqualValues(t, 1, objects.Count)
+	_, ok = searches.Get(node, 10)
+	require.False(t, ok)
+}
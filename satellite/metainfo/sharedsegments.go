@@ -0,0 +1,130 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package metainfo
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+)
+
+// referenceSharedSegments adds a reference to the remote segments of the
+// pointers, which are going to be shared with a copy. It returns the pointers
+// whose segment has been referenced, also when it fails.
+func (copier *ObjectCopier) referenceSharedSegments(ctx context.Context, pointers []*pb.Pointer) (referenced []*pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	for _, pointer := range pointers {
+		if pointer == nil || pointer.Type != pb.Pointer_REMOTE || pointer.Remote == nil {
+			continue
+		}
+		if err := copier.sharedSegments.Reference(ctx, pointer.Remote.RootPieceId); err != nil {
+			return referenced, err
+		}
+		referenced = append(referenced, pointer)
+	}
+	return referenced, nil
+}
+
+// releaseSharedSegments releases the references of the deleted pointers to
+// their remote segments, see ReleaseSharedSegments. The pieces of the
+// segments whose references couldn't be released are kept, their number is
+// returned for being reported as unrecoverable.
+func (endpoint *Endpoint) releaseSharedSegments(ctx context.Context, pointers []*pb.Pointer) (unshared []*pb.Pointer, keptPieces int) {
+	unshared, err := ReleaseSharedSegments(ctx, endpoint.sharedSegments, pointers)
+	if err != nil {
+		keptPieces = countPieces(pointers) - countPieces(unshared)
+		endpoint.log.Error("failed to release shared segments, their pieces are left to garbage collection",
+			zap.Int("pieces", keptPieces), zap.Error(err))
+	}
+	return unshared, keptPieces
+}
+
+// ReleaseSharedSegments releases the references of the deleted pointers to
+// their remote segments and returns the pointers whose pieces have to be
+// deleted, which are the ones of the segments not referenced by other objects
+// anymore, and the pointers without remote segment. Every path which deletes
+// the pieces of pointers must call it, otherwise the pieces of their copies
+// are deleted.
+//
+// The references of all the remote segments are looked up by their root
+// piece ID, usually with a single query: only the segments shared with a copy
+// have one. When they can't be released, the error is returned with the
+// pointers without remote segment: the pieces are kept, and garbage
+// collection deletes them once no pointer references them.
+func ReleaseSharedSegments(ctx context.Context, sharedSegments SharedSegmentsDB, pointers []*pb.Pointer) (unshared []*pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	unshared = make([]*pb.Pointer, 0, len(pointers))
+	var remote []*pb.Pointer
+	for _, pointer := range pointers {
+		if pointer != nil && pointer.Type == pb.Pointer_REMOTE && pointer.Remote != nil {
+			remote = append(remote, pointer)
+			continue
+		}
+		unshared = append(unshared, pointer)
+	}
+	if len(remote) == 0 {
+		return unshared, nil
+	}
+
+	released, err := releaseSegmentReferences(ctx, sharedSegments, remote)
+	if err != nil {
+		return unshared, err
+	}
+	return append(unshared, released...), nil
+}
+
+// releaseSegmentReferences releases the references of the pointers to their
+// remote segments and returns the pointers of the segments which aren't
+// referenced anymore. The pointers of the same segment are released
+// together, only one of them is returned.
+func releaseSegmentReferences(ctx context.Context, sharedSegments SharedSegmentsDB, pointers []*pb.Pointer) (unshared []*pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var rootPieceIDs []storj.PieceID
+	for _, pointer := range pointers {
+		if pointer == nil || pointer.Type != pb.Pointer_REMOTE || pointer.Remote == nil {
+			continue
+		}
+		rootPieceIDs = append(rootPieceIDs, pointer.Remote.RootPieceId)
+	}
+	if len(rootPieceIDs) == 0 {
+		return nil, nil
+	}
+
+	referenced, err := sharedSegments.Release(ctx, rootPieceIDs)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[storj.PieceID]bool, len(referenced))
+	for _, rootPieceID := range referenced {
+		skip[rootPieceID] = true
+	}
+
+	for _, pointer := range pointers {
+		if pointer == nil || pointer.Type != pb.Pointer_REMOTE || pointer.Remote == nil {
+			continue
+		}
+		if skip[pointer.Remote.RootPieceId] {
+			mon.Meter("shared_segment_released").Mark(1)
+			continue
+		}
+		// the pieces of the segment are only deleted once.
+		skip[pointer.Remote.RootPieceId] = true
+		unshared = append(unshared, pointer)
+	}
+	return unshared, nil
+}
+
+// countPieces returns the number of remote pieces of the pointers.
+func countPieces(pointers []*pb.Pointer) (count int) {
+	for _, pointer := range pointers {
+		count += len(pointer.GetRemote().GetRemotePieces())
+	}
+	return count
+}
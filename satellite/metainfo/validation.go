@@ -17,6 +17,7 @@ import (
 
 	"storj.io/common/encryption"
 	"storj.io/common/macaroon"
+	"storj.io/common/memory"
 	"storj.io/common/pb"
 	"storj.io/common/rpc/rpcstatus"
 	"storj.io/common/storj"
@@ -297,6 +298,29 @@ func isDigit(r byte) bool {
 	return r >= '0' && r <= '9'
 }
 
+// validatePointerSize checks that the serialized pointer isn't larger than the
+// configured maximum, so a malformed commit, e.g. with a huge piece list, can't
+// store a pointer which slows down every scan over pointerDB.
+func (endpoint *Endpoint) validatePointerSize(ctx context.Context, pointer *pb.Pointer) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if endpoint.config.MaxPointerSize <= 0 {
+		return nil
+	}
+
+	pointerBytes, err := pb.Marshal(pointer)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	pointerSize := memory.Size(len(pointerBytes))
+	if pointerSize > endpoint.config.MaxPointerSize {
+		mon.Meter("oversized_pointer_rejected").Mark(1)
+		return Error.New("pointer is too large, got %v, maximum allowed is %v", pointerSize, endpoint.config.MaxPointerSize)
+	}
+	return nil
+}
+
 func (endpoint *Endpoint) validatePointer(ctx context.Context, pointer *pb.Pointer, originalLimits []*pb.OrderLimit) (err error) {
 	defer mon.Task()(&ctx)(&err)
 
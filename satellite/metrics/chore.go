@@ -64,6 +64,14 @@ func (chore *Chore) Run(ctx context.Context) (err error) {
 		mon.IntVal("remote_dependent_object_count").Observe(chore.Counter.RemoteDependent)
 		mon.IntVal("inline_object_count").Observe(chore.Counter.Inline)
 		mon.IntVal("total_object_count").Observe(chore.Counter.Total)
+		mon.IntVal("missing_last_segment_object_count").Observe(chore.Counter.MissingLastSegment)
+		mon.IntVal("missing_last_segment_project_count").Observe(int64(len(chore.Counter.MissingLastSegmentByProject)))
+		for projectID, count := range chore.Counter.MissingLastSegmentByProject {
+			chore.log.Warn("objects missing their last segment",
+				zap.Stringer("project_id", projectID),
+				zap.Int64("count", count),
+			)
+		}
 
 		return nil
 	})
@@ -8,6 +8,7 @@ import (
 
 	"storj.io/common/pb"
 	"storj.io/common/rpc/rpcstatus"
+	"storj.io/common/uuid"
 	"storj.io/storj/satellite/metainfo/metabase"
 )
 
@@ -18,11 +19,27 @@ type Counter struct {
 	RemoteDependent int64
 	Inline          int64
 	Total           int64
+
+	// MissingLastSegment is the number of objects whose first segment
+	// exists but whose last segment, which makes the object visible, is
+	// missing, e.g. because its upload or its deletion has been interrupted.
+	MissingLastSegment int64
+	// MissingLastSegmentByProject is MissingLastSegment per project.
+	MissingLastSegmentByProject map[uuid.UUID]int64
+
+	// the loop lists the keys in order, and within a project the last
+	// segments ("l") come before the first segments ("s0"). Hence the
+	// objects with more than one segment of the current project are enough
+	// for telling whether the object of a first segment has a last segment.
+	project      uuid.UUID
+	multiSegment map[metabase.ObjectKey]struct{}
 }
 
 // NewCounter instantiates a new counter to be subscribed to the metainfo loop.
 func NewCounter() *Counter {
-	return &Counter{}
+	return &Counter{
+		MissingLastSegmentByProject: map[uuid.UUID]int64{},
+	}
 }
 
 // Object increments counts for inline objects and remote dependent objects.
@@ -40,15 +57,48 @@ func (counter *Counter) Object(ctx context.Context, location metabase.SegmentLoc
 	}
 	counter.Total++
 
+	// the number of segments is 0 when it's encrypted.
+	if streamMeta.NumberOfSegments != 1 {
+		counter.setProject(location.ProjectID)
+		counter.multiSegment[location.ObjectKey] = struct{}{}
+	}
+
 	return nil
 }
 
-// RemoteSegment returns nil because counter does not interact with remote segments this way for now.
+// RemoteSegment counts the objects missing their last segment.
 func (counter *Counter) RemoteSegment(ctx context.Context, location metabase.SegmentLocation, pointer *pb.Pointer) (err error) {
+	counter.checkLastSegment(location)
 	return nil
 }
 
-// InlineSegment returns nil because counter does not interact with inline segments this way for now.
+// InlineSegment counts the objects missing their last segment.
 func (counter *Counter) InlineSegment(ctx context.Context, location metabase.SegmentLocation, pointer *pb.Pointer) (err error) {
+	counter.checkLastSegment(location)
 	return nil
 }
+
+// checkLastSegment counts the object of the segment as missing its last
+// segment when the segment is the first one of an object without it.
+func (counter *Counter) checkLastSegment(location metabase.SegmentLocation) {
+	if location.Index != 0 {
+		return
+	}
+
+	counter.setProject(location.ProjectID)
+	if _, ok := counter.multiSegment[location.ObjectKey]; ok {
+		return
+	}
+	counter.MissingLastSegment++
+	counter.MissingLastSegmentByProject[location.ProjectID]++
+}
+
+// setProject forgets the objects of the previous project when the loop has
+// moved to another project.
+func (counter *Counter) setProject(projectID uuid.UUID) {
+	if counter.multiSegment != nil && counter.project == projectID {
+		return
+	}
+	counter.project = projectID
+	counter.multiSegment = map[metabase.ObjectKey]struct{}{}
+}
@@ -33,7 +33,10 @@ import (
 	"storj.io/storj/satellite/mailservice"
 	"storj.io/storj/satellite/marketingweb"
 	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+	"storj.io/storj/satellite/metainfo/deletionverifier"
 	"storj.io/storj/satellite/metainfo/expireddeletion"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
 	"storj.io/storj/satellite/metrics"
 	"storj.io/storj/satellite/nodeapiversion"
 	"storj.io/storj/satellite/orders"
@@ -94,6 +97,18 @@ type DB interface {
 	Containment() audit.Containment
 	// Buckets returns the database to interact with buckets
 	Buckets() metainfo.BucketsDB
+	// DeletedObjectStubs returns the database to interact with the stubs of the deleted objects
+	DeletedObjectStubs() metainfo.DeletedObjectStubsDB
+	// DeleteWebhooks returns the database to interact with the webhooks notified of the deleted objects
+	DeleteWebhooks() deletewebhook.DB
+	// InlineContents returns the database to interact with the deduplicated contents of inline segments
+	InlineContents() metainfo.InlineContentsDB
+	// SharedSegments returns the database to interact with the references to the segments shared by copied objects
+	SharedSegments() metainfo.SharedSegmentsDB
+	// DeletionRetries returns the database to interact with the pieces whose deletion is retried
+	DeletionRetries() piecedeletion.Retries
+	// DeletionDeadLetters returns the database to interact with the pieces whose deletion has been given up
+	DeletionDeadLetters() piecedeletion.DeadLetters
 	// GracefulExit returns database for graceful exit
 	GracefulExit() gracefulexit.DB
 	// StripeCoinPayments returns stripecoinpayments database.
@@ -132,6 +147,10 @@ type Config struct {
 
 	ExpiredDeletion expireddeletion.Config
 
+	DeletionVerifier deletionverifier.Config
+
+	DeleteWebhook deletewebhook.Config
+
 	DBCleanup dbcleanup.Config
 
 	Tally            tally.Config
@@ -12,6 +12,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
 
 	"storj.io/common/memory"
 	"storj.io/common/pb"
@@ -20,9 +21,12 @@ import (
 	"storj.io/common/testrand"
 	"storj.io/storj/private/testplanet"
 	"storj.io/storj/satellite"
+	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/metainfo/metabase"
 	"storj.io/storj/satellite/overlay"
+	"storj.io/storj/satellite/repair/repairer"
 	"storj.io/storj/storage"
+	"storj.io/storj/storagenode/pieces"
 )
 
 // TestDataRepair does the following:
@@ -1364,6 +1368,92 @@ func testRepairGracefullyExited(t *testing.T, inMemoryRepair bool) {
 	})
 }
 
+// TestReencodeObject does the following:
+// - Uploads test data
+// - Re-encodes the object with a more durable redundancy scheme
+// - Checks that the segment has the new scheme, that the data can be
+//   downloaded and that the old pieces are deleted from the nodes
+func TestReencodeObject(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 10,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 3, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplinkPeer := planet.Uplinks[0]
+		satellite := planet.Satellites[0]
+		satellite.Audit.Worker.Loop.Pause()
+		satellite.Repair.Checker.Loop.Pause()
+		satellite.Repair.Repairer.Loop.Pause()
+
+		testData := testrand.Bytes(8 * memory.KiB)
+		err := uplinkPeer.Upload(ctx, satellite, "testbucket", "test/path", testData)
+		require.NoError(t, err)
+
+		pointer, key := getRemoteSegment(t, ctx, satellite)
+		segmentLocation, err := metabase.ParseSegmentKey(key)
+		require.NoError(t, err)
+
+		redundancy := &pb.RedundancyScheme{
+			Type:             pb.RedundancyScheme_RS,
+			MinReq:           2,
+			RepairThreshold:  4,
+			SuccessThreshold: 6,
+			Total:            6,
+			ErasureShareSize: pointer.GetRemote().GetRedundancy().GetErasureShareSize(),
+		}
+
+		reencoder := repairer.NewReencoder(zaptest.NewLogger(t), satellite.Repairer.SegmentRepairer, satellite.DB.SharedSegments(), satellite.API.Metainfo.PieceDeletion)
+		result, err := reencoder.ReencodeObject(ctx, segmentLocation.Object(), redundancy)
+		require.NoError(t, err)
+		require.Equal(t, repairer.ReencodeResult{
+			Segments:        1,
+			ReclaimedPieces: len(pointer.GetRemote().GetRemotePieces()),
+		}, result)
+
+		reencoded, err := satellite.Metainfo.Service.Get(ctx, key)
+		require.NoError(t, err)
+		require.True(t, pb.Equal(redundancy, reencoded.GetRemote().GetRedundancy()))
+		require.NotEqual(t, pointer.GetRemote().RootPieceId, reencoded.GetRemote().RootPieceId)
+		require.True(t, len(reencoded.GetRemote().GetRemotePieces()) >= int(redundancy.SuccessThreshold))
+
+		// the object already has the scheme, so it's skipped.
+		result, err = reencoder.ReencodeObject(ctx, segmentLocation.Object(), redundancy)
+		require.NoError(t, err)
+		require.Equal(t, repairer.ReencodeResult{SkippedSegments: 1}, result)
+
+		newData, err := uplinkPeer.Download(ctx, satellite, "testbucket", "test/path")
+		require.NoError(t, err)
+		require.Equal(t, testData, newData)
+
+		oldPieces := make(map[storj.PieceID]bool)
+		for _, piece := range pointer.GetRemote().GetRemotePieces() {
+			oldPieces[pointer.GetRemote().RootPieceId.Derive(piece.NodeId, piece.PieceNum)] = true
+		}
+
+		// the deletions of the old pieces may still be in progress once the
+		// success threshold is reached.
+		require.Eventually(t, func() bool {
+			planet.WaitForStorageNodeDeleters(ctx)
+
+			for _, node := range planet.StorageNodes {
+				found := false
+				err := node.Storage2.Store.WalkSatellitePieces(ctx, satellite.ID(), func(access pieces.StoredPieceAccess) error {
+					found = found || oldPieces[access.PieceID()]
+					return nil
+				})
+				require.NoError(t, err)
+				if found {
+					return false
+				}
+			}
+			return true
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+}
+
 // getRemoteSegment returns a remote pointer its path from satellite.
 // nolint:golint
 func getRemoteSegment(
@@ -1426,3 +1516,78 @@ func corruptPieceData(ctx context.Context, t *testing.T, planet *testplanet.Plan
 	err = writer.Commit(ctx)
 	require.NoError(t, err)
 }
+
+func TestReencodeObject_SharedSegment(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount:   1,
+		StorageNodeCount: 10,
+		UplinkCount:      1,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: testplanet.ReconfigureRS(2, 3, 4, 4),
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		uplinkPeer := planet.Uplinks[0]
+		satellite := planet.Satellites[0]
+		satellite.Audit.Worker.Loop.Pause()
+		satellite.Repair.Checker.Loop.Pause()
+		satellite.Repair.Repairer.Loop.Pause()
+
+		err := uplinkPeer.Upload(ctx, satellite, "testbucket", "test/path", testrand.Bytes(8*memory.KiB))
+		require.NoError(t, err)
+
+		pointer, key := getRemoteSegment(t, ctx, satellite)
+		segmentLocation, err := metabase.ParseSegmentKey(key)
+		require.NoError(t, err)
+
+		bucket := []byte(segmentLocation.BucketName)
+		copyKey := []byte("copy")
+		// the object has a single segment, its keys are kept as they are.
+		keys := metainfo.CopyObjectKeys{EncryptedMetadata: pointer.Metadata}
+		err = satellite.Admin.Metainfo.ObjectCopier.CopyObject(ctx, segmentLocation.ProjectID, bucket, []byte(segmentLocation.ObjectKey), bucket, copyKey, keys)
+		require.NoError(t, err)
+
+		redundancy := &pb.RedundancyScheme{
+			Type:             pb.RedundancyScheme_RS,
+			MinReq:           2,
+			RepairThreshold:  4,
+			SuccessThreshold: 6,
+			Total:            6,
+			ErasureShareSize: pointer.GetRemote().GetRedundancy().GetErasureShareSize(),
+		}
+
+		// the old pieces are kept for the copy.
+		reencoder := repairer.NewReencoder(zaptest.NewLogger(t), satellite.Repairer.SegmentRepairer, satellite.DB.SharedSegments(), satellite.API.Metainfo.PieceDeletion)
+		result, err := reencoder.ReencodeObject(ctx, segmentLocation.Object(), redundancy)
+		require.NoError(t, err)
+		require.Equal(t, repairer.ReencodeResult{Segments: 1}, result)
+
+		oldPieces := make(map[storj.PieceID]bool)
+		for _, piece := range pointer.GetRemote().GetRemotePieces() {
+			oldPieces[pointer.GetRemote().RootPieceId.Derive(piece.NodeId, piece.PieceNum)] = true
+		}
+		countOldPieces := func() (count int) {
+			planet.WaitForStorageNodeDeleters(ctx)
+
+			for _, node := range planet.StorageNodes {
+				err := node.Storage2.Store.WalkSatellitePieces(ctx, satellite.ID(), func(access pieces.StoredPieceAccess) error {
+					if oldPieces[access.PieceID()] {
+						count++
+					}
+					return nil
+				})
+				require.NoError(t, err)
+			}
+			return count
+		}
+		require.Equal(t, len(oldPieces), countOldPieces())
+
+		// the pieces are deleted with the copy, which holds the last
+		// reference.
+		_, err = satellite.Metainfo.Endpoint2.DeleteObjectPieces(ctx, segmentLocation.ProjectID, bucket, copyKey)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return countOldPieces() == 0
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,264 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package repairer
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/common/encryption"
+	"storj.io/common/pb"
+	"storj.io/common/storj"
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+	"storj.io/storj/satellite/metainfo/objectdeletion"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+	"storj.io/storj/satellite/overlay"
+	"storj.io/uplink/private/eestream"
+)
+
+// reencodeDeleteSuccessThreshold is the success threshold of the deletions
+// of the pieces, the old ones once the new ones are committed, and the new
+// ones when they can't be committed.
+const reencodeDeleteSuccessThreshold = 0.75
+
+// PieceDeleter deletes pieces from the storage nodes, see
+// piecedeletion.Service.
+type PieceDeleter interface {
+	Delete(ctx context.Context, requests []piecedeletion.Request, successThreshold float64) error
+}
+
+// ReencodeResult is the outcome of re-encoding an object.
+type ReencodeResult struct {
+	// Segments is the number of remote segments which have been re-encoded.
+	Segments int
+	// SkippedSegments is the number of segments which didn't need to be
+	// re-encoded: the inline ones and the ones which already have the
+	// redundancy scheme.
+	SkippedSegments int
+	// ReclaimedPieces is the number of old pieces sent for deletion. The
+	// pieces of the segments shared with copies aren't deleted.
+	ReclaimedPieces int
+}
+
+// Reencoder re-encodes the remote segments of objects with another redundancy
+// scheme, e.g. a more durable one.
+//
+// architecture: Service
+type Reencoder struct {
+	log            *zap.Logger
+	segments       *SegmentRepairer
+	sharedSegments metainfo.SharedSegmentsDB
+	deleter        PieceDeleter
+}
+
+// NewReencoder creates a new reencoder, which downloads and uploads the pieces
+// like segments and deletes the replaced ones with deleter, unless they are
+// shared with copies.
+func NewReencoder(log *zap.Logger, segments *SegmentRepairer, sharedSegments metainfo.SharedSegmentsDB, deleter PieceDeleter) *Reencoder {
+	return &Reencoder{
+		log:            log,
+		segments:       segments,
+		sharedSegments: sharedSegments,
+		deleter:        deleter,
+	}
+}
+
+// ReencodeObject re-encodes the remote segments of the object with the
+// redundancy scheme.
+//
+// Every segment is downloaded and encoded again into pieces with a new root
+// piece id, which are uploaded to newly selected nodes. The pointer is only
+// replaced once all of them have been uploaded, and only if it hasn't
+// changed meanwhile. The old pieces are deleted afterwards, so the segment
+// stays downloadable during the whole operation. The old pieces of a segment
+// shared with copies are kept for them, only the reference of the object is
+// released. The new pieces of a segment which couldn't be committed are
+// deleted instead.
+func (reencoder *Reencoder) ReencodeObject(ctx context.Context, location metabase.ObjectLocation, redundancy *pb.RedundancyScheme) (result ReencodeResult, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	strategy, err := eestream.NewRedundancyStrategyFromProto(redundancy)
+	if err != nil {
+		return result, invalidRepairError.New("invalid redundancy scheme: %w", err)
+	}
+
+	segments, err := reencoder.segments.metainfo.ListObjectSegments(ctx, location)
+	if err != nil {
+		return result, metainfoGetError.Wrap(err)
+	}
+	if len(segments) == 0 {
+		return result, storj.ErrObjectNotFound.New("%q", location.ObjectKey)
+	}
+
+	for _, segment := range segments {
+		replaced, err := reencoder.reencodeSegment(ctx, segment.Key, redundancy, strategy)
+		if err != nil {
+			return result, err
+		}
+		if replaced == nil {
+			result.SkippedSegments++
+			continue
+		}
+		result.Segments++
+
+		unshared, err := metainfo.ReleaseSharedSegments(ctx, reencoder.sharedSegments, []*pb.Pointer{replaced})
+		if err != nil {
+			reencoder.log.Error("failed to release the replaced shared segment, its pieces are left to garbage collection", zap.Error(err))
+			continue
+		}
+		if len(unshared) == 0 {
+			continue
+		}
+
+		requests := pieceDeletionRequests(replaced)
+		for _, req := range requests {
+			result.ReclaimedPieces += len(req.Pieces)
+		}
+		if err := reencoder.deleter.Delete(ctx, requests, reencodeDeleteSuccessThreshold); err != nil {
+			reencoder.log.Error("failed to delete the replaced pieces", zap.Error(err))
+		}
+	}
+
+	mon.Meter("reencode_segments").Mark(result.Segments)
+	return result, nil
+}
+
+// reencodeSegment re-encodes the segment with the redundancy scheme, see
+// ReencodeObject. It returns the replaced pointer, whose pieces have to be
+// deleted, or nil when the segment didn't need to be re-encoded.
+func (reencoder *Reencoder) reencodeSegment(ctx context.Context, key metabase.SegmentKey, redundancy *pb.RedundancyScheme, strategy eestream.RedundancyStrategy) (replaced *pb.Pointer, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	repairer := reencoder.segments
+
+	pointerBytes, pointer, err := repairer.metainfo.GetWithBytes(ctx, key)
+	if err != nil {
+		return nil, metainfoGetError.Wrap(err)
+	}
+	if pointer.GetType() != pb.Pointer_REMOTE || pb.Equal(pointer.GetRemote().GetRedundancy(), redundancy) {
+		return nil, nil
+	}
+
+	oldStrategy, err := eestream.NewRedundancyStrategyFromProto(pointer.GetRemote().GetRedundancy())
+	if err != nil {
+		return nil, invalidRepairError.New("invalid redundancy strategy: %w", err)
+	}
+
+	segmentLocation, err := metabase.ParseSegmentKey(key)
+	if err != nil {
+		return nil, invalidRepairError.New("could not parse segment key: %w", err)
+	}
+	bucket := segmentLocation.Bucket()
+
+	pieces := pointer.GetRemote().GetRemotePieces()
+	missingPieces, err := repairer.overlay.GetMissingPieces(ctx, pieces)
+	if err != nil {
+		return nil, overlayQueryError.New("error identifying missing pieces: %w", err)
+	}
+	missing := sliceToSet(missingPieces)
+	var healthyPieces []*pb.RemotePiece
+	for _, piece := range pieces {
+		if !missing[piece.GetPieceNum()] {
+			healthyPieces = append(healthyPieces, piece)
+		}
+	}
+
+	getOrderLimits, getPrivateKey, err := repairer.orders.CreateGetRepairOrderLimits(ctx, bucket, pointer, healthyPieces)
+	if err != nil {
+		return nil, orderLimitFailureError.New("could not create GET_REPAIR order limits: %w", err)
+	}
+
+	// the new pieces have a new root piece id, so they don't collide with
+	// the old ones, even on the same nodes.
+	reencoded := pb.Pointer{
+		Type:           pointer.Type,
+		SegmentSize:    pointer.SegmentSize,
+		ExpirationDate: pointer.ExpirationDate,
+		Remote: &pb.RemoteSegment{
+			RootPieceId: storj.NewPieceID(),
+			Redundancy:  redundancy,
+		},
+	}
+
+	newNodes, err := repairer.overlay.FindStorageNodesForUpload(ctx, overlay.FindStorageNodesRequest{
+		RequestedCount: int(math.Ceil(float64(strategy.OptimalThreshold()) * repairer.multiplierOptimalThreshold)),
+	})
+	if err != nil {
+		return nil, overlayQueryError.Wrap(err)
+	}
+
+	putLimits, putPrivateKey, err := repairer.orders.CreatePutRepairOrderLimits(ctx, bucket, &reencoded,
+		make([]*pb.AddressedOrderLimit, strategy.TotalCount()), newNodes, repairer.multiplierOptimalThreshold)
+	if err != nil {
+		return nil, orderLimitFailureError.New("could not create PUT_REPAIR order limits: %w", err)
+	}
+
+	segmentReader, _, err := repairer.ec.Get(ctx, getOrderLimits, getPrivateKey, oldStrategy, pointer.GetSegmentSize(), string(key))
+	if err != nil {
+		return nil, repairReconstructError.New("segment could not be reconstructed: %w", err)
+	}
+	defer func() { err = errs.Combine(err, segmentReader.Close()) }()
+
+	// the decoded data is padded for the stripes of the old scheme, it's
+	// padded again for the ones of the new scheme like the uplinks pad it.
+	data := encryption.PadReader(ioutil.NopCloser(io.LimitReader(segmentReader, pointer.GetSegmentSize())), strategy.StripeSize())
+
+	successfulNodes, hashes, err := repairer.ec.Repair(ctx, putLimits, putPrivateKey, strategy, data, repairer.timeout, string(key), strategy.OptimalThreshold())
+	if err != nil {
+		return nil, repairPutError.Wrap(err)
+	}
+	for i, node := range successfulNodes {
+		if node == nil {
+			continue
+		}
+		reencoded.Remote.RemotePieces = append(reencoded.Remote.RemotePieces, &pb.RemotePiece{
+			PieceNum: int32(i),
+			NodeId:   node.Id,
+			Hash:     hashes[i],
+		})
+	}
+
+	if len(reencoded.Remote.RemotePieces) < strategy.OptimalThreshold() {
+		reencoder.deleteUncommitted(ctx, &reencoded)
+		return nil, repairPutError.New("only %d pieces of %d uploaded", len(reencoded.Remote.RemotePieces), strategy.OptimalThreshold())
+	}
+
+	_, err = repairer.metainfo.ReplaceRemote(ctx, key, pointerBytes, reencoded.Remote)
+	if err != nil {
+		reencoder.deleteUncommitted(ctx, &reencoded)
+		return nil, metainfoPutError.Wrap(err)
+	}
+	return pointer, nil
+}
+
+// deleteUncommitted deletes the pieces of a re-encoded segment which couldn't
+// be committed.
+func (reencoder *Reencoder) deleteUncommitted(ctx context.Context, pointer *pb.Pointer) {
+	if err := reencoder.deleter.Delete(ctx, pieceDeletionRequests(pointer), reencodeDeleteSuccessThreshold); err != nil {
+		reencoder.log.Error("failed to delete the uncommitted pieces", zap.Error(err))
+	}
+}
+
+// pieceDeletionRequests returns the requests for deleting the pieces of the
+// pointer, one per node.
+func pieceDeletionRequests(pointer *pb.Pointer) []piecedeletion.Request {
+	pointers := []*pb.Pointer{pointer}
+	sizes := objectdeletion.GroupPieceSizesByNodeID(pointers)
+
+	var requests []piecedeletion.Request
+	for node, pieces := range objectdeletion.GroupPiecesByNodeID(pointers) {
+		requests = append(requests, piecedeletion.Request{
+			Node:   storj.NodeURL{ID: node},
+			Pieces: pieces,
+			Bytes:  sizes[node],
+		})
+	}
+	return requests
+}
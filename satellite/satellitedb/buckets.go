@@ -138,6 +138,19 @@ func (db *bucketsDB) DeleteBucket(ctx context.Context, bucketName []byte, projec
 func (db *bucketsDB) ListBuckets(ctx context.Context, projectID uuid.UUID, listOpts storj.BucketListOptions, allowedBuckets macaroon.AllowedBuckets) (bucketList storj.BucketList, err error) {
 	defer mon.Task()(&ctx)(&err)
 
+	return db.ListBucketsWithOptions(ctx, projectID, metainfo.ListBucketsOptions{
+		BucketListOptions: listOpts,
+	}, allowedBuckets)
+}
+
+// ListBucketsWithOptions returns a list of buckets for a project which match
+// opts. The creation time filters are applied while paging through the
+// buckets by name, since there is no index by creation time.
+func (db *bucketsDB) ListBucketsWithOptions(ctx context.Context, projectID uuid.UUID, opts metainfo.ListBucketsOptions, allowedBuckets macaroon.AllowedBuckets) (bucketList storj.BucketList, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	listOpts := opts.BucketListOptions
+
 	const defaultListLimit = 10000
 	if listOpts.Limit < 1 {
 		listOpts.Limit = defaultListLimit
@@ -191,13 +204,17 @@ func (db *bucketsDB) ListBuckets(ctx context.Context, projectID uuid.UUID, listO
 				if err != nil {
 					return bucketList, storj.ErrBucket.Wrap(err)
 				}
+				if !opts.Matches(item) {
+					continue
+				}
 				bucketList.Items = append(bucketList.Items, item)
 			}
 		}
 
 		if len(bucketList.Items) < listOpts.Limit && bucketList.More {
-			// If we filtered out disallowed buckets, then get more buckets
-			// out of database so that we return `limit` number of buckets
+			// If we filtered out disallowed or not matching buckets, then get
+			// more buckets out of database so that we return `limit` number
+			// of buckets
 			listOpts = storj.BucketListOptions{
 				Cursor:    string(dbxBuckets[len(dbxBuckets)-1].Name),
 				Limit:     listOpts.Limit,
@@ -354,6 +354,43 @@ CREATE TABLE coupon_usages (
 	period timestamp with time zone NOT NULL,
 	PRIMARY KEY ( coupon_id, period )
 );
+CREATE TABLE delete_webhooks (
+	project_id bytea NOT NULL,
+	url text NOT NULL,
+	secret bytea NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id )
+);
+CREATE TABLE deleted_object_stubs (
+	project_id bytea NOT NULL,
+	bucket_name bytea NOT NULL,
+	object_key bytea NOT NULL,
+	size bigint NOT NULL,
+	segment_count integer NOT NULL,
+	object_created_at timestamp with time zone NOT NULL,
+	deleted_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id, bucket_name, object_key )
+);
+CREATE TABLE deletion_dead_letters (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	reason integer NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retries (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	piece_bytes bigint NOT NULL,
+	queued_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retry_nodes (
+	node_id bytea NOT NULL,
+	failed_attempts integer NOT NULL,
+	retry_after timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id )
+);
 CREATE TABLE graceful_exit_progress (
 	node_id bytea NOT NULL,
 	bytes_transferred bigint NOT NULL,
@@ -385,6 +422,12 @@ CREATE TABLE injuredsegments (
 	num_healthy_pieces integer NOT NULL DEFAULT 52,
 	PRIMARY KEY ( path )
 );
+CREATE TABLE inline_contents (
+	hash bytea NOT NULL,
+	data bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( hash )
+);
 CREATE TABLE irreparabledbs (
 	segmentpath bytea NOT NULL,
 	segmentdetail bytea NOT NULL,
@@ -549,6 +592,11 @@ CREATE TABLE serial_numbers (
 	expires_at timestamp with time zone NOT NULL,
 	PRIMARY KEY ( id )
 );
+CREATE TABLE shared_segments (
+	root_piece_id bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( root_piece_id )
+);
 CREATE TABLE storagenode_bandwidth_rollups (
 	storagenode_id bytea NOT NULL,
 	interval_start timestamp with time zone NOT NULL,
@@ -710,6 +758,7 @@ CREATE INDEX accounting_rollups_start_time_index ON accounting_rollups ( start_t
 CREATE INDEX bucket_bandwidth_rollups_project_id_action_interval_index ON bucket_bandwidth_rollups ( project_id, action, interval_start );
 CREATE INDEX bucket_bandwidth_rollups_action_interval_project_id_index ON bucket_bandwidth_rollups ( action, interval_start, project_id );
 CREATE INDEX consumed_serials_expires_at_index ON consumed_serials ( expires_at );
+CREATE INDEX deletion_dead_letters_created_at_index ON deletion_dead_letters ( created_at );
 CREATE INDEX injuredsegments_attempted_index ON injuredsegments ( attempted );
 CREATE INDEX injuredsegments_num_healthy_pieces_index ON injuredsegments ( num_healthy_pieces );
 CREATE INDEX injuredsegments_updated_at_index ON injuredsegments ( updated_at );
@@ -865,6 +914,43 @@ CREATE TABLE coupon_usages (
 	period timestamp with time zone NOT NULL,
 	PRIMARY KEY ( coupon_id, period )
 );
+CREATE TABLE delete_webhooks (
+	project_id bytea NOT NULL,
+	url text NOT NULL,
+	secret bytea NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id )
+);
+CREATE TABLE deleted_object_stubs (
+	project_id bytea NOT NULL,
+	bucket_name bytea NOT NULL,
+	object_key bytea NOT NULL,
+	size bigint NOT NULL,
+	segment_count integer NOT NULL,
+	object_created_at timestamp with time zone NOT NULL,
+	deleted_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id, bucket_name, object_key )
+);
+CREATE TABLE deletion_dead_letters (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	reason integer NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retries (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	piece_bytes bigint NOT NULL,
+	queued_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retry_nodes (
+	node_id bytea NOT NULL,
+	failed_attempts integer NOT NULL,
+	retry_after timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id )
+);
 CREATE TABLE graceful_exit_progress (
 	node_id bytea NOT NULL,
 	bytes_transferred bigint NOT NULL,
@@ -896,6 +982,12 @@ CREATE TABLE injuredsegments (
 	num_healthy_pieces integer NOT NULL DEFAULT 52,
 	PRIMARY KEY ( path )
 );
+CREATE TABLE inline_contents (
+	hash bytea NOT NULL,
+	data bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( hash )
+);
 CREATE TABLE irreparabledbs (
 	segmentpath bytea NOT NULL,
 	segmentdetail bytea NOT NULL,
@@ -1060,6 +1152,11 @@ CREATE TABLE serial_numbers (
 	expires_at timestamp with time zone NOT NULL,
 	PRIMARY KEY ( id )
 );
+CREATE TABLE shared_segments (
+	root_piece_id bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( root_piece_id )
+);
 CREATE TABLE storagenode_bandwidth_rollups (
 	storagenode_id bytea NOT NULL,
 	interval_start timestamp with time zone NOT NULL,
@@ -1221,6 +1318,7 @@ CREATE INDEX accounting_rollups_start_time_index ON accounting_rollups ( start_t
 CREATE INDEX bucket_bandwidth_rollups_project_id_action_interval_index ON bucket_bandwidth_rollups ( project_id, action, interval_start );
 CREATE INDEX bucket_bandwidth_rollups_action_interval_project_id_index ON bucket_bandwidth_rollups ( action, interval_start, project_id );
 CREATE INDEX consumed_serials_expires_at_index ON consumed_serials ( expires_at );
+CREATE INDEX deletion_dead_letters_created_at_index ON deletion_dead_letters ( created_at );
 CREATE INDEX injuredsegments_attempted_index ON injuredsegments ( attempted );
 CREATE INDEX injuredsegments_num_healthy_pieces_index ON injuredsegments ( num_healthy_pieces );
 CREATE INDEX injuredsegments_updated_at_index ON injuredsegments ( updated_at );
@@ -2338,122 +2436,602 @@ func Coupon_Duration(v int64) Coupon_Duration_Field {
 	return Coupon_Duration_Field{_set: true, _value: v}
 }
 
-func (f Coupon_Duration_Field) value() interface{} {
+func (f Coupon_Duration_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Coupon_Duration_Field) _Column() string { return "duration" }
+
+type Coupon_CreatedAt_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func Coupon_CreatedAt(v time.Time) Coupon_CreatedAt_Field {
+	return Coupon_CreatedAt_Field{_set: true, _value: v}
+}
+
+func (f Coupon_CreatedAt_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Coupon_CreatedAt_Field) _Column() string { return "created_at" }
+
+type CouponUsage struct {
+	CouponId []byte
+	Amount   int64
+	Status   int
+	Period   time.Time
+}
+
+func (CouponUsage) _Table() string { return "coupon_usages" }
+
+type CouponUsage_Update_Fields struct {
+	Status CouponUsage_Status_Field
+}
+
+type CouponUsage_CouponId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func CouponUsage_CouponId(v []byte) CouponUsage_CouponId_Field {
+	return CouponUsage_CouponId_Field{_set: true, _value: v}
+}
+
+func (f CouponUsage_CouponId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (CouponUsage_CouponId_Field) _Column() string { return "coupon_id" }
+
+type CouponUsage_Amount_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func CouponUsage_Amount(v int64) CouponUsage_Amount_Field {
+	return CouponUsage_Amount_Field{_set: true, _value: v}
+}
+
+func (f CouponUsage_Amount_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (CouponUsage_Amount_Field) _Column() string { return "amount" }
+
+type CouponUsage_Status_Field struct {
+	_set   bool
+	_null  bool
+	_value int
+}
+
+func CouponUsage_Status(v int) CouponUsage_Status_Field {
+	return CouponUsage_Status_Field{_set: true, _value: v}
+}
+
+func (f CouponUsage_Status_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (CouponUsage_Status_Field) _Column() string { return "status" }
+
+type CouponUsage_Period_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func CouponUsage_Period(v time.Time) CouponUsage_Period_Field {
+	return CouponUsage_Period_Field{_set: true, _value: v}
+}
+
+func (f CouponUsage_Period_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (CouponUsage_Period_Field) _Column() string { return "period" }
+
+type DeleteWebhook struct {
+	ProjectId []byte
+	Url       string
+	Secret    []byte
+	CreatedAt time.Time
+}
+
+func (DeleteWebhook) _Table() string { return "delete_webhooks" }
+
+type DeleteWebhook_Update_Fields struct {
+}
+
+type DeleteWebhook_ProjectId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeleteWebhook_ProjectId(v []byte) DeleteWebhook_ProjectId_Field {
+	return DeleteWebhook_ProjectId_Field{_set: true, _value: v}
+}
+
+func (f DeleteWebhook_ProjectId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeleteWebhook_ProjectId_Field) _Column() string { return "project_id" }
+
+type DeleteWebhook_Url_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func DeleteWebhook_Url(v string) DeleteWebhook_Url_Field {
+	return DeleteWebhook_Url_Field{_set: true, _value: v}
+}
+
+func (f DeleteWebhook_Url_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeleteWebhook_Url_Field) _Column() string { return "url" }
+
+type DeleteWebhook_Secret_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeleteWebhook_Secret(v []byte) DeleteWebhook_Secret_Field {
+	return DeleteWebhook_Secret_Field{_set: true, _value: v}
+}
+
+func (f DeleteWebhook_Secret_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeleteWebhook_Secret_Field) _Column() string { return "secret" }
+
+type DeleteWebhook_CreatedAt_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func DeleteWebhook_CreatedAt(v time.Time) DeleteWebhook_CreatedAt_Field {
+	return DeleteWebhook_CreatedAt_Field{_set: true, _value: v}
+}
+
+func (f DeleteWebhook_CreatedAt_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeleteWebhook_CreatedAt_Field) _Column() string { return "created_at" }
+
+type DeletedObjectStub struct {
+	ProjectId       []byte
+	BucketName      []byte
+	ObjectKey       []byte
+	Size            int64
+	SegmentCount    int
+	ObjectCreatedAt time.Time
+	DeletedAt       time.Time
+}
+
+func (DeletedObjectStub) _Table() string { return "deleted_object_stubs" }
+
+type DeletedObjectStub_Update_Fields struct {
+}
+
+type DeletedObjectStub_ProjectId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletedObjectStub_ProjectId(v []byte) DeletedObjectStub_ProjectId_Field {
+	return DeletedObjectStub_ProjectId_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_ProjectId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_ProjectId_Field) _Column() string { return "project_id" }
+
+type DeletedObjectStub_BucketName_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletedObjectStub_BucketName(v []byte) DeletedObjectStub_BucketName_Field {
+	return DeletedObjectStub_BucketName_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_BucketName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_BucketName_Field) _Column() string { return "bucket_name" }
+
+type DeletedObjectStub_ObjectKey_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletedObjectStub_ObjectKey(v []byte) DeletedObjectStub_ObjectKey_Field {
+	return DeletedObjectStub_ObjectKey_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_ObjectKey_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_ObjectKey_Field) _Column() string { return "object_key" }
+
+type DeletedObjectStub_Size_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func DeletedObjectStub_Size(v int64) DeletedObjectStub_Size_Field {
+	return DeletedObjectStub_Size_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_Size_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_Size_Field) _Column() string { return "size" }
+
+type DeletedObjectStub_SegmentCount_Field struct {
+	_set   bool
+	_null  bool
+	_value int
+}
+
+func DeletedObjectStub_SegmentCount(v int) DeletedObjectStub_SegmentCount_Field {
+	return DeletedObjectStub_SegmentCount_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_SegmentCount_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_SegmentCount_Field) _Column() string { return "segment_count" }
+
+type DeletedObjectStub_ObjectCreatedAt_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func DeletedObjectStub_ObjectCreatedAt(v time.Time) DeletedObjectStub_ObjectCreatedAt_Field {
+	return DeletedObjectStub_ObjectCreatedAt_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_ObjectCreatedAt_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_ObjectCreatedAt_Field) _Column() string { return "object_created_at" }
+
+type DeletedObjectStub_DeletedAt_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func DeletedObjectStub_DeletedAt(v time.Time) DeletedObjectStub_DeletedAt_Field {
+	return DeletedObjectStub_DeletedAt_Field{_set: true, _value: v}
+}
+
+func (f DeletedObjectStub_DeletedAt_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletedObjectStub_DeletedAt_Field) _Column() string { return "deleted_at" }
+
+type DeletionDeadLetter struct {
+	NodeId    []byte
+	PieceId   []byte
+	Reason    int
+	CreatedAt time.Time
+}
+
+func (DeletionDeadLetter) _Table() string { return "deletion_dead_letters" }
+
+type DeletionDeadLetter_Update_Fields struct {
+}
+
+type DeletionDeadLetter_NodeId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletionDeadLetter_NodeId(v []byte) DeletionDeadLetter_NodeId_Field {
+	return DeletionDeadLetter_NodeId_Field{_set: true, _value: v}
+}
+
+func (f DeletionDeadLetter_NodeId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionDeadLetter_NodeId_Field) _Column() string { return "node_id" }
+
+type DeletionDeadLetter_PieceId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletionDeadLetter_PieceId(v []byte) DeletionDeadLetter_PieceId_Field {
+	return DeletionDeadLetter_PieceId_Field{_set: true, _value: v}
+}
+
+func (f DeletionDeadLetter_PieceId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionDeadLetter_PieceId_Field) _Column() string { return "piece_id" }
+
+type DeletionDeadLetter_Reason_Field struct {
+	_set   bool
+	_null  bool
+	_value int
+}
+
+func DeletionDeadLetter_Reason(v int) DeletionDeadLetter_Reason_Field {
+	return DeletionDeadLetter_Reason_Field{_set: true, _value: v}
+}
+
+func (f DeletionDeadLetter_Reason_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionDeadLetter_Reason_Field) _Column() string { return "reason" }
+
+type DeletionDeadLetter_CreatedAt_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func DeletionDeadLetter_CreatedAt(v time.Time) DeletionDeadLetter_CreatedAt_Field {
+	return DeletionDeadLetter_CreatedAt_Field{_set: true, _value: v}
+}
+
+func (f DeletionDeadLetter_CreatedAt_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionDeadLetter_CreatedAt_Field) _Column() string { return "created_at" }
+
+type DeletionRetry struct {
+	NodeId     []byte
+	PieceId    []byte
+	PieceBytes int64
+	QueuedAt   time.Time
+}
+
+func (DeletionRetry) _Table() string { return "deletion_retries" }
+
+type DeletionRetry_Update_Fields struct {
+}
+
+type DeletionRetry_NodeId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletionRetry_NodeId(v []byte) DeletionRetry_NodeId_Field {
+	return DeletionRetry_NodeId_Field{_set: true, _value: v}
+}
+
+func (f DeletionRetry_NodeId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionRetry_NodeId_Field) _Column() string { return "node_id" }
+
+type DeletionRetry_PieceId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func DeletionRetry_PieceId(v []byte) DeletionRetry_PieceId_Field {
+	return DeletionRetry_PieceId_Field{_set: true, _value: v}
+}
+
+func (f DeletionRetry_PieceId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (DeletionRetry_PieceId_Field) _Column() string { return "piece_id" }
+
+type DeletionRetry_PieceBytes_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func DeletionRetry_PieceBytes(v int64) DeletionRetry_PieceBytes_Field {
+	return DeletionRetry_PieceBytes_Field{_set: true, _value: v}
+}
+
+func (f DeletionRetry_PieceBytes_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Coupon_Duration_Field) _Column() string { return "duration" }
+func (DeletionRetry_PieceBytes_Field) _Column() string { return "piece_bytes" }
 
-type Coupon_CreatedAt_Field struct {
+type DeletionRetry_QueuedAt_Field struct {
 	_set   bool
 	_null  bool
 	_value time.Time
 }
 
-func Coupon_CreatedAt(v time.Time) Coupon_CreatedAt_Field {
-	return Coupon_CreatedAt_Field{_set: true, _value: v}
+func DeletionRetry_QueuedAt(v time.Time) DeletionRetry_QueuedAt_Field {
+	return DeletionRetry_QueuedAt_Field{_set: true, _value: v}
 }
 
-func (f Coupon_CreatedAt_Field) value() interface{} {
+func (f DeletionRetry_QueuedAt_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Coupon_CreatedAt_Field) _Column() string { return "created_at" }
+func (DeletionRetry_QueuedAt_Field) _Column() string { return "queued_at" }
 
-type CouponUsage struct {
-	CouponId []byte
-	Amount   int64
-	Status   int
-	Period   time.Time
+type DeletionRetryNode struct {
+	NodeId         []byte
+	FailedAttempts int
+	RetryAfter     time.Time
 }
 
-func (CouponUsage) _Table() string { return "coupon_usages" }
+func (DeletionRetryNode) _Table() string { return "deletion_retry_nodes" }
 
-type CouponUsage_Update_Fields struct {
-	Status CouponUsage_Status_Field
+type DeletionRetryNode_Update_Fields struct {
 }
 
-type CouponUsage_CouponId_Field struct {
+type DeletionRetryNode_NodeId_Field struct {
 	_set   bool
 	_null  bool
 	_value []byte
 }
 
-func CouponUsage_CouponId(v []byte) CouponUsage_CouponId_Field {
-	return CouponUsage_CouponId_Field{_set: true, _value: v}
-}
-
-func (f CouponUsage_CouponId_Field) value() interface{} {
-	if !f._set || f._null {
-		return nil
-	}
-	return f._value
-}
-
-func (CouponUsage_CouponId_Field) _Column() string { return "coupon_id" }
-
-type CouponUsage_Amount_Field struct {
-	_set   bool
-	_null  bool
-	_value int64
-}
-
-func CouponUsage_Amount(v int64) CouponUsage_Amount_Field {
-	return CouponUsage_Amount_Field{_set: true, _value: v}
+func DeletionRetryNode_NodeId(v []byte) DeletionRetryNode_NodeId_Field {
+	return DeletionRetryNode_NodeId_Field{_set: true, _value: v}
 }
 
-func (f CouponUsage_Amount_Field) value() interface{} {
+func (f DeletionRetryNode_NodeId_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (CouponUsage_Amount_Field) _Column() string { return "amount" }
+func (DeletionRetryNode_NodeId_Field) _Column() string { return "node_id" }
 
-type CouponUsage_Status_Field struct {
+type DeletionRetryNode_FailedAttempts_Field struct {
 	_set   bool
 	_null  bool
 	_value int
 }
 
-func CouponUsage_Status(v int) CouponUsage_Status_Field {
-	return CouponUsage_Status_Field{_set: true, _value: v}
+func DeletionRetryNode_FailedAttempts(v int) DeletionRetryNode_FailedAttempts_Field {
+	return DeletionRetryNode_FailedAttempts_Field{_set: true, _value: v}
 }
 
-func (f CouponUsage_Status_Field) value() interface{} {
+func (f DeletionRetryNode_FailedAttempts_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (CouponUsage_Status_Field) _Column() string { return "status" }
+func (DeletionRetryNode_FailedAttempts_Field) _Column() string { return "failed_attempts" }
 
-type CouponUsage_Period_Field struct {
+type DeletionRetryNode_RetryAfter_Field struct {
 	_set   bool
 	_null  bool
 	_value time.Time
 }
 
-func CouponUsage_Period(v time.Time) CouponUsage_Period_Field {
-	return CouponUsage_Period_Field{_set: true, _value: v}
+func DeletionRetryNode_RetryAfter(v time.Time) DeletionRetryNode_RetryAfter_Field {
+	return DeletionRetryNode_RetryAfter_Field{_set: true, _value: v}
 }
 
-func (f CouponUsage_Period_Field) value() interface{} {
+func (f DeletionRetryNode_RetryAfter_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (CouponUsage_Period_Field) _Column() string { return "period" }
+func (DeletionRetryNode_RetryAfter_Field) _Column() string { return "retry_after" }
 
 type GracefulExitProgress struct {
 	NodeId            []byte
@@ -3052,6 +3630,74 @@ func (f Injuredsegment_NumHealthyPieces_Field) value() interface{} {
 
 func (Injuredsegment_NumHealthyPieces_Field) _Column() string { return "num_healthy_pieces" }
 
+type InlineContent struct {
+	Hash           []byte
+	Data           []byte
+	ReferenceCount int64
+}
+
+func (InlineContent) _Table() string { return "inline_contents" }
+
+type InlineContent_Update_Fields struct {
+}
+
+type InlineContent_Hash_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func InlineContent_Hash(v []byte) InlineContent_Hash_Field {
+	return InlineContent_Hash_Field{_set: true, _value: v}
+}
+
+func (f InlineContent_Hash_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (InlineContent_Hash_Field) _Column() string { return "hash" }
+
+type InlineContent_Data_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func InlineContent_Data(v []byte) InlineContent_Data_Field {
+	return InlineContent_Data_Field{_set: true, _value: v}
+}
+
+func (f InlineContent_Data_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (InlineContent_Data_Field) _Column() string { return "data" }
+
+type InlineContent_ReferenceCount_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func InlineContent_ReferenceCount(v int64) InlineContent_ReferenceCount_Field {
+	return InlineContent_ReferenceCount_Field{_set: true, _value: v}
+}
+
+func (f InlineContent_ReferenceCount_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (InlineContent_ReferenceCount_Field) _Column() string { return "reference_count" }
+
 type Irreparabledb struct {
 	Segmentpath        []byte
 	Segmentdetail      []byte
@@ -5890,6 +6536,54 @@ func (f SerialNumber_ExpiresAt_Field) value() interface{} {
 
 func (SerialNumber_ExpiresAt_Field) _Column() string { return "expires_at" }
 
+type SharedSegment struct {
+	RootPieceId    []byte
+	ReferenceCount int64
+}
+
+func (SharedSegment) _Table() string { return "shared_segments" }
+
+type SharedSegment_Update_Fields struct {
+}
+
+type SharedSegment_RootPieceId_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func SharedSegment_RootPieceId(v []byte) SharedSegment_RootPieceId_Field {
+	return SharedSegment_RootPieceId_Field{_set: true, _value: v}
+}
+
+func (f SharedSegment_RootPieceId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (SharedSegment_RootPieceId_Field) _Column() string { return "root_piece_id" }
+
+type SharedSegment_ReferenceCount_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func SharedSegment_ReferenceCount(v int64) SharedSegment_ReferenceCount_Field {
+	return SharedSegment_ReferenceCount_Field{_set: true, _value: v}
+}
+
+func (f SharedSegment_ReferenceCount_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (SharedSegment_ReferenceCount_Field) _Column() string { return "reference_count" }
+
 type StoragenodeBandwidthRollup struct {
 	StoragenodeId   []byte
 	IntervalStart   time.Time
@@ -14751,6 +15445,16 @@ func (obj *pgxImpl) deleteAll(ctx context.Context) (count int64, err error) {
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM shared_segments;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -14901,6 +15605,16 @@ func (obj *pgxImpl) deleteAll(ctx context.Context) (count int64, err error) {
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM inline_contents;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -14931,6 +15645,56 @@ func (obj *pgxImpl) deleteAll(ctx context.Context) (count int64, err error) {
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_retry_nodes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_retries;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_dead_letters;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deleted_object_stubs;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM delete_webhooks;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -21010,6 +21774,16 @@ func (obj *pgxcockroachImpl) deleteAll(ctx context.Context) (count int64, err er
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM shared_segments;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -21160,6 +21934,16 @@ func (obj *pgxcockroachImpl) deleteAll(ctx context.Context) (count int64, err er
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM inline_contents;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -21190,6 +21974,56 @@ func (obj *pgxcockroachImpl) deleteAll(ctx context.Context) (count int64, err er
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_retry_nodes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_retries;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deletion_dead_letters;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM deleted_object_stubs;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.ExecContext(ctx, "DELETE FROM delete_webhooks;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
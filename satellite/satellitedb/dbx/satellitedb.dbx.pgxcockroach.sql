@@ -81,6 +81,43 @@ CREATE TABLE coupon_usages (
 	period timestamp with time zone NOT NULL,
 	PRIMARY KEY ( coupon_id, period )
 );
+CREATE TABLE delete_webhooks (
+	project_id bytea NOT NULL,
+	url text NOT NULL,
+	secret bytea NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id )
+);
+CREATE TABLE deleted_object_stubs (
+	project_id bytea NOT NULL,
+	bucket_name bytea NOT NULL,
+	object_key bytea NOT NULL,
+	size bigint NOT NULL,
+	segment_count integer NOT NULL,
+	object_created_at timestamp with time zone NOT NULL,
+	deleted_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( project_id, bucket_name, object_key )
+);
+CREATE TABLE deletion_dead_letters (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	reason integer NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retries (
+	node_id bytea NOT NULL,
+	piece_id bytea NOT NULL,
+	piece_bytes bigint NOT NULL,
+	queued_at timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id, piece_id )
+);
+CREATE TABLE deletion_retry_nodes (
+	node_id bytea NOT NULL,
+	failed_attempts integer NOT NULL,
+	retry_after timestamp with time zone NOT NULL,
+	PRIMARY KEY ( node_id )
+);
 CREATE TABLE graceful_exit_progress (
 	node_id bytea NOT NULL,
 	bytes_transferred bigint NOT NULL,
@@ -112,6 +149,12 @@ CREATE TABLE injuredsegments (
 	num_healthy_pieces integer NOT NULL DEFAULT 52,
 	PRIMARY KEY ( path )
 );
+CREATE TABLE inline_contents (
+	hash bytea NOT NULL,
+	data bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( hash )
+);
 CREATE TABLE irreparabledbs (
 	segmentpath bytea NOT NULL,
 	segmentdetail bytea NOT NULL,
@@ -276,6 +319,11 @@ CREATE TABLE serial_numbers (
 	expires_at timestamp with time zone NOT NULL,
 	PRIMARY KEY ( id )
 );
+CREATE TABLE shared_segments (
+	root_piece_id bytea NOT NULL,
+	reference_count bigint NOT NULL,
+	PRIMARY KEY ( root_piece_id )
+);
 CREATE TABLE storagenode_bandwidth_rollups (
 	storagenode_id bytea NOT NULL,
 	interval_start timestamp with time zone NOT NULL,
@@ -437,6 +485,7 @@ CREATE INDEX accounting_rollups_start_time_index ON accounting_rollups ( start_t
 CREATE INDEX bucket_bandwidth_rollups_project_id_action_interval_index ON bucket_bandwidth_rollups ( project_id, action, interval_start );
 CREATE INDEX bucket_bandwidth_rollups_action_interval_project_id_index ON bucket_bandwidth_rollups ( action, interval_start, project_id );
 CREATE INDEX consumed_serials_expires_at_index ON consumed_serials ( expires_at );
+CREATE INDEX deletion_dead_letters_created_at_index ON deletion_dead_letters ( created_at );
 CREATE INDEX injuredsegments_attempted_index ON injuredsegments ( attempted );
 CREATE INDEX injuredsegments_num_healthy_pieces_index ON injuredsegments ( num_healthy_pieces );
 CREATE INDEX injuredsegments_updated_at_index ON injuredsegments ( updated_at );
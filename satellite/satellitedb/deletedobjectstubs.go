@@ -0,0 +1,74 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/satellite/metainfo"
+	"storj.io/storj/satellite/metainfo/metabase"
+)
+
+type deletedObjectStubsDB struct {
+	db *satelliteDB
+}
+
+// DeletedObjectStubs returns database for interacting with the stubs of the
+// deleted objects.
+func (db *satelliteDB) DeletedObjectStubs() metainfo.DeletedObjectStubsDB {
+	return &deletedObjectStubsDB{db: db}
+}
+
+// Add records the stub of a deleted object, replacing the stub of a previous
+// object with the same key.
+func (db *deletedObjectStubsDB) Add(ctx context.Context, stub metainfo.DeletedObjectStub) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO deleted_object_stubs (project_id, bucket_name, object_key, size, segment_count, object_created_at, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (project_id, bucket_name, object_key)
+		DO UPDATE SET size = EXCLUDED.size, segment_count = EXCLUDED.segment_count,
+			object_created_at = EXCLUDED.object_created_at, deleted_at = EXCLUDED.deleted_at
+	`, stub.ProjectID[:], []byte(stub.BucketName), []byte(stub.ObjectKey),
+		stub.Size, stub.Segments, stub.ObjectCreated.UTC(), stub.Deleted.UTC())
+	return Error.Wrap(err)
+}
+
+// List returns at most limit stubs of the bucket whose key is after cursor,
+// ordered by key.
+func (db *deletedObjectStubsDB) List(ctx context.Context, bucket metabase.BucketLocation, cursor metabase.ObjectKey, limit int) (stubs []metainfo.DeletedObjectStub, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rows, err := db.db.QueryContext(ctx, `
+		SELECT object_key, size, segment_count, object_created_at, deleted_at
+		FROM deleted_object_stubs
+		WHERE project_id = $1 AND bucket_name = $2 AND object_key > $3
+		ORDER BY object_key
+		LIMIT $4
+	`, bucket.ProjectID[:], []byte(bucket.BucketName), []byte(cursor), limit)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	for rows.Next() {
+		var key []byte
+		stub := metainfo.DeletedObjectStub{
+			ObjectLocation: metabase.ObjectLocation{
+				ProjectID:  bucket.ProjectID,
+				BucketName: bucket.BucketName,
+			},
+		}
+		err = rows.Scan(&key, &stub.Size, &stub.Segments, &stub.ObjectCreated, &stub.Deleted)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		stub.ObjectKey = metabase.ObjectKey(key)
+		stubs = append(stubs, stub)
+	}
+	return stubs, Error.Wrap(rows.Err())
+}
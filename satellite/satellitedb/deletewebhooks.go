@@ -0,0 +1,66 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/metainfo/deletewebhook"
+)
+
+type deleteWebhooksDB struct {
+	db *satelliteDB
+}
+
+// DeleteWebhooks returns database for interacting with the webhooks which
+// are notified of the deleted objects of the projects.
+func (db *satelliteDB) DeleteWebhooks() deletewebhook.DB {
+	return &deleteWebhooksDB{db: db}
+}
+
+// Set registers the webhook of a project, replacing its previous one.
+func (db *deleteWebhooksDB) Set(ctx context.Context, webhook deletewebhook.Webhook) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO delete_webhooks (project_id, url, secret, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id)
+		DO UPDATE SET url = EXCLUDED.url, secret = EXCLUDED.secret, created_at = EXCLUDED.created_at
+	`, webhook.ProjectID[:], webhook.URL, webhook.Secret, webhook.CreatedAt.UTC())
+	return Error.Wrap(err)
+}
+
+// Get returns the webhook of a project, or nil when it has none.
+func (db *deleteWebhooksDB) Get(ctx context.Context, projectID uuid.UUID) (_ *deletewebhook.Webhook, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	webhook := deletewebhook.Webhook{ProjectID: projectID}
+	err = db.db.QueryRowContext(ctx, `
+		SELECT url, secret, created_at
+		FROM delete_webhooks
+		WHERE project_id = $1
+	`, projectID[:]).Scan(&webhook.URL, &webhook.Secret, &webhook.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return &webhook, nil
+}
+
+// Delete removes the webhook of a project.
+func (db *deleteWebhooksDB) Delete(ctx context.Context, projectID uuid.UUID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		DELETE FROM delete_webhooks
+		WHERE project_id = $1
+	`, projectID[:])
+	return Error.Wrap(err)
+}
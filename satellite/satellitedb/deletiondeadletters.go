@@ -0,0 +1,107 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/common/storj"
+	"storj.io/storj/private/dbutil/pgutil"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+)
+
+type deletionDeadLetters struct {
+	db *satelliteDB
+}
+
+// DeletionDeadLetters returns database for interacting with the pieces whose
+// deletion has been given up.
+func (db *satelliteDB) DeletionDeadLetters() piecedeletion.DeadLetters {
+	return &deletionDeadLetters{db: db}
+}
+
+// Add records the pieces of the node with the reason. A piece which is already
+// recorded keeps its first record.
+func (db *deletionDeadLetters) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, reason piecedeletion.DeadLetterReason) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	pieceIDs := make([][]byte, len(pieces))
+	for i, piece := range pieces {
+		pieceIDs[i] = piece.Bytes()
+	}
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO deletion_dead_letters (node_id, piece_id, reason, created_at)
+		SELECT $1, unnest($2::bytea[]), $3, $4
+		ON CONFLICT (node_id, piece_id) DO NOTHING
+	`, nodeID.Bytes(), pgutil.ByteaArray(pieceIDs), int(reason), time.Now().UTC())
+	return Error.Wrap(err)
+}
+
+// List returns at most limit records of the node, or of every node when
+// nodeID is zero, the oldest first.
+func (db *deletionDeadLetters) List(ctx context.Context, nodeID storj.NodeID, limit int) (letters []piecedeletion.DeadLetter, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var nodeFilter []byte
+	if !nodeID.IsZero() {
+		nodeFilter = nodeID.Bytes()
+	}
+
+	rows, err := db.db.QueryContext(ctx, `
+		SELECT node_id, piece_id, reason, created_at
+		FROM deletion_dead_letters
+		WHERE $1::bytea IS NULL OR node_id = $1::bytea
+		ORDER BY created_at, node_id, piece_id
+		LIMIT $2
+	`, nodeFilter, limit)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	for rows.Next() {
+		var nodeBytes, pieceBytes []byte
+		var letter piecedeletion.DeadLetter
+		var reason int
+		err = rows.Scan(&nodeBytes, &pieceBytes, &reason, &letter.CreatedAt)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+
+		letter.NodeID, err = storj.NodeIDFromBytes(nodeBytes)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		letter.PieceID, err = storj.PieceIDFromBytes(pieceBytes)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		letter.Reason = piecedeletion.DeadLetterReason(reason)
+		letters = append(letters, letter)
+	}
+	return letters, Error.Wrap(rows.Err())
+}
+
+// DeleteBefore deletes the records created before the time and returns how
+// many have been deleted.
+func (db *deletionDeadLetters) DeleteBefore(ctx context.Context, before time.Time) (deleted int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	result, err := db.db.ExecContext(ctx, `
+		DELETE FROM deletion_dead_letters WHERE created_at < $1
+	`, before.UTC())
+	if err != nil {
+		return 0, Error.Wrap(err)
+	}
+	deleted, err = result.RowsAffected()
+	return deleted, Error.Wrap(err)
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/common/storj"
+	"storj.io/storj/private/dbutil/pgutil"
+	"storj.io/storj/satellite/metainfo/piecedeletion"
+)
+
+type deletionRetries struct {
+	db *satelliteDB
+}
+
+// DeletionRetries returns database for interacting with the pieces whose
+// deletion is retried.
+//
+// The queued pieces of a node are in deletion_retries, the failed attempts
+// and the next retry of the node in deletion_retry_nodes. A node without a
+// row in the latter has no failed attempts and its retry is due.
+func (db *satelliteDB) DeletionRetries() piecedeletion.Retries {
+	return &deletionRetries{db: db}
+}
+
+// Add queues the pieces of the node, pieceBytes is the expected size of each
+// of them. The pieces which are already queued are skipped.
+func (db *deletionRetries) Add(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID, pieceBytes int64) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO deletion_retries (node_id, piece_id, piece_bytes, queued_at)
+		SELECT $1, unnest($2::bytea[]), $3, $4
+		ON CONFLICT (node_id, piece_id) DO NOTHING
+	`, nodeID.Bytes(), pgutil.ByteaArray(pieceIDBytes(pieces)), pieceBytes, time.Now().UTC())
+	return Error.Wrap(err)
+}
+
+// Due returns at most limit nodes whose retry is due at now.
+func (db *deletionRetries) Due(ctx context.Context, now time.Time, limit int) (nodes []storj.NodeID, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rows, err := db.db.QueryContext(ctx, `
+		SELECT DISTINCT deletion_retries.node_id
+		FROM deletion_retries
+		LEFT JOIN deletion_retry_nodes ON deletion_retry_nodes.node_id = deletion_retries.node_id
+		WHERE deletion_retry_nodes.retry_after IS NULL OR deletion_retry_nodes.retry_after <= $1
+		LIMIT $2
+	`, now.UTC(), limit)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	for rows.Next() {
+		var nodeBytes []byte
+		if err := rows.Scan(&nodeBytes); err != nil {
+			return nil, Error.Wrap(err)
+		}
+		node, err := storj.NodeIDFromBytes(nodeBytes)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, Error.Wrap(rows.Err())
+}
+
+// Claim returns at most limit queued pieces of the node and postpones its
+// retry until the lease, when the retry is due at now or force is set.
+func (db *deletionRetries) Claim(ctx context.Context, nodeID storj.NodeID, now, lease time.Time, limit int, force bool) (node piecedeletion.RetryNode, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	// the retry is only postponed when it's due, hence a single process
+	// claims it.
+	err = db.db.QueryRowContext(ctx, `
+		INSERT INTO deletion_retry_nodes (node_id, failed_attempts, retry_after)
+		VALUES ($1, 0, $3)
+		ON CONFLICT (node_id) DO UPDATE SET retry_after = $3
+		WHERE $4 OR deletion_retry_nodes.retry_after <= $2
+		RETURNING failed_attempts
+	`, nodeID.Bytes(), now.UTC(), lease.UTC(), force).Scan(&node.FailedAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return piecedeletion.RetryNode{}, nil
+	}
+	if err != nil {
+		return piecedeletion.RetryNode{}, Error.Wrap(err)
+	}
+	node.RetryAfter = lease
+
+	rows, err := db.db.QueryContext(ctx, `
+		SELECT piece_id, piece_bytes, count(*) OVER ()
+		FROM deletion_retries
+		WHERE node_id = $1
+		ORDER BY queued_at, piece_id
+		LIMIT $2
+	`, nodeID.Bytes(), limit)
+	if err != nil {
+		return piecedeletion.RetryNode{}, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	for rows.Next() {
+		var pieceBytes []byte
+		var size int64
+		if err := rows.Scan(&pieceBytes, &size, &node.PieceCount); err != nil {
+			return piecedeletion.RetryNode{}, Error.Wrap(err)
+		}
+		piece, err := storj.PieceIDFromBytes(pieceBytes)
+		if err != nil {
+			return piecedeletion.RetryNode{}, Error.Wrap(err)
+		}
+		node.Pieces = append(node.Pieces, piece)
+		node.Bytes += size
+	}
+	return node, Error.Wrap(rows.Err())
+}
+
+// Remove removes the pieces of the node from the queue and resets its failed
+// attempts.
+func (db *deletionRetries) Remove(ctx context.Context, nodeID storj.NodeID, pieces []storj.PieceID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if len(pieces) > 0 {
+		_, err = db.db.ExecContext(ctx, `
+			DELETE FROM deletion_retries WHERE node_id = $1 AND piece_id = ANY($2::bytea[])
+		`, nodeID.Bytes(), pgutil.ByteaArray(pieceIDBytes(pieces)))
+		if err != nil {
+			return Error.Wrap(err)
+		}
+	}
+
+	_, err = db.db.ExecContext(ctx, `
+		DELETE FROM deletion_retry_nodes WHERE node_id = $1
+	`, nodeID.Bytes())
+	return Error.Wrap(err)
+}
+
+// Failed records a failed attempt of sending the pieces of the node and
+// postpones its retry until retryAfter.
+func (db *deletionRetries) Failed(ctx context.Context, nodeID storj.NodeID, retryAfter time.Time) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO deletion_retry_nodes (node_id, failed_attempts, retry_after)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (node_id) DO UPDATE SET
+			failed_attempts = deletion_retry_nodes.failed_attempts + 1,
+			retry_after = $2
+	`, nodeID.Bytes(), retryAfter.UTC())
+	return Error.Wrap(err)
+}
+
+// Get returns the number and the size of the queued pieces of the node.
+func (db *deletionRetries) Get(ctx context.Context, nodeID storj.NodeID) (node piecedeletion.RetryNode, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	err = db.db.QueryRowContext(ctx, `
+		SELECT count(*), coalesce(sum(piece_bytes), 0)
+		FROM deletion_retries
+		WHERE node_id = $1
+	`, nodeID.Bytes()).Scan(&node.PieceCount, &node.Bytes)
+	if err != nil {
+		return piecedeletion.RetryNode{}, Error.Wrap(err)
+	}
+
+	err = db.db.QueryRowContext(ctx, `
+		SELECT failed_attempts, retry_after
+		FROM deletion_retry_nodes
+		WHERE node_id = $1
+	`, nodeID.Bytes()).Scan(&node.FailedAttempts, &node.RetryAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return node, nil
+	}
+	return node, Error.Wrap(err)
+}
+
+// Count returns the number of queued pieces.
+func (db *deletionRetries) Count(ctx context.Context) (count int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	err = db.db.QueryRowContext(ctx, `SELECT count(*) FROM deletion_retries`).Scan(&count)
+	return count, Error.Wrap(err)
+}
+
+// QueuedAfter returns the pieces, grouped by node, which were queued after since.
+func (db *deletionRetries) QueuedAfter(ctx context.Context, since time.Time) (pieces map[storj.NodeID][]storj.PieceID, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rows, err := db.db.QueryContext(ctx, `
+		SELECT node_id, piece_id
+		FROM deletion_retries
+		WHERE queued_at > $1
+	`, since.UTC())
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	pieces = make(map[storj.NodeID][]storj.PieceID)
+	for rows.Next() {
+		var nodeBytes, pieceBytes []byte
+		if err := rows.Scan(&nodeBytes, &pieceBytes); err != nil {
+			return nil, Error.Wrap(err)
+		}
+		node, err := storj.NodeIDFromBytes(nodeBytes)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		piece, err := storj.PieceIDFromBytes(pieceBytes)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		pieces[node] = append(pieces[node], piece)
+	}
+	return pieces, Error.Wrap(rows.Err())
+}
+
+// pieceIDBytes returns the bytes of the piece IDs.
+func pieceIDBytes(pieces []storj.PieceID) [][]byte {
+	pieceIDs := make([][]byte, len(pieces))
+	for i, piece := range pieces {
+		pieceIDs[i] = piece.Bytes()
+	}
+	return pieceIDs
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"storj.io/storj/satellite/metainfo"
+)
+
+type inlineContentsDB struct {
+	db *satelliteDB
+}
+
+// InlineContents returns database for interacting with the deduplicated
+// contents of inline segments.
+func (db *satelliteDB) InlineContents() metainfo.InlineContentsDB {
+	return &inlineContentsDB{db: db}
+}
+
+// Reference stores the data of the hash, when it isn't stored yet, and adds a
+// reference to it.
+func (db *inlineContentsDB) Reference(ctx context.Context, hash []byte, data []byte) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO inline_contents (hash, data, reference_count)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (hash)
+		DO UPDATE SET reference_count = inline_contents.reference_count + 1
+	`, hash, data)
+	return Error.Wrap(err)
+}
+
+// Get returns the data of the hash.
+func (db *inlineContentsDB) Get(ctx context.Context, hash []byte) (data []byte, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	err = db.db.QueryRowContext(ctx, `
+		SELECT data FROM inline_contents WHERE hash = $1
+	`, hash).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, metainfo.ErrInlineContentNotFound.New("%x", hash)
+	}
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return data, nil
+}
+
+// Release removes a reference to the data of the hash and deletes the data
+// when it was the last one.
+func (db *inlineContentsDB) Release(ctx context.Context, hash []byte) (deleted bool, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var references int64
+	err = db.db.QueryRowContext(ctx, `
+		UPDATE inline_contents SET reference_count = reference_count - 1
+		WHERE hash = $1
+		RETURNING reference_count
+	`, hash).Scan(&references)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, metainfo.ErrInlineContentNotFound.New("%x", hash)
+	}
+	if err != nil {
+		return false, Error.Wrap(err)
+	}
+	if references > 0 {
+		return false, nil
+	}
+
+	// a concurrent Reference may have added a reference in the meantime.
+	result, err := db.db.ExecContext(ctx, `
+		DELETE FROM inline_contents WHERE hash = $1 AND reference_count <= 0
+	`, hash)
+	if err != nil {
+		return false, Error.Wrap(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, Error.Wrap(err)
+	}
+	return count > 0, nil
+}
@@ -993,6 +993,97 @@ func (db *satelliteDB) PostgresMigration() *migrate.Migration {
 					`UPDATE projects SET bandwidth_limit = NULL WHERE bandwidth_limit <= 50000000000;`,
 				},
 			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add inline_contents table",
+				Version:     130,
+				Action: migrate.SQL{
+					`CREATE TABLE inline_contents (
+						hash bytea NOT NULL,
+						data bytea NOT NULL,
+						reference_count bigint NOT NULL,
+						PRIMARY KEY ( hash )
+					);`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add deletion_dead_letters table",
+				Version:     131,
+				Action: migrate.SQL{
+					`CREATE TABLE deletion_dead_letters (
+						node_id bytea NOT NULL,
+						piece_id bytea NOT NULL,
+						reason integer NOT NULL,
+						created_at timestamp with time zone NOT NULL,
+						PRIMARY KEY ( node_id, piece_id )
+					);`,
+					`CREATE INDEX deletion_dead_letters_created_at_index ON deletion_dead_letters ( created_at );`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add deleted_object_stubs table",
+				Version:     132,
+				Action: migrate.SQL{
+					`CREATE TABLE deleted_object_stubs (
+						project_id bytea NOT NULL,
+						bucket_name bytea NOT NULL,
+						object_key bytea NOT NULL,
+						size bigint NOT NULL,
+						segment_count integer NOT NULL,
+						object_created_at timestamp with time zone NOT NULL,
+						deleted_at timestamp with time zone NOT NULL,
+						PRIMARY KEY ( project_id, bucket_name, object_key )
+					);`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add delete_webhooks table",
+				Version:     133,
+				Action: migrate.SQL{
+					`CREATE TABLE delete_webhooks (
+						project_id bytea NOT NULL,
+						url text NOT NULL,
+						secret bytea NOT NULL,
+						created_at timestamp with time zone NOT NULL,
+						PRIMARY KEY ( project_id )
+					);`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add shared_segments table",
+				Version:     134,
+				Action: migrate.SQL{
+					`CREATE TABLE shared_segments (
+						root_piece_id bytea NOT NULL,
+						reference_count bigint NOT NULL,
+						PRIMARY KEY ( root_piece_id )
+					);`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add deletion_retries and deletion_retry_nodes tables",
+				Version:     135,
+				Action: migrate.SQL{
+					`CREATE TABLE deletion_retries (
+						node_id bytea NOT NULL,
+						piece_id bytea NOT NULL,
+						piece_bytes bigint NOT NULL,
+						queued_at timestamp with time zone NOT NULL,
+						PRIMARY KEY ( node_id, piece_id )
+					);`,
+					`CREATE TABLE deletion_retry_nodes (
+						node_id bytea NOT NULL,
+						failed_attempts integer NOT NULL,
+						retry_after timestamp with time zone NOT NULL,
+						PRIMARY KEY ( node_id )
+					);`,
+				},
+			},
 		},
 	}
 }
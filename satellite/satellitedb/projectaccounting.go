@@ -610,6 +610,8 @@ func (db *ProjectAccounting) GetBucketTotals(ctx context.Context, projectID uuid
 
 		// fill storage and object count
 		bucketUsage.Storage = memory.Size(inline + remote).GB()
+		bucketUsage.InlineStorage = memory.Size(inline).GB()
+		bucketUsage.RemoteStorage = memory.Size(remote).GB()
 		bucketUsage.ObjectCount = objectCount
 
 		bucketUsages = append(bucketUsages, bucketUsage)
@@ -673,3 +675,41 @@ func (db *ProjectAccounting) GetProjectLimits(ctx context.Context, projectID uui
 		Bandwidth: row.BandwidthLimit,
 	}, nil
 }
+
+// GetLargestBuckets returns the storage tallies of the buckets of a projectID
+// from the latest tally run, at most limit of them, by descending storage
+// usage (inline and remote).
+func (db *ProjectAccounting) GetLargestBuckets(ctx context.Context, projectID uuid.UUID, limit int) (_ []accounting.BucketStorageTally, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	// All records for a project that have the same interval start are part
+	// of the same tally run, see GetStorageTotals.
+	query := db.db.Rebind(`SELECT bucket_name, interval_start, object_count,
+			inline_segments_count, remote_segments_count, inline, remote, metadata_size
+		FROM bucket_storage_tallies
+		WHERE project_id = ? AND interval_start = (
+			SELECT MAX(interval_start) FROM bucket_storage_tallies WHERE project_id = ?
+		)
+		ORDER BY inline + remote DESC, bucket_name ASC
+		LIMIT ?`)
+
+	rows, err := db.db.QueryContext(ctx, query, projectID[:], projectID[:], limit)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	var tallies []accounting.BucketStorageTally
+	for rows.Next() {
+		var bucketName []byte
+		tally := accounting.BucketStorageTally{ProjectID: projectID}
+		err = rows.Scan(&bucketName, &tally.IntervalStart, &tally.ObjectCount,
+			&tally.InlineSegmentCount, &tally.RemoteSegmentCount, &tally.InlineBytes, &tally.RemoteBytes, &tally.MetadataSize)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		tally.BucketName = string(bucketName)
+		tallies = append(tallies, tally)
+	}
+	return tallies, Error.Wrap(rows.Err())
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package satellitedb
+
+import (
+	"context"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/common/storj"
+	"storj.io/storj/private/dbutil/pgutil"
+	"storj.io/storj/private/tagsql"
+	"storj.io/storj/satellite/metainfo"
+)
+
+type sharedSegmentsDB struct {
+	db *satelliteDB
+}
+
+// SharedSegments returns database for interacting with the references to the
+// segments shared by copied objects.
+func (db *satelliteDB) SharedSegments() metainfo.SharedSegmentsDB {
+	return &sharedSegmentsDB{db: db}
+}
+
+// Reference adds a reference to the segment. A segment without a row has a
+// single reference, hence the first added reference makes two.
+func (db *sharedSegmentsDB) Reference(ctx context.Context, rootPieceID storj.PieceID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	_, err = db.db.ExecContext(ctx, `
+		INSERT INTO shared_segments (root_piece_id, reference_count)
+		VALUES ($1, 2)
+		ON CONFLICT (root_piece_id)
+		DO UPDATE SET reference_count = shared_segments.reference_count + 1
+	`, rootPieceID.Bytes())
+	return Error.Wrap(err)
+}
+
+// Release removes a reference to each of the segments, once per occurrence,
+// and returns the ones which are still referenced. The segments are usually
+// released with a single query, the rows are deleted once a single reference
+// is left.
+func (db *sharedSegmentsDB) Release(ctx context.Context, rootPieceIDs []storj.PieceID) (referenced []storj.PieceID, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	// the same segment may be released several times at once, e.g. when an
+	// object and its copy are deleted together.
+	occurrences := make(map[storj.PieceID]int64, len(rootPieceIDs))
+	for _, rootPieceID := range rootPieceIDs {
+		occurrences[rootPieceID]++
+	}
+	byOccurrences := make(map[int64][][]byte)
+	for rootPieceID, count := range occurrences {
+		byOccurrences[count] = append(byOccurrences[count], rootPieceID.Bytes())
+	}
+
+	var unreferenced [][]byte
+	for count, ids := range byOccurrences {
+		rows, err := db.db.QueryContext(ctx, `
+			UPDATE shared_segments SET reference_count = reference_count - $2
+			WHERE root_piece_id = ANY($1::BYTEA[])
+			RETURNING root_piece_id, reference_count
+		`, pgutil.ByteaArray(ids), count)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		referenced, unreferenced, err = scanReleasedSegments(rows, referenced, unreferenced)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(unreferenced) == 0 {
+		return referenced, nil
+	}
+
+	// a concurrent Reference may have added a reference in the meantime.
+	_, err = db.db.ExecContext(ctx, `
+		DELETE FROM shared_segments WHERE root_piece_id = ANY($1::BYTEA[]) AND reference_count <= 1
+	`, pgutil.ByteaArray(unreferenced))
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return referenced, nil
+}
+
+// scanReleasedSegments appends the released segments which are still
+// referenced to referenced, and the ones whose row has to be deleted to
+// unreferenced.
+func scanReleasedSegments(rows tagsql.Rows, referenced []storj.PieceID, unreferenced [][]byte) (_ []storj.PieceID, _ [][]byte, err error) {
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	for rows.Next() {
+		var id []byte
+		var references int64
+		if err := rows.Scan(&id, &references); err != nil {
+			return nil, nil, Error.Wrap(err)
+		}
+		if references <= 1 {
+			unreferenced = append(unreferenced, id)
+		}
+		if references < 1 {
+			continue
+		}
+
+		rootPieceID, err := storj.PieceIDFromBytes(id)
+		if err != nil {
+			return nil, nil, Error.Wrap(err)
+		}
+		referenced = append(referenced, rootPieceID)
+	}
+	return referenced, unreferenced, Error.Wrap(rows.Err())
+}
@@ -14,6 +14,9 @@ type ListOptions struct {
 	Recursive    bool
 	IncludeValue bool
 	Limit        int
+
+	// IncludeStartAfter also lists the StartAfter key itself, when it exists.
+	IncludeStartAfter bool
 }
 
 // ListV2 lists all keys corresponding to ListOptions.
@@ -66,7 +69,7 @@ func ListV2Iterate(ctx context.Context, store KeyValueStore, opts ListOptions, f
 	first := opts.StartAfter
 	iterate := func(ctx context.Context, it Iterator) error {
 		var item ListItem
-		skipFirst := true
+		skipFirst := !opts.IncludeStartAfter
 		for ; limit > 0; limit-- {
 			if !it.Next(ctx, &item) {
 				more = false
@@ -124,6 +124,29 @@ func testListV2(t *testing.T, ctx *testcontext.Context, store storage.KeyValueSt
 				newItem("my-album/", "", true),
 			},
 		},
+		{"start at 2",
+			storage.ListOptions{
+				Prefix:            storage.Key("music/"),
+				StartAfter:        storage.Key("a-song2.mp3"),
+				IncludeStartAfter: true,
+				Limit:             2,
+			},
+			true, storage.Items{
+				newItem("a-song2.mp3", "", false),
+				newItem("my-album/", "", true),
+			},
+		},
+		{"start at prefix",
+			storage.ListOptions{
+				Prefix:            storage.Key("music/"),
+				StartAfter:        storage.Key("my-album/"),
+				IncludeStartAfter: true,
+			},
+			false, storage.Items{
+				newItem("my-album/", "", true),
+				newItem("z-song5.mp3", "", false),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -426,6 +426,69 @@ func TestDelete(t *testing.T) {
 	})
 }
 
+func TestDelete_OrderLimitVerification(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellite := planet.Satellites[0]
+		storagenode := planet.StorageNodes[0]
+		uplink := planet.Uplinks[0]
+
+		pieceID := testrand.PieceID()
+		data, _, _ := uploadPiece(t, ctx, pieceID, storagenode, uplink, satellite)
+
+		conn, err := uplink.Dialer.DialNodeURL(ctx, storagenode.NodeURL())
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+
+		client := pb.NewDRPCPiecestoreClient(conn)
+
+		deleteWith := func(signer signing.Signer) error {
+			orderLimit, _ := GenerateOrderLimit(
+				t,
+				satellite.ID(),
+				storagenode.ID(),
+				pieceID,
+				pb.PieceAction_DELETE,
+				testrand.SerialNumber(),
+				24*time.Hour,
+				24*time.Hour,
+				100,
+			)
+			if signer != nil {
+				orderLimit, err = signing.SignOrderLimit(ctx, signer, orderLimit)
+				require.NoError(t, err)
+			}
+
+			_, err := client.Delete(ctx, &pb.PieceDeleteRequest{
+				Limit: orderLimit,
+			})
+			return err
+		}
+
+		// an unsigned order limit is rejected.
+		err = deleteWith(nil)
+		require.Error(t, err)
+		require.Equal(t, rpcstatus.Unauthenticated, rpcstatus.Code(err))
+
+		// an order limit which isn't signed by the satellite is rejected.
+		err = deleteWith(signing.SignerFromFullIdentity(uplink.Identity))
+		require.Error(t, err)
+		require.Equal(t, rpcstatus.Unauthenticated, rpcstatus.Code(err))
+
+		downloaded, err := downloadPiece(t, ctx, pieceID, int64(len(data)), storagenode, uplink, satellite)
+		require.NoError(t, err)
+		require.Equal(t, data, downloaded)
+
+		// an order limit signed by the satellite deletes the piece.
+		err = deleteWith(signing.SignerFromFullIdentity(satellite.Identity))
+		require.NoError(t, err)
+
+		_, err = downloadPiece(t, ctx, pieceID, int64(len(data)), storagenode, uplink, satellite)
+		require.Error(t, err)
+	})
+}
+
 func TestDeletePieces(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 1,